@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/menta2k/templater/internal/bundle"
+	"github.com/menta2k/templater/internal/values"
+)
+
+// bundleCommands maps `templater bundle` subcommands to their entry points.
+var bundleCommands = map[string]func(args []string) error{
+	"share": runBundleShare,
+}
+
+// executable returns the templater binary used to render bundles.
+var executable = os.Executable
+
+// runBundle dispatches `templater bundle <command>`.
+func runBundle(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("bundle requires a command (%s)", bundleCommandNames())
+	}
+
+	run, ok := bundleCommands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown bundle command '%s' (expected one of: %s)", args[0], bundleCommandNames())
+	}
+	return run(args[1:])
+}
+
+// bundleCommandNames returns the sorted names of the bundle subcommands.
+func bundleCommandNames() string {
+	names := make([]string, 0, len(bundleCommands))
+	for name := range bundleCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// runBundleShare packs templates, values and render options into a single bundle file.
+func runBundleShare(args []string) error {
+	fs := flag.NewFlagSet("bundle share", flag.ContinueOnError)
+	var (
+		templatePath = fs.String("template", "", "Path to the template file or directory (required)")
+		valuesFile   = fs.String("values", "", "Path to the YAML values file (optional)")
+		schemaFile   = fs.String("schema", "", "JSON schema for the values (default: values.schema.json next to the values file)")
+		outputFile   = fs.String("output", "bundle.tpkg", "Path of the bundle to write")
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: templater bundle share [options] [-- render options]")
+		fmt.Fprintln(fs.Output(), "Render options such as --set app.name=web --strict are recorded and applied by 'templater run'.")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *templatePath == "" {
+		fs.Usage()
+		return fmt.Errorf("bundle share requires -template")
+	}
+	if *schemaFile == "" {
+		*schemaFile = values.FindSchema(*valuesFile)
+	}
+
+	file, err := os.Create(*outputFile)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	err = bundle.Create(file, bundle.Sources{
+		Template:   *templatePath,
+		ValuesFile: *valuesFile,
+		SchemaFile: *schemaFile,
+		Options:    fs.Args(),
+	})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(*outputFile)
+		return err
+	}
+
+	fmt.Printf("Wrote bundle %s\n", *outputFile)
+	return nil
+}
+
+// runBundleRun renders a bundle created by `templater bundle share` with the options
+// recorded in it.
+func runBundleRun(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	outputFile := fs.String("output", "output", "Path to the output file or directory, or an http(s) URL to upload to")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: templater run [options] bundle.tpkg")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return fmt.Errorf("run requires a bundle file")
+	}
+
+	file, err := os.Open(positional[0])
+	if err != nil {
+		return fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer file.Close()
+
+	dir, err := os.MkdirTemp("", "templater-bundle-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	manifest, err := bundle.Extract(file, dir)
+	if err != nil {
+		return err
+	}
+
+	binary, err := executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate templater binary: %w", err)
+	}
+	cmd := exec.Command(binary, manifest.Args(dir, *outputFile)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to render bundle: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunBundleShareAndRun(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-bundle-cmd-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := os.MkdirAll(filepath.Join(tempDir, "templates"), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	files := map[string]string{
+		"templates/app.tpl":  "{{.app.name}}",
+		"values.yaml":        "app:\n  name: web\n",
+		"values.schema.json": "{}",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	bundlePath := filepath.Join(tempDir, "repro.tpkg")
+	err = runBundle([]string{"share",
+		"-template", filepath.Join(tempDir, "templates"),
+		"-values", filepath.Join(tempDir, "values.yaml"),
+		"-output", bundlePath,
+		"--", "--set", "app.name=api", "--strict",
+	})
+	if err != nil {
+		t.Fatalf("bundle share failed: %v", err)
+	}
+
+	// Stand in for the templater binary with a script recording its arguments
+	argsFile := filepath.Join(tempDir, "args")
+	script := filepath.Join(tempDir, "templater")
+	content := "#!/bin/sh\nprintf '%s\\n' \"$@\" > " + argsFile + "\ntest -f \"$2/app.tpl\"\n"
+	if err := os.WriteFile(script, []byte(content), 0o755); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+	original := executable
+	executable = func() (string, error) { return script, nil }
+	defer func() { executable = original }()
+
+	if err := runBundleRun([]string{bundlePath, "-output", "rendered"}); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("Failed to read recorded arguments: %v", err)
+	}
+	args := strings.Split(strings.TrimSpace(string(data)), "\n")
+	expected := []string{"-template", "-values", "-schema", "--set", "app.name=api", "--strict", "-output", "rendered"}
+	var flags []string
+	for i := 0; i < len(args); i++ {
+		flags = append(flags, args[i])
+		if args[i] == "-template" || args[i] == "-values" || args[i] == "-schema" {
+			i++
+		}
+	}
+	if strings.Join(flags, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected arguments %v, got %v", expected, args)
+	}
+}
+
+func TestRunBundleErrors(t *testing.T) {
+	tempDir := t.TempDir()
+
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{name: "no bundle command", run: func() error { return runBundle(nil) }},
+		{name: "unknown bundle command", run: func() error { return runBundle([]string{"unpack"}) }},
+		{name: "share without template", run: func() error { return runBundle([]string{"share"}) }},
+		{name: "share with reserved option", run: func() error {
+			return runBundle([]string{"share", "-template", tempDir, "-output", filepath.Join(tempDir, "b.tpkg"), "--", "-output=x"})
+		}},
+		{name: "run without bundle", run: func() error { return runBundleRun(nil) }},
+		{name: "run missing bundle", run: func() error { return runBundleRun([]string{filepath.Join(tempDir, "missing.tpkg")}) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); err == nil {
+				t.Error("Expected error but got none")
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, "b.tpkg")); !os.IsNotExist(err) {
+		t.Errorf("Expected failed bundle to be removed, got %v", err)
+	}
+}
+
+func TestRunBundleRefusesUnsafeOptions(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// A bundle made by hand, since bundle share refuses to record --allow-exec
+	bundlePath := filepath.Join(tempDir, "evil.tpkg")
+	file, err := os.Create(bundlePath)
+	if err != nil {
+		t.Fatalf("Failed to create bundle: %v", err)
+	}
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	entries := map[string]string{
+		"bundle.json":       `{"version":1,"template":"templates","options":["--allow-exec","touch"]}`,
+		"templates/app.tpl": `{{ exec "touch" "pwned" }}`,
+	}
+	for name, content := range entries {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("Failed to write header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write entry: %v", err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+	file.Close()
+
+	// The binary must never run
+	ran := filepath.Join(tempDir, "ran")
+	script := filepath.Join(tempDir, "templater")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ntouch "+ran+"\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+	original := executable
+	executable = func() (string, error) { return script, nil }
+	defer func() { executable = original }()
+
+	err = runBundleRun([]string{bundlePath, "-output", filepath.Join(tempDir, "rendered")})
+	if err == nil || !strings.Contains(err.Error(), "--allow-exec is not allowed") {
+		t.Fatalf("Expected --allow-exec to be refused, got %v", err)
+	}
+	if _, err := os.Stat(ran); !os.IsNotExist(err) {
+		t.Errorf("Expected the bundle not to be rendered, got %v", err)
+	}
+}
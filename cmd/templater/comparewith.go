@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/menta2k/templater/internal/compare"
+)
+
+// runCompareWith renders the same inputs with this templater and with another templater
+// binary, and reports every output that differs.
+func runCompareWith(args []string) error {
+	fs := flag.NewFlagSet("compare-with", flag.ContinueOnError)
+	var (
+		binary = fs.String("binary", "", "Path to the templater binary to compare with (required)")
+		quiet  = fs.Bool("quiet", false, "List the differing outputs without their diffs")
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: templater compare-with -binary ./templater-old -- render options")
+		fmt.Fprintln(fs.Output(), "Render options such as -template ./templates -values values.yaml are passed to both binaries.")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *binary == "" {
+		fs.Usage()
+		return fmt.Errorf("compare-with requires -binary")
+	}
+	options := fs.Args()
+	for _, option := range options {
+		if name, _, _ := strings.Cut(option, "="); name == "-output" || name == "--output" {
+			return fmt.Errorf("option %s is set by compare-with and cannot be passed", option)
+		}
+	}
+
+	self, err := executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate templater binary: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "templater-compare-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	oldOutput := filepath.Join(dir, "old", "output")
+	newOutput := filepath.Join(dir, "new", "output")
+	if err := renderWith(*binary, options, oldOutput); err != nil {
+		return err
+	}
+	if err := renderWith(self, options, newOutput); err != nil {
+		return err
+	}
+
+	differences, err := compare.Outputs(oldOutput, newOutput)
+	if err != nil {
+		return err
+	}
+	return printDifferences(os.Stdout, differences, *quiet)
+}
+
+// renderWith renders with the given templater binary into output, reporting the
+// binary's output when rendering fails.
+func renderWith(binary string, options []string, output string) error {
+	if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	var combined bytes.Buffer
+	cmd := exec.Command(binary, append(append([]string{}, options...), "-output", output)...)
+	cmd.Stdout = &combined
+	cmd.Stderr = &combined
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("render with %s failed: %w\n%s", binary, err, strings.TrimSpace(combined.String()))
+	}
+	return nil
+}
+
+// printDifferences writes the differing outputs, with their diffs unless quiet, and
+// returns an error when there is any.
+func printDifferences(w io.Writer, differences []compare.Difference, quiet bool) error {
+	if len(differences) == 0 {
+		_, err := fmt.Fprintln(w, "No differences")
+		return err
+	}
+
+	for _, d := range differences {
+		fmt.Fprintf(w, "%s: %s\n", d.Status, d.Path)
+		if !quiet {
+			fmt.Fprint(w, d.Diff)
+		}
+	}
+	return fmt.Errorf("found %d differing output(s)", len(differences))
+}
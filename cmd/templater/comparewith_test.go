@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/menta2k/templater/internal/compare"
+)
+
+// fakeTemplater writes a script standing in for a templater binary, rendering content to
+// app.yaml in the -output directory, which is always its last argument.
+func fakeTemplater(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	script := filepath.Join(dir, name)
+	body := "#!/bin/sh\nfor last; do :; done\nmkdir -p \"$last\" && printf '" + content + "' > \"$last/app.yaml\"\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+	return script
+}
+
+func TestRunCompareWith(t *testing.T) {
+	tempDir := t.TempDir()
+	current := fakeTemplater(t, tempDir, "templater", "port: 8080\\n")
+	same := fakeTemplater(t, tempDir, "templater-same", "port: 8080\\n")
+	old := fakeTemplater(t, tempDir, "templater-old", "port: 80\\n")
+	failing := filepath.Join(tempDir, "templater-failing")
+	if err := os.WriteFile(failing, []byte("#!/bin/sh\necho 'unknown flag' >&2\nexit 2\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+
+	original := executable
+	executable = func() (string, error) { return current, nil }
+	defer func() { executable = original }()
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantError string
+	}{
+		{name: "identical", args: []string{"-binary", same, "--", "-template", "t"}},
+		{name: "different", args: []string{"-binary", old, "--", "-template", "t"}, wantError: "found 1 differing output(s)"},
+		{name: "failing binary", args: []string{"-binary", failing, "--", "-template", "t"}, wantError: "unknown flag"},
+		{name: "output option", args: []string{"-binary", old, "--", "-output=x"}, wantError: "set by compare-with"},
+		{name: "missing binary flag", args: []string{"--", "-template", "t"}, wantError: "requires -binary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runCompareWith(tt.args)
+			if tt.wantError == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+			}
+		})
+	}
+}
+
+func TestPrintDifferences(t *testing.T) {
+	differences := []compare.Difference{
+		{Path: "app.yaml", Status: compare.Changed, Diff: "--- old/app.yaml\n+++ new/app.yaml\n"},
+		{Path: "svc.yaml", Status: compare.Added, Diff: "+++ new/svc.yaml\n"},
+	}
+
+	var buf bytes.Buffer
+	if err := printDifferences(&buf, differences, false); err == nil {
+		t.Error("Expected error for differing outputs")
+	}
+	expected := "changed: app.yaml\n--- old/app.yaml\n+++ new/app.yaml\nadded: svc.yaml\n+++ new/svc.yaml\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	_ = printDifferences(&buf, differences, true)
+	if buf.String() != "changed: app.yaml\nadded: svc.yaml\n" {
+		t.Errorf("Expected only the paths when quiet, got %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := printDifferences(&buf, nil, false); err != nil || buf.String() != "No differences\n" {
+		t.Errorf("Expected no differences, got %q (%v)", buf.String(), err)
+	}
+}
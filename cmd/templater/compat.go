@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/menta2k/templater/internal/compat"
+	"github.com/menta2k/templater/internal/oci"
+)
+
+// runCompat reports the changes between two versions of a template pack that break or
+// affect its consumers.
+func runCompat(args []string) error {
+	fs := flag.NewFlagSet("compat", flag.ContinueOnError)
+	var (
+		registry = addRegistryFlags(fs)
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: templater compat [options] old-pack new-pack")
+		fmt.Fprintln(fs.Output(), "Packs are directories or oci://registry/repository:tag references.")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		fs.Usage()
+		return fmt.Errorf("compat requires an old and a new pack")
+	}
+
+	client := registry.client()
+	packs := make([]*compat.Pack, len(positional))
+	for i, location := range positional {
+		dir, cleanup, err := packDirectory(client, location)
+		if err != nil {
+			return err
+		}
+		packs[i], err = compat.Analyze(dir)
+		cleanup()
+		if err != nil {
+			return err
+		}
+	}
+
+	return printCompat(os.Stdout, compat.Compare(packs[0], packs[1]))
+}
+
+// packDirectory returns the directory of a pack, pulling oci:// references into a
+// temporary directory removed by cleanup.
+func packDirectory(client *oci.Client, location string) (dir string, cleanup func(), err error) {
+	if !strings.HasPrefix(location, "oci://") {
+		info, err := os.Stat(location)
+		if err != nil {
+			return "", nil, fmt.Errorf("cannot stat pack '%s': %w", location, err)
+		}
+		if !info.IsDir() {
+			return "", nil, fmt.Errorf("pack '%s' is not a directory", location)
+		}
+		return location, func() {}, nil
+	}
+
+	ref, err := oci.ParseReference(location)
+	if err != nil {
+		return "", nil, err
+	}
+	artifact, _, err := client.Pull(ref)
+	if err != nil {
+		return "", nil, err
+	}
+
+	dir, err = os.MkdirTemp("", "templater-compat-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+	if err := artifact.Extract(dir); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return dir, cleanup, nil
+}
+
+// printCompat prints the changes and fails when any of them is breaking.
+func printCompat(w io.Writer, changes []compat.Change) error {
+	breaking := 0
+	for _, change := range changes {
+		fmt.Fprintln(w, change)
+		if change.Breaking {
+			breaking++
+		}
+	}
+
+	if breaking > 0 {
+		return fmt.Errorf("found %d breaking change(s)", breaking)
+	}
+	fmt.Fprintln(w, "No breaking changes")
+	return nil
+}
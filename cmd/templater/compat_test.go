@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/menta2k/templater/internal/compat"
+)
+
+func TestRunCompat(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-compat-cmd-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	packs := map[string]string{
+		"v1": "{{.app.name}}",
+		"v2": "{{.app.name}}",
+		"v3": "{{.app.name}} {{.app.image}}",
+	}
+	for name, content := range packs {
+		if err := os.MkdirAll(filepath.Join(tempDir, name), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(tempDir, name, "app.tpl"), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write template: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantError bool
+	}{
+		{name: "compatible", args: []string{filepath.Join(tempDir, "v1"), filepath.Join(tempDir, "v2")}},
+		{name: "breaking", args: []string{filepath.Join(tempDir, "v1"), filepath.Join(tempDir, "v3")}, wantError: true},
+		{name: "missing pack", args: []string{filepath.Join(tempDir, "v1"), filepath.Join(tempDir, "v9")}, wantError: true},
+		{name: "one pack", args: []string{filepath.Join(tempDir, "v1")}, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runCompat(tt.args)
+			if tt.wantError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.wantError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestPrintCompat(t *testing.T) {
+	var buf bytes.Buffer
+	err := printCompat(&buf, []compat.Change{
+		{Breaking: true, Message: "value .app.image is now required (app.tpl:1:20)"},
+		{Message: "value .legacy is no longer used"},
+	})
+	if err == nil || err.Error() != "found 1 breaking change(s)" {
+		t.Errorf("Expected breaking change error, got %v", err)
+	}
+
+	expected := "BREAKING: value .app.image is now required (app.tpl:1:20)\ndeprecated: value .legacy is no longer used\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := printCompat(&buf, []compat.Change{{Message: "value .legacy is no longer used"}}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if buf.String() != "deprecated: value .legacy is no longer used\nNo breaking changes\n" {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+}
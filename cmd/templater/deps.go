@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/menta2k/templater/internal/deps"
+)
+
+// depsCommands maps `templater deps` subcommands to their entry points.
+var depsCommands = map[string]func(args []string) error{
+	"fetch": runDepsFetch,
+}
+
+// runDeps dispatches `templater deps <command>`.
+func runDeps(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("deps requires a command (%s)", depsCommandNames())
+	}
+
+	run, ok := depsCommands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown deps command '%s' (expected one of: %s)", args[0], depsCommandNames())
+	}
+	return run(args[1:])
+}
+
+// depsCommandNames returns the sorted names of the deps subcommands.
+func depsCommandNames() string {
+	names := make([]string, 0, len(depsCommands))
+	for name := range depsCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// runDepsFetch resolves the dependencies declared in a pack's templater.yaml and vendors them.
+func runDepsFetch(args []string) error {
+	fs := flag.NewFlagSet("deps fetch", flag.ContinueOnError)
+	var (
+		templateDir = fs.String("template", ".", "Path to the template pack containing "+deps.ManifestFile)
+		update      = fs.Bool("update", false, "Resolve the newest versions matching the constraints, ignoring "+deps.LockFile)
+		registry    = addRegistryFlags(fs)
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: templater deps fetch [options]")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return fmt.Errorf("deps fetch takes no arguments (use -template to select the pack)")
+	}
+
+	locked, err := deps.Fetch(*templateDir, deps.Options{
+		Update:   *update,
+		Registry: registry.client(),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, dep := range locked {
+		fmt.Printf("Vendored: %s %s (%s)\n", dep.Name, dep.Version, dep.Source)
+	}
+	fmt.Printf("Wrote %d dependencies to %s\n", len(locked), deps.LockFile)
+	return nil
+}
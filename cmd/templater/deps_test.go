@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunDeps(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-deps-cmd-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := os.WriteFile(filepath.Join(tempDir, "templater.yaml"), []byte("name: empty\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantError bool
+	}{
+		{name: "missing command", args: nil, wantError: true},
+		{name: "unknown command", args: []string{"install"}, wantError: true},
+		{name: "unexpected argument", args: []string{"fetch", "extra"}, wantError: true},
+		{name: "missing manifest", args: []string{"fetch", "-template", filepath.Join(tempDir, "missing")}, wantError: true},
+		{name: "no dependencies", args: []string{"fetch", "-template", tempDir}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runDeps(tt.args)
+			if tt.wantError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.wantError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, "templater.lock")); err != nil {
+		t.Errorf("Expected lock file to be written: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/menta2k/templater/internal/docs"
+)
+
+// runDocs generates a reference of the values a template pack accepts.
+func runDocs(args []string) error {
+	fs := flag.NewFlagSet("docs", flag.ContinueOnError)
+	var (
+		templateDir = fs.String("template", ".", "Path to the template pack")
+		valuesFile  = fs.String("values", "", "Path to the default values file (default: values.yaml in the pack, if present)")
+		schemaFile  = fs.String("schema", "", "Path to the values JSON schema (default: values.schema.json next to the values file, if present)")
+		format      = fs.String("format", docs.FormatMarkdown, "Output format: markdown or html")
+		output      = fs.String("output", "", "File to write the reference to (default: stdout)")
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: templater docs [options]")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		fs.Usage()
+		return fmt.Errorf("unexpected arguments: %v", positional)
+	}
+
+	sources := docs.Sources{TemplateDir: *templateDir, ValuesFile: *valuesFile, SchemaFile: *schemaFile}
+	if sources.ValuesFile == "" {
+		sources.ValuesFile = existingFile(filepath.Join(*templateDir, "values.yaml"))
+	}
+	if sources.SchemaFile == "" {
+		dir := *templateDir
+		if sources.ValuesFile != "" {
+			dir = filepath.Dir(sources.ValuesFile)
+		}
+		sources.SchemaFile = existingFile(filepath.Join(dir, "values.schema.json"))
+	}
+
+	entries, err := docs.Generate(sources)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+	return docs.Render(w, entries, *format)
+}
+
+// existingFile returns path if it names an existing file, and "" otherwise.
+func existingFile(path string) string {
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		return path
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunDocs(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-docs-cmd-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"values.yaml":        "app:\n  port: 8080\n",
+		"values.schema.json": `{"properties":{"app":{"properties":{"port":{"type":"integer","description":"Listen port"}}}}}`,
+		"app.tpl":            "{{.app.name}}:{{.app.port}}",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		args      []string
+		contains  []string
+		wantError bool
+	}{
+		{
+			name:     "markdown with default values and schema",
+			args:     []string{"-template", tempDir},
+			contains: []string{"| `.app.port` | integer | `8080` | Listen port | `app.tpl` |", "| `.app.name` |  |  |  | `app.tpl` |"},
+		},
+		{
+			name:     "html",
+			args:     []string{"-template", tempDir, "-format", "html"},
+			contains: []string{"<td><code>.app.port</code></td><td>integer</td><td><code>8080</code></td><td>Listen port</td>"},
+		},
+		{name: "unknown format", args: []string{"-template", tempDir, "-format", "pdf"}, wantError: true},
+		{name: "missing values file", args: []string{"-template", tempDir, "-values", filepath.Join(tempDir, "missing.yaml")}, wantError: true},
+		{name: "unexpected argument", args: []string{"-template", tempDir, "extra"}, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(tempDir, "VALUES.md")
+			defer os.Remove(output)
+
+			err := runDocs(append(tt.args, "-output", output))
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			content, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(string(content), want) {
+					t.Errorf("Expected output to contain %q, got:\n%s", want, content)
+				}
+			}
+		})
+	}
+}
@@ -1,26 +1,139 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/menta2k/templater/internal/cache"
 	"github.com/menta2k/templater/internal/cli"
 	"github.com/menta2k/templater/internal/config"
+	"github.com/menta2k/templater/internal/deps"
+	"github.com/menta2k/templater/internal/output"
 	"github.com/menta2k/templater/internal/processor"
+	"github.com/menta2k/templater/internal/providers"
+	"github.com/menta2k/templater/internal/telemetry"
+	"github.com/menta2k/templater/internal/values"
 )
 
+// subcommands maps subcommand names to their entry points.
+var subcommands = map[string]func(args []string) error{
+	"bundle":       runBundle,
+	"compat":       runCompat,
+	"compare-with": runCompareWith,
+	"deps":         runDeps,
+	"docs":         runDocs,
+	"new":          runNew,
+	"push":         runPush,
+	"refactor":     runRefactor,
+	"run":          runBundleRun,
+	"values":       runValues,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			if err := run(os.Args[2:]); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	var (
 		templateFile = flag.String("template", "", "Path to the template file or directory (required)")
-		valuesFile   = flag.String("values", "", "Path to the YAML values file (optional)")
-		outputFile   = flag.String("output", "output", "Path to the output file or directory")
+		valuesFile   = flag.String("values", "", "Path to the YAML values file, git::<repository>//<path>?ref=<ref>, s3://bucket/key or gs://bucket/object (optional)")
+		schemaFile   = flag.String("schema", "", "JSON schema the merged values must match (default: values.schema.json next to the values file)")
+		skipSchema   = flag.Bool("skip-schema", false, "Do not validate values against a JSON schema")
+		skipDirVals  = flag.Bool("skip-dir-values", false, "Do not merge values.yaml and _values.yaml files of template subdirectories over the values of their templates")
+		outputFile   = flag.String("output", "output", "Path to the output file or directory, an http(s) URL to upload to, or - to print rendered files to stdout")
+		combineFile  = flag.String("combine", "", "Concatenate every rendered file, separated by --- # source: <path> comments, into this single file instead of -output")
+		dryRun       = flag.Bool("dry-run", false, "Render every template and report which files would be created, updated or left unchanged, with their sizes, without writing any")
+		copyStatic   = flag.Bool("copy-static", false, "In directory mode, copy files without the .tpl extension verbatim into the output tree")
 		setVals      = cli.SetValues{}
+		setStrVals   = cli.SetValues{}
+		setFileVals  = cli.SetValues{}
+		setJSONVals  = cli.StringList{}
+		outHeaders   = cli.StringList{}
+		envFiles     = cli.StringList{}
+		valuesFrom   = cli.StringList{}
+		valuesK8s    = cli.StringList{}
+		ageIDs       = cli.StringList{}
+		mergeSpecs   = cli.StringList{}
+		secretCLIs   = cli.StringList{}
+		funcPlugins  = cli.StringList{}
+		procPlugins  = cli.StringList{}
+		starFiles    = cli.StringList{}
+		wasmPlugins  = cli.StringList{}
+		allowEnv     = cli.OptionalString{}
+		allowExec    = cli.StringList{}
+		allowHTTP    = cli.StringList{}
+		includes     = cli.StringList{}
+		excludes     = cli.StringList{}
+		sourceWait   = flag.Duration("values-from-timeout", 30*time.Second, "Timeout for each -values-from source; sources are fetched concurrently")
+		sourceTTL    = flag.Duration("source-cache-ttl", 0, "How long git and object storage values files are reused before checking them for changes, e.g. 10m (default: check on every run)")
+		allowGCPSM   = flag.Bool("allow-gcp-sm", false, "Resolve gcp-sm:// references in values from Google Cloud Secret Manager")
+		allowKeyring = flag.Bool("allow-keyring", false, "Resolve keyring://<service>/<account> references in values from the OS keyring")
+		offline      = flag.Bool("offline", false, "Only use git and object storage values files fetched by earlier runs, without network access")
+		recordDir    = flag.String("record", "", "Save responses from external values sources as fixtures in this directory")
+		replayDir    = flag.String("replay", "", "Answer external values sources from fixtures saved with -record, without network access")
+		environment  = flag.String("env", "", "Environment whose values.<env>.yaml is merged over values.yaml, which defaults to the one in the template directory (e.g. prod)")
+		envPrefix    = flag.String("env-prefix", "", "Only import environment variables with this prefix, stripping it from the keys (e.g. TEMPLATER_VAL_)")
+		outMethod    = flag.String("output-method", "PUT", "HTTP method used when uploading output (PUT or POST)")
+		outRetries   = flag.Int("output-retries", 3, "Number of retries for failed HTTP uploads")
+		renderWait   = flag.Duration("render-timeout", 0, "Abandon a template execution that runs longer than this, e.g. 30s (default no timeout)")
+		workers      = flag.Int("workers", 1, "Number of templates rendered concurrently in directory mode")
+		maxMemory    = cli.ByteSize(0)
+		useCache     = flag.Bool("cache", false, "Restore the outputs of unchanged templates and values from the render cache")
+		cacheDir     = flag.String("cache-dir", "", "Directory for cached render results, enabling the render cache (default with --cache: user cache directory)")
+		noCache      = flag.Bool("no-cache", false, "Always render templates, bypassing the render cache even with --cache, --cache-dir or --remote-cache")
+		noFileFuncs  = flag.Bool("no-file-functions", false, "Disable the functions reading files below the template directory, such as readFile and glob")
+		helmCompat   = flag.Bool("helm-compat", false, "Execute templates with Helm's builtin objects: values under .Values, plus .Release and .Template")
+		releaseName  = flag.String("release-name", processor.DefaultReleaseName, "Release name for .Release.Name with --helm-compat")
+		namespace    = flag.String("namespace", processor.DefaultReleaseNamespace, "Release namespace for .Release.Namespace with --helm-compat")
+		sortKeys     = flag.Bool("sort-keys", false, "Make toYaml, toJson and toToml write every key in sorted order, like toYamlSorted, toJsonSorted and toTomlSorted")
+		remoteCache  = flag.String("remote-cache", "", "Shared render cache location (http(s):// base URL or s3://bucket/prefix), enabling the render cache")
+		cacheHeaders = cli.StringList{}
+		failOnEmpty  = flag.Bool("fail-on-empty", false, "Exit with an error when a template directory contains no *.tpl files")
 		help         = flag.Bool("help", false, "Show help message")
 		strict       = flag.Bool("strict", false, "Enable strict mode - exit on undefined values")
+		staticCheck  = flag.Bool("static-check", false, "With --strict, report every undefined value reference before rendering, without executing templates")
+		lazyValues   = flag.Bool("lazy-values", false, "Only decode the top-level keys of the values file that templates reference")
+		tmplValues   = flag.Bool("template-values", false, "Render {{ }} expressions in the values file, which may reference other values and env vars")
+		preciseNums  = flag.Bool("precise-numbers", false, "Keep numbers from the values file, --set and --set-json as written, such as large integers and 1e3, instead of converting them to int or float")
+		otelTrace    = flag.Bool("otel", false, "Export OpenTelemetry traces via OTLP/HTTP, configured with the standard OTEL_EXPORTER_OTLP_* variables")
+		parseOnly    = flag.Bool("parse-only", false, "Only check template and path syntax, without rendering or writing output")
+		explainVals  = flag.Bool("explain-values", false, "Print the source of every merged value and the values it overrides, without rendering")
+		showValues   = flag.Bool("show-values", false, "Print the merged values templates would see, without rendering")
+		showFormat   = flag.String("show-values-format", "yaml", "Format used by -show-values (yaml, json or dotenv)")
 	)
 
 	flag.Var(&setVals, "set", "Set values on the command line (can be used multiple times or comma-separated)")
+	flag.Var(&setStrVals, "set-string", "Set string values on the command line without type conversion (can be used multiple times or comma-separated)")
+	flag.Var(&setFileVals, "set-file", "Set values from file contents on the command line as key=path (can be used multiple times or comma-separated)")
+	flag.Var(&setJSONVals, "set-json", "Set a JSON value on the command line as key=<json> (can be used multiple times)")
+	flag.Var(&valuesFrom, "values-from", "Load values from an external source, e.g. ssm:///myapp/prod/ or gcp-sm://projects/p/secrets/name (can be used multiple times)")
+	flag.Var(&valuesK8s, "values-k8s", "Load values from a Kubernetes ConfigMap or Secret as configmap|secret/[namespace/]name, via the kubeconfig (can be used multiple times)")
+	flag.Var(&envFiles, "env-file", "Path to a dotenv file whose variables are merged into values (can be used multiple times)")
+	flag.Var(&mergeSpecs, "merge-strategy", "How lists from several sources are merged: replace, append, merge-by-index or merge-by-key:<field>, optionally for one key as key=strategy (can be used multiple times)")
+	flag.Var(&secretCLIs, "allow-secret-cli", "Resolve password manager references in values with this CLI: op for op:// (1Password) or bw for bw:// (Bitwarden) (can be used multiple times or comma-separated)")
+	flag.Var(&ageIDs, "age-identity", "Path to an age identity file used to decrypt !age values and .age values files (can be used multiple times; default: $"+values.AgeIdentityEnv+")")
+	flag.Var(&maxMemory, "max-memory", "Cap on rendered output held in memory by concurrent workers, e.g. 256MiB (default unlimited)")
+	flag.Var(&allowEnv, "allow-env", "Enable the env and expandenv template functions; with --allow-env=PREFIX only variables starting with PREFIX can be read")
+	flag.Var(&allowExec, "allow-exec", "Enable the exec template function for these commands, e.g. kubeseal,sops; {{ exec \"sops\" \"-d\" \"secrets.yaml\" }} runs an allowed command and returns its output (can be used multiple times or comma-separated)")
+	flag.Var(&allowHTTP, "allow-http", "Enable the httpGet template function for these hosts, e.g. github.com; {{ httpGet \"https://github.com/octocat.keys\" }} returns the body of a URL on an allowed host (can be used multiple times or comma-separated)")
+	flag.Var(&includes, "include", "In directory mode, only process files matching this glob relative to the template directory, where ** matches any number of directories, e.g. 'k8s/**' (can be used multiple times)")
+	flag.Var(&excludes, "exclude", "In directory mode, skip files and directories matching this glob relative to the template directory, e.g. '**/legacy/*' (can be used multiple times)")
+	flag.Var(&funcPlugins, "funcs-plugin", "Path to a Go plugin (.so) exporting a Funcs template.FuncMap with additional template functions (can be used multiple times)")
+	flag.Var(&procPlugins, "plugin", "Add a template function running a program as name=path; {{ name \"arg\" }} runs it with the arguments and returns its output (can be used multiple times)")
+	flag.Var(&starFiles, "starlark", "Path to a Starlark (.star) file whose top-level functions become template functions (can be used multiple times)")
+	flag.Var(&wasmPlugins, "funcs-wasm", "Path to a WebAssembly module (.wasm) whose exported functions become sandboxed template functions (can be used multiple times)")
+	flag.Var(&cacheHeaders, "remote-cache-header", "HTTP header for remote cache requests in 'Name: value' form (can be used multiple times)")
+	flag.Var(&outHeaders, "output-header", "HTTP header for output uploads in 'Name: value' form (can be used multiple times)")
 	flag.Parse()
 
 	if *help {
@@ -34,6 +147,22 @@ func main() {
 		fmt.Println("  # Process directory of templates")
 		fmt.Println("  go run main.go -template=./templates -values=values.yaml -output=./output")
 		fmt.Println("  ")
+		fmt.Println("  # Use a values file from a config repository at a pinned ref")
+		fmt.Println("  go run main.go -template=./templates -values='git::https://github.com/org/config.git//prod/values.yaml?ref=v1.2.0'")
+		fmt.Println("  ")
+		fmt.Println("  # Check an upgrade of templater against the outputs of the previous version")
+		fmt.Println("  go run main.go compare-with -binary ./templater-old -- -template=./templates -values=values.yaml")
+		fmt.Println("  ")
+		fmt.Println("  # Use a values file published to S3")
+		fmt.Println("  go run main.go -template=./templates -values=s3://platform-config/prod/values.yaml")
+		fmt.Println("  ")
+		fmt.Println("  # Reuse a fetched values file for 10 minutes, or render without network access")
+		fmt.Println("  go run main.go -template=./templates -values=s3://platform-config/prod/values.yaml --source-cache-ttl 10m")
+		fmt.Println("  go run main.go -template=./templates -values=s3://platform-config/prod/values.yaml --offline")
+		fmt.Println("  ")
+		fmt.Println("  # Render for production: values.yaml, then values.prod.yaml from the template directory")
+		fmt.Println("  go run main.go -template=./templates -output=./output --env prod")
+		fmt.Println("  ")
 		fmt.Println("  # Use --set values")
 		fmt.Println("  go run main.go -template=./templates --set app.name=myapp,app.version=2.0")
 		fmt.Println("  go run main.go -template=config.tmpl --set app.name=myapp --set debug=true")
@@ -45,7 +174,78 @@ func main() {
 		fmt.Println("  ")
 		fmt.Println("  # Strict mode - exit on undefined values")
 		fmt.Println("  go run main.go -template=config.tmpl -values=values.yaml --strict")
+		fmt.Println("  go run main.go -template=./templates -values=values.yaml --strict --static-check")
+		fmt.Println("  ")
+		fmt.Println("  # Check template syntax without rendering (e.g. in a pre-commit hook)")
+		fmt.Println("  go run main.go -template=./templates --parse-only")
+		fmt.Println("  ")
+		fmt.Println("  # Render a large tree with 8 workers on a small runner")
+		fmt.Println("  go run main.go -template=./templates -values=values.yaml --workers 8 --max-memory 256MiB")
+		fmt.Println("  ")
+		fmt.Println("  # Restore unchanged outputs from the render cache")
+		fmt.Println("  go run main.go -template=./templates -values=values.yaml --cache")
+		fmt.Println("  ")
+		fmt.Println("  # Render untrusted templates without access to files next to them")
+		fmt.Println("  go run main.go -template=./templates -values=values.yaml --no-file-functions")
+		fmt.Println("  ")
+		fmt.Println("  # Read APP_* environment variables with env in templates")
+		fmt.Println("  go run main.go -template=./templates -values=values.yaml --allow-env=APP_")
+		fmt.Println("  ")
+		fmt.Println("  # Render Helm chart templates using .Values and .Release")
+		fmt.Println("  go run main.go -template=./chart/templates -values=./chart/values.yaml -output=./out --helm-compat --release-name web")
+		fmt.Println("  ")
+		fmt.Println("  # Let templates run sops and kubeseal with exec")
+		fmt.Println("  go run main.go -template=./templates -values=values.yaml --allow-exec sops,kubeseal")
+		fmt.Println("  ")
+		fmt.Println("  # Let templates fetch public keys from github.com with httpGet")
+		fmt.Println("  go run main.go -template=./templates -values=values.yaml --allow-http github.com")
+		fmt.Println("  ")
+		fmt.Println("  # Add organization-specific template functions from a Go plugin")
+		fmt.Println("  go run main.go -template=./templates -values=values.yaml --funcs-plugin ./acme-funcs.so")
+		fmt.Println("  ")
+		fmt.Println("  # Add template functions written in Starlark")
+		fmt.Println("  go run main.go -template=./templates -values=values.yaml --starlark naming.star")
+		fmt.Println("  ")
+		fmt.Println("  # Add template functions from a WebAssembly module, e.g. built with TinyGo or Rust")
+		fmt.Println("  go run main.go -template=./templates -values=values.yaml --funcs-wasm ./acme-funcs.wasm")
+		fmt.Println("  ")
+		fmt.Println("  # Add a template function implemented by a script")
+		fmt.Println("  go run main.go -template=./templates -values=values.yaml --plugin vaultPath=./scripts/vault-path.sh")
+		fmt.Println("  ")
+		fmt.Println("  # Record external sources once, then render offline from the fixtures")
+		fmt.Println("  go run main.go -template=./templates --values-from ssm:///myapp/prod/ --record fixtures/")
+		fmt.Println("  go run main.go -template=./templates --values-from ssm:///myapp/prod/ --replay fixtures/")
 		fmt.Println("  ")
+		fmt.Println("  # Render with the live configuration of a cluster")
+		fmt.Println("  go run main.go -template=./templates --values-k8s configmap/prod/app-config --values-k8s secret/prod/app-credentials")
+		fmt.Println("  ")
+		fmt.Println("  # Print the merged values as JSON")
+		fmt.Println("  go run main.go -template=./templates -values=values.yaml --set app.name=myapp --show-values --show-values-format json")
+		fmt.Println("  ")
+		fmt.Println("  # Export the merged values as a .env file")
+		fmt.Println("  go run main.go -template=./templates -values=values.yaml --show-values --show-values-format dotenv > .env")
+		fmt.Println("  ")
+		fmt.Println("  # Show which source each value comes from")
+		fmt.Println("  go run main.go -template=./templates -values=values.yaml --set app.name=myapp --explain-values")
+		fmt.Println("  ")
+		fmt.Println("  # Upload rendered files to an HTTP endpoint")
+		fmt.Println("  go run main.go -template=./templates -output=https://config-store.internal/bundles/ \\")
+		fmt.Println("    --output-header 'Authorization: Bearer $TOKEN'")
+		fmt.Println("  ")
+		fmt.Println("\nSubcommands:")
+		fmt.Println("  bundle share -template dir [-- ...] Pack templates, values and render options into one file")
+		fmt.Println("  compat old-pack new-pack            Report breaking changes between two versions of a pack")
+		fmt.Println("  compare-with -binary old -- ...     Diff the outputs of another templater binary against this one")
+		fmt.Println("  deps fetch [-template dir]          Vendor the packs declared in templater.yaml into vendor/")
+		fmt.Println("  docs [-template dir] [-format fmt]  Generate a reference of the values a pack accepts")
+		fmt.Println("  new <pack> [directory]              Create a starter template pack (use 'new -list' to see packs)")
+		fmt.Println("  push oci://registry/repository:tag  Package a template directory and push it to an OCI registry")
+		fmt.Println("  refactor rename-key .old .new       Rename a value key across templates and values files")
+		fmt.Println("  refactor extract-partial -lines N-M Move a block of lines into a named define")
+		fmt.Println("  run bundle.tpkg [-output dir]       Render a bundle created by 'bundle share'")
+		fmt.Println("  values migrate -from v1 -to v2      Apply declarative key migrations to values files")
+		fmt.Println("  values encrypt -key age1...         Encrypt values matching -match patterns with age")
+		fmt.Println("  values decrypt -identity key.txt    Decrypt !age values")
 		fmt.Println("\nTemplate discovery:")
 		fmt.Println("  - Single file: processes the specified .tpl file")
 		fmt.Println("  - Directory: recursively finds all *.tpl files and processes them")
@@ -53,34 +253,103 @@ func main() {
 		fmt.Println("  - Directory paths can contain template variables (e.g., srv/{{.app.name}}/config.tpl)")
 		fmt.Println("  - Templated paths are processed with the same variables as file contents")
 		fmt.Println("\nTemplate variables can come from (in order of precedence):")
-		fmt.Println("  1. --set values (highest precedence)")
+		fmt.Println("  1. --set-json, --set, --set-string and --set-file values (highest precedence)")
 		fmt.Println("  2. Environment variables (converted to camelCase)")
-		fmt.Println("  3. YAML values file (lowest precedence)")
+		fmt.Println("  3. Dotenv files from -env-file (converted to camelCase)")
+		fmt.Println("  4. External sources from -values-from, in the order given")
+		fmt.Println("  5. YAML values file (lowest precedence)")
 		fmt.Println("\nEnvironment variable conversion examples:")
 		fmt.Println("  DATABASE_HOST → databaseHost")
 		fmt.Println("  APP_VERSION → appVersion")
 		fmt.Println("  MAX_CONNECTIONS → maxConnections")
+		fmt.Println("  TEMPLATER_VAL_DATABASE_HOST → databaseHost (with -env-prefix TEMPLATER_VAL_)")
 		fmt.Println("\nSet value formats:")
 		fmt.Println("  --set key=value")
 		fmt.Println("  --set key1=value1,key2=value2")
 		fmt.Println("  --set nested.key=value")
 		fmt.Println("  --set debug=true (converts to boolean)")
 		fmt.Println("  --set port=8080 (converts to integer)")
+		fmt.Println("  --set app.legacyFlag=null (removes the key from the merged values)")
+		fmt.Println("  --set-string version=1.10 (always kept as a string)")
+		fmt.Println("  --set-file tls.cert=certs/tls.crt (value is the file content)")
+		fmt.Println("  --set-json 'resources={\"limits\":{\"cpu\":\"500m\"}}' (value is parsed as JSON)")
 		return
 	}
 
+	if *staticCheck && !*strict {
+		fmt.Println("Error: --static-check requires --strict")
+		os.Exit(1)
+	}
+
+	if *recordDir != "" && *replayDir != "" {
+		fmt.Println("Error: --record and --replay cannot be used together")
+		os.Exit(1)
+	}
+
+	if strings.ContainsAny(*environment, `/\`) || *environment == "." || *environment == ".." {
+		fmt.Printf("Error: invalid -env '%s' (expected an environment name such as prod)\n", *environment)
+		os.Exit(1)
+	}
+
+	if *showFormat != "yaml" && *showFormat != "json" && *showFormat != "dotenv" {
+		fmt.Printf("Error: unsupported -show-values-format '%s' (expected yaml, json or dotenv)\n", *showFormat)
+		os.Exit(1)
+	}
+
 	if *templateFile == "" {
 		fmt.Println("Error: template file or directory is required")
 		fmt.Println("Use -help for usage information")
 		os.Exit(1)
 	}
 
+	if output.IsMemoryURL(*outputFile) {
+		fmt.Println("Error: mem:// output is only available to programs embedding templater through its pkg/templater package")
+		os.Exit(1)
+	}
+
 	// Check if template file/directory exists
 	if _, err := os.Stat(*templateFile); os.IsNotExist(err) {
 		fmt.Printf("Error: template path '%s' does not exist\n", *templateFile)
 		os.Exit(1)
 	}
 
+	// Check if env files exist (if specified)
+	for _, envFile := range envFiles {
+		if _, err := os.Stat(envFile); os.IsNotExist(err) {
+			fmt.Printf("Error: env file '%s' does not exist\n", envFile)
+			os.Exit(1)
+		}
+	}
+
+	if *recordDir != "" {
+		if err := providers.Record(*recordDir); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *replayDir != "" {
+		if err := providers.Replay(*replayDir); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := providers.AllowSecretCLIs(secretCLIs); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	providers.AllowKeyring(*allowKeyring)
+	providers.AllowGCPSecretReferences(*allowGCPSM)
+
+	// Fetch values files kept in a git repository or object storage
+	policy := cache.SourcePolicy{TTL: *sourceTTL, Offline: *offline}
+	fetchedFile, err := fetchValuesFile(*valuesFile, *sourceWait, policy)
+	if err != nil {
+		fmt.Printf("Error: failed to fetch values file: %v\n", err)
+		os.Exit(1)
+	}
+	*valuesFile = fetchedFile
+
 	// Check if values file exists (if specified)
 	if *valuesFile != "" {
 		if _, err := os.Stat(*valuesFile); os.IsNotExist(err) {
@@ -89,6 +358,14 @@ func main() {
 		}
 	}
 
+	// Check if schema file exists (if specified)
+	if *schemaFile != "" {
+		if _, err := os.Stat(*schemaFile); os.IsNotExist(err) {
+			fmt.Printf("Error: schema file '%s' does not exist\n", *schemaFile)
+			os.Exit(1)
+		}
+	}
+
 	// Determine if template is a directory
 	fileInfo, err := os.Stat(*templateFile)
 	if err != nil {
@@ -97,12 +374,145 @@ func main() {
 	}
 
 	cfg := config.NewConfig(*templateFile, *valuesFile, *outputFile, []string(setVals), fileInfo.IsDir(), *strict)
+	cfg.SetStrings = []string(setStrVals)
+	cfg.SetFiles = []string(setFileVals)
+	cfg.SetJSON = []string(setJSONVals)
+	cfg.ValuesFrom = []string(valuesFrom)
+	for _, object := range valuesK8s {
+		cfg.ValuesFrom = append(cfg.ValuesFrom, "k8s://"+strings.TrimPrefix(object, "k8s://"))
+	}
+	cfg.ValuesFromTimeout = *sourceWait
+	cfg.EnvFiles = []string(envFiles)
+	cfg.EnvPrefix = *envPrefix
+	cfg.Environment = *environment
+	cfg.AgeIdentities = ageIdentityFiles(ageIDs)
+	cfg.MergeStrategies = []string(mergeSpecs)
+	cfg.OutputMethod = *outMethod
+	cfg.OutputHeaders = []string(outHeaders)
+	cfg.OutputRetries = *outRetries
+	cfg.CombineFile = *combineFile
+	cfg.DryRun = *dryRun
+	cfg.CopyStatic = *copyStatic
+	cfg.Include = []string(includes)
+	cfg.Exclude = []string(excludes)
+	cfg.ParseOnly = *parseOnly
+	cfg.LazyValues = *lazyValues
+	cfg.TemplateValues = *tmplValues
+	cfg.PreciseNumbers = *preciseNums
+	cfg.SchemaFile = *schemaFile
+	cfg.SkipSchema = *skipSchema
+	cfg.SkipDirectoryValues = *skipDirVals
+	cfg.DisableFileFunctions = *noFileFuncs
+	cfg.SortKeys = *sortKeys
+	cfg.HelmCompat = *helmCompat
+	cfg.ReleaseName = *releaseName
+	cfg.ReleaseNamespace = *namespace
+	cfg.AllowEnv = allowEnv.Enabled
+	cfg.AllowEnvPrefix = allowEnv.Value
+	cfg.AllowExec = []string(allowExec)
+	cfg.AllowHTTP = []string(allowHTTP)
+	cfg.FuncPlugins = []string(funcPlugins)
+	cfg.ProcessPlugins = []string(procPlugins)
+	cfg.StarlarkFiles = []string(starFiles)
+	cfg.WasmPlugins = []string(wasmPlugins)
+	cfg.StaticCheck = *staticCheck
+	cfg.FailOnEmpty = *failOnEmpty
+	cfg.RenderTimeout = *renderWait
+	cfg.Workers = *workers
+	cfg.MaxMemory = int64(maxMemory)
+
+	// The render cache is opt-in, as cached outputs may hold secrets
+	if !*noCache && (*useCache || *cacheDir != "" || *remoteCache != "") {
+		cfg.CacheDir = *cacheDir
+		if cfg.CacheDir == "" {
+			// Caching is an optimization, run without it when there is no cache directory
+			if dir, err := cache.DefaultDir(); err == nil {
+				cfg.CacheDir = dir
+			}
+		}
+		cfg.RemoteCache = *remoteCache
+		cfg.RemoteCacheHeaders = []string(cacheHeaders)
+	}
+
+	// From here on, failures set exitCode and return, as os.Exit would skip the deferred
+	// trace flush; this call is deferred first so it exits after the flush
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
+	if *otelTrace {
+		shutdownTracing, err := telemetry.Setup(context.Background())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		// Flush spans before exiting, also when rendering failed
+		defer func() {
+			if err := shutdownTracing(context.Background()); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to export traces: %v\n", err)
+			}
+		}()
+	}
 
 	processor := processor.NewTemplateProcessor(cfg)
 
-	err = processor.Process()
-	if err != nil {
+	if *explainVals {
+		explained, err := processor.ExplainValues()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exitCode = 1
+			return
+		}
+		printExplanation(os.Stdout, explained)
+		return
+	}
+
+	if *showValues {
+		merged, err := processor.LoadValues()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exitCode = 1
+			return
+		}
+		data, err := values.Dump(merged, *showFormat)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exitCode = 1
+			return
+		}
+		os.Stdout.Write(data)
+		return
+	}
+
+	if err := processor.Process(); err != nil {
 		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
+		exitCode = 1
+	}
+}
+
+// fetchValuesFile downloads a git:: or s3:// / gs:// values file, or reuses an earlier
+// download following policy, and returns its local path. Other locations are returned
+// unchanged.
+func fetchValuesFile(location string, timeout time.Duration, policy cache.SourcePolicy) (string, error) {
+	switch {
+	case deps.IsGitSource(location):
+		dir, err := deps.DefaultCheckoutDir()
+		if err != nil {
+			return "", err
+		}
+		return deps.CheckoutFile(location, dir, policy)
+	case providers.IsObjectLocation(location):
+		dir, err := providers.DefaultObjectDir()
+		if err != nil {
+			return "", err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return providers.FetchValuesFile(ctx, location, dir, policy)
+	default:
+		return location, nil
 	}
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/menta2k/templater/internal/packs"
+)
+
+// runNew materializes an embedded starter template pack.
+func runNew(args []string) error {
+	fs := flag.NewFlagSet("new", flag.ContinueOnError)
+	var (
+		list  = fs.Bool("list", false, "List available template packs")
+		force = fs.Bool("force", false, "Overwrite existing files")
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: templater new [options] <pack> [directory]")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if *list || len(positional) == 0 {
+		fmt.Println("Available template packs:")
+		for _, pack := range packs.List() {
+			fmt.Printf("  %-24s %s\n", pack.Name, pack.Description)
+		}
+		if !*list {
+			return fmt.Errorf("new requires a pack name")
+		}
+		return nil
+	}
+	if len(positional) > 2 {
+		fs.Usage()
+		return fmt.Errorf("too many arguments")
+	}
+
+	pack, err := packs.Get(positional[0])
+	if err != nil {
+		return err
+	}
+
+	destination := pack.Name
+	if len(positional) == 2 {
+		destination = positional[1]
+	}
+
+	written, err := packs.Materialize(pack.Name, destination, *force)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range written {
+		fmt.Printf("Created: %s\n", file)
+	}
+	fmt.Printf("\nTemplate pack '%s' created in %s. Render it with:\n  cd %s && %s\n", pack.Name, destination, destination, pack.Usage)
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/menta2k/templater/internal/oci"
+)
+
+// runPush packages a template directory and pushes it to an OCI registry.
+func runPush(args []string) error {
+	fs := flag.NewFlagSet("push", flag.ContinueOnError)
+	var (
+		templateDir = fs.String("template", ".", "Path to the template directory to package")
+		valuesFile  = fs.String("values", "", "Default values file to include in the pack (optional)")
+		schemaFile  = fs.String("schema", "", "Values JSON schema to include in the pack (optional)")
+		registry    = addRegistryFlags(fs)
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: templater push [options] oci://registry/repository:tag")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return fmt.Errorf("push requires exactly one oci:// reference")
+	}
+
+	ref, err := oci.ParseReference(positional[0])
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(*templateDir)
+	if err != nil {
+		return fmt.Errorf("cannot stat template directory '%s': %w", *templateDir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("template path '%s' is not a directory", *templateDir)
+	}
+
+	artifact, err := oci.PackageDirectory(ref, *templateDir, *valuesFile, *schemaFile)
+	if err != nil {
+		return err
+	}
+
+	digest, err := registry.client().Push(ref, artifact)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Pushed %s\nDigest: %s\n", ref, digest)
+	return nil
+}
+
+// parseInterspersed parses flags that may appear before or after positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestParseInterspersed(t *testing.T) {
+	tests := []struct {
+		name               string
+		args               []string
+		expectedPositional []string
+		expectedTemplate   string
+	}{
+		{
+			name:               "flags before reference",
+			args:               []string{"-template", "./templates", "oci://registry/org/templates:1.0"},
+			expectedPositional: []string{"oci://registry/org/templates:1.0"},
+			expectedTemplate:   "./templates",
+		},
+		{
+			name:               "flags after reference",
+			args:               []string{"oci://registry/org/templates:1.0", "-template", "./pack"},
+			expectedPositional: []string{"oci://registry/org/templates:1.0"},
+			expectedTemplate:   "./pack",
+		},
+		{
+			name:               "no positional arguments",
+			args:               []string{"-template", "./pack"},
+			expectedPositional: nil,
+			expectedTemplate:   "./pack",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			templateDir := fs.String("template", ".", "")
+
+			positional, err := parseInterspersed(fs, tt.args)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(positional, tt.expectedPositional) {
+				t.Errorf("Expected positional %v, got %v", tt.expectedPositional, positional)
+			}
+			if *templateDir != tt.expectedTemplate {
+				t.Errorf("Expected template %s, got %s", tt.expectedTemplate, *templateDir)
+			}
+		})
+	}
+}
+
+func TestRunPushRequiresReference(t *testing.T) {
+	if err := runPush([]string{"-template", "."}); err == nil {
+		t.Error("Expected error when no reference is given")
+	}
+	if err := runPush([]string{"registry/org/templates:1.0"}); err == nil {
+		t.Error("Expected error for reference without oci:// scheme")
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/menta2k/templater/internal/cli"
+	"github.com/menta2k/templater/internal/refactor"
+)
+
+// refactorCommands maps `templater refactor` subcommands to their entry points.
+var refactorCommands = map[string]func(args []string) error{
+	"extract-partial": runRefactorExtractPartial,
+	"rename-key":      runRefactorRenameKey,
+}
+
+// runRefactor dispatches `templater refactor <command>`.
+func runRefactor(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("refactor requires a command (%s)", refactorCommandNames())
+	}
+
+	run, ok := refactorCommands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown refactor command '%s' (expected one of: %s)", args[0], refactorCommandNames())
+	}
+	return run(args[1:])
+}
+
+// refactorCommandNames returns the sorted names of the refactor subcommands.
+func refactorCommandNames() string {
+	names := make([]string, 0, len(refactorCommands))
+	for name := range refactorCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// runRefactorRenameKey renames a value key in templates and values files.
+func runRefactorRenameKey(args []string) error {
+	fs := flag.NewFlagSet("refactor rename-key", flag.ContinueOnError)
+	var (
+		templateDir = fs.String("template", ".", "Template directory to rewrite")
+		valuesFiles = cli.StringList{}
+		dryRun      = fs.Bool("dry-run", false, "Report the changes without modifying any file")
+	)
+	fs.Var(&valuesFiles, "values", "Values file to rewrite (can be used multiple times)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: templater refactor rename-key [options] .old.key .new.key")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		fs.Usage()
+		return fmt.Errorf("refactor rename-key requires the old and new key")
+	}
+
+	oldPath, err := refactor.ParsePath(positional[0])
+	if err != nil {
+		return err
+	}
+	newPath, err := refactor.ParsePath(positional[1])
+	if err != nil {
+		return err
+	}
+
+	changes, err := refactor.RenameKeyInDir(*templateDir, oldPath, newPath, *dryRun)
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		if change.NewPath != "" {
+			fmt.Printf("Updated: %s -> %s (%d reference(s))\n", change.Path, change.NewPath, change.References)
+			continue
+		}
+		fmt.Printf("Updated: %s (%d reference(s))\n", change.Path, change.References)
+	}
+
+	for _, file := range valuesFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read values file: %w", err)
+		}
+
+		renamed, changed, err := refactor.RenameValuesKey(data, oldPath, newPath)
+		if err != nil {
+			return fmt.Errorf("failed to process %s: %w", file, err)
+		}
+		if !changed {
+			continue
+		}
+
+		if !*dryRun {
+			if err := os.WriteFile(file, renamed, 0o644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", file, err)
+			}
+		}
+		fmt.Printf("Updated: %s (moved %s -> %s)\n", file, positional[0], positional[1])
+	}
+
+	return nil
+}
+
+// runRefactorExtractPartial moves a block of lines into a named define.
+func runRefactorExtractPartial(args []string) error {
+	fs := flag.NewFlagSet("refactor extract-partial", flag.ContinueOnError)
+	var (
+		lines  = fs.String("lines", "", "Line range to extract, e.g. 10-24 (required)")
+		name   = fs.String("name", "", "Name of the new partial (required)")
+		dryRun = fs.Bool("dry-run", false, "Print the result instead of rewriting the template")
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: templater refactor extract-partial -lines 10-24 -name labels [options] template.tpl")
+		fs.PrintDefaults()
+	}
+
+	files, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if *lines == "" || *name == "" || len(files) != 1 {
+		fs.Usage()
+		return fmt.Errorf("refactor extract-partial requires -lines, -name and a template file")
+	}
+
+	start, end, err := parseLineRange(*lines)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(files[0])
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+	content, err := os.ReadFile(files[0])
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+
+	result, err := refactor.ExtractPartial(string(content), start, end, *name)
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		fmt.Print(result)
+		return nil
+	}
+	if err := os.WriteFile(files[0], []byte(result), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", files[0], err)
+	}
+	fmt.Printf("Updated: %s (extracted lines %d-%d into %q)\n", files[0], start, end, *name)
+	return nil
+}
+
+// parseLineRange parses "N-M" or "N" into an inclusive line range.
+func parseLineRange(value string) (int, int, error) {
+	startText, endText, isRange := strings.Cut(value, "-")
+	if !isRange {
+		endText = startText
+	}
+
+	start, err := strconv.Atoi(strings.TrimSpace(startText))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid line range %s", value)
+	}
+	end, err := strconv.Atoi(strings.TrimSpace(endText))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid line range %s", value)
+	}
+	return start, end, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunRefactorUnknownCommand(t *testing.T) {
+	if err := runRefactor(nil); err == nil {
+		t.Error("Expected error when no refactor command is given")
+	}
+	if err := runRefactor([]string{"unknown"}); err == nil {
+		t.Error("Expected error for unknown refactor command")
+	}
+}
+
+func TestRunRefactorRenameKey(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-refactor-rename-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templatePath := filepath.Join(tempDir, "app.tpl")
+	valuesPath := filepath.Join(tempDir, "values.yaml")
+	if err := os.WriteFile(templatePath, []byte("name: {{ .app.name }}\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	if err := os.WriteFile(valuesPath, []byte("app:\n  name: web\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+
+	err = runRefactorRenameKey([]string{".app.name", ".service.name", "-template", tempDir, "-values", valuesPath})
+	if err != nil {
+		t.Fatalf("refactor rename-key failed: %v", err)
+	}
+
+	template, err := os.ReadFile(templatePath)
+	if err != nil {
+		t.Fatalf("Failed to read template: %v", err)
+	}
+	if string(template) != "name: {{ .service.name }}\n" {
+		t.Errorf("Expected template to be rewritten, got %q", template)
+	}
+
+	values, err := os.ReadFile(valuesPath)
+	if err != nil {
+		t.Fatalf("Failed to read values: %v", err)
+	}
+	if !strings.Contains(string(values), "service:\n  name: web") {
+		t.Errorf("Expected values to be rewritten, got %q", values)
+	}
+}
+
+func TestParseLineRange(t *testing.T) {
+	tests := []struct {
+		value     string
+		start     int
+		end       int
+		wantError bool
+	}{
+		{value: "3-7", start: 3, end: 7},
+		{value: "5", start: 5, end: 5},
+		{value: "a-b", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			start, end, err := parseLineRange(tt.value)
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if start != tt.start || end != tt.end {
+				t.Errorf("Expected %d-%d, got %d-%d", tt.start, tt.end, start, end)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/menta2k/templater/internal/oci"
+)
+
+// Environment variables holding the default registry credentials.
+const (
+	registryUsernameEnv = "TEMPLATER_REGISTRY_USERNAME"
+	registryPasswordEnv = "TEMPLATER_REGISTRY_PASSWORD"
+)
+
+// registryFlags are the flags of commands reaching OCI registries.
+type registryFlags struct {
+	username  *string
+	password  *string
+	plainHTTP *bool
+}
+
+// addRegistryFlags registers -username, -password and -plain-http on fs. The credentials
+// fall back to environment variables after parsing rather than through flag defaults,
+// which usage output would print.
+func addRegistryFlags(fs *flag.FlagSet) *registryFlags {
+	return &registryFlags{
+		username:  fs.String("username", "", "Registry username (default: $"+registryUsernameEnv+")"),
+		password:  fs.String("password", "", "Registry password or token (default: $"+registryPasswordEnv+")"),
+		plainHTTP: fs.Bool("plain-http", false, "Use plain HTTP instead of HTTPS to reach the registry"),
+	}
+}
+
+// credentials returns the username and password from the flags or, when unset, the
+// environment.
+func (r *registryFlags) credentials() (username, password string) {
+	username, password = *r.username, *r.password
+	if username == "" {
+		username = os.Getenv(registryUsernameEnv)
+	}
+	if password == "" {
+		password = os.Getenv(registryPasswordEnv)
+	}
+	return username, password
+}
+
+// client returns the registry client for the parsed flags.
+func (r *registryFlags) client() *oci.Client {
+	username, password := r.credentials()
+	return oci.NewClient(username, password, *r.plainHTTP)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestRegistryFlags(t *testing.T) {
+	t.Setenv(registryUsernameEnv, "ci-bot")
+	t.Setenv(registryPasswordEnv, "s3cret-token")
+
+	fs := flag.NewFlagSet("push", flag.ContinueOnError)
+	var usage strings.Builder
+	fs.SetOutput(&usage)
+	registry := addRegistryFlags(fs)
+	fs.PrintDefaults()
+	if strings.Contains(usage.String(), "s3cret-token") {
+		t.Errorf("Expected usage without the password, got %s", usage.String())
+	}
+
+	if err := fs.Parse(nil); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if username, password := registry.credentials(); username != "ci-bot" || password != "s3cret-token" {
+		t.Errorf("Expected credentials from the environment, got %s/%s", username, password)
+	}
+
+	if err := fs.Parse([]string{"-password", "flag-token"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if _, password := registry.credentials(); password != "flag-token" {
+		t.Errorf("Expected the -password flag to win, got %s", password)
+	}
+}
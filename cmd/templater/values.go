@@ -0,0 +1,207 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/menta2k/templater/internal/cli"
+	"github.com/menta2k/templater/internal/values"
+)
+
+// valuesCommands maps `templater values` subcommands to their entry points.
+var valuesCommands = map[string]func(args []string) error{
+	"decrypt": runValuesDecrypt,
+	"encrypt": runValuesEncrypt,
+	"migrate": runValuesMigrate,
+}
+
+// runValues dispatches `templater values <command>`.
+func runValues(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("values requires a command (%s)", valuesCommandNames())
+	}
+
+	run, ok := valuesCommands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown values command '%s' (expected one of: %s)", args[0], valuesCommandNames())
+	}
+	return run(args[1:])
+}
+
+// valuesCommandNames returns the sorted names of the values subcommands.
+func valuesCommandNames() string {
+	names := make([]string, 0, len(valuesCommands))
+	for name := range valuesCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// runValuesMigrate applies declarative migration rules to values files.
+func runValuesMigrate(args []string) error {
+	fs := flag.NewFlagSet("values migrate", flag.ContinueOnError)
+	var (
+		from      = fs.String("from", "", "Schema version the values files currently follow (required)")
+		to        = fs.String("to", "", "Schema version to migrate to (required)")
+		rulesFile = fs.String("rules", "", "Path to the migration rules file (required)")
+		write     = fs.Bool("write", false, "Rewrite the values files in place instead of printing the result")
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: templater values migrate -from v1 -to v2 -rules rules.yaml [options] values.yaml...")
+		fs.PrintDefaults()
+	}
+
+	files, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if *from == "" || *to == "" || *rulesFile == "" || len(files) == 0 {
+		fs.Usage()
+		return fmt.Errorf("values migrate requires -from, -to, -rules and at least one values file")
+	}
+
+	rules, err := values.LoadMigrationRules(*rulesFile)
+	if err != nil {
+		return err
+	}
+
+	plan, err := rules.Plan(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	return rewriteValuesFiles(files, *write, func(data []byte) ([]byte, string, error) {
+		migrated, err := values.MigrateYAML(data, plan)
+		return migrated, fmt.Sprintf("migrated %s -> %s", *from, *to), err
+	})
+}
+
+// runValuesEncrypt encrypts matching values with age recipients.
+func runValuesEncrypt(args []string) error {
+	fs := flag.NewFlagSet("values encrypt", flag.ContinueOnError)
+	var (
+		keys    = cli.StringList{}
+		matches = cli.StringList{}
+		write   = fs.Bool("write", false, "Rewrite the values files in place instead of printing the result")
+	)
+	fs.Var(&keys, "key", "age recipient public key (age1...), can be used multiple times")
+	fs.Var(&matches, "match", "Pattern of dotted value paths to encrypt, e.g. '*.password' (can be used multiple times)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: templater values encrypt -key age1... -match '*.password' [options] values.yaml...")
+		fs.PrintDefaults()
+	}
+
+	files, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 || len(matches) == 0 || len(files) == 0 {
+		fs.Usage()
+		return fmt.Errorf("values encrypt requires -key, -match and at least one values file")
+	}
+
+	recipients, err := values.ParseAgeRecipients(keys)
+	if err != nil {
+		return err
+	}
+
+	return rewriteValuesFiles(files, *write, func(data []byte) ([]byte, string, error) {
+		encrypted, count, err := values.EncryptYAML(data, recipients, matches)
+		return encrypted, fmt.Sprintf("encrypted %d value(s)", count), err
+	})
+}
+
+// runValuesDecrypt decrypts !age values with age identities.
+func runValuesDecrypt(args []string) error {
+	fs := flag.NewFlagSet("values decrypt", flag.ContinueOnError)
+	var (
+		identityFiles = cli.StringList{}
+		write         = fs.Bool("write", false, "Rewrite the values files in place instead of printing the result")
+	)
+	fs.Var(&identityFiles, "identity", "Path to an age identity file (can be used multiple times; default: $"+values.AgeIdentityEnv+")")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: templater values decrypt -identity key.txt [options] values.yaml...")
+		fs.PrintDefaults()
+	}
+
+	files, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	identityPaths := ageIdentityFiles(identityFiles)
+	if len(identityPaths) == 0 || len(files) == 0 {
+		fs.Usage()
+		return fmt.Errorf("values decrypt requires -identity and at least one values file")
+	}
+
+	identities, err := values.LoadAgeIdentities(identityPaths)
+	if err != nil {
+		return err
+	}
+
+	return rewriteValuesFiles(files, *write, func(data []byte) ([]byte, string, error) {
+		decrypted, count, err := values.DecryptYAML(data, identities)
+		return decrypted, fmt.Sprintf("decrypted %d value(s)", count), err
+	})
+}
+
+// ageIdentityFiles returns the age identity files given as flags, or the one named by
+// AGE_IDENTITY when there are none.
+func ageIdentityFiles(files []string) []string {
+	if len(files) == 0 {
+		if file := os.Getenv(values.AgeIdentityEnv); file != "" {
+			return []string{file}
+		}
+	}
+	return files
+}
+
+// rewriteValuesFiles applies transform to each file, printing the result or rewriting the file in place.
+func rewriteValuesFiles(files []string, write bool, transform func(data []byte) ([]byte, string, error)) error {
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read values file: %w", err)
+		}
+
+		result, summary, err := transform(data)
+		if err != nil {
+			return fmt.Errorf("failed to process %s: %w", file, err)
+		}
+
+		if !write {
+			if len(files) > 1 {
+				fmt.Printf("# Source: %s\n", file)
+			}
+			fmt.Print(string(result))
+			continue
+		}
+
+		if err := os.WriteFile(file, result, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file, err)
+		}
+		fmt.Printf("Updated: %s (%s)\n", file, summary)
+	}
+
+	return nil
+}
+
+// printExplanation prints where each merged value came from, followed by the values it
+// overrides from lower-precedence sources.
+func printExplanation(w io.Writer, explained []values.Provenance) {
+	for _, p := range explained {
+		if p.Removed {
+			fmt.Fprintf(w, "%s: removed by %s\n", p.Path, p.Source)
+		} else {
+			fmt.Fprintf(w, "%s = %s (%s)\n", p.Path, values.FormatValue(p.Value), p.Source)
+		}
+		for _, shadowed := range p.Shadowed {
+			fmt.Fprintf(w, "    overrides %s (%s)\n", values.FormatValue(shadowed.Value), shadowed.Source)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/menta2k/templater/internal/values"
+)
+
+func TestRunValuesUnknownCommand(t *testing.T) {
+	if err := runValues(nil); err == nil {
+		t.Error("Expected error when no values command is given")
+	}
+	if err := runValues([]string{"unknown"}); err == nil {
+		t.Error("Expected error for unknown values command")
+	}
+}
+
+func TestRunValuesMigrateWrite(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-values-migrate-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	valuesPath := filepath.Join(tempDir, "values.yaml")
+	rulesPath := filepath.Join(tempDir, "rules.yaml")
+	if err := os.WriteFile(valuesPath, []byte("db:\n  host: localhost\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+	rules := "migrations:\n  - from: v1\n    to: v2\n    rules:\n      - move: {from: db, to: database}\n"
+	if err := os.WriteFile(rulesPath, []byte(rules), 0o644); err != nil {
+		t.Fatalf("Failed to write rules: %v", err)
+	}
+
+	err = runValuesMigrate([]string{"-from", "v1", "-to", "v2", "-rules", rulesPath, "-write", valuesPath})
+	if err != nil {
+		t.Fatalf("values migrate failed: %v", err)
+	}
+
+	content, err := os.ReadFile(valuesPath)
+	if err != nil {
+		t.Fatalf("Failed to read values: %v", err)
+	}
+	expected := "database:\n  host: localhost\n"
+	if string(content) != expected {
+		t.Errorf("Expected %q, got %q", expected, content)
+	}
+}
+
+func TestRunValuesEncryptDecrypt(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("Failed to generate identity: %v", err)
+	}
+
+	tempDir, err := os.MkdirTemp("", "test-values-encrypt-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	original := "database:\n  password: s3cret\n"
+	valuesPath := filepath.Join(tempDir, "values.yaml")
+	identityPath := filepath.Join(tempDir, "key.txt")
+	if err := os.WriteFile(valuesPath, []byte(original), 0o644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+	if err := os.WriteFile(identityPath, []byte(identity.String()), 0o600); err != nil {
+		t.Fatalf("Failed to write identity: %v", err)
+	}
+
+	err = runValuesEncrypt([]string{"-key", identity.Recipient().String(), "-match", "*.password", "-write", valuesPath})
+	if err != nil {
+		t.Fatalf("values encrypt failed: %v", err)
+	}
+	encrypted, _ := os.ReadFile(valuesPath)
+	if strings.Contains(string(encrypted), "s3cret") {
+		t.Fatal("Expected password to be encrypted")
+	}
+
+	if err := runValuesDecrypt([]string{"-identity", identityPath, "-write", valuesPath}); err != nil {
+		t.Fatalf("values decrypt failed: %v", err)
+	}
+	decrypted, _ := os.ReadFile(valuesPath)
+	if string(decrypted) != original {
+		t.Errorf("Expected %q after decrypt, got %q", original, decrypted)
+	}
+}
+
+func TestPrintExplanation(t *testing.T) {
+	explained := []values.Provenance{
+		{Path: ".app.legacyFlag", Source: "--set", Removed: true, Shadowed: []values.ShadowedValue{{Source: "values file values.yaml", Value: true}}},
+		{Path: ".app.name", Value: "api", Source: "--set", Shadowed: []values.ShadowedValue{{Source: "values file values.yaml", Value: "web"}}},
+		{Path: ".ports", Value: []any{80, 443}, Source: "values file values.yaml"},
+	}
+
+	var out strings.Builder
+	printExplanation(&out, explained)
+
+	expected := `.app.legacyFlag: removed by --set
+    overrides true (values file values.yaml)
+.app.name = "api" (--set)
+    overrides "web" (values file values.yaml)
+.ports = [80,443] (values file values.yaml)
+`
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
@@ -0,0 +1,294 @@
+package awsauth
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// metadataTimeout bounds each request to a local credentials endpoint, so lookups fail
+// fast outside AWS.
+const metadataTimeout = 2 * time.Second
+
+// Endpoints of the credential providers, variables for tests.
+var (
+	containerCredentialsHost = "http://169.254.170.2"
+	instanceMetadataEndpoint = "http://169.254.169.254"
+)
+
+// DefaultCredentials resolves credentials in the order of the AWS SDK default chain:
+// environment variables, a web identity token (AWS_WEB_IDENTITY_TOKEN_FILE and
+// AWS_ROLE_ARN, as used on EKS), the shared credentials file profile selected by
+// AWS_PROFILE, container credentials (ECS and EKS Pod Identity) and finally the EC2
+// instance metadata service.
+func DefaultCredentials(ctx context.Context) (Credentials, error) {
+	if creds, err := CredentialsFromEnv(); err == nil {
+		return creds, nil
+	}
+
+	providers := []struct {
+		name string
+		load func(ctx context.Context) (Credentials, bool, error)
+	}{
+		{"web identity", webIdentityCredentials},
+		{"shared credentials file", sharedFileCredentials},
+		{"container credentials", containerCredentials},
+		{"instance metadata", instanceCredentials},
+	}
+	for _, provider := range providers {
+		creds, ok, err := provider.load(ctx)
+		if err != nil {
+			return Credentials{}, fmt.Errorf("failed to load AWS credentials from %s: %w", provider.name, err)
+		}
+		if ok {
+			return creds, nil
+		}
+	}
+	return Credentials{}, errors.New("AWS credentials not found (set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, AWS_PROFILE, or run with an instance or container role)")
+}
+
+// sharedFileCredentials reads the AWS_PROFILE profile, "default" unless set, from
+// AWS_SHARED_CREDENTIALS_FILE or ~/.aws/credentials.
+func sharedFileCredentials(context.Context) (Credentials, bool, error) {
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return Credentials{}, false, nil
+		}
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Credentials{}, false, nil
+	}
+	if err != nil {
+		return Credentials{}, false, err
+	}
+	defer file.Close()
+
+	profile := FirstEnv("AWS_PROFILE", "AWS_DEFAULT_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+
+	var creds Credentials
+	found := false
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			found = found || section == profile
+			continue
+		}
+		if section != profile {
+			continue
+		}
+		key, value, _ := strings.Cut(line, "=")
+		switch strings.TrimSpace(key) {
+		case "aws_access_key_id":
+			creds.AccessKeyID = strings.TrimSpace(value)
+		case "aws_secret_access_key":
+			creds.SecretAccessKey = strings.TrimSpace(value)
+		case "aws_session_token":
+			creds.SessionToken = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Credentials{}, false, err
+	}
+
+	if !found {
+		if os.Getenv("AWS_PROFILE") != "" {
+			return Credentials{}, false, fmt.Errorf("profile %s not found in %s", profile, path)
+		}
+		return Credentials{}, false, nil
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return Credentials{}, false, fmt.Errorf("profile %s in %s has no aws_access_key_id and aws_secret_access_key", profile, path)
+	}
+	return creds, true, nil
+}
+
+// webIdentityCredentials exchanges the token in AWS_WEB_IDENTITY_TOKEN_FILE for
+// credentials of AWS_ROLE_ARN with STS.
+func webIdentityCredentials(ctx context.Context) (Credentials, bool, error) {
+	tokenFile, roleARN := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"), os.Getenv("AWS_ROLE_ARN")
+	if tokenFile == "" || roleARN == "" {
+		return Credentials{}, false, nil
+	}
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return Credentials{}, false, err
+	}
+
+	sessionName := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if sessionName == "" {
+		sessionName = fmt.Sprintf("templater-%d", time.Now().Unix())
+	}
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {roleARN},
+		"RoleSessionName":  {sessionName},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, Endpoint("sts", Region())+"/", strings.NewReader(form.Encode()))
+	if err != nil {
+		return Credentials{}, false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	body, err := doMetadataRequest(req, 0)
+	if err != nil {
+		return Credentials{}, false, err
+	}
+	var response struct {
+		Credentials struct {
+			AccessKeyID     string `xml:"AccessKeyId"`
+			SecretAccessKey string `xml:"SecretAccessKey"`
+			SessionToken    string `xml:"SessionToken"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := xml.Unmarshal(body, &response); err != nil {
+		return Credentials{}, false, fmt.Errorf("failed to parse STS response: %w", err)
+	}
+	return Credentials(response.Credentials), true, nil
+}
+
+// containerCredentials reads credentials from the endpoint ECS and EKS Pod Identity
+// expose through AWS_CONTAINER_CREDENTIALS_RELATIVE_URI or AWS_CONTAINER_CREDENTIALS_FULL_URI.
+func containerCredentials(ctx context.Context) (Credentials, bool, error) {
+	endpoint := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if relative := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); relative != "" {
+		endpoint = containerCredentialsHost + relative
+	}
+	if endpoint == "" {
+		return Credentials{}, false, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return Credentials{}, false, err
+	}
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if tokenFile := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); tokenFile != "" {
+		data, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return Credentials{}, false, err
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	creds, err := fetchRoleCredentials(req)
+	if err != nil {
+		return Credentials{}, false, err
+	}
+	return creds, true, nil
+}
+
+// instanceCredentials reads the credentials of the instance role from the EC2 instance
+// metadata service (IMDSv2), unless AWS_EC2_METADATA_DISABLED is true. An unreachable
+// metadata service means there are no credentials.
+func instanceCredentials(ctx context.Context) (Credentials, bool, error) {
+	if strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		return Credentials{}, false, nil
+	}
+	endpoint := instanceMetadataEndpoint
+	if override := os.Getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT"); override != "" {
+		endpoint = strings.TrimSuffix(override, "/")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return Credentials{}, false, err
+	}
+	req.Header.Set("X-Aws-Ec2-Metadata-Token-Ttl-Seconds", "300")
+	token, err := doMetadataRequest(req, metadataTimeout)
+	if err != nil {
+		return Credentials{}, false, nil
+	}
+
+	rolesURL := endpoint + "/latest/meta-data/iam/security-credentials/"
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, rolesURL, nil)
+	if err != nil {
+		return Credentials{}, false, err
+	}
+	req.Header.Set("X-Aws-Ec2-Metadata-Token", string(token))
+	roles, err := doMetadataRequest(req, metadataTimeout)
+	if err != nil {
+		// Instances without a role have no credentials
+		return Credentials{}, false, nil
+	}
+	role, _, _ := strings.Cut(strings.TrimSpace(string(roles)), "\n")
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, rolesURL+url.PathEscape(role), nil)
+	if err != nil {
+		return Credentials{}, false, err
+	}
+	req.Header.Set("X-Aws-Ec2-Metadata-Token", string(token))
+	creds, err := fetchRoleCredentials(req)
+	if err != nil {
+		return Credentials{}, false, err
+	}
+	return creds, true, nil
+}
+
+// fetchRoleCredentials reads the JSON credentials document returned by the container
+// and instance metadata endpoints.
+func fetchRoleCredentials(req *http.Request) (Credentials, error) {
+	body, err := doMetadataRequest(req, metadataTimeout)
+	if err != nil {
+		return Credentials{}, err
+	}
+	var document struct {
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string `json:"SecretAccessKey"`
+		Token           string `json:"Token"`
+	}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return Credentials{}, fmt.Errorf("failed to parse credentials: %w", err)
+	}
+	if document.AccessKeyID == "" || document.SecretAccessKey == "" {
+		return Credentials{}, errors.New("credentials response has no access key")
+	}
+	return Credentials{AccessKeyID: document.AccessKeyID, SecretAccessKey: document.SecretAccessKey, SessionToken: document.Token}, nil
+}
+
+// doMetadataRequest sends req, bounded by timeout unless it is zero, and returns the
+// body of a successful response.
+func doMetadataRequest(req *http.Request, timeout time.Duration) ([]byte, error) {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s: unexpected status %s", req.Method, req.URL.Redacted(), resp.Status)
+	}
+	return body, nil
+}
@@ -0,0 +1,136 @@
+package awsauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// clearCredentialEnv unsets every variable the default chain reads and disables the
+// instance metadata service.
+func clearCredentialEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{
+		"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE", "AWS_DEFAULT_PROFILE",
+		"AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_ROLE_ARN", "AWS_CONTAINER_CREDENTIALS_FULL_URI",
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_AUTHORIZATION_TOKEN",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE", "AWS_EC2_METADATA_SERVICE_ENDPOINT",
+	} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+}
+
+func TestDefaultCredentials(t *testing.T) {
+	roleCredentials := `{"AccessKeyId":"ROLEKEY","SecretAccessKey":"rolesecret","Token":"roletoken"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			_, _ = w.Write([]byte("imds-token"))
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/" && r.Header.Get("X-Aws-Ec2-Metadata-Token") == "imds-token":
+			_, _ = w.Write([]byte("web-role\n"))
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/web-role" && r.Header.Get("X-Aws-Ec2-Metadata-Token") == "imds-token":
+			_, _ = w.Write([]byte(roleCredentials))
+		case r.URL.Path == "/container" && r.Header.Get("Authorization") == "container-token":
+			_, _ = w.Write([]byte(roleCredentials))
+		case r.URL.Path == "/" && r.Method == http.MethodPost:
+			if err := r.ParseForm(); err != nil || r.Form.Get("WebIdentityToken") != "jwt" || r.Form.Get("RoleArn") != "arn:aws:iam::1:role/app" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte(`<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>` +
+				`<AccessKeyId>STSKEY</AccessKeyId><SecretAccessKey>stssecret</SecretAccessKey><SessionToken>ststoken</SessionToken>` +
+				`</Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	credentialsFile := filepath.Join(dir, "credentials")
+	content := "[default]\naws_access_key_id = DEFAULTKEY\naws_secret_access_key = defaultsecret\n\n" +
+		"# staging account\n[staging]\naws_access_key_id=STAGINGKEY\naws_secret_access_key=stagingsecret\naws_session_token=stagingtoken\n"
+	if err := os.WriteFile(credentialsFile, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write credentials file: %v", err)
+	}
+	tokenFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenFile, []byte("jwt\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write token file: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		env       map[string]string
+		expected  Credentials
+		wantError string
+	}{
+		{
+			name:     "environment",
+			env:      map[string]string{"AWS_ACCESS_KEY_ID": "ENVKEY", "AWS_SECRET_ACCESS_KEY": "envsecret", "AWS_SHARED_CREDENTIALS_FILE": credentialsFile},
+			expected: Credentials{AccessKeyID: "ENVKEY", SecretAccessKey: "envsecret"},
+		},
+		{
+			name:     "default profile",
+			env:      map[string]string{"AWS_SHARED_CREDENTIALS_FILE": credentialsFile},
+			expected: Credentials{AccessKeyID: "DEFAULTKEY", SecretAccessKey: "defaultsecret"},
+		},
+		{
+			name:     "named profile",
+			env:      map[string]string{"AWS_SHARED_CREDENTIALS_FILE": credentialsFile, "AWS_PROFILE": "staging"},
+			expected: Credentials{AccessKeyID: "STAGINGKEY", SecretAccessKey: "stagingsecret", SessionToken: "stagingtoken"},
+		},
+		{
+			name:      "missing profile",
+			env:       map[string]string{"AWS_SHARED_CREDENTIALS_FILE": credentialsFile, "AWS_PROFILE": "prod"},
+			wantError: "profile prod not found",
+		},
+		{
+			name:     "web identity",
+			env:      map[string]string{"AWS_WEB_IDENTITY_TOKEN_FILE": tokenFile, "AWS_ROLE_ARN": "arn:aws:iam::1:role/app", "AWS_ENDPOINT_URL_STS": server.URL},
+			expected: Credentials{AccessKeyID: "STSKEY", SecretAccessKey: "stssecret", SessionToken: "ststoken"},
+		},
+		{
+			name:     "container",
+			env:      map[string]string{"AWS_CONTAINER_CREDENTIALS_FULL_URI": server.URL + "/container", "AWS_CONTAINER_AUTHORIZATION_TOKEN": "container-token"},
+			expected: Credentials{AccessKeyID: "ROLEKEY", SecretAccessKey: "rolesecret", SessionToken: "roletoken"},
+		},
+		{
+			name:     "instance metadata",
+			env:      map[string]string{"AWS_EC2_METADATA_DISABLED": "", "AWS_EC2_METADATA_SERVICE_ENDPOINT": server.URL},
+			expected: Credentials{AccessKeyID: "ROLEKEY", SecretAccessKey: "rolesecret", SessionToken: "roletoken"},
+		},
+		{
+			name:      "none",
+			wantError: "AWS credentials not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearCredentialEnv(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			creds, err := DefaultCredentials(context.Background())
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if creds != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, creds)
+			}
+		})
+	}
+}
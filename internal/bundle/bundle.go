@@ -0,0 +1,297 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Files and directories inside a bundle.
+const (
+	ManifestFile = "bundle.json"
+	TemplatesDir = "templates"
+	ValuesFile   = "values.yaml"
+	SchemaFile   = "values.schema.json"
+)
+
+// formatVersion is the version of the bundle layout written by Create.
+const formatVersion = 1
+
+// reservedOptions are render options set from the bundle contents when it is run.
+var reservedOptions = map[string]bool{"template": true, "values": true, "schema": true, "output": true}
+
+// allowedOptions are the render options a bundle may record. They only affect how the
+// bundled templates and values render, unlike options that run commands, reach the
+// network or read and write files on the machine running the bundle.
+var allowedOptions = map[string]bool{
+	"set":             true,
+	"set-string":      true,
+	"set-json":        true,
+	"merge-strategy":  true,
+	"env":             true,
+	"strict":          true,
+	"static-check":    true,
+	"sort-keys":       true,
+	"precise-numbers": true,
+	"lazy-values":     true,
+	"skip-schema":     true,
+	"skip-dir-values": true,
+	"helm-compat":     true,
+	"release-name":    true,
+	"namespace":       true,
+	"include":         true,
+	"exclude":         true,
+	"fail-on-empty":   true,
+	"render-timeout":  true,
+	"workers":         true,
+}
+
+// Manifest describes the contents of a bundle.
+type Manifest struct {
+	Version int `json:"version"`
+	// Template is the bundled template file or directory, relative to the bundle root.
+	Template string `json:"template"`
+	// Values and Schema are set when the bundle includes a values or schema file.
+	Values bool `json:"values,omitempty"`
+	Schema bool `json:"schema,omitempty"`
+	// Options are the render flags to run the bundle with, e.g. --set app.name=web.
+	Options []string `json:"options,omitempty"`
+}
+
+// Sources are the inputs packed into a bundle. ValuesFile and SchemaFile are optional.
+type Sources struct {
+	Template   string
+	ValuesFile string
+	SchemaFile string
+	Options    []string
+}
+
+// Create writes a bundle holding the template file or directory, the values and schema
+// files and the render options as a gzipped tarball to w.
+func Create(w io.Writer, sources Sources) error {
+	if err := checkOptions(sources.Options); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(sources.Template)
+	if err != nil {
+		return fmt.Errorf("cannot stat template path '%s': %w", sources.Template, err)
+	}
+	manifest := Manifest{
+		Version:  formatVersion,
+		Template: TemplatesDir,
+		Values:   sources.ValuesFile != "",
+		Schema:   sources.SchemaFile != "",
+		Options:  sources.Options,
+	}
+	if !info.IsDir() {
+		manifest.Template = TemplatesDir + "/" + info.Name()
+	}
+	manifestContent, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode bundle manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	if err := writeEntry(tw, ManifestFile, manifestContent); err != nil {
+		return err
+	}
+	if err := addTemplates(tw, sources.Template, info); err != nil {
+		return fmt.Errorf("failed to bundle templates: %w", err)
+	}
+	optional := []struct{ name, path string }{{ValuesFile, sources.ValuesFile}, {SchemaFile, sources.SchemaFile}}
+	for _, file := range optional {
+		if file.path == "" {
+			continue
+		}
+		content, err := os.ReadFile(file.path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file.path, err)
+		}
+		if err := writeEntry(tw, file.name, content); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// addTemplates adds the template file, or the files below the template directory, under
+// the templates directory of the bundle.
+func addTemplates(tw *tar.Writer, template string, info os.FileInfo) error {
+	if !info.IsDir() {
+		content, err := os.ReadFile(template)
+		if err != nil {
+			return err
+		}
+		return writeEntry(tw, TemplatesDir+"/"+info.Name(), content)
+	}
+
+	return filepath.Walk(template, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(template, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return writeEntry(tw, TemplatesDir+"/"+filepath.ToSlash(relativePath), content)
+	})
+}
+
+// writeEntry writes a regular file to the bundle.
+func writeEntry(tw *tar.Writer, name string, content []byte) error {
+	header := &tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s to bundle: %w", name, err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		return fmt.Errorf("failed to write %s to bundle: %w", name, err)
+	}
+	return nil
+}
+
+// Extract unpacks the bundle read from r into dir and returns its manifest. Entries
+// escaping dir are rejected.
+func Extract(r io.Reader, dir string) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := extractEntry(tr, header.Name, dir); err != nil {
+			return nil, err
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("bundle has no %s", ManifestFile)
+	}
+	manifest := &Manifest{}
+	if err := json.Unmarshal(content, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle manifest: %w", err)
+	}
+	if manifest.Version != formatVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d (expected %d)", manifest.Version, formatVersion)
+	}
+	if manifest.Template != TemplatesDir && !strings.HasPrefix(manifest.Template, TemplatesDir+"/") {
+		return nil, fmt.Errorf("invalid bundle template path %s", manifest.Template)
+	}
+	// Bundles come from others, so their options are checked again before running them
+	if err := checkOptions(manifest.Options); err != nil {
+		return nil, fmt.Errorf("refusing to run bundle: %w", err)
+	}
+	return manifest, nil
+}
+
+// extractEntry writes one bundle file below dir.
+func extractEntry(r io.Reader, name, dir string) error {
+	localName := filepath.FromSlash(name)
+	if !filepath.IsLocal(localName) {
+		return fmt.Errorf("bundle entry %s escapes the bundle directory", name)
+	}
+	path := filepath.Join(dir, localName)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", name, err)
+	}
+	return nil
+}
+
+// Args returns the render flags running a bundle extracted to dir, writing to output.
+func (m *Manifest) Args(dir, output string) []string {
+	args := []string{"-template", filepath.Join(dir, filepath.FromSlash(m.Template))}
+	if m.Values {
+		args = append(args, "-values", filepath.Join(dir, ValuesFile))
+	}
+	if m.Schema {
+		args = append(args, "-schema", filepath.Join(dir, SchemaFile))
+	}
+	args = append(args, m.Options...)
+	return append(args, "-output", output)
+}
+
+// checkOptions rejects recorded options that are set by the bundle or not allowed in
+// one. Arguments starting with - are always taken as options, so flag values cannot
+// smuggle in another option.
+func checkOptions(options []string) error {
+	for _, option := range options {
+		if !strings.HasPrefix(option, "-") {
+			continue
+		}
+		name, _ := optionName(option)
+		if reservedOptions[name] {
+			return fmt.Errorf("option %s is set by the bundle and cannot be recorded", option)
+		}
+		if !allowedOptions[name] {
+			return fmt.Errorf("option %s is not allowed in a bundle (allowed options: %s)", option, allowedOptionNames())
+		}
+	}
+	return nil
+}
+
+// allowedOptionNames returns the sorted names of the options bundles may record.
+func allowedOptionNames() string {
+	names := make([]string, 0, len(allowedOptions))
+	for name := range allowedOptions {
+		names = append(names, "--"+name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// optionName returns the flag name of a -name, --name or --name=value option.
+func optionName(option string) (string, bool) {
+	if !strings.HasPrefix(option, "-") {
+		return "", false
+	}
+	name, _, _ := strings.Cut(strings.TrimLeft(option, "-"), "=")
+	return name, name != ""
+}
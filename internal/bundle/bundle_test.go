@@ -0,0 +1,169 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestCreateExtract(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "templates", "app.yaml.tpl"), "name: {{.app.name}}")
+	writeFile(t, filepath.Join(src, "templates", "nested", "svc.yaml.tpl"), "port: {{.port}}")
+	writeFile(t, filepath.Join(src, "values.yaml"), "app:\n  name: web\n")
+	writeFile(t, filepath.Join(src, "values.schema.json"), "{}")
+
+	tests := []struct {
+		name     string
+		sources  Sources
+		expected Manifest
+		files    map[string]string
+	}{
+		{
+			name: "directory with values and schema",
+			sources: Sources{
+				Template:   filepath.Join(src, "templates"),
+				ValuesFile: filepath.Join(src, "values.yaml"),
+				SchemaFile: filepath.Join(src, "values.schema.json"),
+				Options:    []string{"--set", "port=80", "--strict"},
+			},
+			expected: Manifest{Version: 1, Template: "templates", Values: true, Schema: true, Options: []string{"--set", "port=80", "--strict"}},
+			files: map[string]string{
+				"templates/app.yaml.tpl":        "name: {{.app.name}}",
+				"templates/nested/svc.yaml.tpl": "port: {{.port}}",
+				"values.yaml":                   "app:\n  name: web\n",
+				"values.schema.json":            "{}",
+			},
+		},
+		{
+			name:     "single file",
+			sources:  Sources{Template: filepath.Join(src, "templates", "app.yaml.tpl")},
+			expected: Manifest{Version: 1, Template: "templates/app.yaml.tpl"},
+			files:    map[string]string{"templates/app.yaml.tpl": "name: {{.app.name}}"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Create(&buf, tt.sources); err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+
+			dir := t.TempDir()
+			manifest, err := Extract(&buf, dir)
+			if err != nil {
+				t.Fatalf("Extract failed: %v", err)
+			}
+			if !reflect.DeepEqual(*manifest, tt.expected) {
+				t.Errorf("Expected manifest %+v, got %+v", tt.expected, *manifest)
+			}
+			for name, content := range tt.files {
+				data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+				if err != nil {
+					t.Errorf("Expected %s in bundle: %v", name, err)
+					continue
+				}
+				if string(data) != content {
+					t.Errorf("Expected %s to contain %q, got %q", name, content, data)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateErrors(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "app.tpl"), "{{.name}}")
+
+	tests := []struct {
+		name      string
+		sources   Sources
+		wantError string
+	}{
+		{name: "reserved option", sources: Sources{Template: src, Options: []string{"--output=/tmp/out"}}, wantError: "set by the bundle"},
+		{name: "reserved single dash option", sources: Sources{Template: src, Options: []string{"-values", "other.yaml"}}, wantError: "set by the bundle"},
+		{name: "exec option", sources: Sources{Template: src, Options: []string{"--allow-exec", "touch"}}, wantError: "option --allow-exec is not allowed in a bundle"},
+		{name: "file option", sources: Sources{Template: src, Options: []string{"--set-file=key=/etc/passwd"}}, wantError: "not allowed in a bundle"},
+		{name: "option after flag value", sources: Sources{Template: src, Options: []string{"--set", "--plugin=x=/bin/sh"}}, wantError: "not allowed in a bundle"},
+		{name: "end of options", sources: Sources{Template: src, Options: []string{"--", "x"}}, wantError: "not allowed in a bundle"},
+		{name: "missing template", sources: Sources{Template: filepath.Join(src, "missing")}, wantError: "cannot stat template path"},
+		{name: "missing values file", sources: Sources{Template: src, ValuesFile: filepath.Join(src, "missing.yaml")}, wantError: "failed to read"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Create(&bytes.Buffer{}, tt.sources)
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+			}
+		})
+	}
+}
+
+func TestExtractErrors(t *testing.T) {
+	archive := func(entries map[string]string) *bytes.Buffer {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		for name, content := range entries {
+			if err := writeEntry(tw, name, []byte(content)); err != nil {
+				t.Fatalf("Failed to write entry: %v", err)
+			}
+		}
+		tw.Close()
+		gz.Close()
+		return &buf
+	}
+
+	tests := []struct {
+		name      string
+		bundle    *bytes.Buffer
+		wantError string
+	}{
+		{name: "not a bundle", bundle: bytes.NewBufferString("plain text"), wantError: "failed to read bundle"},
+		{name: "escaping entry", bundle: archive(map[string]string{"../evil.tpl": "x"}), wantError: "escapes the bundle directory"},
+		{name: "no manifest", bundle: archive(map[string]string{"templates/a.tpl": "x"}), wantError: "bundle has no bundle.json"},
+		{name: "newer version", bundle: archive(map[string]string{ManifestFile: `{"version":2,"template":"templates"}`}), wantError: "unsupported bundle version 2"},
+		{name: "template outside templates", bundle: archive(map[string]string{ManifestFile: `{"version":1,"template":"../x"}`}), wantError: "invalid bundle template path"},
+		{name: "exec option", bundle: archive(map[string]string{ManifestFile: `{"version":1,"template":"templates","options":["--allow-exec","touch"]}`}), wantError: "refusing to run bundle: option --allow-exec is not allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Extract(tt.bundle, t.TempDir())
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+			}
+		})
+	}
+}
+
+func TestManifestArgs(t *testing.T) {
+	manifest := Manifest{Version: 1, Template: "templates/app.tpl", Values: true, Schema: true, Options: []string{"--strict"}}
+	expected := []string{
+		"-template", filepath.Join("b", "templates", "app.tpl"),
+		"-values", filepath.Join("b", "values.yaml"),
+		"-schema", filepath.Join("b", "values.schema.json"),
+		"--strict",
+		"-output", "out",
+	}
+	if got := manifest.Args("b", "out"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,207 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+)
+
+// Cache stores rendered outputs on disk, keyed by a digest of their inputs.
+type Cache struct {
+	dir string
+}
+
+// New creates a cache rooted at dir.
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// DefaultDir returns the default render cache directory.
+func DefaultDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate user cache directory: %w", err)
+	}
+	return filepath.Join(base, "templater", "renders"), nil
+}
+
+// Key derives a cache key from the given parts. Parts are length-prefixed so that
+// different splits of the same bytes produce different keys.
+func Key(parts ...[]byte) string {
+	h := sha256.New()
+	var size [8]byte
+	for _, part := range parts {
+		binary.BigEndian.PutUint64(size[:], uint64(len(part)))
+		h.Write(size[:])
+		h.Write(part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ValuesDigest returns a stable digest of merged values.
+func ValuesDigest(values map[string]any) (string, error) {
+	// encoding/json sorts map keys, which makes the encoding canonical
+	data, err := json.Marshal(normalize(values))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode values: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// normalize converts YAML maps with interface keys so values can be JSON encoded.
+func normalize(v any) any {
+	switch x := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]any, len(x))
+		for k, val := range x {
+			m[fmt.Sprint(k)] = normalize(val)
+		}
+		return m
+	case map[string]any:
+		m := make(map[string]any, len(x))
+		for k, val := range x {
+			m[k] = normalize(val)
+		}
+		return m
+	case []any:
+		s := make([]any, len(x))
+		for i, val := range x {
+			s[i] = normalize(val)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+// Get returns the cached content for key, if present.
+func (c *Cache) Get(key string) ([]byte, bool, error) {
+	content, err := os.ReadFile(c.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+	return content, true, nil
+}
+
+// Put stores content under key.
+func (c *Cache) Put(key string, content []byte) error {
+	path := c.path(key)
+	// Rendered outputs may hold secrets, so only the user can read the cache
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Write to a temporary file first so concurrent readers never see partial entries
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store cache entry: %w", err)
+	}
+	return nil
+}
+
+// path returns the location of a cache entry, sharded by the first key byte.
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+var (
+	toolVersionOnce sync.Once
+	toolVersion     string
+	buildKeyOnce    sync.Once
+	buildKey        string
+)
+
+// ToolVersion identifies the running templater build. Development builds include the
+// VCS revision so cache entries are invalidated when the code changes.
+func ToolVersion() string {
+	toolVersionOnce.Do(func() {
+		toolVersion = "unknown"
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+
+		toolVersion = info.Main.Version
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				toolVersion += "+" + setting.Value
+			case "vcs.modified":
+				if setting.Value == "true" {
+					toolVersion += "-dirty"
+				}
+			}
+		}
+	})
+	return toolVersion
+}
+
+// BuildKey identifies the running templater build in render cache keys. Builds without a
+// release version or an unmodified VCS revision, such as go run or builds outside a
+// repository, are identified by a digest of the executable, so their entries are not
+// reused once the code changes. It returns "" when the build cannot be identified.
+func BuildKey() string {
+	buildKeyOnce.Do(func() {
+		if info, ok := debug.ReadBuildInfo(); ok && identifiesCode(info) {
+			buildKey = ToolVersion()
+			return
+		}
+
+		executable, err := os.Executable()
+		if err != nil {
+			return
+		}
+		file, err := os.Open(executable)
+		if err != nil {
+			return
+		}
+		defer file.Close()
+		hash := sha256.New()
+		if _, err := io.Copy(hash, file); err != nil {
+			return
+		}
+		buildKey = ToolVersion() + "+sha256:" + hex.EncodeToString(hash.Sum(nil))
+	})
+	return buildKey
+}
+
+// identifiesCode reports whether the build info pins the code that was built: a release
+// version, or a VCS revision without local modifications.
+func identifiesCode(info *debug.BuildInfo) bool {
+	revision := ""
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			if setting.Value == "true" {
+				return false
+			}
+		}
+	}
+	return revision != "" || (info.Main.Version != "" && info.Main.Version != "(devel)")
+}
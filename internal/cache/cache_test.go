@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	if Key([]byte("ab"), []byte("c")) == Key([]byte("a"), []byte("bc")) {
+		t.Error("Expected different splits of the same bytes to produce different keys")
+	}
+	if Key([]byte("a"), []byte("b")) != Key([]byte("a"), []byte("b")) {
+		t.Error("Expected identical parts to produce identical keys")
+	}
+}
+
+func TestValuesDigest(t *testing.T) {
+	tests := []struct {
+		name  string
+		a     map[string]any
+		b     map[string]any
+		equal bool
+	}{
+		{
+			name:  "same values",
+			a:     map[string]any{"app": map[string]any{"name": "web", "port": 8080}},
+			b:     map[string]any{"app": map[string]any{"port": 8080, "name": "web"}},
+			equal: true,
+		},
+		{
+			name:  "yaml maps",
+			a:     map[string]any{"app": map[interface{}]interface{}{"name": "web"}},
+			b:     map[string]any{"app": map[string]any{"name": "web"}},
+			equal: true,
+		},
+		{
+			name:  "different values",
+			a:     map[string]any{"app": "web"},
+			b:     map[string]any{"app": "api"},
+			equal: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := ValuesDigest(tt.a)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			b, err := ValuesDigest(tt.b)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if (a == b) != tt.equal {
+				t.Errorf("Expected equal=%v, got %s and %s", tt.equal, a, b)
+			}
+		})
+	}
+}
+
+func TestCacheGetPut(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-cache-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	c := New(tempDir)
+	key := Key([]byte("template"), []byte("values"))
+
+	if _, ok, err := c.Get(key); err != nil || ok {
+		t.Fatalf("Expected cache miss, got ok=%v err=%v", ok, err)
+	}
+
+	if err := c.Put(key, []byte("rendered")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	content, ok, err := c.Get(key)
+	if err != nil || !ok {
+		t.Fatalf("Expected cache hit, got ok=%v err=%v", ok, err)
+	}
+	if string(content) != "rendered" {
+		t.Errorf("Expected rendered, got %s", content)
+	}
+}
+
+func TestBuildKey(t *testing.T) {
+	// Test binaries have no VCS revision, so they are identified by their digest
+	key := BuildKey()
+	if !strings.Contains(key, "+sha256:") {
+		t.Errorf("Expected a build key with the executable digest, got %q", key)
+	}
+	if BuildKey() != key {
+		t.Error("Expected the build key to be stable")
+	}
+}
@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/menta2k/templater/internal/awsauth"
+	"github.com/menta2k/templater/internal/retry"
+)
+
+const remoteTimeout = 30 * time.Second
+
+// NewRemoteStore creates a remote store for an http(s):// base URL or an
+// s3://bucket/prefix location. Headers are given in "Name: value" form and sent with
+// every HTTP request.
+func NewRemoteStore(location string, headers []string) (Store, error) {
+	switch {
+	case strings.HasPrefix(location, "http://"), strings.HasPrefix(location, "https://"):
+		header := make(http.Header)
+		for _, h := range headers {
+			name, value, ok := strings.Cut(h, ":")
+			if !ok || strings.TrimSpace(name) == "" {
+				return nil, fmt.Errorf("invalid header format: %s (expected Name: value)", h)
+			}
+			header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		}
+		return &HTTPStore{
+			BaseURL: strings.TrimSuffix(location, "/"),
+			Headers: header,
+			Client:  retry.NewClient(remoteTimeout),
+		}, nil
+	case strings.HasPrefix(location, "s3://"):
+		return NewS3Store(location)
+	default:
+		return nil, fmt.Errorf("unsupported remote cache %s (expected http(s):// or s3://)", location)
+	}
+}
+
+// HTTPStore keeps cache entries on an HTTP server that supports GET and PUT, such as
+// a WebDAV share or a generic build cache service.
+type HTTPStore struct {
+	BaseURL string
+	Headers http.Header
+	Client  *http.Client
+}
+
+// Get downloads the entry for key. A 404 response is a cache miss.
+func (s *HTTPStore) Get(key string) ([]byte, bool, error) {
+	req, err := http.NewRequest(http.MethodGet, s.BaseURL+"/"+key, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	return doGet(s.Client, s.addHeaders(req))
+}
+
+// Put uploads the entry for key.
+func (s *HTTPStore) Put(key string, content []byte) error {
+	req, err := http.NewRequest(http.MethodPut, s.BaseURL+"/"+key, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	return doPut(s.Client, s.addHeaders(req))
+}
+
+// addHeaders sets the configured headers on req.
+func (s *HTTPStore) addHeaders(req *http.Request) *http.Request {
+	for name, values := range s.Headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	return req
+}
+
+// S3Store keeps cache entries in an S3-compatible bucket using path-style requests
+// signed with AWS Signature Version 4.
+type S3Store struct {
+	Endpoint    string
+	Bucket      string
+	Prefix      string
+	Region      string
+	Credentials awsauth.Credentials
+	Client      *http.Client
+}
+
+// NewS3Store creates a store for s3://bucket/prefix. Credentials come from the AWS
+// default credential chain and the region from the standard AWS_* environment variables;
+// AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL selects an S3-compatible service such as MinIO.
+func NewS3Store(location string) (*S3Store, error) {
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(location, "s3://"), "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("invalid S3 location %s (expected s3://bucket/prefix)", location)
+	}
+
+	creds, err := awsauth.DefaultCredentials(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("S3 remote cache: %w", err)
+	}
+
+	region := awsauth.Region()
+	return &S3Store{
+		Endpoint:    awsauth.Endpoint("s3", region),
+		Bucket:      bucket,
+		Prefix:      strings.Trim(prefix, "/"),
+		Region:      region,
+		Credentials: creds,
+		Client:      retry.NewClient(remoteTimeout),
+	}, nil
+}
+
+// Get downloads the object for key. A 404 response is a cache miss.
+func (s *S3Store) Get(key string) ([]byte, bool, error) {
+	req, err := http.NewRequest(http.MethodGet, s.objectURL(key), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	awsauth.Sign(req, awsauth.EmptyPayloadHash, s.Credentials, s.Region, "s3", time.Now())
+	return doGet(s.Client, req)
+}
+
+// Put uploads the object for key.
+func (s *S3Store) Put(key string, content []byte) error {
+	req, err := http.NewRequest(http.MethodPut, s.objectURL(key), bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	awsauth.Sign(req, awsauth.PayloadHash(content), s.Credentials, s.Region, "s3", time.Now())
+	return doPut(s.Client, req)
+}
+
+// objectURL returns the path-style URL of the object for key.
+func (s *S3Store) objectURL(key string) string {
+	object := key
+	if s.Prefix != "" {
+		object = s.Prefix + "/" + key
+	}
+
+	segments := strings.Split(s.Bucket+"/"+object, "/")
+	for i, segment := range segments {
+		segments[i] = awsauth.EscapeSegment(segment)
+	}
+	return s.Endpoint + "/" + strings.Join(segments, "/")
+}
+
+// doGet performs a cache read, treating 404 as a miss.
+func doGet(client *http.Client, req *http.Request) ([]byte, bool, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read remote cache: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("failed to read remote cache: unexpected status %s", resp.Status)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read remote cache: %w", err)
+	}
+	return content, true, nil
+}
+
+// doPut performs a cache write.
+func doPut(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to write remote cache: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to write remote cache: unexpected status %s", resp.Status)
+	}
+	return nil
+}
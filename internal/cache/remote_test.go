@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNewRemoteStore(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ENDPOINT_URL", "")
+	t.Setenv("AWS_ENDPOINT_URL_S3", "")
+
+	tests := []struct {
+		name      string
+		location  string
+		headers   []string
+		wantError bool
+	}{
+		{name: "http", location: "https://cache.example.com/renders", headers: []string{"Authorization: Bearer token"}},
+		{name: "s3", location: "s3://ci-cache/templater"},
+		{name: "s3 without bucket", location: "s3://", wantError: true},
+		{name: "invalid header", location: "https://cache.example.com", headers: []string{"no-colon"}, wantError: true},
+		{name: "unsupported scheme", location: "ftp://cache.example.com", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRemoteStore(tt.location, tt.headers)
+			if tt.wantError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.wantError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestHTTPStore(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		entries = map[string][]byte{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			entries[r.URL.Path] = body
+		case http.MethodGet:
+			body, ok := entries[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(body)
+		}
+	}))
+	defer server.Close()
+
+	store, err := NewRemoteStore(server.URL+"/renders/", []string{"Authorization: Bearer token"})
+	if err != nil {
+		t.Fatalf("NewRemoteStore failed: %v", err)
+	}
+
+	if _, ok, err := store.Get("abc"); err != nil || ok {
+		t.Fatalf("Expected miss, got ok=%v err=%v", ok, err)
+	}
+	if err := store.Put("abc", []byte("rendered")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	content, ok, err := store.Get("abc")
+	if err != nil || !ok || string(content) != "rendered" {
+		t.Errorf("Expected hit with rendered, got ok=%v content=%s err=%v", ok, content, err)
+	}
+	if _, ok := entries["/renders/abc"]; !ok {
+		t.Error("Expected entry to be stored under the base path")
+	}
+}
+
+func TestS3StoreRequests(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		entries = map[string][]byte{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			entries[r.URL.Path] = body
+		case http.MethodGet:
+			body, ok := entries[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(body)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_ENDPOINT_URL_S3", server.URL)
+
+	store, err := NewS3Store("s3://ci-cache/templater")
+	if err != nil {
+		t.Fatalf("NewS3Store failed: %v", err)
+	}
+	if err := store.Put("abc", []byte("rendered")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, ok := entries["/ci-cache/templater/abc"]; !ok {
+		t.Errorf("Expected path-style object key, got %v", entries)
+	}
+	content, ok, err := store.Get("abc")
+	if err != nil || !ok || string(content) != "rendered" {
+		t.Errorf("Expected hit with rendered, got ok=%v content=%s err=%v", ok, content, err)
+	}
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrNotCached is returned in offline mode for a source that was never fetched.
+var ErrNotCached = errors.New("not cached")
+
+// SourcePolicy controls when sources fetched from remote locations, such as values files
+// in git or object storage, are checked for changes.
+type SourcePolicy struct {
+	// TTL is how long a fetched source is used without contacting the remote. Once it
+	// expires, the remote is asked whether the source changed, by ETag or commit, and the
+	// source is only downloaded again when it did.
+	TTL time.Duration
+	// Offline only uses sources fetched before, however old.
+	Offline bool
+}
+
+// SourceEntry records which version of a remote source was fetched, and when.
+type SourceEntry struct {
+	Location string `json:"location"`
+	// Version identifies the fetched content, such as an ETag or a commit.
+	Version string    `json:"version,omitempty"`
+	Fetched time.Time `json:"fetched"`
+}
+
+// LoadSourceEntry reads the entry saved at path. A missing or unreadable entry yields
+// nil, so the source is fetched again.
+func LoadSourceEntry(path string) *SourceEntry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	entry := &SourceEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil
+	}
+	return entry
+}
+
+// Save writes the entry to path.
+func (e *SourceEntry) Save(path string) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode source cache entry: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create source cache directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write source cache entry: %w", err)
+	}
+	return nil
+}
+
+// UseCached reports whether the source fetched as entry can be used without contacting
+// the remote: in offline mode, or while its TTL runs. Offline sources without an entry fail.
+func (p SourcePolicy) UseCached(entry *SourceEntry, location string) (bool, error) {
+	if entry == nil {
+		if p.Offline {
+			return false, fmt.Errorf("%s is %w (fetch it once without offline mode)", location, ErrNotCached)
+		}
+		return false, nil
+	}
+	return p.Offline || time.Since(entry.Fetched) < p.TTL, nil
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSourceEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "entries", "source.json")
+	if entry := LoadSourceEntry(path); entry != nil {
+		t.Errorf("Expected no entry before saving, got %+v", entry)
+	}
+
+	entry := &SourceEntry{Location: "gs://configs/values.yaml", Version: `"v1"`, Fetched: time.Now().Round(0)}
+	if err := entry.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded := LoadSourceEntry(path)
+	if loaded == nil || loaded.Location != entry.Location || loaded.Version != entry.Version || !loaded.Fetched.Equal(entry.Fetched) {
+		t.Errorf("Expected %+v, got %+v", entry, loaded)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatalf("Failed to corrupt entry: %v", err)
+	}
+	if entry := LoadSourceEntry(path); entry != nil {
+		t.Errorf("Expected a corrupt entry to be ignored, got %+v", entry)
+	}
+}
+
+func TestSourcePolicyUseCached(t *testing.T) {
+	recent := &SourceEntry{Fetched: time.Now().Add(-time.Minute)}
+	old := &SourceEntry{Fetched: time.Now().Add(-time.Hour)}
+
+	tests := []struct {
+		name      string
+		policy    SourcePolicy
+		entry     *SourceEntry
+		expected  bool
+		wantError bool
+	}{
+		{name: "not cached", policy: SourcePolicy{TTL: time.Hour}, entry: nil, expected: false},
+		{name: "within TTL", policy: SourcePolicy{TTL: 10 * time.Minute}, entry: recent, expected: true},
+		{name: "expired", policy: SourcePolicy{TTL: 10 * time.Minute}, entry: old, expected: false},
+		{name: "no TTL", policy: SourcePolicy{}, entry: recent, expected: false},
+		{name: "offline", policy: SourcePolicy{Offline: true}, entry: old, expected: true},
+		{name: "offline not cached", policy: SourcePolicy{Offline: true}, entry: nil, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cached, err := tt.policy.UseCached(tt.entry, "gs://configs/values.yaml")
+			if tt.wantError {
+				if !errors.Is(err, ErrNotCached) {
+					t.Errorf("Expected ErrNotCached, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if cached != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, cached)
+			}
+		})
+	}
+}
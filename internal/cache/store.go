@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Store is a render cache backend.
+type Store interface {
+	Get(key string) ([]byte, bool, error)
+	Put(key string, content []byte) error
+}
+
+// entryHeader prefixes remote entries with the digest of their content.
+const entryHeader = "templater-cache-v1 sha256:"
+
+// Tiered combines a local store with a shared remote store. Reads go to the local store
+// first and fall through to the remote one, populating the local store on a hit; writes
+// go to both. Remote entries carry a content digest that is verified on read.
+type Tiered struct {
+	Local  Store
+	Remote Store
+}
+
+// Get returns the cached content for key from the local store or, failing that, the remote one.
+func (t *Tiered) Get(key string) ([]byte, bool, error) {
+	if t.Local != nil {
+		content, ok, err := t.Local.Get(key)
+		if err != nil || ok {
+			return content, ok, err
+		}
+	}
+
+	entry, ok, err := t.Remote.Get(key)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	content, err := decodeEntry(entry)
+	if err != nil {
+		return nil, false, fmt.Errorf("remote cache entry %s: %w", key, err)
+	}
+
+	if t.Local != nil {
+		if err := t.Local.Put(key, content); err != nil {
+			return nil, false, err
+		}
+	}
+	return content, true, nil
+}
+
+// Put stores content in both stores.
+func (t *Tiered) Put(key string, content []byte) error {
+	if t.Local != nil {
+		if err := t.Local.Put(key, content); err != nil {
+			return err
+		}
+	}
+	return t.Remote.Put(key, encodeEntry(content))
+}
+
+// encodeEntry prepends the content digest to a remote cache entry.
+func encodeEntry(content []byte) []byte {
+	sum := sha256.Sum256(content)
+	return append([]byte(entryHeader+hex.EncodeToString(sum[:])+"\n"), content...)
+}
+
+// decodeEntry verifies a remote cache entry and returns its content.
+func decodeEntry(entry []byte) ([]byte, error) {
+	header, content, ok := bytes.Cut(entry, []byte("\n"))
+	if !ok || !bytes.HasPrefix(header, []byte(entryHeader)) {
+		return nil, fmt.Errorf("malformed entry")
+	}
+
+	sum := sha256.Sum256(content)
+	if string(header[len(entryHeader):]) != hex.EncodeToString(sum[:]) {
+		return nil, fmt.Errorf("integrity check failed")
+	}
+	return content, nil
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"os"
+	"testing"
+)
+
+// memoryStore is an in-memory Store used as a fake remote.
+type memoryStore map[string][]byte
+
+func (m memoryStore) Get(key string) ([]byte, bool, error) {
+	content, ok := m[key]
+	return content, ok, nil
+}
+
+func (m memoryStore) Put(key string, content []byte) error {
+	m[key] = content
+	return nil
+}
+
+func TestTieredReadThrough(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-cache-tiered-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	key := Key([]byte("template"))
+	remote := memoryStore{}
+
+	// Another runner populated the remote cache
+	writer := &Tiered{Remote: remote}
+	if err := writer.Put(key, []byte("rendered")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	local := New(tempDir)
+	reader := &Tiered{Local: local, Remote: remote}
+	content, ok, err := reader.Get(key)
+	if err != nil || !ok {
+		t.Fatalf("Expected remote hit, got ok=%v err=%v", ok, err)
+	}
+	if string(content) != "rendered" {
+		t.Errorf("Expected rendered, got %s", content)
+	}
+
+	// The remote hit is now cached locally
+	if content, ok, _ := local.Get(key); !ok || string(content) != "rendered" {
+		t.Errorf("Expected local cache to be populated, got ok=%v content=%s", ok, content)
+	}
+}
+
+func TestTieredIntegrityCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry []byte
+	}{
+		{name: "tampered content", entry: append(encodeEntry([]byte("rendered")), '!')},
+		{name: "missing header", entry: []byte("rendered")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := memoryStore{"key": tt.entry}
+			if _, ok, err := (&Tiered{Remote: remote}).Get("key"); err == nil || ok {
+				t.Errorf("Expected integrity error, got ok=%v err=%v", ok, err)
+			}
+		})
+	}
+}
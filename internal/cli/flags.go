@@ -1,6 +1,10 @@
 package cli
 
-import "strings"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 // SetValues is a custom flag type for handling multiple --set flags.
 type SetValues []string
@@ -13,3 +17,84 @@ func (s *SetValues) Set(value string) error {
 	*s = append(*s, value)
 	return nil
 }
+
+// StringList is a flag type collecting every occurrence of a repeatable flag.
+type StringList []string
+
+func (s *StringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *StringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// ByteSize is a flag type for sizes such as 512MiB, 2G or 1048576 (bytes).
+type ByteSize int64
+
+// byteUnits maps size suffixes to multipliers. Decimal and binary suffixes are both
+// treated as powers of 1024, as is common for memory limits.
+var byteUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30},
+	{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30},
+	{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30},
+	{"B", 1},
+}
+
+func (b *ByteSize) String() string {
+	return strconv.FormatInt(int64(*b), 10)
+}
+
+func (b *ByteSize) Set(value string) error {
+	number := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range byteUnits {
+		if strings.HasSuffix(number, unit.suffix) {
+			number = strings.TrimSpace(strings.TrimSuffix(number, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	size, err := strconv.ParseFloat(number, 64)
+	if err != nil || size < 0 {
+		return fmt.Errorf("invalid size %s (expected e.g. 512MiB, 2G or a number of bytes)", value)
+	}
+	*b = ByteSize(size * float64(multiplier))
+	return nil
+}
+
+// OptionalString is a flag type that may be given alone, as --flag, or with a value, as
+// --flag=value. Enabled reports whether the flag was given and not set to false.
+type OptionalString struct {
+	Enabled bool
+	Value   string
+}
+
+func (o *OptionalString) String() string {
+	if o == nil {
+		return ""
+	}
+	return o.Value
+}
+
+func (o *OptionalString) Set(value string) error {
+	switch value {
+	case "true":
+		o.Enabled, o.Value = true, ""
+	case "false":
+		o.Enabled, o.Value = false, ""
+	default:
+		o.Enabled, o.Value = true, value
+	}
+	return nil
+}
+
+// IsBoolFlag lets the flag package accept the flag without a value.
+func (o *OptionalString) IsBoolFlag() bool {
+	return true
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"flag"
 	"strings"
 	"testing"
 )
@@ -197,3 +198,87 @@ func TestSetValues_SpecialCharacters(t *testing.T) {
 		t.Errorf("Expected %d commas in string representation, got %d", expectedParts, actualCommas)
 	}
 }
+
+func TestStringList(t *testing.T) {
+	var list StringList
+
+	for _, value := range []string{"Authorization: Bearer token", "X-Team: platform"} {
+		if err := list.Set(value); err != nil {
+			t.Fatalf("Set(%s) failed: %v", value, err)
+		}
+	}
+
+	if len(list) != 2 {
+		t.Errorf("Expected 2 values, got %d", len(list))
+	}
+
+	expected := "Authorization: Bearer token,X-Team: platform"
+	if list.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, list.String())
+	}
+}
+
+func TestByteSize_Set(t *testing.T) {
+	tests := []struct {
+		value     string
+		expected  ByteSize
+		wantError bool
+	}{
+		{value: "1048576", expected: 1 << 20},
+		{value: "512MiB", expected: 512 << 20},
+		{value: "2G", expected: 2 << 30},
+		{value: "1.5gb", expected: 3 << 29},
+		{value: "64k", expected: 64 << 10},
+		{value: "lots", wantError: true},
+		{value: "-1M", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var size ByteSize
+			err := size.Set(tt.value)
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if size != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, size)
+			}
+		})
+	}
+}
+
+func TestOptionalString(t *testing.T) {
+	tests := []struct {
+		args    []string
+		enabled bool
+		value   string
+	}{
+		{args: nil},
+		{args: []string{"-allow-env"}, enabled: true},
+		{args: []string{"--allow-env=APP_"}, enabled: true, value: "APP_"},
+		{args: []string{"--allow-env=APP_", "--allow-env=false"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var opt OptionalString
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.Var(&opt, "allow-env", "")
+			if err := fs.Parse(append(tt.args, "template.tpl")); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if opt.Enabled != tt.enabled || opt.Value != tt.value {
+				t.Errorf("Expected %v %q, got %v %q", tt.enabled, tt.value, opt.Enabled, opt.Value)
+			}
+			if fs.NArg() != 1 {
+				t.Errorf("Expected the positional argument to remain, got %v", fs.Args())
+			}
+		})
+	}
+}
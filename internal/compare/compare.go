@@ -0,0 +1,126 @@
+package compare
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Status describes how an output differs between two renders.
+type Status string
+
+// Statuses of a Difference.
+const (
+	Added   Status = "added"
+	Removed Status = "removed"
+	Changed Status = "changed"
+)
+
+// Difference is an output file that differs between two renders.
+type Difference struct {
+	// Path is the output path relative to the render output.
+	Path   string
+	Status Status
+	// Diff is a unified diff of the old and new content.
+	Diff string
+}
+
+// Outputs compares the rendered outputs at oldPath and newPath, which are both either an
+// output file or an output directory. Differences are sorted by path.
+func Outputs(oldPath, newPath string) ([]Difference, error) {
+	oldFiles, err := readOutputs(oldPath)
+	if err != nil {
+		return nil, err
+	}
+	newFiles, err := readOutputs(newPath)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(oldFiles)+len(newFiles))
+	for path := range oldFiles {
+		paths = append(paths, path)
+	}
+	for path := range newFiles {
+		if _, ok := oldFiles[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var differences []Difference
+	for _, path := range paths {
+		oldContent, inOld := oldFiles[path]
+		newContent, inNew := newFiles[path]
+		if oldContent == newContent && inOld == inNew {
+			continue
+		}
+
+		status := Changed
+		switch {
+		case !inOld:
+			status = Added
+		case !inNew:
+			status = Removed
+		}
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(oldContent),
+			B:        difflib.SplitLines(newContent),
+			FromFile: "old/" + path,
+			ToFile:   "new/" + path,
+			Context:  3,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff %s: %w", path, err)
+		}
+		differences = append(differences, Difference{Path: path, Status: status, Diff: diff})
+	}
+	return differences, nil
+}
+
+// readOutputs reads the files below an output directory, keyed by their slash-separated
+// relative path, or a single output file keyed by its name. A missing output has no files.
+func readOutputs(root string) (map[string]string, error) {
+	files := make(map[string]string)
+	info, err := os.Stat(root)
+	if os.IsNotExist(err) {
+		return files, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat output: %w", err)
+	}
+	if !info.IsDir() {
+		content, err := os.ReadFile(root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read output: %w", err)
+		}
+		files[info.Name()] = string(content)
+		return files, nil
+	}
+
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		relativePath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(relativePath)] = string(content)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outputs: %w", err)
+	}
+	return files, nil
+}
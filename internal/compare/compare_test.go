@@ -0,0 +1,86 @@
+package compare
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestOutputs(t *testing.T) {
+	oldDir, newDir := t.TempDir(), t.TempDir()
+	writeFiles(t, oldDir, map[string]string{
+		"same.yaml":        "a: 1\n",
+		"nested/app.yaml":  "name: web\nport: 80\nreplicas: 2\n",
+		"removed.yaml":     "gone: true\n",
+		"nested/empty.txt": "",
+	})
+	writeFiles(t, newDir, map[string]string{
+		"same.yaml":        "a: 1\n",
+		"nested/app.yaml":  "name: web\nport: 8080\nreplicas: 2\n",
+		"added.yaml":       "new: true\n",
+		"nested/empty.txt": "",
+	})
+
+	differences, err := Outputs(oldDir, newDir)
+	if err != nil {
+		t.Fatalf("Outputs failed: %v", err)
+	}
+
+	var summary []string
+	for _, d := range differences {
+		summary = append(summary, string(d.Status)+" "+d.Path)
+	}
+	expected := []string{"added added.yaml", "changed nested/app.yaml", "removed removed.yaml"}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Fatalf("Expected %v, got %v", expected, summary)
+	}
+
+	diff := differences[1].Diff
+	for _, want := range []string{"--- old/nested/app.yaml", "+++ new/nested/app.yaml", "-port: 80\n", "+port: 8080\n", " replicas: 2\n"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("Expected diff to contain %q, got:\n%s", want, diff)
+		}
+	}
+}
+
+func TestOutputsSingleFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"old/out": "v1\n", "new/out": "v2\n", "same/out": "v1\n"})
+
+	tests := []struct {
+		name     string
+		newPath  string
+		expected int
+	}{
+		{name: "changed", newPath: filepath.Join(dir, "new", "out"), expected: 1},
+		{name: "identical", newPath: filepath.Join(dir, "same", "out"), expected: 0},
+		{name: "missing", newPath: filepath.Join(dir, "missing", "out"), expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			differences, err := Outputs(filepath.Join(dir, "old", "out"), tt.newPath)
+			if err != nil {
+				t.Fatalf("Outputs failed: %v", err)
+			}
+			if len(differences) != tt.expected {
+				t.Errorf("Expected %d difference(s), got %v", tt.expected, differences)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package compat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template/parse"
+
+	yaml3 "gopkg.in/yaml.v3"
+
+	"github.com/menta2k/templater/internal/deps"
+	templatepkg "github.com/menta2k/templater/internal/template"
+)
+
+// Files of a template pack read alongside its templates.
+const (
+	valuesFile = "values.yaml"
+	schemaFile = "values.schema.json"
+)
+
+// Pack is what a template pack requires from its consumers and offers to other templates.
+type Pack struct {
+	// Required maps value paths that must be provided by the consumer to the location of
+	// their first use: paths referenced by a template without a default in values.yaml,
+	// and paths the values schema marks as required.
+	Required map[string]string
+	// Used maps every referenced value path to the location of its first use.
+	Used map[string]string
+	// Defines maps named templates to the file defining them.
+	Defines map[string]string
+}
+
+// Change is a difference between two versions of a pack.
+type Change struct {
+	Breaking bool
+	Message  string
+}
+
+// String formats the change for display.
+func (c Change) String() string {
+	if c.Breaking {
+		return "BREAKING: " + c.Message
+	}
+	return "deprecated: " + c.Message
+}
+
+// Analyze reads the templates, default values and values schema of the pack in dir.
+// Templates are every *.tpl file below dir, except vendored packs; templated file and
+// directory names are analyzed too.
+func Analyze(dir string) (*Pack, error) {
+	pack := &Pack{Required: map[string]string{}, Used: map[string]string{}, Defines: map[string]string{}}
+
+	defaults, err := loadDefaults(filepath.Join(dir, valuesFile))
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && deps.IsVendorDir(dir, path) {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || !strings.HasSuffix(strings.ToLower(info.Name()), ".tpl") {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read template: %w", err)
+		}
+		name := filepath.ToSlash(relativePath)
+		if err := pack.addReferences("path "+name, name, defaults); err != nil {
+			return err
+		}
+		return pack.addTemplate(name, string(content), defaults)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze pack %s: %w", dir, err)
+	}
+
+	required, err := loadSchemaRequired(filepath.Join(dir, schemaFile))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range required {
+		if _, ok := pack.Required[path]; !ok {
+			pack.Required[path] = schemaFile
+		}
+	}
+
+	return pack, nil
+}
+
+// addTemplate records the value references and defines of one template.
+func (p *Pack) addTemplate(name, content string, defaults map[string]any) error {
+	if err := p.addReferences(name, content, defaults); err != nil {
+		return err
+	}
+
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck
+	treeSet := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(content, "", "", treeSet); err != nil {
+		return err
+	}
+	for define := range treeSet {
+		if define != name {
+			p.Defines[define] = name
+		}
+	}
+	return nil
+}
+
+// addReferences records the value paths referenced by a template or templated path.
+func (p *Pack) addReferences(name, content string, defaults map[string]any) error {
+	refs, err := templatepkg.FindReferences(name, content)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		path := "." + strings.Join(ref.Path, ".")
+		if _, ok := p.Used[path]; !ok {
+			p.Used[path] = ref.Location
+		}
+		if _, ok := p.Required[path]; !ok && !templatepkg.LookupPath(defaults, ref.Path) {
+			p.Required[path] = ref.Location
+		}
+	}
+	return nil
+}
+
+// Compare reports the changes from oldPack to newPack that affect consumers: value paths
+// that become required and removed defines are breaking; value paths no longer used are
+// deprecations, since consumers still setting them can drop them.
+func Compare(oldPack, newPack *Pack) []Change {
+	var changes []Change
+
+	for _, path := range sortedKeys(newPack.Required) {
+		if _, ok := oldPack.Required[path]; !ok {
+			changes = append(changes, Change{Breaking: true, Message: fmt.Sprintf("value %s is now required (%s)", path, newPack.Required[path])})
+		}
+	}
+	for _, name := range sortedKeys(oldPack.Defines) {
+		if _, ok := newPack.Defines[name]; !ok {
+			changes = append(changes, Change{Breaking: true, Message: fmt.Sprintf("define %q was removed (was in %s)", name, oldPack.Defines[name])})
+		}
+	}
+	for _, path := range sortedKeys(oldPack.Used) {
+		if _, ok := newPack.Used[path]; !ok {
+			changes = append(changes, Change{Message: fmt.Sprintf("value %s is no longer used", path)})
+		}
+	}
+
+	return changes
+}
+
+// loadDefaults reads the default values of a pack; a missing file yields no defaults.
+func loadDefaults(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]any{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values file: %w", err)
+	}
+
+	defaults := map[string]any{}
+	if err := yaml3.Unmarshal(data, &defaults); err != nil {
+		return nil, fmt.Errorf("failed to parse values file %s: %w", path, err)
+	}
+	return defaults, nil
+}
+
+// loadSchemaRequired returns the value paths a JSON schema marks as required, following
+// nested object properties. A missing schema requires nothing.
+func loadSchemaRequired(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values schema: %w", err)
+	}
+
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse values schema %s: %w", path, err)
+	}
+
+	var required []string
+	collectRequired(schema, "", &required)
+	return required, nil
+}
+
+// collectRequired appends the required properties of schema, and of the required
+// object properties below it, to required.
+func collectRequired(schema map[string]any, prefix string, required *[]string) {
+	properties, _ := schema["properties"].(map[string]any)
+	names, _ := schema["required"].([]any)
+	for _, name := range names {
+		key, ok := name.(string)
+		if !ok {
+			continue
+		}
+		*required = append(*required, prefix+"."+key)
+		if property, ok := properties[key].(map[string]any); ok {
+			collectRequired(property, prefix+"."+key, required)
+		}
+	}
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
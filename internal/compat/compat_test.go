@@ -0,0 +1,155 @@
+package compat
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writePack(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "test-compat-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestAnalyze(t *testing.T) {
+	dir := writePack(t, map[string]string{
+		"values.yaml":                      "app:\n  port: 8080\n",
+		"values.schema.json":               `{"required":["app"],"properties":{"app":{"required":["image"]}}}`,
+		"templates/deploy.yaml.tpl":        "name: {{.app.name}}\nport: {{.app.port}}\n{{template \"labels\" .}}",
+		"templates/_helpers.tpl":           `{{define "labels"}}app: {{.app.name}}{{end}}`,
+		"templater.yaml":                   "name: web\n",
+		"vendor/common/templates/a.tpl":    "{{.vendored}}",
+		"templates/notes.txt":              "{{.notATemplate}}",
+		"templates/nested/service.tpl":     "{{with .service}}{{.type}}{{end}}",
+		"templates/nested/_unused.tpl":     `{{define "unused"}}{{end}}`,
+		"templates/nested/_optional.tpl":   `{{define "optional"}}{{.x}}{{end}}`,
+		"templates/nested/ingress.yml.tpl": "{{.ingress.host}}",
+		"templates/{{.app.env}}.conf.tpl":  "env",
+	})
+
+	pack, err := Analyze(dir)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	expectedRequired := []string{".app", ".app.env", ".app.image", ".app.name", ".ingress.host", ".service", ".service.type"}
+	if got := sortedKeys(pack.Required); !reflect.DeepEqual(got, expectedRequired) {
+		t.Errorf("Expected required %v, got %v", expectedRequired, got)
+	}
+	if pack.Required[".app.name"] != "templates/deploy.yaml.tpl:1:12" {
+		t.Errorf("Expected location of first use, got %s", pack.Required[".app.name"])
+	}
+
+	expectedUsed := []string{".app.env", ".app.name", ".app.port", ".ingress.host", ".service", ".service.type"}
+	if got := sortedKeys(pack.Used); !reflect.DeepEqual(got, expectedUsed) {
+		t.Errorf("Expected used %v, got %v", expectedUsed, got)
+	}
+
+	expectedDefines := []string{"labels", "optional", "unused"}
+	if got := sortedKeys(pack.Defines); !reflect.DeepEqual(got, expectedDefines) {
+		t.Errorf("Expected defines %v, got %v", expectedDefines, got)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldPack  map[string]string
+		newPack  map[string]string
+		expected []Change
+	}{
+		{
+			name:    "identical packs",
+			oldPack: map[string]string{"a.tpl": "{{.app.name}}"},
+			newPack: map[string]string{"a.tpl": "{{.app.name}}"},
+		},
+		{
+			name:    "new required value",
+			oldPack: map[string]string{"a.tpl": "{{.app.name}}"},
+			newPack: map[string]string{"a.tpl": "{{.app.name}} {{.app.image}}"},
+			expected: []Change{
+				{Breaking: true, Message: "value .app.image is now required (a.tpl:1:20)"},
+			},
+		},
+		{
+			name:    "new value with a default",
+			oldPack: map[string]string{"a.tpl": "{{.app.name}}"},
+			newPack: map[string]string{"a.tpl": "{{.app.name}} {{.app.port}}", "values.yaml": "app:\n  port: 80\n"},
+		},
+		{
+			name:    "default removed",
+			oldPack: map[string]string{"a.tpl": "{{.app.port}}", "values.yaml": "app:\n  port: 80\n"},
+			newPack: map[string]string{"a.tpl": "{{.app.port}}"},
+			expected: []Change{
+				{Breaking: true, Message: "value .app.port is now required (a.tpl:1:6)"},
+			},
+		},
+		{
+			name:    "newly required by schema",
+			oldPack: map[string]string{"a.tpl": "{{.app.name}}"},
+			newPack: map[string]string{"a.tpl": "{{.app.name}}", "values.schema.json": `{"required":["region"]}`},
+			expected: []Change{
+				{Breaking: true, Message: "value .region is now required (values.schema.json)"},
+			},
+		},
+		{
+			name:    "removed define and unused value",
+			oldPack: map[string]string{"a.tpl": `{{define "labels"}}{{end}}{{.legacy}} {{.app.name}}`},
+			newPack: map[string]string{"a.tpl": "{{.app.name}}"},
+			expected: []Change{
+				{Breaking: true, Message: `define "labels" was removed (was in a.tpl)`},
+				{Message: "value .legacy is no longer used"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldPack, err := Analyze(writePack(t, tt.oldPack))
+			if err != nil {
+				t.Fatalf("Analyze failed: %v", err)
+			}
+			newPack, err := Analyze(writePack(t, tt.newPack))
+			if err != nil {
+				t.Fatalf("Analyze failed: %v", err)
+			}
+
+			if changes := Compare(oldPack, newPack); !reflect.DeepEqual(changes, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, changes)
+			}
+		})
+	}
+}
+
+func TestAnalyzeInvalidTemplate(t *testing.T) {
+	dir := writePack(t, map[string]string{"a.tpl": "{{.app.name"})
+	if _, err := Analyze(dir); err == nil {
+		t.Error("Expected error for a template that does not parse")
+	}
+}
+
+func TestChangeString(t *testing.T) {
+	if got := (Change{Breaking: true, Message: "x"}).String(); got != "BREAKING: x" {
+		t.Errorf("Expected BREAKING prefix, got %s", got)
+	}
+	if got := (Change{Message: "x"}).String(); got != "deprecated: x" {
+		t.Errorf("Expected deprecated prefix, got %s", got)
+	}
+}
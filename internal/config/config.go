@@ -1,14 +1,127 @@
 package config
 
+import "time"
+
 // Config holds the configuration for template processing.
 type Config struct {
-	TemplateFile string
-	ValuesFile   string
-	OutputFile   string
-	SetValues    []string
-	Values       map[string]any
-	IsDirectory  bool
-	StrictMode   bool
+	TemplateFile  string
+	ValuesFile    string
+	ValuesFrom    []string
+	LazyValues    bool
+	SchemaFile    string
+	SkipSchema    bool
+	OutputFile    string
+	SetValues     []string
+	SetStrings    []string
+	SetFiles      []string
+	SetJSON       []string
+	Values        map[string]any
+	IsDirectory   bool
+	StrictMode    bool
+	ParseOnly     bool
+	StaticCheck   bool
+	FailOnEmpty   bool
+	EnvFiles      []string
+	EnvPrefix     string
+	AgeIdentities []string
+
+	// Environment selects the values.<env>.yaml file merged over the values file, which
+	// defaults to the values.yaml of the template directory.
+	Environment string
+
+	// SkipDirectoryValues disables merging the values.yaml and _values.yaml files of
+	// template subdirectories over the values of the templates below them.
+	SkipDirectoryValues bool
+
+	// DisableFileFunctions makes the file functions, such as readFile and glob, fail
+	// instead of reading files below the template directory.
+	DisableFileFunctions bool
+
+	// HelmCompat executes templates with the Helm builtin objects .Values, .Release and
+	// .Template instead of the values, with the release named ReleaseName in
+	// ReleaseNamespace.
+	HelmCompat       bool
+	ReleaseName      string
+	ReleaseNamespace string
+
+	// SortKeys makes toYaml, toJson, toToml and their variants write every key in sorted
+	// order, including the fields of structs returned by functions.
+	SortKeys bool
+
+	// AllowEnv makes the env and expandenv functions available in templates, restricted
+	// to variables starting with AllowEnvPrefix when set.
+	AllowEnv       bool
+	AllowEnvPrefix string
+
+	// AllowExec lists the commands the exec template function may run; entries may be
+	// comma-separated. exec is not available when it is empty.
+	AllowExec []string
+
+	// AllowHTTP lists the hosts the httpGet template function may fetch from; entries
+	// may be comma-separated. httpGet is not available when it is empty.
+	AllowHTTP []string
+
+	// FuncPlugins are Go plugins (.so files) exporting additional template functions.
+	FuncPlugins []string
+
+	// ProcessPlugins are name=path specs of template functions running an external program.
+	ProcessPlugins []string
+
+	// StarlarkFiles are .star files whose top-level functions become template functions.
+	StarlarkFiles []string
+
+	// WasmPlugins are WebAssembly modules exporting additional template functions.
+	WasmPlugins []string
+
+	// MergeStrategies are --merge-strategy specs controlling how lists from several
+	// sources are merged: a strategy, or key=strategy for a single list.
+	MergeStrategies []string
+
+	// PreciseNumbers keeps numbers in values as written, such as large integers and
+	// decimals like 1.10, instead of converting them to int or float64.
+	PreciseNumbers bool
+
+	// TemplateValues renders {{ }} expressions in the values file before merging.
+	TemplateValues bool
+
+	// ValuesFromTimeout bounds each ValuesFrom source; sources are fetched concurrently.
+	ValuesFromTimeout time.Duration
+
+	// RenderTimeout abandons a template execution running longer than this; 0 means no limit.
+	RenderTimeout time.Duration
+
+	// Workers is the number of templates rendered concurrently in directory mode.
+	Workers int
+	// MaxMemory caps the rendered bytes held in memory at once; 0 means unlimited.
+	MaxMemory int64
+
+	// CacheDir enables render caching in the given directory when set.
+	CacheDir string
+	// RemoteCache is an http(s):// or s3:// location shared between machines.
+	RemoteCache        string
+	RemoteCacheHeaders []string
+
+	// CombineFile, when set, receives every rendered file concatenated with separators,
+	// instead of OutputFile.
+	CombineFile string
+
+	// Include and Exclude are glob patterns selecting the files of a template directory
+	// to process, relative to it.
+	Include []string
+	Exclude []string
+
+	// CopyStatic copies the files of a template directory without the template
+	// extension verbatim into the output tree.
+	CopyStatic bool
+
+	// DryRun renders every template and reports which files would be created, updated
+	// or left unchanged, without writing any.
+	DryRun bool
+
+	// HTTP upload settings, used when OutputFile is an http(s) URL.
+	OutputMethod  string
+	OutputHeaders []string
+	OutputRetries int
 }
 
 // NewConfig creates a new configuration instance.
@@ -21,5 +134,11 @@ func NewConfig(templateFile, valuesFile, outputFile string, setValues []string,
 		Values:       make(map[string]any),
 		IsDirectory:  isDirectory,
 		StrictMode:   strictMode,
+		Workers:      1,
+
+		ValuesFromTimeout: 30 * time.Second,
+
+		OutputMethod:  "PUT",
+		OutputRetries: 3,
 	}
 }
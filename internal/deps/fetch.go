@@ -0,0 +1,399 @@
+package deps
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/menta2k/templater/internal/oci"
+)
+
+// Options controls how dependencies are fetched.
+type Options struct {
+	// Update resolves every dependency to the newest version satisfying its constraint,
+	// ignoring the versions pinned in the lock file.
+	Update bool
+	// Registry is the client used for oci:// sources.
+	Registry *oci.Client
+}
+
+// source lists the versions of a dependency and fetches one of them into a directory.
+type source interface {
+	versions() ([]string, error)
+	fetch(version, dir string) (digest string, err error)
+}
+
+// requirement is a dependency together with the pack that declared it.
+type requirement struct {
+	Dependency
+	requiredBy string
+}
+
+// Fetch resolves the dependencies declared in dir's templater.yaml, including the
+// dependencies of dependencies, vendors each one into dir/vendor/<name> and writes
+// dir/templater.lock. Versions pinned in the lock file are reused while they still
+// satisfy their constraint, and their digests are verified.
+func Fetch(dir string, opts Options) ([]Locked, error) {
+	manifest, err := LoadManifest(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := LoadLock(filepath.Join(dir, LockFile))
+	if err != nil {
+		return nil, err
+	}
+
+	vendorDir := filepath.Join(dir, VendorDir)
+	if err := os.MkdirAll(vendorDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create vendor directory: %w", err)
+	}
+
+	queue := requirements(manifest, ManifestFile)
+	resolved := make(map[string]Locked)
+	requiredBy := make(map[string]string)
+	for len(queue) > 0 {
+		req := queue[0]
+		queue = queue[1:]
+
+		if existing, ok := resolved[req.Name]; ok {
+			if err := checkCompatible(existing, req, requiredBy[req.Name]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		locked, err := fetchDependency(vendorDir, req.Dependency, lock, opts)
+		if err != nil {
+			return nil, err
+		}
+		resolved[req.Name] = locked
+		requiredBy[req.Name] = req.requiredBy
+
+		nested := filepath.Join(vendorDir, req.Name, ManifestFile)
+		if _, err := os.Stat(nested); err == nil {
+			packManifest, err := LoadManifest(nested)
+			if err != nil {
+				return nil, err
+			}
+			queue = append(queue, requirements(packManifest, req.Name)...)
+		}
+	}
+
+	if err := pruneVendorDir(vendorDir, resolved); err != nil {
+		return nil, err
+	}
+
+	result := &Lock{Dependencies: make([]Locked, 0, len(resolved))}
+	for _, locked := range resolved {
+		result.Dependencies = append(result.Dependencies, locked)
+	}
+	if err := result.Save(filepath.Join(dir, LockFile)); err != nil {
+		return nil, err
+	}
+	return result.Dependencies, nil
+}
+
+// requirements returns the dependencies declared by a manifest.
+func requirements(manifest *Manifest, requiredBy string) []requirement {
+	reqs := make([]requirement, 0, len(manifest.Dependencies))
+	for _, dep := range manifest.Dependencies {
+		reqs = append(reqs, requirement{Dependency: dep, requiredBy: requiredBy})
+	}
+	return reqs
+}
+
+// checkCompatible reports an error when a pack required several times cannot be
+// satisfied by the version already vendored.
+func checkCompatible(existing Locked, req requirement, existingRequiredBy string) error {
+	if existing.Source != req.Source {
+		return fmt.Errorf("conflicting sources for %s: %s (required by %s) and %s (required by %s)",
+			req.Name, existing.Source, existingRequiredBy, req.Source, req.requiredBy)
+	}
+
+	constraint, err := semver.NewConstraint(req.Version)
+	if err != nil {
+		return fmt.Errorf("dependency %s: invalid version constraint %q: %w", req.Name, req.Version, err)
+	}
+	version, err := semver.NewVersion(existing.Version)
+	if err != nil || !constraint.Check(version) {
+		return fmt.Errorf("conflicting versions for %s: %s (required by %s) does not satisfy %s (required by %s)",
+			req.Name, existing.Version, existingRequiredBy, req.Version, req.requiredBy)
+	}
+	return nil
+}
+
+// fetchDependency resolves a dependency to a version and vendors it into vendorDir/<name>.
+func fetchDependency(vendorDir string, dep Dependency, lock *Lock, opts Options) (Locked, error) {
+	src, err := newSource(dep.Source, opts)
+	if err != nil {
+		return Locked{}, fmt.Errorf("dependency %s: %w", dep.Name, err)
+	}
+
+	constraint, err := semver.NewConstraint(dep.Version)
+	if err != nil {
+		return Locked{}, fmt.Errorf("dependency %s: invalid version constraint %q: %w", dep.Name, dep.Version, err)
+	}
+
+	pinned, ok := lock.find(dep.Name)
+	usePinned := ok && !opts.Update && pinned.Source == dep.Source && satisfies(constraint, pinned.Version)
+
+	version := pinned.Version
+	if !usePinned {
+		available, err := src.versions()
+		if err != nil {
+			return Locked{}, fmt.Errorf("dependency %s: %w", dep.Name, err)
+		}
+		if version, err = highestMatching(available, constraint); err != nil {
+			return Locked{}, fmt.Errorf("dependency %s: %w", dep.Name, err)
+		}
+	}
+
+	staging, err := os.MkdirTemp(vendorDir, ".fetch-"+dep.Name+"-*")
+	if err != nil {
+		return Locked{}, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	digest, err := src.fetch(version, staging)
+	if err != nil {
+		return Locked{}, fmt.Errorf("failed to fetch %s %s: %w", dep.Name, version, err)
+	}
+	if usePinned && pinned.Digest != "" && digest != pinned.Digest {
+		return Locked{}, fmt.Errorf("dependency %s %s: digest %s does not match %s in %s", dep.Name, version, digest, pinned.Digest, LockFile)
+	}
+
+	// Dependencies of the pack are vendored next to it rather than inside it
+	if err := os.RemoveAll(filepath.Join(staging, VendorDir)); err != nil {
+		return Locked{}, fmt.Errorf("failed to remove nested vendor directory: %w", err)
+	}
+
+	target := filepath.Join(vendorDir, dep.Name)
+	if err := os.RemoveAll(target); err != nil {
+		return Locked{}, fmt.Errorf("failed to remove %s: %w", target, err)
+	}
+	if err := os.Rename(staging, target); err != nil {
+		return Locked{}, fmt.Errorf("failed to vendor %s: %w", dep.Name, err)
+	}
+	if err := os.Chmod(target, 0o755); err != nil {
+		return Locked{}, fmt.Errorf("failed to vendor %s: %w", dep.Name, err)
+	}
+
+	return Locked{Name: dep.Name, Source: dep.Source, Version: version, Digest: digest}, nil
+}
+
+// satisfies reports whether version parses and matches constraint.
+func satisfies(constraint *semver.Constraints, version string) bool {
+	v, err := semver.NewVersion(version)
+	return err == nil && constraint.Check(v)
+}
+
+// highestMatching returns the highest of the available versions matching constraint.
+// Versions that are not semantic versions are ignored.
+func highestMatching(available []string, constraint *semver.Constraints) (string, error) {
+	var best *semver.Version
+	bestName := ""
+	for _, name := range available {
+		v, err := semver.NewVersion(name)
+		if err != nil || !constraint.Check(v) {
+			continue
+		}
+		if best == nil || v.GreaterThan(best) {
+			best, bestName = v, name
+		}
+	}
+	if best == nil {
+		return "", fmt.Errorf("no version matches %s (available: %s)", constraint, strings.Join(available, ", "))
+	}
+	return bestName, nil
+}
+
+// pruneVendorDir removes vendored packs that are no longer required.
+func pruneVendorDir(vendorDir string, resolved map[string]Locked) error {
+	entries, err := os.ReadDir(vendorDir)
+	if err != nil {
+		return fmt.Errorf("failed to read vendor directory: %w", err)
+	}
+	for _, entry := range entries {
+		if _, ok := resolved[entry.Name()]; ok || !entry.IsDir() {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(vendorDir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to remove unused pack %s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// newSource returns the source for an oci:// or git:: reference.
+func newSource(ref string, opts Options) (source, error) {
+	if strings.HasPrefix(ref, gitPrefix) {
+		repository, subdir := splitGitSource(strings.TrimPrefix(ref, gitPrefix))
+		return &gitSource{repository: repository, subdir: subdir}, nil
+	}
+
+	parsed, err := parseOCISource(ref)
+	if err != nil {
+		return nil, err
+	}
+	client := opts.Registry
+	if client == nil {
+		client = oci.NewClient("", "", false)
+	}
+	return &ociSource{ref: parsed, client: client}, nil
+}
+
+// parseOCISource parses an oci:// source, which selects its version through the
+// dependency constraint rather than a tag.
+func parseOCISource(ref string) (oci.Reference, error) {
+	parsed, err := oci.ParseReference(ref)
+	if err != nil {
+		return oci.Reference{}, err
+	}
+	if strings.HasSuffix(ref, ":"+parsed.Tag) {
+		return oci.Reference{}, fmt.Errorf("source %s must not include a tag (use version to select one)", ref)
+	}
+	return parsed, nil
+}
+
+// splitGitSource splits a repository reference from its optional //subdir suffix.
+func splitGitSource(ref string) (repository, subdir string) {
+	schemeEnd := 0
+	if i := strings.Index(ref, "://"); i >= 0 {
+		schemeEnd = i + len("://")
+	}
+	if i := strings.Index(ref[schemeEnd:], "//"); i >= 0 {
+		return ref[:schemeEnd+i], ref[schemeEnd+i+2:]
+	}
+	return ref, ""
+}
+
+// ociSource fetches packs pushed with `templater push`; versions are registry tags.
+type ociSource struct {
+	ref    oci.Reference
+	client *oci.Client
+}
+
+func (s *ociSource) versions() ([]string, error) {
+	return s.client.Tags(s.ref)
+}
+
+func (s *ociSource) fetch(version, dir string) (string, error) {
+	ref := s.ref
+	ref.Tag = version
+	artifact, digest, err := s.client.Pull(ref)
+	if err != nil {
+		return "", err
+	}
+	if err := artifact.Extract(dir); err != nil {
+		return "", err
+	}
+	return digest, nil
+}
+
+// gitSource fetches packs from a git repository; versions are tags.
+type gitSource struct {
+	repository string
+	subdir     string
+}
+
+func (s *gitSource) versions() ([]string, error) {
+	out, err := runGit("", "ls-remote", "--tags", "--refs", s.repository)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		_, ref, ok := strings.Cut(line, "\t")
+		if ok {
+			tags = append(tags, strings.TrimPrefix(ref, "refs/tags/"))
+		}
+	}
+	return tags, nil
+}
+
+func (s *gitSource) fetch(version, dir string) (string, error) {
+	checkout, err := os.MkdirTemp("", "templater-git-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create checkout directory: %w", err)
+	}
+	defer os.RemoveAll(checkout)
+
+	if _, err := runGit("", "clone", "--quiet", "--depth", "1", "--branch", version, s.repository, checkout); err != nil {
+		return "", err
+	}
+	commit, err := runGit(checkout, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	packDir := filepath.Join(checkout, filepath.FromSlash(s.subdir))
+	if info, err := os.Stat(packDir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("directory %s not found in %s", s.subdir, s.repository)
+	}
+	if err := copyTree(packDir, dir); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(commit), nil
+}
+
+// runGit runs a git command and returns its output.
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// copyTree copies the regular files below src into dst, skipping .git.
+func copyTree(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		relativePath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, relativePath)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
@@ -0,0 +1,343 @@
+package deps
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/menta2k/templater/internal/oci"
+)
+
+// newGitRepo creates a git repository with one commit per version, each tagged with its
+// version and containing the given files.
+func newGitRepo(t *testing.T, versions []string, files func(version string) map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+			"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	git("init", "--quiet")
+	for _, version := range versions {
+		for path, content := range files(version) {
+			writeFile(t, filepath.Join(dir, path), content)
+		}
+		git("add", "-A")
+		git("commit", "--quiet", "-m", version)
+		git("tag", version)
+	}
+	return dir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestFetchGit(t *testing.T) {
+	labels := newGitRepo(t, []string{"v1.0.0", "v1.1.0", "v2.0.0"}, func(version string) map[string]string {
+		return map[string]string{
+			"README.md":                         "not part of the pack",
+			"packs/labels/_labels.tpl":          "labels " + version,
+			"packs/labels/vendor/stale/old.tpl": "vendored upstream",
+		}
+	})
+	source := "git::file://" + labels + "//packs/labels"
+
+	packDir := t.TempDir()
+	writeFile(t, filepath.Join(packDir, ManifestFile), "dependencies:\n  - name: labels\n    source: "+source+"\n    version: ^1.0\n")
+
+	locked, err := Fetch(packDir, Options{})
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(locked) != 1 || locked[0].Version != "v1.1.0" || locked[0].Digest == "" {
+		t.Fatalf("Expected labels v1.1.0 to be locked, got %+v", locked)
+	}
+	if got := readFile(t, filepath.Join(packDir, VendorDir, "labels", "_labels.tpl")); got != "labels v1.1.0" {
+		t.Errorf("Expected vendored labels v1.1.0, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(packDir, VendorDir, "labels", "README.md")); err == nil {
+		t.Error("Expected only the pack subdirectory to be vendored")
+	}
+	if _, err := os.Stat(filepath.Join(packDir, VendorDir, "labels", VendorDir)); err == nil {
+		t.Error("Expected the nested vendor directory to be removed")
+	}
+
+	// Lower the lock to v1.0.0: fetching again keeps the pinned version
+	lock, err := LoadLock(filepath.Join(packDir, LockFile))
+	if err != nil {
+		t.Fatalf("LoadLock failed: %v", err)
+	}
+	lock.Dependencies[0].Version = "v1.0.0"
+	lock.Dependencies[0].Digest = ""
+	if err := lock.Save(filepath.Join(packDir, LockFile)); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if locked, err = Fetch(packDir, Options{}); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if locked[0].Version != "v1.0.0" {
+		t.Errorf("Expected pinned version v1.0.0, got %s", locked[0].Version)
+	}
+
+	// A digest not matching the lock file is rejected
+	lock.Dependencies[0].Digest = "0000000"
+	if err := lock.Save(filepath.Join(packDir, LockFile)); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := Fetch(packDir, Options{}); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("Expected digest mismatch error, got %v", err)
+	}
+
+	// Updating ignores the lock file
+	if locked, err = Fetch(packDir, Options{Update: true}); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if locked[0].Version != "v1.1.0" {
+		t.Errorf("Expected updated version v1.1.0, got %s", locked[0].Version)
+	}
+}
+
+func TestFetchTransitive(t *testing.T) {
+	base := newGitRepo(t, []string{"v1.0.0", "v1.4.0"}, func(version string) map[string]string {
+		return map[string]string{"_base.tpl": "base " + version}
+	})
+	baseSource := "git::file://" + base
+	web := newGitRepo(t, []string{"v0.1.0"}, func(string) map[string]string {
+		return map[string]string{
+			"_web.tpl":   "web",
+			ManifestFile: "dependencies:\n  - name: base\n    source: " + baseSource + "\n    version: '>= 1.2'\n",
+		}
+	})
+
+	tests := []struct {
+		name        string
+		manifest    string
+		expected    map[string]string
+		errContains string
+	}{
+		{
+			name:     "dependency of dependency",
+			manifest: "dependencies:\n  - name: web\n    source: git::file://" + web + "\n    version: ~0.1\n",
+			expected: map[string]string{"web": "v0.1.0", "base": "v1.4.0"},
+		},
+		{
+			name: "compatible constraints",
+			manifest: "dependencies:\n  - name: base\n    source: " + baseSource + "\n    version: ^1.0\n" +
+				"  - name: web\n    source: git::file://" + web + "\n    version: ~0.1\n",
+			expected: map[string]string{"web": "v0.1.0", "base": "v1.4.0"},
+		},
+		{
+			name: "conflicting constraints",
+			manifest: "dependencies:\n  - name: base\n    source: " + baseSource + "\n    version: ~1.0.0\n" +
+				"  - name: web\n    source: git::file://" + web + "\n    version: ~0.1\n",
+			errContains: "conflicting versions for base",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packDir := t.TempDir()
+			writeFile(t, filepath.Join(packDir, ManifestFile), tt.manifest)
+			writeFile(t, filepath.Join(packDir, VendorDir, "unused", "_old.tpl"), "old")
+
+			locked, err := Fetch(packDir, Options{})
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("Expected error containing %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch failed: %v", err)
+			}
+
+			versions := make(map[string]string)
+			for _, l := range locked {
+				versions[l.Name] = l.Version
+			}
+			for name, version := range tt.expected {
+				if versions[name] != version {
+					t.Errorf("Expected %s %s, got %s", name, version, versions[name])
+				}
+				if _, err := os.Stat(filepath.Join(packDir, VendorDir, name)); err != nil {
+					t.Errorf("Expected %s to be vendored: %v", name, err)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(packDir, VendorDir, "unused")); err == nil {
+				t.Error("Expected unused vendored pack to be pruned")
+			}
+		})
+	}
+}
+
+// newRegistry starts an in-memory OCI registry without authentication.
+func newRegistry(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var mu sync.Mutex
+	blobs := map[string][]byte{}
+	manifests := map[string][]byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		path := strings.TrimPrefix(r.URL.Path, "/v2/packs/common/")
+		switch {
+		case r.Method == http.MethodHead && strings.HasPrefix(path, "blobs/"):
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPost && path == "blobs/uploads/":
+			w.Header().Set("Location", "/v2/packs/common/blobs/uploads/session")
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodPut && strings.HasPrefix(path, "blobs/uploads/"):
+			blobs[r.URL.Query().Get("digest")], _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPut && strings.HasPrefix(path, "manifests/"):
+			manifests[strings.TrimPrefix(path, "manifests/")], _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodGet && strings.HasPrefix(path, "manifests/"):
+			_, _ = w.Write(manifests[strings.TrimPrefix(path, "manifests/")])
+		case r.Method == http.MethodGet && strings.HasPrefix(path, "blobs/"):
+			_, _ = w.Write(blobs[strings.TrimPrefix(path, "blobs/")])
+		case r.Method == http.MethodGet && path == "tags/list":
+			tags := []string{}
+			for tag := range manifests {
+				tags = append(tags, tag)
+			}
+			sort.Strings(tags)
+			_ = json.NewEncoder(w).Encode(map[string]any{"tags": tags})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchOCI(t *testing.T) {
+	server := newRegistry(t)
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := oci.NewClient("", "", true)
+
+	for _, version := range []string{"1.0.0", "1.3.0", "2.0.0"} {
+		templateDir := t.TempDir()
+		writeFile(t, filepath.Join(templateDir, "_common.tpl"), "common "+version)
+
+		ref := oci.Reference{Registry: host, Repository: "packs/common", Tag: version}
+		artifact, err := oci.PackageDirectory(ref, templateDir, "", "")
+		if err != nil {
+			t.Fatalf("PackageDirectory failed: %v", err)
+		}
+		if _, err := client.Push(ref, artifact); err != nil {
+			t.Fatalf("Push failed: %v", err)
+		}
+	}
+
+	packDir := t.TempDir()
+	writeFile(t, filepath.Join(packDir, ManifestFile), "dependencies:\n  - name: common\n    source: oci://"+host+"/packs/common\n    version: ^1\n")
+
+	locked, err := Fetch(packDir, Options{Registry: client})
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(locked) != 1 || locked[0].Version != "1.3.0" || !strings.HasPrefix(locked[0].Digest, "sha256:") {
+		t.Fatalf("Expected common 1.3.0 locked by digest, got %+v", locked)
+	}
+	if got := readFile(t, filepath.Join(packDir, VendorDir, "common", "_common.tpl")); got != "common 1.3.0" {
+		t.Errorf("Expected vendored common 1.3.0, got %q", got)
+	}
+}
+
+func TestHighestMatching(t *testing.T) {
+	tests := []struct {
+		constraint string
+		available  []string
+		expected   string
+		wantError  bool
+	}{
+		{"^1.0", []string{"v1.0.0", "v1.2.0", "v2.0.0", "latest"}, "v1.2.0", false},
+		{"~1.0.0", []string{"1.0.1", "1.0.3", "1.1.0"}, "1.0.3", false},
+		{">= 2.0", []string{"1.0.0", "2.1.0-rc.1"}, "", true},
+		{"*", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			constraint, err := semver.NewConstraint(tt.constraint)
+			if err != nil {
+				t.Fatalf("Invalid constraint: %v", err)
+			}
+			got, err := highestMatching(tt.available, constraint)
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("Expected error but got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSplitGitSource(t *testing.T) {
+	tests := []struct {
+		ref        string
+		repository string
+		subdir     string
+	}{
+		{"https://github.com/example/packs.git", "https://github.com/example/packs.git", ""},
+		{"https://github.com/example/packs.git//labels", "https://github.com/example/packs.git", "labels"},
+		{"file:///srv/packs//a/b", "file:///srv/packs", "a/b"},
+		{"git@github.com:example/packs.git//labels", "git@github.com:example/packs.git", "labels"},
+	}
+
+	for _, tt := range tests {
+		repository, subdir := splitGitSource(tt.ref)
+		if repository != tt.repository || subdir != tt.subdir {
+			t.Errorf("splitGitSource(%s) = (%s, %s), expected (%s, %s)", tt.ref, repository, subdir, tt.repository, tt.subdir)
+		}
+	}
+}
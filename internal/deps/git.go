@@ -0,0 +1,164 @@
+package deps
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/menta2k/templater/internal/cache"
+)
+
+// commitPattern matches full and abbreviated commit hashes.
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// IsGitSource reports whether location is a git:: reference.
+func IsGitSource(location string) bool {
+	return strings.HasPrefix(location, gitPrefix)
+}
+
+// DefaultCheckoutDir returns the directory holding the checkouts made by CheckoutFile.
+func DefaultCheckoutDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate user cache directory: %w", err)
+	}
+	return filepath.Join(base, "templater", "git"), nil
+}
+
+// CheckoutFile fetches a file referenced as git::<repository>//<path>?ref=<ref> and returns
+// its local path. The ref may be a tag, a branch or a commit and defaults to the remote
+// HEAD. Each repository and ref is checked out once below dir, so the rest of the checkout
+// (such as a values.schema.json next to the file) is available too. Following policy, the
+// checkout is reused, or refreshed when the ref points to a different commit than before.
+func CheckoutFile(location, dir string, policy cache.SourcePolicy) (string, error) {
+	repository, path, ref, err := parseGitFile(location)
+	if err != nil {
+		return "", err
+	}
+
+	key := sha256.Sum256([]byte(repository + "\x00" + ref))
+	checkout := filepath.Join(dir, hex.EncodeToString(key[:8]))
+	entryPath := checkout + ".json"
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	entry := cache.LoadSourceEntry(entryPath)
+	if _, err := os.Stat(filepath.Join(checkout, ".git")); err != nil {
+		entry = nil
+	}
+	cached, err := policy.UseCached(entry, location)
+	if err != nil {
+		return "", err
+	}
+	if !cached && entry != nil {
+		if cached, err = unchangedRef(repository, ref, entry.Version); err != nil {
+			return "", err
+		}
+		if cached {
+			entry.Fetched = time.Now()
+			if err := entry.Save(entryPath); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if !cached {
+		revision, err := fetchRef(checkout, repository, ref)
+		if err != nil {
+			return "", err
+		}
+		entry = &cache.SourceEntry{Location: location, Version: revision, Fetched: time.Now()}
+		if err := entry.Save(entryPath); err != nil {
+			return "", err
+		}
+	}
+
+	file := filepath.Join(checkout, filepath.FromSlash(path))
+	if info, err := os.Stat(file); err != nil || !info.Mode().IsRegular() {
+		return "", fmt.Errorf("file %s not found in %s at %s", path, repository, ref)
+	}
+	return file, nil
+}
+
+// fetchRef checks out ref of repository into checkout and returns its commit.
+func fetchRef(checkout, repository, ref string) (string, error) {
+	if _, err := os.Stat(filepath.Join(checkout, ".git")); err != nil {
+		if err := os.MkdirAll(checkout, 0o755); err != nil {
+			return "", fmt.Errorf("failed to create checkout directory: %w", err)
+		}
+		if _, err := runGit(checkout, "init", "--quiet"); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := runGit(checkout, "fetch", "--quiet", "--depth", "1", repository, ref); err != nil {
+		return "", err
+	}
+	if _, err := runGit(checkout, "checkout", "--quiet", "--force", "--detach", "FETCH_HEAD"); err != nil {
+		return "", err
+	}
+	out, err := runGit(checkout, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// unchangedRef reports whether ref of repository still points to commit. Commits are
+// compared directly; tags and branches are resolved with git ls-remote, preferring tags
+// as git fetch does.
+func unchangedRef(repository, ref, commit string) (bool, error) {
+	if commit == "" {
+		return false, nil
+	}
+	if commitPattern.MatchString(ref) && strings.HasPrefix(commit, ref) {
+		return true, nil
+	}
+
+	out, err := runGit("", "ls-remote", repository, ref)
+	if err != nil {
+		return false, err
+	}
+	resolved := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if hash, name, ok := strings.Cut(line, "\t"); ok {
+			resolved[name] = hash
+		}
+	}
+	for _, name := range []string{ref, "refs/tags/" + ref + "^{}", "refs/tags/" + ref, "refs/heads/" + ref} {
+		if hash, ok := resolved[name]; ok {
+			return hash == commit, nil
+		}
+	}
+	return false, nil
+}
+
+// parseGitFile splits a git::<repository>//<path>?ref=<ref> reference.
+func parseGitFile(location string) (repository, path, ref string, err error) {
+	source := strings.TrimPrefix(location, gitPrefix)
+	if i := strings.LastIndex(source, "?"); i >= 0 {
+		query, err := url.ParseQuery(source[i+1:])
+		if err != nil {
+			return "", "", "", fmt.Errorf("invalid git reference %s: %w", location, err)
+		}
+		for name := range query {
+			if name != "ref" {
+				return "", "", "", fmt.Errorf("invalid git reference %s: unknown parameter %q", location, name)
+			}
+		}
+		source, ref = source[:i], query.Get("ref")
+	}
+
+	repository, path = splitGitSource(source)
+	if repository == "" || path == "" || !filepath.IsLocal(filepath.FromSlash(path)) {
+		return "", "", "", fmt.Errorf("invalid git reference %s (expected git::<repository>//<path>[?ref=<ref>])", location)
+	}
+	return repository, path, ref, nil
+}
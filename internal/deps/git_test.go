@@ -0,0 +1,160 @@
+package deps
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/menta2k/templater/internal/cache"
+)
+
+func TestCheckoutFile(t *testing.T) {
+	repo := newGitRepo(t, []string{"v1.0.0", "v1.1.0"}, func(version string) map[string]string {
+		return map[string]string{
+			"config/values.yaml":        "version: " + version + "\n",
+			"config/values.schema.json": "{}",
+		}
+	})
+	out, err := exec.Command("git", "-C", repo, "rev-parse", "v1.0.0").Output()
+	if err != nil {
+		t.Fatalf("Failed to resolve tag: %v", err)
+	}
+	commit := strings.TrimSpace(string(out))
+	dir := t.TempDir()
+
+	tests := []struct {
+		name      string
+		location  string
+		expected  string
+		wantError string
+	}{
+		{name: "tag", location: "git::file://" + repo + "//config/values.yaml?ref=v1.0.0", expected: "version: v1.0.0\n"},
+		{name: "default branch", location: "git::file://" + repo + "//config/values.yaml", expected: "version: v1.1.0\n"},
+		{name: "commit", location: "git::file://" + repo + "//config/values.yaml?ref=" + commit, expected: "version: v1.0.0\n"},
+		{name: "refetch", location: "git::file://" + repo + "//config/values.yaml?ref=v1.0.0", expected: "version: v1.0.0\n"},
+		{name: "missing file", location: "git::file://" + repo + "//config/prod.yaml?ref=v1.0.0", wantError: "not found"},
+		{name: "missing ref", location: "git::file://" + repo + "//config/values.yaml?ref=v9", wantError: "git fetch failed"},
+		{name: "no path", location: "git::file://" + repo, wantError: "invalid git reference"},
+		{name: "escaping path", location: "git::file://" + repo + "//../values.yaml", wantError: "invalid git reference"},
+		{name: "unknown parameter", location: "git::file://" + repo + "//config/values.yaml?depth=1", wantError: "unknown parameter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := CheckoutFile(tt.location, dir, cache.SourcePolicy{})
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := readFile(t, path); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+			if got := readFile(t, filepath.Join(filepath.Dir(path), "values.schema.json")); got != "{}" {
+				t.Errorf("Expected the rest of the checkout next to the file, got %q", got)
+			}
+		})
+	}
+}
+
+func TestCheckoutFileCache(t *testing.T) {
+	repo := newGitRepo(t, []string{"v1.0.0"}, func(version string) map[string]string {
+		return map[string]string{"values.yaml": "version: " + version + "\n"}
+	})
+	location := "git::file://" + repo + "//values.yaml"
+	dir := t.TempDir()
+
+	if _, err := CheckoutFile(location, dir, cache.SourcePolicy{Offline: true}); !errors.Is(err, cache.ErrNotCached) {
+		t.Fatalf("Expected offline checkout of an uncached repository to fail, got %v", err)
+	}
+
+	path, err := CheckoutFile(location, dir, cache.SourcePolicy{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	writeFile(t, filepath.Join(repo, "values.yaml"), "version: v2.0.0\n")
+	cmd := exec.Command("git", "commit", "--quiet", "-am", "v2.0.0")
+	cmd.Dir = repo
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v: %s", err, out)
+	}
+
+	tests := []struct {
+		name     string
+		policy   cache.SourcePolicy
+		expected string
+	}{
+		{name: "within TTL", policy: cache.SourcePolicy{TTL: time.Hour}, expected: "version: v1.0.0\n"},
+		{name: "offline", policy: cache.SourcePolicy{Offline: true}, expected: "version: v1.0.0\n"},
+		{name: "expired", policy: cache.SourcePolicy{}, expected: "version: v2.0.0\n"},
+		{name: "unchanged", policy: cache.SourcePolicy{}, expected: "version: v2.0.0\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CheckoutFile(location, dir, tt.policy)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != path {
+				t.Errorf("Expected checkout %s, got %s", path, got)
+			}
+			if content := readFile(t, got); content != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, content)
+			}
+		})
+	}
+}
+
+func TestUnchangedRef(t *testing.T) {
+	repo := newGitRepo(t, []string{"v1.0.0", "v1.1.0"}, func(version string) map[string]string {
+		return map[string]string{"values.yaml": "version: " + version + "\n"}
+	})
+	commit := func(ref string) string {
+		out, err := exec.Command("git", "-C", repo, "rev-parse", ref+"^{commit}").Output()
+		if err != nil {
+			t.Fatalf("Failed to resolve %s: %v", ref, err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	v1, head := commit("v1.0.0"), commit("HEAD")
+
+	tests := []struct {
+		name     string
+		ref      string
+		commit   string
+		expected bool
+	}{
+		{name: "tag unchanged", ref: "v1.0.0", commit: v1, expected: true},
+		{name: "tag moved", ref: "v1.1.0", commit: v1, expected: false},
+		{name: "head unchanged", ref: "HEAD", commit: head, expected: true},
+		{name: "commit", ref: v1[:12], commit: v1, expected: true},
+		{name: "unknown ref", ref: "v9", commit: v1, expected: false},
+		{name: "never fetched", ref: "v1.0.0", commit: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unchanged, err := unchangedRef("file://"+repo, tt.ref, tt.commit)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if unchanged != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, unchanged)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package deps
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// Well-known files and directories of a template pack with dependencies.
+const (
+	ManifestFile = "templater.yaml"
+	LockFile     = "templater.lock"
+	VendorDir    = "vendor"
+	gitPrefix    = "git::"
+	ociPrefix    = "oci://"
+)
+
+// Manifest is the templater.yaml file describing a template pack and the packs it depends on.
+type Manifest struct {
+	Name         string       `yaml:"name,omitempty"`
+	Version      string       `yaml:"version,omitempty"`
+	Dependencies []Dependency `yaml:"dependencies,omitempty"`
+}
+
+// Dependency declares a pack to vendor under vendor/<name>.
+type Dependency struct {
+	Name string `yaml:"name"`
+	// Source is an oci://registry/repository reference without a tag, or a
+	// git::https://host/repo.git reference optionally followed by //subdir.
+	Source string `yaml:"source"`
+	// Version is a semantic version constraint, e.g. "^1.2" or ">= 1.0, < 2.0".
+	Version string `yaml:"version"`
+}
+
+// Lock is the templater.lock file pinning the exact version and digest of every vendored pack.
+type Lock struct {
+	Dependencies []Locked `yaml:"dependencies"`
+}
+
+// Locked is a resolved dependency. Digest is the OCI manifest digest or the git commit.
+type Locked struct {
+	Name    string `yaml:"name"`
+	Source  string `yaml:"source"`
+	Version string `yaml:"version"`
+	Digest  string `yaml:"digest"`
+}
+
+// LoadManifest reads and validates a templater.yaml file.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	manifest := &Manifest{}
+	decoder := yaml3.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(manifest); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+
+	if err := manifest.validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	return manifest, nil
+}
+
+// validate checks that dependency names are unique directory names and that sources and
+// version constraints parse.
+func (m *Manifest) validate() error {
+	seen := make(map[string]bool)
+	for _, dep := range m.Dependencies {
+		if dep.Name == "" || dep.Name != filepath.Base(dep.Name) || strings.HasPrefix(dep.Name, ".") {
+			return fmt.Errorf("invalid dependency name %q", dep.Name)
+		}
+		if seen[dep.Name] {
+			return fmt.Errorf("duplicate dependency %s", dep.Name)
+		}
+		seen[dep.Name] = true
+
+		switch {
+		case strings.HasPrefix(dep.Source, ociPrefix):
+			if _, err := parseOCISource(dep.Source); err != nil {
+				return fmt.Errorf("dependency %s: %w", dep.Name, err)
+			}
+		case strings.HasPrefix(dep.Source, gitPrefix):
+		default:
+			return fmt.Errorf("dependency %s: unsupported source %q (expected %s or %s)", dep.Name, dep.Source, ociPrefix, gitPrefix)
+		}
+		if _, err := semver.NewConstraint(dep.Version); err != nil {
+			return fmt.Errorf("dependency %s: invalid version constraint %q: %w", dep.Name, dep.Version, err)
+		}
+	}
+	return nil
+}
+
+// LoadLock reads a templater.lock file. A missing lock file yields an empty lock.
+func LoadLock(path string) (*Lock, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Lock{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lock file: %w", err)
+	}
+
+	lock := &Lock{}
+	if err := yaml3.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("failed to parse lock file %s: %w", path, err)
+	}
+	return lock, nil
+}
+
+// Save writes the lock file with its dependencies sorted by name.
+func (l *Lock) Save(path string) error {
+	sort.Slice(l.Dependencies, func(i, j int) bool {
+		return l.Dependencies[i].Name < l.Dependencies[j].Name
+	})
+
+	var buf bytes.Buffer
+	encoder := yaml3.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(l); err != nil {
+		return fmt.Errorf("failed to encode lock file: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode lock file: %w", err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+	return nil
+}
+
+// find returns the locked entry for name.
+func (l *Lock) find(name string) (Locked, bool) {
+	for _, locked := range l.Dependencies {
+		if locked.Name == name {
+			return locked, true
+		}
+	}
+	return Locked{}, false
+}
+
+// IsVendorDir reports whether path is the vendor directory of a template pack rooted at
+// root, i.e. root declares dependencies in a templater.yaml manifest.
+func IsVendorDir(root, path string) bool {
+	if filepath.Clean(path) != filepath.Join(root, VendorDir) {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(root, ManifestFile))
+	return err == nil
+}
@@ -0,0 +1,171 @@
+package deps
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadManifest(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		expected  []Dependency
+		wantError bool
+	}{
+		{
+			name: "oci and git dependencies",
+			content: `name: web
+version: 1.0.0
+dependencies:
+  - name: common
+    source: oci://registry.example.com/packs/common
+    version: ^1.2
+  - name: labels
+    source: git::https://github.com/example/packs.git//labels
+    version: ">= 0.3, < 1.0"
+`,
+			expected: []Dependency{
+				{Name: "common", Source: "oci://registry.example.com/packs/common", Version: "^1.2"},
+				{Name: "labels", Source: "git::https://github.com/example/packs.git//labels", Version: ">= 0.3, < 1.0"},
+			},
+		},
+		{
+			name:    "no dependencies",
+			content: "name: web\n",
+		},
+		{
+			name:      "unknown field",
+			content:   "name: web\nrequires: []\n",
+			wantError: true,
+		},
+		{
+			name:      "duplicate name",
+			content:   "dependencies:\n  - {name: a, source: 'git::https://x/a.git', version: '1'}\n  - {name: a, source: 'git::https://x/b.git', version: '1'}\n",
+			wantError: true,
+		},
+		{
+			name:      "name with path separator",
+			content:   "dependencies:\n  - {name: ../a, source: 'git::https://x/a.git', version: '1'}\n",
+			wantError: true,
+		},
+		{
+			name:      "unsupported source",
+			content:   "dependencies:\n  - {name: a, source: 'https://x/a.git', version: '1'}\n",
+			wantError: true,
+		},
+		{
+			name:      "oci source with tag",
+			content:   "dependencies:\n  - {name: a, source: 'oci://registry.example.com/packs/a:1.0.0', version: '1'}\n",
+			wantError: true,
+		},
+		{
+			name:      "invalid constraint",
+			content:   "dependencies:\n  - {name: a, source: 'git::https://x/a.git', version: 'latest'}\n",
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "test-deps-manifest-*")
+			if err != nil {
+				t.Fatalf("Failed to create temp dir: %v", err)
+			}
+			defer os.RemoveAll(tempDir)
+
+			path := filepath.Join(tempDir, ManifestFile)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("Failed to write manifest: %v", err)
+			}
+
+			manifest, err := LoadManifest(path)
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(manifest.Dependencies, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, manifest.Dependencies)
+			}
+		})
+	}
+}
+
+func TestLockSaveAndLoad(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-deps-lock-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	path := filepath.Join(tempDir, LockFile)
+
+	missing, err := LoadLock(path)
+	if err != nil {
+		t.Fatalf("Expected a missing lock file to load as empty, got %v", err)
+	}
+	if len(missing.Dependencies) != 0 {
+		t.Errorf("Expected no dependencies, got %+v", missing.Dependencies)
+	}
+
+	lock := &Lock{Dependencies: []Locked{
+		{Name: "labels", Source: "git::https://x/labels.git", Version: "v0.3.1", Digest: "abc123"},
+		{Name: "common", Source: "oci://registry.example.com/packs/common", Version: "1.2.0", Digest: "sha256:def"},
+	}}
+	if err := lock.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := LoadLock(path)
+	if err != nil {
+		t.Fatalf("LoadLock failed: %v", err)
+	}
+	if len(loaded.Dependencies) != 2 || loaded.Dependencies[0].Name != "common" {
+		t.Errorf("Expected dependencies sorted by name, got %+v", loaded.Dependencies)
+	}
+	if !reflect.DeepEqual(loaded.Dependencies, lock.Dependencies) {
+		t.Errorf("Expected %+v, got %+v", lock.Dependencies, loaded.Dependencies)
+	}
+}
+
+func TestIsVendorDir(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-deps-vendor-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	withManifest := filepath.Join(tempDir, "pack")
+	withoutManifest := filepath.Join(tempDir, "plain")
+	for _, dir := range []string{withManifest, withoutManifest} {
+		if err := os.MkdirAll(filepath.Join(dir, VendorDir), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(withManifest, ManifestFile), []byte("name: pack\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+
+	tests := []struct {
+		root     string
+		path     string
+		expected bool
+	}{
+		{withManifest, filepath.Join(withManifest, VendorDir), true},
+		{withManifest, filepath.Join(withManifest, "templates"), false},
+		{withManifest, filepath.Join(withManifest, "nested", VendorDir), false},
+		{withoutManifest, filepath.Join(withoutManifest, VendorDir), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsVendorDir(tt.root, tt.path); got != tt.expected {
+			t.Errorf("IsVendorDir(%s, %s) = %v, expected %v", tt.root, tt.path, got, tt.expected)
+		}
+	}
+}
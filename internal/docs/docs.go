@@ -0,0 +1,294 @@
+package docs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	yaml3 "gopkg.in/yaml.v3"
+
+	"github.com/menta2k/templater/internal/deps"
+	templatepkg "github.com/menta2k/templater/internal/template"
+	"github.com/menta2k/templater/internal/values"
+)
+
+// Entry documents one value key.
+type Entry struct {
+	Path        string
+	Type        string
+	Default     any
+	HasDefault  bool
+	Required    bool
+	Description string
+	// UsedBy lists the templates referencing the key, sorted.
+	UsedBy []string
+}
+
+// Sources are the inputs documentation is generated from. Every field is optional.
+type Sources struct {
+	TemplateDir string
+	ValuesFile  string
+	SchemaFile  string
+}
+
+// schemaNode is the subset of a JSON schema describing values.
+type schemaNode struct {
+	Type        any                    `json:"type"`
+	Description string                 `json:"description"`
+	Default     any                    `json:"default"`
+	Required    []string               `json:"required"`
+	Properties  map[string]*schemaNode `json:"properties"`
+}
+
+// Generate documents every key declared by the schema, set by the default values or
+// referenced by the templates, sorted by path. Objects are described through their
+// properties, except free-form objects the schema declares without properties.
+func Generate(sources Sources) ([]Entry, error) {
+	schema, err := loadSchema(sources.SchemaFile)
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := loadDefaults(sources.ValuesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]*Entry)
+	entry := func(path string) *Entry {
+		if e, ok := entries[path]; ok {
+			return e
+		}
+		e := &Entry{Path: path}
+		entries[path] = e
+		return e
+	}
+
+	if schema != nil {
+		addSchema(schema, "", false, entry)
+	}
+	addDefaults(defaults, "", schema, entry)
+
+	if sources.TemplateDir != "" {
+		usage, err := findUsage(sources.TemplateDir)
+		if err != nil {
+			return nil, err
+		}
+		for path, templates := range usage {
+			entry(path).UsedBy = templates
+		}
+	}
+
+	// Objects whose keys are documented individually are only listed themselves when
+	// the schema describes or requires them
+	paths := make([]string, 0, len(entries))
+	for path := range entries {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	result := make([]Entry, 0, len(paths))
+	for i, path := range paths {
+		e := entries[path]
+		if i+1 < len(paths) && strings.HasPrefix(paths[i+1], path+".") && e.Description == "" && !e.Required {
+			continue
+		}
+		if e.Type == "" && e.HasDefault {
+			e.Type = typeOf(e.Default)
+		}
+		result = append(result, *e)
+	}
+	return result, nil
+}
+
+// addSchema records the type, description, default and required flag of node and of its
+// properties.
+func addSchema(node *schemaNode, path string, required bool, entry func(string) *Entry) {
+	if path != "" {
+		e := entry(path)
+		e.Type = schemaType(node.Type)
+		e.Description = node.Description
+		e.Required = required
+		if node.Default != nil {
+			e.Default, e.HasDefault = node.Default, true
+		}
+	}
+
+	requiredKeys := make(map[string]bool, len(node.Required))
+	for _, key := range node.Required {
+		requiredKeys[key] = true
+	}
+	for key, property := range node.Properties {
+		if property != nil {
+			addSchema(property, path+"."+key, requiredKeys[key], entry)
+		}
+	}
+}
+
+// addDefaults records the default values below v. Maps are descended into, unless the
+// schema declares a free-form object at their path; lists and scalars are leaves.
+func addDefaults(v any, path string, schema *schemaNode, entry func(string) *Entry) {
+	m, ok := v.(map[string]any)
+	freeForm := schema != nil && schema.Properties == nil && path != ""
+	if !ok || freeForm {
+		if path != "" {
+			e := entry(path)
+			e.Default, e.HasDefault = v, true
+		}
+		return
+	}
+
+	for key, value := range m {
+		var child *schemaNode
+		if schema != nil {
+			child = schema.Properties[key]
+		}
+		addDefaults(value, path+"."+key, child, entry)
+	}
+}
+
+// findUsage maps every value path referenced below dir to the templates using it.
+// Vendored packs are skipped.
+func findUsage(dir string) (map[string][]string, error) {
+	seen := make(map[string]map[string]bool)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && deps.IsVendorDir(dir, path) {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || !strings.HasSuffix(strings.ToLower(info.Name()), ".tpl") {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(relativePath)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read template: %w", err)
+		}
+
+		for _, source := range []struct{ name, content string }{{"path " + name, name}, {name, string(content)}} {
+			refs, err := templatepkg.FindReferences(source.name, source.content)
+			if err != nil {
+				return fmt.Errorf("failed to parse template %s: %w", name, err)
+			}
+			for _, ref := range refs {
+				key := "." + strings.Join(ref.Path, ".")
+				if seen[key] == nil {
+					seen[key] = make(map[string]bool)
+				}
+				seen[key][name] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error walking template directory: %w", err)
+	}
+
+	usage := make(map[string][]string, len(seen))
+	for key, templates := range seen {
+		for name := range templates {
+			usage[key] = append(usage[key], name)
+		}
+		sort.Strings(usage[key])
+	}
+	return usage, nil
+}
+
+// loadSchema reads a JSON schema; an empty path yields no schema.
+func loadSchema(path string) (*schemaNode, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values schema: %w", err)
+	}
+
+	schema := &schemaNode{}
+	if err := json.Unmarshal(data, schema); err != nil {
+		return nil, fmt.Errorf("failed to parse values schema %s: %w", path, err)
+	}
+	return schema, nil
+}
+
+// loadDefaults reads the default values; an empty path yields no defaults.
+func loadDefaults(path string) (map[string]any, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("values file %s does not exist", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values file: %w", err)
+	}
+
+	defaults := map[string]any{}
+	if err := yaml3.Unmarshal(data, &defaults); err != nil {
+		return nil, fmt.Errorf("failed to parse values file %s: %w", path, err)
+	}
+	return defaults, nil
+}
+
+// schemaType formats a schema type, which may be a list of types.
+func schemaType(t any) string {
+	switch v := t.(type) {
+	case string:
+		return v
+	case []any:
+		types := make([]string, 0, len(v))
+		for _, item := range v {
+			types = append(types, fmt.Sprint(item))
+		}
+		return strings.Join(types, " | ")
+	default:
+		return ""
+	}
+}
+
+// typeOf names the JSON schema type of a default value.
+func typeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// formatDefault formats a default value for display as compact JSON. Unlike
+// values.FormatValue, HTML characters are kept as is since renderers escape them.
+func formatDefault(e Entry) string {
+	if !e.HasDefault {
+		return ""
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(e.Default); err != nil {
+		return values.FormatValue(e.Default)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
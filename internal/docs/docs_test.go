@@ -0,0 +1,161 @@
+package docs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "test-docs-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestGenerate(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"values.yaml": "app:\n  port: 8080\n  labels:\n    tier: web\nreplicas: 2\nports: [80, 443]\n",
+		"values.schema.json": `{
+			"required": ["app"],
+			"properties": {
+				"app": {
+					"description": "Application settings",
+					"required": ["image"],
+					"properties": {
+						"image": {"type": "string", "description": "Container image"},
+						"port": {"type": "integer"},
+						"labels": {"type": "object"}
+					}
+				},
+				"debug": {"type": "boolean", "default": false}
+			}
+		}`,
+		"templates/deploy.yaml.tpl":       "image: {{.app.image}}\nreplicas: {{.replicas}}\n{{range .ports}}{{.}}{{end}}",
+		"templates/service.yaml.tpl":      "port: {{.app.port}}\n{{with .service}}{{.type}}{{end}}",
+		"templates/{{.app.env}}.conf.tpl": "env",
+		"templater.yaml":                  "name: web\n",
+		"vendor/common/templates/a.tpl":   "{{.vendored}}",
+	})
+
+	entries, err := Generate(Sources{
+		TemplateDir: dir,
+		ValuesFile:  filepath.Join(dir, "values.yaml"),
+		SchemaFile:  filepath.Join(dir, "values.schema.json"),
+	})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	expected := []Entry{
+		{Path: ".app", Required: true, Description: "Application settings"},
+		{Path: ".app.env", UsedBy: []string{"templates/{{.app.env}}.conf.tpl"}},
+		{Path: ".app.image", Type: "string", Required: true, Description: "Container image", UsedBy: []string{"templates/deploy.yaml.tpl"}},
+		{Path: ".app.labels", Type: "object", Default: map[string]any{"tier": "web"}, HasDefault: true},
+		{Path: ".app.port", Type: "integer", Default: 8080, HasDefault: true, UsedBy: []string{"templates/service.yaml.tpl"}},
+		{Path: ".debug", Type: "boolean", Default: false, HasDefault: true},
+		{Path: ".ports", Type: "array", Default: []any{80, 443}, HasDefault: true, UsedBy: []string{"templates/deploy.yaml.tpl"}},
+		{Path: ".replicas", Type: "integer", Default: 2, HasDefault: true, UsedBy: []string{"templates/deploy.yaml.tpl"}},
+		{Path: ".service.type", UsedBy: []string{"templates/service.yaml.tpl"}},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, entries)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"values.yaml":        "a: [",
+		"values.schema.json": "{",
+		"bad.tpl":            "{{.a",
+	})
+
+	tests := []struct {
+		name      string
+		sources   Sources
+		wantError string
+	}{
+		{name: "missing values file", sources: Sources{ValuesFile: filepath.Join(dir, "missing.yaml")}, wantError: "does not exist"},
+		{name: "invalid values file", sources: Sources{ValuesFile: filepath.Join(dir, "values.yaml")}, wantError: "failed to parse values file"},
+		{name: "invalid schema", sources: Sources{SchemaFile: filepath.Join(dir, "values.schema.json")}, wantError: "failed to parse values schema"},
+		{name: "invalid template", sources: Sources{TemplateDir: dir}, wantError: "failed to parse template bad.tpl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Generate(tt.sources)
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	entries := []Entry{
+		{Path: ".app.image", Type: "string", Required: true, Description: "Image | tag", UsedBy: []string{"a.tpl", "b.tpl"}},
+		{Path: ".app.ports", Type: "array", Default: []any{80, 443}, HasDefault: true},
+		{Path: ".name", Default: "<web>", HasDefault: true, Description: "Name & title"},
+	}
+
+	tests := []struct {
+		format    string
+		contains  []string
+		wantError bool
+	}{
+		{
+			format: FormatMarkdown,
+			contains: []string{
+				"| Key | Type | Default | Description | Used by |\n",
+				"| `.app.image` | string | *required* | Image \\| tag | `a.tpl`, `b.tpl` |\n",
+				"| `.app.ports` | array | `[80,443]` |  |  |\n",
+			},
+		},
+		{
+			format: FormatHTML,
+			contains: []string{
+				"<tr><td><code>.app.image</code></td><td>string</td><td><em>required</em></td><td>Image | tag</td><td><code>a.tpl</code><br><code>b.tpl</code></td></tr>\n",
+				"<td><code>&#34;&lt;web&gt;&#34;</code></td><td>Name &amp; title</td>",
+			},
+		},
+		{format: "pdf", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Render(&buf, entries, tt.format)
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("Expected output to contain %q, got:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
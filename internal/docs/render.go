@@ -0,0 +1,87 @@
+package docs
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// Formats supported by Render.
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// Render writes the entries as a values reference table in the given format.
+func Render(w io.Writer, entries []Entry, format string) error {
+	switch format {
+	case FormatMarkdown:
+		return renderMarkdown(w, entries)
+	case FormatHTML:
+		return renderHTML(w, entries)
+	default:
+		return fmt.Errorf("unsupported docs format '%s' (expected %s or %s)", format, FormatMarkdown, FormatHTML)
+	}
+}
+
+// renderMarkdown writes a Markdown table.
+func renderMarkdown(w io.Writer, entries []Entry) error {
+	var b strings.Builder
+	b.WriteString("| Key | Type | Default | Description | Used by |\n")
+	b.WriteString("|-----|------|---------|-------------|---------|\n")
+	for _, e := range entries {
+		defaultValue := ""
+		switch {
+		case e.HasDefault:
+			defaultValue = "`" + markdownCell(formatDefault(e)) + "`"
+		case e.Required:
+			defaultValue = "*required*"
+		}
+
+		usedBy := make([]string, len(e.UsedBy))
+		for i, name := range e.UsedBy {
+			usedBy[i] = "`" + markdownCell(name) + "`"
+		}
+
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s |\n",
+			markdownCell(e.Path), markdownCell(e.Type), defaultValue, markdownCell(e.Description), strings.Join(usedBy, ", "))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell escapes text for a Markdown table cell.
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(text, "|", `\|`)
+	return strings.ReplaceAll(text, "\n", " ")
+}
+
+// renderHTML writes a standalone HTML page holding the table.
+func renderHTML(w io.Writer, entries []Entry) error {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Values reference</title>\n</head>\n<body>\n")
+	b.WriteString("<table>\n<thead>\n<tr><th>Key</th><th>Type</th><th>Default</th><th>Description</th><th>Used by</th></tr>\n</thead>\n<tbody>\n")
+	for _, e := range entries {
+		defaultValue := ""
+		switch {
+		case e.HasDefault:
+			defaultValue = "<code>" + html.EscapeString(formatDefault(e)) + "</code>"
+		case e.Required:
+			defaultValue = "<em>required</em>"
+		}
+
+		usedBy := make([]string, len(e.UsedBy))
+		for i, name := range e.UsedBy {
+			usedBy[i] = "<code>" + html.EscapeString(name) + "</code>"
+		}
+
+		fmt.Fprintf(&b, "<tr><td><code>%s</code></td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+			html.EscapeString(e.Path), html.EscapeString(e.Type), defaultValue, html.EscapeString(e.Description), strings.Join(usedBy, "<br>"))
+	}
+	b.WriteString("</tbody>\n</table>\n</body>\n</html>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,102 @@
+package fixtures
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-fixtures-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.URL.Path == "/binary":
+			_, _ = w.Write([]byte{0xff, 0x00, 0xfe})
+		case r.URL.Path == "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"echo":"` + string(body) + `"}`))
+		}
+	}))
+
+	requests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		want   string
+	}{
+		{http.MethodPost, "/values", "page1", http.StatusOK, `{"echo":"page1"}`},
+		{http.MethodPost, "/values", "page2", http.StatusOK, `{"echo":"page2"}`},
+		{http.MethodGet, "/binary", "", http.StatusOK, "\xff\x00\xfe"},
+		{http.MethodGet, "/missing", "", http.StatusNotFound, ""},
+	}
+
+	do := func(client *http.Client, method, url, body string) (int, string, error) {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		if err != nil {
+			return 0, "", err
+		}
+		req.Header.Set("Authorization", "Bearer secret-token")
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, "", err
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data), err
+	}
+
+	recorder := &http.Client{Transport: &Recorder{Dir: tempDir}}
+	for _, r := range requests {
+		status, body, err := do(recorder, r.method, server.URL+r.path, r.body)
+		if err != nil {
+			t.Fatalf("Recording %s %s failed: %v", r.method, r.path, err)
+		}
+		if status != r.status || body != r.want {
+			t.Errorf("Recording %s %s: expected %d %q, got %d %q", r.method, r.path, r.status, r.want, status, body)
+		}
+	}
+	server.Close()
+
+	// Credentials must not be written to fixtures
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to read fixtures: %v", err)
+	}
+	if len(entries) != len(requests) {
+		t.Errorf("Expected %d fixtures, got %d", len(requests), len(entries))
+	}
+	for _, entry := range entries {
+		data, _ := os.ReadFile(tempDir + "/" + entry.Name())
+		if strings.Contains(string(data), "secret-token") {
+			t.Errorf("Fixture %s contains request credentials", entry.Name())
+		}
+	}
+
+	// The server is gone, every response comes from the fixtures
+	replayer := &http.Client{Transport: &Replayer{Dir: tempDir}}
+	for _, r := range requests {
+		status, body, err := do(replayer, r.method, server.URL+r.path, r.body)
+		if err != nil {
+			t.Fatalf("Replaying %s %s failed: %v", r.method, r.path, err)
+		}
+		if status != r.status || body != r.want {
+			t.Errorf("Replaying %s %s: expected %d %q, got %d %q", r.method, r.path, r.status, r.want, status, body)
+		}
+	}
+
+	if _, _, err := do(replayer, http.MethodPost, server.URL+"/values", "page3"); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("Expected missing fixture error, got %v", err)
+	}
+}
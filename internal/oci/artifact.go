@@ -0,0 +1,308 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Media types describing a templater template pack artifact.
+const (
+	ArtifactType        = "application/vnd.templater.pack.v1"
+	ConfigMediaType     = "application/vnd.templater.pack.config.v1+json"
+	TemplatesMediaType  = "application/vnd.templater.pack.templates.v1.tar+gzip"
+	ValuesMediaType     = "application/vnd.templater.pack.values.v1+yaml"
+	SchemaMediaType     = "application/vnd.templater.pack.schema.v1+json"
+	ManifestMediaType   = "application/vnd.oci.image.manifest.v1+json"
+	titleAnnotation     = "org.opencontainers.image.title"
+	referencePrefix     = "oci://"
+	defaultReferenceTag = "latest"
+)
+
+// Reference identifies a repository and tag in an OCI registry.
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+}
+
+// ParseReference parses an oci://registry/repository[:tag] reference.
+func ParseReference(ref string) (Reference, error) {
+	if !strings.HasPrefix(ref, referencePrefix) {
+		return Reference{}, fmt.Errorf("invalid reference %s (expected %sregistry/repository:tag)", ref, referencePrefix)
+	}
+
+	rest := strings.TrimPrefix(ref, referencePrefix)
+	registry, repository, ok := strings.Cut(rest, "/")
+	if !ok || registry == "" || repository == "" {
+		return Reference{}, fmt.Errorf("invalid reference %s: missing repository", ref)
+	}
+
+	tag := defaultReferenceTag
+	if i := strings.LastIndex(repository, ":"); i >= 0 {
+		tag = repository[i+1:]
+		repository = repository[:i]
+	}
+	if tag == "" || repository == "" {
+		return Reference{}, fmt.Errorf("invalid reference %s: empty repository or tag", ref)
+	}
+
+	return Reference{Registry: registry, Repository: repository, Tag: tag}, nil
+}
+
+// String returns the reference in oci:// form.
+func (r Reference) String() string {
+	return referencePrefix + r.Registry + "/" + r.Repository + ":" + r.Tag
+}
+
+// Descriptor describes a blob stored in a registry.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Manifest is an OCI image manifest describing a template pack.
+type Manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	ArtifactType  string       `json:"artifactType"`
+	Config        Descriptor   `json:"config"`
+	Layers        []Descriptor `json:"layers"`
+}
+
+// Blob is a piece of content pushed to a registry.
+type Blob struct {
+	Descriptor Descriptor
+	Content    []byte
+}
+
+// Artifact is a packaged template pack ready to be pushed.
+type Artifact struct {
+	Config Blob
+	Layers []Blob
+}
+
+// PackConfig is stored as the artifact config blob.
+type PackConfig struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// NewBlob creates a blob with its descriptor computed from the content.
+func NewBlob(mediaType, title string, content []byte) Blob {
+	sum := sha256.Sum256(content)
+	descriptor := Descriptor{
+		MediaType: mediaType,
+		Digest:    "sha256:" + hex.EncodeToString(sum[:]),
+		Size:      int64(len(content)),
+	}
+	if title != "" {
+		descriptor.Annotations = map[string]string{titleAnnotation: title}
+	}
+	return Blob{Descriptor: descriptor, Content: content}
+}
+
+// PackageDirectory packages a template directory, plus optional values and schema files, into an artifact.
+func PackageDirectory(ref Reference, templateDir, valuesFile, schemaFile string) (*Artifact, error) {
+	archive, err := archiveDirectory(templateDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive template directory: %w", err)
+	}
+
+	configContent, err := json.Marshal(PackConfig{Name: ref.Repository, Version: ref.Tag})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode artifact config: %w", err)
+	}
+
+	artifact := &Artifact{
+		Config: NewBlob(ConfigMediaType, "", configContent),
+		Layers: []Blob{NewBlob(TemplatesMediaType, "templates.tar.gz", archive)},
+	}
+
+	optional := []struct {
+		path      string
+		mediaType string
+		title     string
+	}{
+		{valuesFile, ValuesMediaType, "values.yaml"},
+		{schemaFile, SchemaMediaType, "values.schema.json"},
+	}
+	for _, file := range optional {
+		if file.path == "" {
+			continue
+		}
+		content, err := os.ReadFile(file.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file.path, err)
+		}
+		artifact.Layers = append(artifact.Layers, NewBlob(file.mediaType, file.title, content))
+	}
+
+	return artifact, nil
+}
+
+// Manifest builds the OCI manifest for the artifact.
+func (a *Artifact) Manifest() Manifest {
+	layers := make([]Descriptor, 0, len(a.Layers))
+	for _, layer := range a.Layers {
+		layers = append(layers, layer.Descriptor)
+	}
+
+	return Manifest{
+		SchemaVersion: 2,
+		MediaType:     ManifestMediaType,
+		ArtifactType:  ArtifactType,
+		Config:        a.Config.Descriptor,
+		Layers:        layers,
+	}
+}
+
+// archiveDirectory creates a reproducible gzipped tarball of a directory.
+func archiveDirectory(dir string) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if relativePath == "." {
+			return nil
+		}
+
+		header := &tar.Header{
+			Name: filepath.ToSlash(relativePath),
+			Mode: int64(info.Mode().Perm()),
+		}
+		if info.IsDir() {
+			header.Typeflag = tar.TypeDir
+			header.Name += "/"
+			return tw.WriteHeader(header)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		header.Typeflag = tar.TypeReg
+		header.Size = info.Size()
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Extract writes the pack in the artifact to dir: the template files, plus the default
+// values and schema files when the pack includes them.
+func (a *Artifact) Extract(dir string) error {
+	files := map[string]string{
+		ValuesMediaType: "values.yaml",
+		SchemaMediaType: "values.schema.json",
+	}
+
+	extracted := false
+	for _, layer := range a.Layers {
+		if layer.Descriptor.MediaType == TemplatesMediaType {
+			if err := extractArchive(layer.Content, dir); err != nil {
+				return fmt.Errorf("failed to extract templates: %w", err)
+			}
+			extracted = true
+			continue
+		}
+		if name, ok := files[layer.Descriptor.MediaType]; ok {
+			if err := os.WriteFile(filepath.Join(dir, name), layer.Content, 0o644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", name, err)
+			}
+		}
+	}
+
+	if !extracted {
+		return fmt.Errorf("artifact has no templates layer")
+	}
+	return nil
+}
+
+// extractArchive unpacks a gzipped tarball created by archiveDirectory into dir. Entries
+// escaping dir are rejected.
+func extractArchive(archive []byte, dir string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := filepath.FromSlash(header.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("archive entry %s escapes the pack directory", header.Name)
+		}
+		path := filepath.Join(dir, name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm()|0o600)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(file, tr)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		name      string
+		ref       string
+		expected  Reference
+		wantError bool
+	}{
+		{
+			name:     "with tag",
+			ref:      "oci://registry.example.com/org/templates:1.2.0",
+			expected: Reference{Registry: "registry.example.com", Repository: "org/templates", Tag: "1.2.0"},
+		},
+		{
+			name:     "registry with port and default tag",
+			ref:      "oci://localhost:5000/templates",
+			expected: Reference{Registry: "localhost:5000", Repository: "templates", Tag: "latest"},
+		},
+		{name: "missing scheme", ref: "registry.example.com/org/templates:1.0", wantError: true},
+		{name: "missing repository", ref: "oci://registry.example.com", wantError: true},
+		{name: "empty tag", ref: "oci://registry.example.com/org/templates:", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := ParseReference(tt.ref)
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if ref != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, ref)
+			}
+		})
+	}
+}
+
+func TestPackageDirectory(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-oci-package-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templateDir := filepath.Join(tempDir, "templates")
+
+	files := map[string]string{
+		filepath.Join(templateDir, "config.tpl"):        "name: {{.app.name}}",
+		filepath.Join(templateDir, "nested", "app.tpl"): "app",
+		filepath.Join(tempDir, "values.yaml"):           "app:\n  name: demo\n",
+		filepath.Join(tempDir, "values.schema.json"):    `{"type":"object"}`,
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	ref := Reference{Registry: "localhost", Repository: "org/templates", Tag: "1.0.0"}
+	artifact, err := PackageDirectory(ref, templateDir, filepath.Join(tempDir, "values.yaml"), filepath.Join(tempDir, "values.schema.json"))
+	if err != nil {
+		t.Fatalf("PackageDirectory failed: %v", err)
+	}
+
+	if len(artifact.Layers) != 3 {
+		t.Fatalf("Expected 3 layers, got %d", len(artifact.Layers))
+	}
+	expectedTypes := []string{TemplatesMediaType, ValuesMediaType, SchemaMediaType}
+	for i, mediaType := range expectedTypes {
+		if artifact.Layers[i].Descriptor.MediaType != mediaType {
+			t.Errorf("Expected layer %d media type %s, got %s", i, mediaType, artifact.Layers[i].Descriptor.MediaType)
+		}
+	}
+
+	// Packaging the same directory twice must produce identical digests
+	again, err := PackageDirectory(ref, templateDir, "", "")
+	if err != nil {
+		t.Fatalf("PackageDirectory failed: %v", err)
+	}
+	if !bytes.Equal(again.Layers[0].Content, artifact.Layers[0].Content) {
+		t.Error("Expected template archive to be reproducible")
+	}
+
+	manifest := artifact.Manifest()
+	if manifest.ArtifactType != ArtifactType || manifest.Config.MediaType != ConfigMediaType {
+		t.Errorf("Unexpected manifest: %+v", manifest)
+	}
+}
+
+func TestArtifactExtract(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-oci-extract-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templateDir := filepath.Join(tempDir, "templates")
+	if err := os.MkdirAll(filepath.Join(templateDir, "nested"), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir, "nested", "app.tpl"), []byte("app"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	valuesFile := filepath.Join(tempDir, "values.yaml")
+	if err := os.WriteFile(valuesFile, []byte("app: demo\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+
+	ref := Reference{Registry: "localhost", Repository: "org/templates", Tag: "1.0.0"}
+	artifact, err := PackageDirectory(ref, templateDir, valuesFile, "")
+	if err != nil {
+		t.Fatalf("PackageDirectory failed: %v", err)
+	}
+
+	outputDir := filepath.Join(tempDir, "output")
+	if err := artifact.Extract(outputDir); err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+
+	expected := map[string]string{
+		filepath.Join("nested", "app.tpl"): "app",
+		"values.yaml":                      "app: demo\n",
+	}
+	for name, content := range expected {
+		data, err := os.ReadFile(filepath.Join(outputDir, name))
+		if err != nil {
+			t.Errorf("Expected %s to be extracted: %v", name, err)
+			continue
+		}
+		if string(data) != content {
+			t.Errorf("Expected %s to contain %q, got %q", name, content, string(data))
+		}
+	}
+}
+
+func TestExtractArchiveRejectsEscapingEntries(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	content := []byte("evil")
+	if err := tw.WriteHeader(&tar.Header{Name: "../evil.tpl", Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))}); err != nil {
+		t.Fatalf("Failed to write header: %v", err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatalf("Failed to write content: %v", err)
+	}
+	tw.Close()
+	gz.Close()
+
+	tempDir, err := os.MkdirTemp("", "test-oci-extract-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := extractArchive(buf.Bytes(), filepath.Join(tempDir, "pack")); err == nil {
+		t.Error("Expected an entry escaping the pack directory to be rejected")
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "evil.tpl")); err == nil {
+		t.Error("Expected escaping entry not to be written")
+	}
+}
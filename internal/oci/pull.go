@@ -0,0 +1,107 @@
+package oci
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxManifestSize bounds the manifests read from a registry.
+const maxManifestSize = 4 << 20
+
+// Tags returns the tags of the reference repository.
+func (c *Client) Tags(ref Reference) ([]string, error) {
+	resp, err := c.do(ref, pullActions, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, c.endpoint(ref, "tags/list"), http.NoBody)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags of %s: %w", ref.Repository, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list tags of %s: %w", ref.Repository, statusError(resp))
+	}
+
+	var list struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to decode tags of %s: %w", ref.Repository, err)
+	}
+	return list.Tags, nil
+}
+
+// Pull downloads the template pack tagged by ref, verifying every blob against its
+// digest. It returns the artifact and the digest of its manifest.
+func (c *Client) Pull(ref Reference) (*Artifact, string, error) {
+	resp, err := c.do(ref, pullActions, func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodGet, c.endpoint(ref, "manifests/"+ref.Tag), http.NoBody)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", ManifestMediaType)
+		return req, nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to pull manifest: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to pull manifest %s: %w", ref, statusError(resp))
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, "", fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	if manifest.ArtifactType != ArtifactType && manifest.Config.MediaType != ConfigMediaType {
+		return nil, "", fmt.Errorf("%s is not a template pack (artifact type %q)", ref, manifest.ArtifactType)
+	}
+
+	artifact := &Artifact{}
+	if artifact.Config, err = c.pullBlob(ref, manifest.Config); err != nil {
+		return nil, "", err
+	}
+	for _, descriptor := range manifest.Layers {
+		layer, err := c.pullBlob(ref, descriptor)
+		if err != nil {
+			return nil, "", err
+		}
+		artifact.Layers = append(artifact.Layers, layer)
+	}
+
+	return artifact, NewBlob(ManifestMediaType, "", content).Descriptor.Digest, nil
+}
+
+// pullBlob downloads a blob and checks its digest.
+func (c *Client) pullBlob(ref Reference, descriptor Descriptor) (Blob, error) {
+	resp, err := c.do(ref, pullActions, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, c.endpoint(ref, "blobs/"+descriptor.Digest), http.NoBody)
+	})
+	if err != nil {
+		return Blob{}, fmt.Errorf("failed to pull blob %s: %w", descriptor.Digest, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Blob{}, fmt.Errorf("failed to pull blob %s: %w", descriptor.Digest, statusError(resp))
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, descriptor.Size+1))
+	if err != nil {
+		return Blob{}, fmt.Errorf("failed to read blob %s: %w", descriptor.Digest, err)
+	}
+	if NewBlob("", "", content).Descriptor.Digest != descriptor.Digest {
+		return Blob{}, fmt.Errorf("blob %s does not match its digest", descriptor.Digest)
+	}
+
+	return Blob{Descriptor: descriptor, Content: content}, nil
+}
@@ -0,0 +1,73 @@
+package oci
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClientPull(t *testing.T) {
+	registry := newFakeRegistry(t)
+
+	ref := Reference{
+		Registry:   strings.TrimPrefix(registry.server.URL, "http://"),
+		Repository: "org/templates",
+		Tag:        "1.2.0",
+	}
+	artifact := &Artifact{
+		Config: NewBlob(ConfigMediaType, "", []byte(`{"name":"org/templates","version":"1.2.0"}`)),
+		Layers: []Blob{NewBlob(TemplatesMediaType, "templates.tar.gz", []byte("archive"))},
+	}
+
+	client := NewClient("user", "pass", true)
+	pushed, err := client.Push(ref, artifact)
+	if err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+
+	pulled, digest, err := client.Pull(ref)
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if digest != pushed {
+		t.Errorf("Expected manifest digest %s, got %s", pushed, digest)
+	}
+	if !reflect.DeepEqual(pulled, artifact) {
+		t.Errorf("Expected pulled artifact %+v, got %+v", artifact, pulled)
+	}
+
+	tags, err := client.Tags(ref)
+	if err != nil {
+		t.Fatalf("Tags failed: %v", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"1.2.0"}) {
+		t.Errorf("Expected tags [1.2.0], got %v", tags)
+	}
+
+	missing := ref
+	missing.Tag = "9.9.9"
+	if _, _, err := client.Pull(missing); err == nil {
+		t.Error("Expected pulling a missing tag to fail")
+	}
+}
+
+func TestClientPullCorruptBlob(t *testing.T) {
+	registry := newFakeRegistry(t)
+
+	ref := Reference{Registry: strings.TrimPrefix(registry.server.URL, "http://"), Repository: "org/templates", Tag: "1.0.0"}
+	layer := NewBlob(TemplatesMediaType, "templates.tar.gz", []byte("archive"))
+	artifact := &Artifact{
+		Config: NewBlob(ConfigMediaType, "", []byte("{}")),
+		Layers: []Blob{layer},
+	}
+
+	client := NewClient("user", "pass", true)
+	if _, err := client.Push(ref, artifact); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	registry.blobs[layer.Descriptor.Digest] = []byte("tampered")
+
+	if _, _, err := client.Pull(ref); err == nil {
+		t.Error("Expected a blob not matching its digest to be rejected")
+	}
+}
@@ -0,0 +1,272 @@
+package oci
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/menta2k/templater/internal/retry"
+)
+
+const defaultTimeout = 60 * time.Second
+
+// Repository actions requested when fetching a registry token.
+const (
+	pullActions = "pull"
+	pushActions = "pull,push"
+)
+
+// Client pushes and pulls artifacts in an OCI distribution registry.
+type Client struct {
+	Username  string
+	Password  string
+	PlainHTTP bool
+	HTTP      *http.Client
+
+	token string
+}
+
+// NewClient creates a registry client.
+func NewClient(username, password string, plainHTTP bool) *Client {
+	return &Client{
+		Username:  username,
+		Password:  password,
+		PlainHTTP: plainHTTP,
+		HTTP:      retry.NewClient(defaultTimeout),
+	}
+}
+
+// Push uploads the artifact blobs and tags its manifest, returning the manifest digest.
+func (c *Client) Push(ref Reference, artifact *Artifact) (string, error) {
+	blobs := append([]Blob{artifact.Config}, artifact.Layers...)
+	for _, blob := range blobs {
+		if err := c.pushBlob(ref, blob); err != nil {
+			return "", fmt.Errorf("failed to push blob %s: %w", blob.Descriptor.Digest, err)
+		}
+	}
+
+	manifest, err := json.Marshal(artifact.Manifest())
+	if err != nil {
+		return "", fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	resp, err := c.do(ref, pushActions, func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPut, c.endpoint(ref, "manifests/"+ref.Tag), bytes.NewReader(manifest))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", ManifestMediaType)
+		return req, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to push manifest: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to push manifest: %w", statusError(resp))
+	}
+
+	return NewBlob(ManifestMediaType, "", manifest).Descriptor.Digest, nil
+}
+
+// pushBlob uploads a blob unless the registry already has it.
+func (c *Client) pushBlob(ref Reference, blob Blob) error {
+	digest := blob.Descriptor.Digest
+
+	resp, err := c.do(ref, pushActions, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodHead, c.endpoint(ref, "blobs/"+digest), http.NoBody)
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	resp, err = c.do(ref, pushActions, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodPost, c.endpoint(ref, "blobs/uploads/"), http.NoBody)
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("failed to start upload: %w", statusError(resp))
+	}
+
+	location, err := c.uploadLocation(ref, resp.Header.Get("Location"), digest)
+	if err != nil {
+		return err
+	}
+
+	resp, err = c.do(ref, pushActions, func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPut, location, bytes.NewReader(blob.Content))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/octet-stream")
+		return req, nil
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("failed to upload blob: %w", statusError(resp))
+	}
+	return nil
+}
+
+// uploadLocation resolves the upload URL returned by the registry and appends the blob digest.
+func (c *Client) uploadLocation(ref Reference, location, digest string) (string, error) {
+	if location == "" {
+		return "", fmt.Errorf("registry did not return an upload location")
+	}
+
+	base, err := url.Parse(c.endpoint(ref, ""))
+	if err != nil {
+		return "", err
+	}
+	resolved, err := base.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("invalid upload location %s: %w", location, err)
+	}
+
+	query := resolved.Query()
+	query.Set("digest", digest)
+	resolved.RawQuery = query.Encode()
+	return resolved.String(), nil
+}
+
+// endpoint builds a registry API URL for the reference repository.
+func (c *Client) endpoint(ref Reference, path string) string {
+	scheme := "https"
+	if c.PlainHTTP {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/%s", scheme, ref.Registry, ref.Repository, path)
+}
+
+// do sends a request, authenticating for actions and retrying once when the registry
+// answers 401.
+func (c *Client) do(ref Reference, actions string, build func() (*http.Request, error)) (*http.Response, error) {
+	req, err := build()
+	if err != nil {
+		return nil, err
+	}
+	c.authorize(req)
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+
+	if err := c.authenticate(ref, actions, challenge); err != nil {
+		return nil, err
+	}
+
+	req, err = build()
+	if err != nil {
+		return nil, err
+	}
+	c.authorize(req)
+	return c.HTTP.Do(req)
+}
+
+// authorize adds credentials to a request.
+func (c *Client) authorize(req *http.Request) {
+	switch {
+	case c.token != "":
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	case c.Username != "":
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+}
+
+// authenticate handles a registry auth challenge, fetching a bearer token for actions on
+// the repository when required.
+func (c *Client) authenticate(ref Reference, actions, challenge string) error {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		if c.Username == "" {
+			return fmt.Errorf("registry %s requires authentication", ref.Registry)
+		}
+		return nil
+	}
+
+	attrs := parseChallenge(params)
+	realm := attrs["realm"]
+	if realm == "" {
+		return fmt.Errorf("invalid auth challenge from %s: %s", ref.Registry, challenge)
+	}
+
+	query := url.Values{}
+	if service := attrs["service"]; service != "" {
+		query.Set("service", service)
+	}
+	query.Set("scope", fmt.Sprintf("repository:%s:%s", ref.Repository, actions))
+
+	req, err := http.NewRequest(http.MethodGet, realm+"?"+query.Encode(), http.NoBody)
+	if err != nil {
+		return err
+	}
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch registry token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch registry token: %w", statusError(resp))
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("failed to decode registry token: %w", err)
+	}
+
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+	if c.token == "" {
+		return fmt.Errorf("registry token response did not contain a token")
+	}
+	return nil
+}
+
+// parseChallenge parses the key="value" pairs of a WWW-Authenticate header.
+func parseChallenge(params string) map[string]string {
+	attrs := make(map[string]string)
+	for _, part := range strings.Split(params, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok {
+			attrs[strings.ToLower(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return attrs
+}
+
+// statusError describes an unexpected registry response.
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,151 @@
+package oci
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRegistry is a minimal in-memory OCI distribution registry requiring bearer auth.
+type fakeRegistry struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+	server    *httptest.Server
+}
+
+func newFakeRegistry(t *testing.T) *fakeRegistry {
+	t.Helper()
+
+	registry := &fakeRegistry{blobs: map[string][]byte{}, manifests: map[string][]byte{}}
+	registry.server = httptest.NewServer(http.HandlerFunc(registry.handle))
+	t.Cleanup(registry.server.Close)
+	return registry
+}
+
+func (f *fakeRegistry) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/token" {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": "secret-token"})
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer secret-token" {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake"`, f.server.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/v2/org/templates/")
+	switch {
+	case r.Method == http.MethodHead && strings.HasPrefix(path, "blobs/"):
+		if _, ok := f.blobs[strings.TrimPrefix(path, "blobs/")]; ok {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == http.MethodPost && path == "blobs/uploads/":
+		w.Header().Set("Location", "/v2/org/templates/blobs/uploads/session-1?state=abc")
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodPut && strings.HasPrefix(path, "blobs/uploads/"):
+		body, _ := io.ReadAll(r.Body)
+		digest := r.URL.Query().Get("digest")
+		if NewBlob("", "", body).Descriptor.Digest != digest || r.URL.Query().Get("state") != "abc" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.blobs[digest] = body
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && strings.HasPrefix(path, "manifests/"):
+		body, _ := io.ReadAll(r.Body)
+		f.manifests[strings.TrimPrefix(path, "manifests/")] = body
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "manifests/"):
+		manifest, ok := f.manifests[strings.TrimPrefix(path, "manifests/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", ManifestMediaType)
+		_, _ = w.Write(manifest)
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "blobs/"):
+		blob, ok := f.blobs[strings.TrimPrefix(path, "blobs/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(blob)
+	case r.Method == http.MethodGet && path == "tags/list":
+		tags := []string{}
+		for tag := range f.manifests {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		_ = json.NewEncoder(w).Encode(map[string]any{"name": "org/templates", "tags": tags})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestClientPush(t *testing.T) {
+	registry := newFakeRegistry(t)
+
+	ref := Reference{
+		Registry:   strings.TrimPrefix(registry.server.URL, "http://"),
+		Repository: "org/templates",
+		Tag:        "1.2.0",
+	}
+	artifact := &Artifact{
+		Config: NewBlob(ConfigMediaType, "", []byte(`{"name":"org/templates","version":"1.2.0"}`)),
+		Layers: []Blob{NewBlob(TemplatesMediaType, "templates.tar.gz", []byte("archive"))},
+	}
+
+	client := NewClient("user", "pass", true)
+	digest, err := client.Push(ref, artifact)
+	if err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if !strings.HasPrefix(digest, "sha256:") {
+		t.Errorf("Expected sha256 digest, got %s", digest)
+	}
+
+	if len(registry.blobs) != 2 {
+		t.Errorf("Expected 2 blobs to be uploaded, got %d", len(registry.blobs))
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(registry.manifests["1.2.0"], &manifest); err != nil {
+		t.Fatalf("Failed to decode pushed manifest: %v", err)
+	}
+	if manifest.ArtifactType != ArtifactType || len(manifest.Layers) != 1 {
+		t.Errorf("Unexpected manifest: %+v", manifest)
+	}
+
+	// Pushing again should skip existing blobs and still succeed
+	if _, err := client.Push(ref, artifact); err != nil {
+		t.Errorf("Second push failed: %v", err)
+	}
+}
+
+func TestClientPushUnauthorized(t *testing.T) {
+	registry := newFakeRegistry(t)
+
+	ref := Reference{Registry: strings.TrimPrefix(registry.server.URL, "http://"), Repository: "org/templates", Tag: "1.0.0"}
+	artifact := &Artifact{Config: NewBlob(ConfigMediaType, "", []byte("{}"))}
+
+	if _, err := NewClient("user", "wrong", true).Push(ref, artifact); err == nil {
+		t.Error("Expected push with invalid credentials to fail")
+	}
+}
@@ -0,0 +1,73 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Change describes what writing a rendered file would do to the file on disk.
+type Change string
+
+const (
+	Created   Change = "create"
+	Updated   Change = "update"
+	Unchanged Change = "unchanged"
+)
+
+// PlannedWrite is a file a dry run would have written.
+type PlannedWrite struct {
+	Location string
+	Change   Change
+	Size     int
+	// PreviousSize is the size of the file on disk, for updated files
+	PreviousSize int
+}
+
+// DryRunWriter compares rendered output with the files on disk without writing them,
+// for --dry-run.
+type DryRunWriter struct {
+	mu     sync.Mutex
+	writes []PlannedWrite
+}
+
+// NewDryRunWriter creates a writer that records planned writes.
+func NewDryRunWriter() *DryRunWriter {
+	return &DryRunWriter{}
+}
+
+// Write records whether content would create, update or leave the file at location
+// unchanged.
+func (w *DryRunWriter) Write(location string, content []byte) error {
+	planned := PlannedWrite{Location: location, Change: Created, Size: len(content)}
+	existing, err := os.ReadFile(location)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to compare output file %s: %w", location, err)
+	case bytes.Equal(existing, content):
+		planned.Change = Unchanged
+	default:
+		planned.Change = Updated
+		planned.PreviousSize = len(existing)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes = append(w.writes, planned)
+	return nil
+}
+
+// Writes returns the planned writes, sorted by location.
+func (w *DryRunWriter) Writes() []PlannedWrite {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	writes := append([]PlannedWrite(nil), w.writes...)
+	sort.Slice(writes, func(i, j int) bool { return writes[i].Location < writes[j].Location })
+	return writes
+}
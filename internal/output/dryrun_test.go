@@ -0,0 +1,43 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDryRunWriter(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "same"), []byte("kept"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "changed"), []byte("old"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	writer := NewDryRunWriter()
+	files := map[string]string{"same": "kept", "changed": "newer", "new/file": "created"}
+	for name, content := range files {
+		if err := writer.Write(filepath.Join(dir, name), []byte(content)); err != nil {
+			t.Fatalf("Write %s failed: %v", name, err)
+		}
+	}
+
+	expected := []PlannedWrite{
+		{Location: filepath.Join(dir, "changed"), Change: Updated, Size: 5, PreviousSize: 3},
+		{Location: filepath.Join(dir, "new/file"), Change: Created, Size: 7},
+		{Location: filepath.Join(dir, "same"), Change: Unchanged, Size: 4},
+	}
+	if writes := writer.Writes(); !reflect.DeepEqual(writes, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, writes)
+	}
+
+	// Nothing is written
+	if content, _ := os.ReadFile(filepath.Join(dir, "changed")); string(content) != "old" {
+		t.Errorf("Expected changed to keep old, got %s", content)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new")); !os.IsNotExist(err) {
+		t.Errorf("Expected no new directory, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/menta2k/templater/internal/retry"
+)
+
+const (
+	defaultBackoff = 500 * time.Millisecond
+	defaultTimeout = 30 * time.Second
+)
+
+// Writer persists rendered template output at the given location.
+type Writer interface {
+	Write(location string, content []byte) error
+}
+
+// HTTPWriter uploads rendered output to an HTTP endpoint.
+type HTTPWriter struct {
+	Method  string
+	Headers http.Header
+	Retries int
+	Backoff time.Duration
+	Client  *http.Client
+}
+
+// NewHTTPWriter creates an HTTP writer. Headers are given in "Name: value" form.
+func NewHTTPWriter(method string, headers []string, retries int) (*HTTPWriter, error) {
+	method = strings.ToUpper(method)
+	if method == "" {
+		method = http.MethodPut
+	}
+	if method != http.MethodPut && method != http.MethodPost {
+		return nil, fmt.Errorf("unsupported upload method %s (expected PUT or POST)", method)
+	}
+
+	header := make(http.Header)
+	for _, h := range headers {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid header format: %s (expected Name: value)", h)
+		}
+		header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	if retries < 0 {
+		retries = 0
+	}
+
+	return &HTTPWriter{
+		Method:  method,
+		Headers: header,
+		Retries: retries,
+		Backoff: defaultBackoff,
+		Client:  &http.Client{Timeout: defaultTimeout},
+	}, nil
+}
+
+// IsHTTPURL reports whether the output location is an HTTP(S) endpoint.
+func IsHTTPURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// JoinURL appends a relative file path to a base URL. The path is cleaned as if rooted
+// at the base, so .. elements cannot climb above it.
+func JoinURL(base, relativePath string) string {
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(relativePath, "\\", "/")), "/")
+}
+
+// Write uploads content to the given URL, retrying transient failures with exponential backoff.
+func (w *HTTPWriter) Write(location string, content []byte) error {
+	err := retry.Do(retry.Policy{Retries: w.Retries, Backoff: w.Backoff}, func() error {
+		return w.upload(location, content)
+	}, isTransientUploadError)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", location, err)
+	}
+	return nil
+}
+
+// statusError is an upload answered with an unsuccessful HTTP status.
+type statusError struct {
+	code    int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+// isTransientUploadError reports whether a failed upload may be retried: network errors,
+// throttling and server errors are, other client errors are not.
+func isTransientUploadError(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return retry.IsTransientStatus(statusErr.code)
+	}
+	return true
+}
+
+// upload performs a single upload attempt.
+func (w *HTTPWriter) upload(location string, content []byte) error {
+	req, err := http.NewRequest(w.Method, location, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+
+	for name, values := range w.Headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	if req.Header.Get("Content-Type") == "" {
+		contentType := mime.TypeByExtension(path.Ext(location))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return &statusError{
+		code:    resp.StatusCode,
+		message: strings.TrimSpace(fmt.Sprintf("unexpected status %s %s", resp.Status, body)),
+	}
+}
@@ -0,0 +1,128 @@
+package output
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestNewHTTPWriter(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		headers   []string
+		wantError bool
+	}{
+		{name: "default method", method: "", headers: nil},
+		{name: "post method", method: "post", headers: []string{"Authorization: Bearer token"}},
+		{name: "unsupported method", method: "DELETE", wantError: true},
+		{name: "invalid header", method: "PUT", headers: []string{"no-colon"}, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer, err := NewHTTPWriter(tt.method, tt.headers, 1)
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if writer.Method != http.MethodPut && writer.Method != http.MethodPost {
+				t.Errorf("Unexpected method %s", writer.Method)
+			}
+		})
+	}
+}
+
+func TestHTTPWriterWrite(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		attempts int
+		received string
+		auth     string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		auth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	writer, err := NewHTTPWriter("PUT", []string{"Authorization: Bearer secret"}, 2)
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	writer.Backoff = 0
+
+	err = writer.Write(server.URL+"/bundles/app.yaml", []byte("name: app"))
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+	if received != "name: app" {
+		t.Errorf("Expected body 'name: app', got %s", received)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Expected Authorization header to be forwarded, got %s", auth)
+	}
+}
+
+func TestHTTPWriterWriteClientError(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	writer, err := NewHTTPWriter("PUT", nil, 3)
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	writer.Backoff = 0
+
+	if err := writer.Write(server.URL+"/file", []byte("data")); err == nil {
+		t.Error("Expected error for 403 response")
+	}
+	if attempts != 1 {
+		t.Errorf("Client errors should not be retried, got %d attempts", attempts)
+	}
+}
+
+func TestJoinURL(t *testing.T) {
+	tests := []struct {
+		base     string
+		relative string
+		expected string
+	}{
+		{"https://store/bundles/", "app/config.yaml", "https://store/bundles/app/config.yaml"},
+		{"https://store/bundles", "config.yaml", "https://store/bundles/config.yaml"},
+		{"https://store/bundles/", "/nested/../config.yaml", "https://store/bundles/config.yaml"},
+		{"https://store/bundles/", "../config.yaml", "https://store/bundles/config.yaml"},
+		{"https://store/bundles", `..\..\etc\config.yaml`, "https://store/bundles/etc/config.yaml"},
+	}
+
+	for _, tt := range tests {
+		if result := JoinURL(tt.base, tt.relative); result != tt.expected {
+			t.Errorf("JoinURL(%s, %s) = %s, expected %s", tt.base, tt.relative, result, tt.expected)
+		}
+	}
+}
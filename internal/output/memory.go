@@ -0,0 +1,60 @@
+package output
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"testing/fstest"
+	"time"
+)
+
+// memoryScheme prefixes output locations kept in memory, e.g. mem://app/config.yaml.
+const memoryScheme = "mem://"
+
+// MemoryWriter keeps rendered output in an in-memory file system, so tests and programs
+// embedding templater can inspect rendered trees without touching disk.
+type MemoryWriter struct {
+	mu    sync.Mutex
+	files fstest.MapFS
+}
+
+// NewMemoryWriter creates an empty in-memory writer.
+func NewMemoryWriter() *MemoryWriter {
+	return &MemoryWriter{files: make(fstest.MapFS)}
+}
+
+// IsMemoryURL reports whether the output location is kept in memory.
+func IsMemoryURL(location string) bool {
+	return strings.HasPrefix(location, memoryScheme)
+}
+
+// Write stores content under location, which may carry the mem:// prefix.
+func (w *MemoryWriter) Write(location string, content []byte) error {
+	name := path.Clean(strings.TrimPrefix(strings.TrimPrefix(location, memoryScheme), "/"))
+	if !fs.ValidPath(name) || name == "." {
+		return fmt.Errorf("invalid in-memory output path %s", location)
+	}
+
+	data := make([]byte, len(content))
+	copy(data, content)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.files[name] = &fstest.MapFile{Data: data, Mode: 0o644, ModTime: time.Now()}
+	return nil
+}
+
+// FS returns a snapshot of the files written so far. It implements fs.FS and can be
+// checked with fstest.TestFS.
+func (w *MemoryWriter) FS() fstest.MapFS {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	snapshot := make(fstest.MapFS, len(w.files))
+	for name, file := range w.files {
+		snapshot[name] = file
+	}
+	return snapshot
+}
@@ -0,0 +1,71 @@
+package output
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestMemoryWriter(t *testing.T) {
+	writer := NewMemoryWriter()
+
+	files := map[string]string{
+		"mem://config":                  "name: app",
+		"mem://services/web/deployment": "replicas: 2",
+		"/nested/../readme":             "docs",
+	}
+	for location, content := range files {
+		if err := writer.Write(location, []byte(content)); err != nil {
+			t.Fatalf("Write %s failed: %v", location, err)
+		}
+	}
+
+	fsys := writer.FS()
+	if err := fstest.TestFS(fsys, "config", "services/web/deployment", "readme"); err != nil {
+		t.Fatalf("In-memory output is not a valid file system: %v", err)
+	}
+
+	content, err := fsys.ReadFile("services/web/deployment")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(content) != "replicas: 2" {
+		t.Errorf("Expected replicas: 2, got %s", content)
+	}
+
+	// Later writes do not change an earlier snapshot
+	if err := writer.Write("mem://config", []byte("name: changed")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if content, _ := fsys.ReadFile("config"); string(content) != "name: app" {
+		t.Errorf("Expected snapshot to keep name: app, got %s", content)
+	}
+}
+
+func TestMemoryWriterInvalidPath(t *testing.T) {
+	tests := []string{"mem://", "mem://../outside", "../outside"}
+
+	writer := NewMemoryWriter()
+	for _, location := range tests {
+		if err := writer.Write(location, []byte("data")); err == nil {
+			t.Errorf("Expected error for %s", location)
+		}
+	}
+}
+
+func TestIsMemoryURL(t *testing.T) {
+	tests := []struct {
+		location string
+		expected bool
+	}{
+		{"mem://", true},
+		{"mem://bundles/app", true},
+		{"./output", false},
+		{"https://store/bundles/", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsMemoryURL(tt.location); got != tt.expected {
+			t.Errorf("IsMemoryURL(%s) = %v, expected %v", tt.location, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Stdout is the output location that prints rendered files to standard output.
+const Stdout = "-"
+
+// IsStdout reports whether the output location is standard output, or a file below it
+// in directory mode, such as -/deployment.yaml.
+func IsStdout(location string) bool {
+	return location == Stdout || strings.HasPrefix(location, Stdout+"/")
+}
+
+// StreamWriter writes rendered files to a single stream as a multi-document YAML
+// stream, each file preceded by a "--- # source: <path>" separator like helm template,
+// so the output can be piped into kubectl apply -f -.
+type StreamWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewStreamWriter creates a writer printing rendered files to w.
+func NewStreamWriter(w io.Writer) *StreamWriter {
+	return &StreamWriter{w: w}
+}
+
+// Write prints content after a separator naming location, relative to the - prefix.
+func (w *StreamWriter) Write(location string, content []byte) error {
+	source := path.Clean(strings.TrimPrefix(strings.ReplaceAll(location, "\\", "/"), Stdout+"/"))
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := fmt.Fprintf(w.w, "--- # source: %s\n", source); err != nil {
+		return fmt.Errorf("failed to write %s: %w", source, err)
+	}
+	if _, err := w.w.Write(content); err != nil {
+		return fmt.Errorf("failed to write %s: %w", source, err)
+	}
+	// Start the next separator on a line of its own
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		if _, err := io.WriteString(w.w, "\n"); err != nil {
+			return fmt.Errorf("failed to write %s: %w", source, err)
+		}
+	}
+	return nil
+}
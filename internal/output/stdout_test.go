@@ -0,0 +1,43 @@
+package output
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStreamWriter(t *testing.T) {
+	var buf strings.Builder
+	writer := NewStreamWriter(&buf)
+
+	files := []struct{ location, content string }{
+		{"-/app/deployment.yaml", "kind: Deployment\n"},
+		{"-/app/service.yaml", "kind: Service"},
+		{"-", ""},
+	}
+	for _, file := range files {
+		if err := writer.Write(file.location, []byte(file.content)); err != nil {
+			t.Fatalf("Write %s failed: %v", file.location, err)
+		}
+	}
+
+	expected := "--- # source: app/deployment.yaml\nkind: Deployment\n" +
+		"--- # source: app/service.yaml\nkind: Service\n" +
+		"--- # source: -\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestIsStdout(t *testing.T) {
+	for location, expected := range map[string]bool{
+		"-":            true,
+		"-/config":     true,
+		"-output":      false,
+		"output/-":     false,
+		"mem://config": false,
+	} {
+		if got := IsStdout(location); got != expected {
+			t.Errorf("IsStdout(%q) = %v, expected %v", location, got, expected)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+name: {{ .workflow.name }}
+
+on:
+  push:
+    branches: [{{ join ", " .workflow.branches }}]
+  pull_request:
+    branches: [{{ join ", " .workflow.branches }}]
+
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+        with:
+          go-version: {{ .go.version | quote }}
+      - name: Vet
+        run: go vet {{ default "./..." .go.packages }}
+      - name: Test
+        run: go test -race {{ default "./..." .go.packages }}
+{{- if .lint }}
+
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+        with:
+          go-version: {{ .go.version | quote }}
+      - uses: golangci/golangci-lint-action@v6
+        with:
+          version: latest
+{{- end }}
@@ -0,0 +1,35 @@
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .app.name }}
+  namespace: {{ .namespace }}
+  labels:
+    app.kubernetes.io/name: {{ .app.name }}
+    app.kubernetes.io/version: {{ .app.tag | quote }}
+spec:
+  replicas: {{ .replicas }}
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: {{ .app.name }}
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: {{ .app.name }}
+    spec:
+      containers:
+        - name: {{ .app.name }}
+          image: "{{ .app.image }}:{{ .app.tag }}"
+          ports:
+            - name: http
+              containerPort: {{ .app.port }}
+          {{- with .env }}
+          env:
+            {{- range $name, $value := . }}
+            - name: {{ $name }}
+              value: {{ $value | quote }}
+            {{- end }}
+          {{- end }}
+          {{- with .resources }}
+          resources:
+            {{- toYaml . | nindent 12 }}
+          {{- end }}
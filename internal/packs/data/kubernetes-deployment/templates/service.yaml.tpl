@@ -0,0 +1,14 @@
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .app.name }}
+  namespace: {{ .namespace }}
+  labels:
+    app.kubernetes.io/name: {{ .app.name }}
+spec:
+  selector:
+    app.kubernetes.io/name: {{ .app.name }}
+  ports:
+    - name: http
+      port: 80
+      targetPort: http
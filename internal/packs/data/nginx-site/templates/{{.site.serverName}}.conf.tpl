@@ -0,0 +1,30 @@
+{{- if .upstream.enabled }}
+upstream {{ .upstream.name }} {
+{{- range .upstream.servers }}
+    server {{ . }};
+{{- end }}
+}
+
+{{ end -}}
+server {
+    listen {{ .site.port }};
+{{- if .tls.enabled }}
+    listen 443 ssl;
+    ssl_certificate     {{ .tls.certificate }};
+    ssl_certificate_key {{ .tls.key }};
+{{- end }}
+    server_name {{ .site.serverName }}{{ range .site.aliases }} {{ . }}{{ end }};
+
+    root {{ .site.root }};
+    index index.html;
+
+    location / {
+{{- if .upstream.enabled }}
+        proxy_pass http://{{ .upstream.name }};
+        proxy_set_header Host $host;
+        proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
+{{- else }}
+        try_files $uri $uri/ =404;
+{{- end }}
+    }
+}
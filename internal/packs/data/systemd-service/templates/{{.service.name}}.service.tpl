@@ -0,0 +1,25 @@
+[Unit]
+Description={{ .service.description }}
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+{{- with .service.user }}
+User={{ . }}
+{{- end }}
+{{- with .service.group }}
+Group={{ . }}
+{{- end }}
+{{- with .service.workingDirectory }}
+WorkingDirectory={{ . }}
+{{- end }}
+ExecStart={{ .service.execStart }}
+Restart={{ default "on-failure" .service.restart }}
+RestartSec={{ default 5 .service.restartSec }}
+{{- range $name, $value := .environment }}
+Environment="{{ $name }}={{ $value }}"
+{{- end }}
+
+[Install]
+WantedBy=multi-user.target
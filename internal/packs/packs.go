@@ -0,0 +1,118 @@
+package packs
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+//go:embed data
+var packFS embed.FS
+
+const packRoot = "data"
+
+// Pack describes an embedded starter template pack.
+type Pack struct {
+	Name        string
+	Description string
+	Usage       string
+}
+
+// catalog lists the embedded packs with their descriptions.
+var catalog = map[string]Pack{
+	"kubernetes-deployment": {
+		Name:        "kubernetes-deployment",
+		Description: "Kubernetes Deployment and Service for a single container",
+		Usage:       "templater -template templates -values values.yaml -output manifests",
+	},
+	"nginx-site": {
+		Name:        "nginx-site",
+		Description: "nginx server block with optional TLS and upstream proxying",
+		Usage:       "templater -template templates -values values.yaml -output sites-available",
+	},
+	"systemd-service": {
+		Name:        "systemd-service",
+		Description: "systemd unit for a long-running service",
+		Usage:       "templater -template templates -values values.yaml -output /etc/systemd/system",
+	},
+	"github-actions": {
+		Name:        "github-actions",
+		Description: "GitHub Actions CI workflow for a Go project",
+		Usage:       "templater -template templates -values values.yaml -output .github",
+	},
+}
+
+// List returns the available packs sorted by name.
+func List() []Pack {
+	packs := make([]Pack, 0, len(catalog))
+	for _, pack := range catalog {
+		packs = append(packs, pack)
+	}
+	sort.Slice(packs, func(i, j int) bool { return packs[i].Name < packs[j].Name })
+	return packs
+}
+
+// Get returns the pack with the given name.
+func Get(name string) (Pack, error) {
+	pack, ok := catalog[name]
+	if !ok {
+		return Pack{}, fmt.Errorf("unknown pack '%s'", name)
+	}
+	return pack, nil
+}
+
+// Materialize writes the pack files into the destination directory.
+// It refuses to overwrite existing files unless force is set.
+func Materialize(name, destination string, force bool) ([]string, error) {
+	if _, err := Get(name); err != nil {
+		return nil, err
+	}
+
+	root := path.Join(packRoot, name)
+	var written []string
+
+	err := fs.WalkDir(packFS, root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(destination, relativePath)
+
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+
+		if !force {
+			if _, err := os.Stat(target); err == nil {
+				return fmt.Errorf("file %s already exists (use -force to overwrite)", target)
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+
+		content, err := packFS.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, content, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", target, err)
+		}
+
+		written = append(written, target)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return written, nil
+}
@@ -0,0 +1,106 @@
+package packs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/menta2k/templater/internal/config"
+	"github.com/menta2k/templater/internal/processor"
+)
+
+func TestList(t *testing.T) {
+	list := List()
+	if len(list) != len(catalog) {
+		t.Fatalf("Expected %d packs, got %d", len(catalog), len(list))
+	}
+	for i := 1; i < len(list); i++ {
+		if list[i-1].Name > list[i].Name {
+			t.Errorf("Expected packs sorted by name, got %s before %s", list[i-1].Name, list[i].Name)
+		}
+	}
+}
+
+func TestGetUnknownPack(t *testing.T) {
+	if _, err := Get("does-not-exist"); err == nil {
+		t.Error("Expected error for unknown pack")
+	}
+}
+
+func TestMaterializeAndRender(t *testing.T) {
+	tests := []struct {
+		pack     string
+		expected map[string]string
+	}{
+		{
+			pack:     "kubernetes-deployment",
+			expected: map[string]string{"deployment.yaml": "kind: Deployment", "service.yaml": "kind: Service"},
+		},
+		{
+			pack:     "nginx-site",
+			expected: map[string]string{"example.com.conf": "try_files $uri $uri/ =404;"},
+		},
+		{
+			pack:     "systemd-service",
+			expected: map[string]string{"my-service.service": "ExecStart=/opt/my-service/bin/my-service"},
+		},
+		{
+			pack:     "github-actions",
+			expected: map[string]string{filepath.Join("workflows", "ci.yml"): "golangci-lint-action"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pack, func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "test-pack-*")
+			if err != nil {
+				t.Fatalf("Failed to create temp dir: %v", err)
+			}
+			defer os.RemoveAll(tempDir)
+
+			packDir := filepath.Join(tempDir, tt.pack)
+			written, err := Materialize(tt.pack, packDir, false)
+			if err != nil {
+				t.Fatalf("Materialize failed: %v", err)
+			}
+			if len(written) == 0 {
+				t.Fatal("Expected files to be written")
+			}
+
+			outputDir := filepath.Join(tempDir, "output")
+			cfg := config.NewConfig(filepath.Join(packDir, "templates"), filepath.Join(packDir, "values.yaml"), outputDir, nil, true, true)
+			if err := processor.NewTemplateProcessor(cfg).Process(); err != nil {
+				t.Fatalf("Rendering pack failed: %v", err)
+			}
+
+			for file, fragment := range tt.expected {
+				content, err := os.ReadFile(filepath.Join(outputDir, file))
+				if err != nil {
+					t.Fatalf("Expected output %s: %v", file, err)
+				}
+				if !strings.Contains(string(content), fragment) {
+					t.Errorf("Expected %s to contain %q, got:\n%s", file, fragment, content)
+				}
+			}
+		})
+	}
+}
+
+func TestMaterializeRefusesOverwrite(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-pack-overwrite-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if _, err := Materialize("systemd-service", tempDir, false); err != nil {
+		t.Fatalf("Materialize failed: %v", err)
+	}
+	if _, err := Materialize("systemd-service", tempDir, false); err == nil {
+		t.Error("Expected error when overwriting existing files")
+	}
+	if _, err := Materialize("systemd-service", tempDir, true); err != nil {
+		t.Errorf("Materialize with force failed: %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// ExecAllowlist holds the programs the exec template function may run, keyed by the
+// name templates use for them.
+type ExecAllowlist struct {
+	commands map[string]string
+}
+
+// NewExecAllowlist resolves the --allow-exec commands, which may be comma-separated.
+// Commands without a directory are looked up in PATH, so a missing tool is reported
+// before rendering starts.
+func NewExecAllowlist(names []string) (*ExecAllowlist, error) {
+	commands := map[string]string{}
+	for _, list := range names {
+		for _, name := range strings.Split(list, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			resolved, err := exec.LookPath(name)
+			if err != nil {
+				return nil, fmt.Errorf("allowed command %s: %w", name, err)
+			}
+			commands[name] = resolved
+		}
+	}
+	return &ExecAllowlist{commands: commands}, nil
+}
+
+// Len returns the number of allowed commands.
+func (a *ExecAllowlist) Len() int {
+	return len(a.commands)
+}
+
+// Func returns the exec template function. {{ exec "name" "arg" }} runs an allowed
+// command with the arguments and returns its standard output without trailing newlines;
+// other commands, failures and runs longer than ProcessTimeout fail rendering. Commands
+// still running when ctx is canceled are killed.
+func (a *ExecAllowlist) Func(ctx context.Context) func(name string, args ...any) (string, error) {
+	return func(name string, args ...any) (string, error) {
+		path, ok := a.commands[name]
+		if !ok {
+			return "", fmt.Errorf("exec of %s is not allowed (allowed commands: %s)", name, a.names())
+		}
+		return runProgram(ctx, "exec "+name, path, args)
+	}
+}
+
+// names returns the sorted, comma-separated names of the allowed commands.
+func (a *ExecAllowlist) names() string {
+	names := make([]string, 0, len(a.commands))
+	for name := range a.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
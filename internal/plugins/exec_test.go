@@ -0,0 +1,51 @@
+package plugins
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewExecAllowlist(t *testing.T) {
+	script := writeScript(t, "echo ok\n")
+
+	allowlist, err := NewExecAllowlist([]string{"sh, " + script, ""})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if allowlist.Len() != 2 {
+		t.Errorf("Expected 2 allowed commands, got %d", allowlist.Len())
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing")
+	if _, err := NewExecAllowlist([]string{missing}); err == nil || !strings.Contains(err.Error(), "allowed command "+missing) {
+		t.Errorf("Expected missing command error, got %v", err)
+	}
+}
+
+func TestExecAllowlistFunc(t *testing.T) {
+	script := writeScript(t, "echo \"$1:$2\"\n")
+	allowlist, err := NewExecAllowlist([]string{script, "sh"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	execFunc := allowlist.Func(context.Background())
+
+	result, err := execFunc(script, "web", 8080)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != "web:8080" {
+		t.Errorf("Expected %q, got %q", "web:8080", result)
+	}
+
+	if _, err := execFunc("sh", "-c", "echo denied >&2; exit 2"); err == nil || err.Error() != "exec sh failed: exit status 2: denied" {
+		t.Errorf("Expected failure error, got %v", err)
+	}
+
+	expected := "exec of curl is not allowed (allowed commands: " + strings.Join([]string{script, "sh"}, ", ") + ")"
+	if _, err := execFunc("curl", "https://example.com"); err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+}
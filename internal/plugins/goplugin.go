@@ -0,0 +1,49 @@
+// Package plugins loads custom template functions from outside the templater binary.
+package plugins
+
+import (
+	"fmt"
+	"plugin"
+	"text/template"
+)
+
+// GoPluginSymbol is the symbol a Go plugin exports its template functions as: a
+// template.FuncMap (or map[string]any) variable, or a function returning one.
+const GoPluginSymbol = "Funcs"
+
+// LoadGoPlugin opens a Go plugin built with go build -buildmode=plugin and returns the
+// template functions it exports as Funcs. Plugins must be built with the same Go version
+// and versions of shared packages as templater, and are only supported on Linux, macOS
+// and FreeBSD with cgo.
+func LoadGoPlugin(path string) (template.FuncMap, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open function plugin %s: %w", path, err)
+	}
+	symbol, err := p.Lookup(GoPluginSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("function plugin %s does not export %s: %w", path, GoPluginSymbol, err)
+	}
+	funcs, err := symbolFuncs(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("function plugin %s: %w", path, err)
+	}
+	return funcs, nil
+}
+
+// symbolFuncs returns the functions of an exported Funcs symbol. Looking up a variable
+// yields a pointer to it.
+func symbolFuncs(symbol plugin.Symbol) (template.FuncMap, error) {
+	switch funcs := symbol.(type) {
+	case *template.FuncMap:
+		return *funcs, nil
+	case *map[string]any:
+		return *funcs, nil
+	case func() template.FuncMap:
+		return funcs(), nil
+	case func() map[string]any:
+		return funcs(), nil
+	default:
+		return nil, fmt.Errorf("%s is a %T, expected a template.FuncMap or a function returning one", GoPluginSymbol, symbol)
+	}
+}
@@ -0,0 +1,50 @@
+package plugins
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestSymbolFuncs(t *testing.T) {
+	upper := func(s string) string { return strings.ToUpper(s) }
+	funcMap := template.FuncMap{"shout": upper}
+	plainMap := map[string]any{"shout": upper}
+
+	tests := []struct {
+		name      string
+		symbol    any
+		wantError bool
+	}{
+		{name: "FuncMap variable", symbol: &funcMap},
+		{name: "map variable", symbol: &plainMap},
+		{name: "FuncMap function", symbol: func() template.FuncMap { return funcMap }},
+		{name: "map function", symbol: func() map[string]any { return plainMap }},
+		{name: "wrong type", symbol: &upper, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			funcs, err := symbolFuncs(tt.symbol)
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error for an unsupported symbol")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if _, ok := funcs["shout"]; !ok || len(funcs) != 1 {
+				t.Errorf("Expected the shout function, got %v", funcs)
+			}
+		})
+	}
+}
+
+func TestLoadGoPluginMissing(t *testing.T) {
+	if _, err := LoadGoPlugin(filepath.Join(t.TempDir(), "missing.so")); err == nil || !strings.Contains(err.Error(), "failed to open function plugin") {
+		t.Errorf("Expected an open error, got %v", err)
+	}
+}
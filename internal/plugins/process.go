@@ -0,0 +1,79 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ProcessTimeout bounds each run of a process plugin.
+var ProcessTimeout = 30 * time.Second
+
+// functionNamePattern matches names usable as template functions.
+var functionNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ProcessPlugin is a template function running an external program.
+type ProcessPlugin struct {
+	Name string
+	Path string
+}
+
+// ParseProcessPlugin parses a name=path --plugin spec and resolves the program, which is
+// looked up in PATH when path has no directory.
+func ParseProcessPlugin(spec string) (ProcessPlugin, error) {
+	name, path, ok := strings.Cut(spec, "=")
+	name, path = strings.TrimSpace(name), strings.TrimSpace(path)
+	if !ok || path == "" {
+		return ProcessPlugin{}, fmt.Errorf("invalid plugin %s (expected name=path)", spec)
+	}
+	if !functionNamePattern.MatchString(name) {
+		return ProcessPlugin{}, fmt.Errorf("invalid plugin name '%s' (expected letters, digits and underscores)", name)
+	}
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return ProcessPlugin{}, fmt.Errorf("plugin %s: %w", name, err)
+	}
+	return ProcessPlugin{Name: name, Path: resolved}, nil
+}
+
+// Func returns the template function of the plugin. It runs the program with its
+// arguments formatted as strings and returns the standard output without trailing
+// newlines, as shell command substitution does. A program failing, or running longer
+// than ProcessTimeout, fails rendering with its standard error. Programs still running
+// when ctx is canceled are killed.
+func (p ProcessPlugin) Func(ctx context.Context) func(args ...any) (string, error) {
+	return func(args ...any) (string, error) {
+		return runProgram(ctx, "plugin "+p.Name, p.Path, args)
+	}
+}
+
+// runProgram runs the program at path with args formatted as strings and returns its
+// standard output without trailing newlines. label names the program in errors.
+func runProgram(ctx context.Context, label, path string, args []any) (string, error) {
+	argv := make([]string, len(args))
+	for i, arg := range args {
+		argv[i] = fmt.Sprint(arg)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ProcessTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, argv...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("%s timed out after %s", label, ProcessTimeout)
+		}
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("%s stopped: %w", label, ctx.Err())
+		}
+		return "", fmt.Errorf("%s failed: %w: %s", label, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
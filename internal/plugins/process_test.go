@@ -0,0 +1,104 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeScript writes an executable shell script and returns its path.
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not executable on windows")
+	}
+	path := filepath.Join(t.TempDir(), "plugin.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0o755); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+	return path
+}
+
+func TestParseProcessPlugin(t *testing.T) {
+	script := writeScript(t, "echo ok\n")
+
+	tests := []struct {
+		spec      string
+		name      string
+		wantError string
+	}{
+		{spec: "greet=" + script, name: "greet"},
+		{spec: " greet_2 = " + script, name: "greet_2"},
+		{spec: "greet", wantError: "expected name=path"},
+		{spec: "greet=", wantError: "expected name=path"},
+		{spec: "2greet=" + script, wantError: "invalid plugin name"},
+		{spec: "greet-me=" + script, wantError: "invalid plugin name"},
+		{spec: "greet=" + filepath.Join(t.TempDir(), "missing.sh"), wantError: "plugin greet"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			plugin, err := ParseProcessPlugin(tt.spec)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if plugin.Name != tt.name || plugin.Path != script {
+				t.Errorf("Expected %s=%s, got %s=%s", tt.name, script, plugin.Name, plugin.Path)
+			}
+		})
+	}
+}
+
+func TestProcessPluginFunc(t *testing.T) {
+	tests := []struct {
+		name      string
+		script    string
+		args      []any
+		expected  string
+		wantError string
+	}{
+		{name: "arguments", script: "echo \"$1-$2\"\n", args: []any{"web", 8080}, expected: "web-8080"},
+		{name: "no arguments", script: "printf 'line1\\nline2\\n\\n'\n", expected: "line1\nline2"},
+		{name: "failure", script: "echo 'no such key' >&2\nexit 3\n", wantError: "plugin lookup failed: exit status 3: no such key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := ProcessPlugin{Name: "lookup", Path: writeScript(t, tt.script)}
+			result, err := plugin.Func(context.Background())(tt.args...)
+			if tt.wantError != "" {
+				if err == nil || err.Error() != tt.wantError {
+					t.Errorf("Expected error %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestProcessPluginTimeout(t *testing.T) {
+	timeout := ProcessTimeout
+	ProcessTimeout = 50 * time.Millisecond
+	defer func() { ProcessTimeout = timeout }()
+
+	plugin := ProcessPlugin{Name: "slow", Path: writeScript(t, "exec sleep 5\n")}
+	if _, err := plugin.Func(context.Background())(); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+}
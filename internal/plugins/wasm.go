@@ -0,0 +1,226 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// WasmTimeout bounds each call of a WebAssembly function.
+var WasmTimeout = 30 * time.Second
+
+// wasmMemoryLimitPages caps the memory of a WebAssembly module at 64 MiB.
+const wasmMemoryLimitPages = 1024
+
+// WasmAllocExport is the export the host calls to reserve guest memory for the arguments
+// of a function: alloc(size i32) i32 returns the address of size free bytes.
+const WasmAllocExport = "alloc"
+
+// WasmHostModule is the module of the functions the host provides to WebAssembly
+// modules. fail(ptr i32, len i32) makes the current call fail with the UTF-8 message at
+// ptr, once the function returns.
+const WasmHostModule = "templater"
+
+// WasmPlugin is a compiled WebAssembly module whose exports are template functions.
+// Every exported function taking (ptr i32, len i32) and returning i64, except alloc,
+// is a function of the same name. It receives its arguments as a JSON array written to
+// memory returned by alloc, and returns the address of its JSON result in the upper 32
+// bits and its length in the lower 32 bits.
+type WasmPlugin struct {
+	path    string
+	runtime wazero.Runtime
+	module  wazero.CompiledModule
+	names   []string
+}
+
+// wasmFailure carries the message passed to fail during a call.
+type wasmFailure struct {
+	message string
+}
+
+// wasmFailureKey is the context key of the *wasmFailure of a call.
+type wasmFailureKey struct{}
+
+// LoadWasmPlugin compiles the WebAssembly module at path. Modules run without access to
+// files, the network or the environment; WASI is available for toolchains that need it,
+// with fixed clocks and a deterministic random source, so functions render the same
+// output for the same arguments.
+func LoadWasmPlugin(path string) (*WasmPlugin, error) {
+	binary, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wasm plugin %s: %w", path, err)
+	}
+
+	ctx := context.Background()
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(wasmMemoryLimitPages))
+	p, err := compileWasmPlugin(ctx, runtime, path, binary)
+	if err != nil {
+		runtime.Close(ctx)
+		return nil, err
+	}
+	return p, nil
+}
+
+// compileWasmPlugin compiles binary in runtime and checks its exports.
+func compileWasmPlugin(ctx context.Context, runtime wazero.Runtime, path string, binary []byte) (*WasmPlugin, error) {
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		return nil, fmt.Errorf("wasm plugin %s: %w", path, err)
+	}
+	_, err := runtime.NewHostModuleBuilder(WasmHostModule).
+		NewFunctionBuilder().WithFunc(wasmFail).Export("fail").
+		Instantiate(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("wasm plugin %s: %w", path, err)
+	}
+
+	module, err := runtime.CompileModule(ctx, binary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile wasm plugin %s: %w", path, err)
+	}
+
+	exports := module.ExportedFunctions()
+	alloc, ok := exports[WasmAllocExport]
+	if !ok || !hasSignature(alloc, []api.ValueType{api.ValueTypeI32}, []api.ValueType{api.ValueTypeI32}) {
+		return nil, fmt.Errorf("wasm plugin %s does not export %s(size i32) i32", path, WasmAllocExport)
+	}
+	if _, ok := module.ExportedMemories()["memory"]; !ok {
+		return nil, fmt.Errorf("wasm plugin %s does not export its memory", path)
+	}
+
+	var names []string
+	for name, def := range exports {
+		if name == WasmAllocExport || !functionNamePattern.MatchString(name) {
+			continue
+		}
+		if hasSignature(def, []api.ValueType{api.ValueTypeI32, api.ValueTypeI32}, []api.ValueType{api.ValueTypeI64}) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("wasm plugin %s exports no template functions (expected name(ptr i32, len i32) i64)", path)
+	}
+	sort.Strings(names)
+
+	return &WasmPlugin{path: path, runtime: runtime, module: module, names: names}, nil
+}
+
+// hasSignature reports whether def takes params and returns results.
+func hasSignature(def api.FunctionDefinition, params, results []api.ValueType) bool {
+	return bytes.Equal(def.ParamTypes(), params) && bytes.Equal(def.ResultTypes(), results)
+}
+
+// Funcs returns the template functions of the module. Each call runs in a new instance
+// of the module, so calls do not share state and may run concurrently. A call running
+// longer than WasmTimeout, or still running when ctx is canceled, is stopped.
+func (p *WasmPlugin) Funcs(ctx context.Context) template.FuncMap {
+	funcs := template.FuncMap{}
+	for _, name := range p.names {
+		funcs[name] = func(args ...any) (any, error) {
+			result, err := p.call(ctx, name, args)
+			if err != nil {
+				return nil, fmt.Errorf("wasm plugin %s: %s: %w", p.path, name, err)
+			}
+			return result, nil
+		}
+	}
+	return funcs
+}
+
+// call runs the exported function name with args in a new instance of the module.
+func (p *WasmPlugin) call(ctx context.Context, name string, args []any) (any, error) {
+	if args == nil {
+		args = []any{}
+	}
+	input, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode arguments: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, WasmTimeout)
+	defer cancel()
+	failure := &wasmFailure{}
+	ctx = context.WithValue(ctx, wasmFailureKey{}, failure)
+
+	var stderr bytes.Buffer
+	instance, err := p.runtime.InstantiateModule(ctx, p.module, wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize").
+		WithStderr(&stderr))
+	if err != nil {
+		return nil, wasmError(ctx, err, &stderr)
+	}
+	defer instance.Close(context.Background())
+
+	results, err := instance.ExportedFunction(WasmAllocExport).Call(ctx, uint64(len(input)))
+	if err != nil {
+		return nil, wasmError(ctx, err, &stderr)
+	}
+	ptr := uint32(results[0])
+	if !instance.Memory().Write(ptr, input) {
+		return nil, fmt.Errorf("%s returned %d, out of memory bounds for %d bytes", WasmAllocExport, ptr, len(input))
+	}
+
+	results, err = instance.ExportedFunction(name).Call(ctx, uint64(ptr), uint64(len(input)))
+	if err != nil {
+		return nil, wasmError(ctx, err, &stderr)
+	}
+	if failure.message != "" {
+		return nil, errors.New(failure.message)
+	}
+
+	resultPtr, resultLen := uint32(results[0]>>32), uint32(results[0])
+	output, ok := instance.Memory().Read(resultPtr, resultLen)
+	if !ok {
+		return nil, fmt.Errorf("result at %d is out of memory bounds", resultPtr)
+	}
+
+	var result any
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	decoder.UseNumber()
+	if err := decoder.Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid JSON result: %w", err)
+	}
+	return result, nil
+}
+
+// wasmError describes a failed instantiation or call, with the standard error of the
+// module when it wrote any.
+func wasmError(ctx context.Context, err error, stderr *bytes.Buffer) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s", WasmTimeout)
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("stopped: %w", ctx.Err())
+	}
+	if message := strings.TrimSpace(stderr.String()); message != "" {
+		return fmt.Errorf("%w: %s", err, message)
+	}
+	return err
+}
+
+// wasmFail is the fail host function, recording the message of the current call.
+func wasmFail(ctx context.Context, m api.Module, ptr, size uint32) {
+	failure, ok := ctx.Value(wasmFailureKey{}).(*wasmFailure)
+	if !ok {
+		return
+	}
+	message, ok := m.Memory().Read(ptr, size)
+	if !ok || len(message) == 0 {
+		failure.message = "failed"
+		return
+	}
+	failure.message = string(message)
+}
@@ -0,0 +1,159 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wasmSection encodes a module section. Contents of the test modules stay below 128
+// bytes, so sizes fit in a single LEB128 byte.
+func wasmSection(id byte, content ...byte) []byte {
+	return append([]byte{id, byte(len(content))}, content...)
+}
+
+// wasmName encodes a name.
+func wasmName(name string) []byte {
+	return append([]byte{byte(len(name))}, name...)
+}
+
+// wasmBody encodes a function body without locals.
+func wasmBody(code ...byte) []byte {
+	return append([]byte{byte(len(code) + 1), 0x00}, code...)
+}
+
+// concat joins byte slices.
+func concat(parts ...[]byte) []byte {
+	var joined []byte
+	for _, part := range parts {
+		joined = append(joined, part...)
+	}
+	return joined
+}
+
+// testWasmModule returns a module exporting alloc and three functions: echo returns its
+// arguments, broken fails with "boom" and spin never returns.
+func testWasmModule() []byte {
+	types := concat([]byte{3},
+		[]byte{0x60, 2, 0x7f, 0x7f, 0},       // (i32, i32)
+		[]byte{0x60, 1, 0x7f, 1, 0x7f},       // (i32) i32
+		[]byte{0x60, 2, 0x7f, 0x7f, 1, 0x7e}, // (i32, i32) i64
+	)
+	imports := concat([]byte{1}, wasmName(WasmHostModule), wasmName("fail"), []byte{0x00, 0})
+	functions := []byte{4, 1, 2, 2, 2}
+	memory := []byte{1, 0x00, 1}
+	exports := concat([]byte{5},
+		wasmName("memory"), []byte{0x02, 0},
+		wasmName("alloc"), []byte{0x00, 1},
+		wasmName("echo"), []byte{0x00, 2},
+		wasmName("broken"), []byte{0x00, 3},
+		wasmName("spin"), []byte{0x00, 4},
+	)
+	code := concat([]byte{4},
+		// alloc: arguments are written at 1024
+		wasmBody(0x41, 0x80, 0x08, 0x0b),
+		// echo: (ptr << 32) | len
+		wasmBody(0x20, 0, 0xad, 0x42, 32, 0x86, 0x20, 1, 0xad, 0x84, 0x0b),
+		// broken: fail(0, 4) and return 0
+		wasmBody(0x41, 0, 0x41, 4, 0x10, 0, 0x42, 0, 0x0b),
+		// spin: loop forever
+		wasmBody(0x03, 0x40, 0x0c, 0, 0x0b, 0x00, 0x0b),
+	)
+	data := concat([]byte{1, 0x00, 0x41, 0, 0x0b}, wasmName("boom"))
+
+	return concat(
+		[]byte{0x00, 'a', 's', 'm', 1, 0, 0, 0},
+		wasmSection(1, types...),
+		wasmSection(2, imports...),
+		wasmSection(3, functions...),
+		wasmSection(5, memory...),
+		wasmSection(7, exports...),
+		wasmSection(10, code...),
+		wasmSection(11, data...),
+	)
+}
+
+// writeWasm writes a module and returns its path.
+func writeWasm(t *testing.T, module []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "funcs.wasm")
+	if err := os.WriteFile(path, module, 0o644); err != nil {
+		t.Fatalf("Failed to write module: %v", err)
+	}
+	return path
+}
+
+func TestWasmPlugin(t *testing.T) {
+	plugin, err := LoadWasmPlugin(writeWasm(t, testWasmModule()))
+	if err != nil {
+		t.Fatalf("LoadWasmPlugin failed: %v", err)
+	}
+	funcs := plugin.Funcs(context.Background())
+	if len(funcs) != 3 {
+		t.Fatalf("Expected echo, broken and spin, got %v", funcs)
+	}
+
+	echo := funcs["echo"].(func(...any) (any, error))
+	result, err := echo("web", 2, map[string]any{"tier": "gold"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []any{"web", json.Number("2"), map[string]any{"tier": "gold"}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+
+	broken := funcs["broken"].(func(...any) (any, error))
+	if _, err := broken(); err == nil || !strings.Contains(err.Error(), "broken: boom") {
+		t.Errorf("Expected the message passed to fail, got %v", err)
+	}
+
+	timeout := WasmTimeout
+	WasmTimeout = 50 * time.Millisecond
+	defer func() { WasmTimeout = timeout }()
+	spin := funcs["spin"].(func(...any) (any, error))
+	if _, err := spin(); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+
+	// Calls stop once the context of the render is canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	spin = plugin.Funcs(ctx)["spin"].(func(...any) (any, error))
+	if _, err := spin(); err == nil || !strings.Contains(err.Error(), "stopped") {
+		t.Errorf("Expected canceled call to stop, got %v", err)
+	}
+}
+
+func TestLoadWasmPluginErrors(t *testing.T) {
+	withoutAlloc := testWasmModule()
+	i := strings.Index(string(withoutAlloc), "alloc")
+	withoutAlloc[i] = 'A'
+
+	tests := []struct {
+		name      string
+		module    []byte
+		wantError string
+	}{
+		{name: "not wasm", module: []byte("#!/bin/sh\n"), wantError: "failed to compile"},
+		{name: "without alloc", module: withoutAlloc, wantError: "does not export alloc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadWasmPlugin(writeWasm(t, tt.module))
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+			}
+		})
+	}
+
+	if _, err := LoadWasmPlugin(filepath.Join(t.TempDir(), "missing.wasm")); err == nil {
+		t.Error("Expected error for missing module")
+	}
+}
@@ -0,0 +1,80 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/menta2k/templater/internal/cache"
+	templatepkg "github.com/menta2k/templater/internal/template"
+)
+
+// unreferencedEnvironmentKeys returns the keys only environment variables could set that
+// no template or helper references. Without --env-prefix, every environment variable is
+// merged into the values, and variables such as CI build IDs or timestamps would change
+// the values digest, and miss the render cache, on every run. Values passed to templates
+// as a whole keep every key, as do variables selected with --env-prefix.
+func (tp *TemplateProcessor) unreferencedEnvironmentKeys() (map[string]bool, error) {
+	if tp.config.EnvPrefix != "" || tp.config.HelmCompat || len(tp.envKeys) == 0 {
+		return nil, nil
+	}
+
+	keys, all, err := tp.referencedValueKeys()
+	if err != nil || all {
+		return nil, err
+	}
+
+	// Helpers are parsed with every template, including those of vendored packs
+	if info, err := os.Stat(tp.config.TemplateFile); err == nil && info.IsDir() {
+		ignore, err := templatepkg.LoadIgnoreRules(tp.config.TemplateFile)
+		if err != nil {
+			return nil, err
+		}
+		filter, err := templatepkg.NewPathFilter(tp.config.Include, tp.config.Exclude)
+		if err != nil {
+			return nil, err
+		}
+		helpers, err := loadHelpers(tp.config.TemplateFile, ignore, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, helper := range helpers {
+			found, all, err := templatepkg.ReferencedTopLevelKeys(helper.Name, helper.Content)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse helper %s: %w", helper.Name, err)
+			}
+			if all {
+				return nil, nil
+			}
+			keys = append(keys, found...)
+		}
+	}
+
+	skipped := make(map[string]bool, len(tp.envKeys))
+	for _, key := range tp.envKeys {
+		skipped[key] = true
+	}
+	for _, key := range keys {
+		delete(skipped, key)
+	}
+	return skipped, nil
+}
+
+// digestValues returns the values digest of the render cache, leaving out the
+// environment variables no template reads.
+func (tp *TemplateProcessor) digestValues(values map[string]any) (string, error) {
+	if len(tp.digestSkipped) > 0 {
+		digested := make(map[string]any, len(values))
+		for key, value := range values {
+			if !tp.digestSkipped[key] {
+				digested[key] = value
+			}
+		}
+		values = digested
+	}
+
+	digest, err := cache.ValuesDigest(values)
+	if err != nil {
+		return "", fmt.Errorf("error computing values digest: %w", err)
+	}
+	return digest, nil
+}
@@ -0,0 +1,118 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/menta2k/templater/internal/providers"
+	"github.com/menta2k/templater/internal/values"
+)
+
+// directoryValuesFiles are the values files picked up in template subdirectories, in
+// merge order.
+var directoryValuesFiles = []string{"values.yaml", "_values.yaml"}
+
+// valuesScope holds the values templates below a subdirectory are rendered with.
+type valuesScope struct {
+	values map[string]any
+	digest string
+	// base is values without the --set layers, which nested directories merge over
+	base map[string]any
+	// files are the directory values files merged into values, outermost first
+	files []string
+}
+
+// scopedValues returns the values and values digest for the template at relativePath:
+// the merged values, with the values files of its directory and parent directories
+// merged below the --set layers when there are any.
+func (tp *TemplateProcessor) scopedValues(relativePath string, allValues map[string]any) (map[string]any, string) {
+	if scope := tp.scopes[filepath.Dir(relativePath)]; scope != nil {
+		return scope.values, scope.digest
+	}
+	return allValues, tp.valuesDigest
+}
+
+// loadDirectoryScope prepares the values of templates in dir, relative to templateDir:
+// the values files of every directory from the outermost subdirectory down to dir are
+// merged over the values files, external sources and environment, and the --set layers
+// are applied over them again, so only --set values override them for that subtree. The
+// template directory itself is not a scope, since its values.yaml is the values file.
+// Scopes are loaded once and validated against the values schema.
+func (tp *TemplateProcessor) loadDirectoryScope(templateDir, dir string, allValues map[string]any) error {
+	if tp.config.SkipDirectoryValues || dir == "." {
+		return nil
+	}
+	if _, loaded := tp.scopes[dir]; loaded {
+		return nil
+	}
+	if tp.scopes == nil {
+		tp.scopes = make(map[string]*valuesScope)
+	}
+
+	// Start from the parent's scope, which holds the values of every outer directory
+	parentValues := tp.baseValues
+	if parentValues == nil {
+		parentValues = allValues
+	}
+	var files []string
+	if parent := filepath.Dir(dir); parent != "." {
+		if err := tp.loadDirectoryScope(templateDir, parent, allValues); err != nil {
+			return err
+		}
+		if scope := tp.scopes[parent]; scope != nil {
+			parentValues = scope.base
+			files = scope.files
+		}
+	}
+
+	var layers []values.Layer
+	for _, name := range directoryValuesFiles {
+		path := filepath.Join(templateDir, dir, name)
+		if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		dirValues, err := tp.valuesLoader.LoadYAMLValues(path)
+		if err != nil {
+			return fmt.Errorf("error loading directory values %s: %w", path, err)
+		}
+		if err := providers.ResolveReferences(dirValues); err != nil {
+			return fmt.Errorf("error resolving secret references in %s: %w", path, err)
+		}
+		layers = append(layers, values.Layer{Source: "directory values " + path, Values: dirValues})
+		files = append(files[:len(files):len(files)], path)
+	}
+
+	if len(layers) == 0 {
+		// Without values files of its own, dir shares its parent's scope
+		tp.scopes[dir] = tp.scopes[filepath.Dir(dir)]
+		return nil
+	}
+
+	base := tp.valuesLoader.MergeLayers(append([]values.Layer{{Values: parentValues}}, layers...))
+	scope := &valuesScope{
+		values: tp.valuesLoader.MergeLayers(append([]values.Layer{{Values: base}}, tp.setLayers...)),
+		base:   base,
+		files:  files,
+	}
+	if err := providers.ResolveReferences(scope.values); err != nil {
+		return fmt.Errorf("error resolving secret references: %w", err)
+	}
+	if err := tp.checkMetadataKey(scope.values); err != nil {
+		return fmt.Errorf("values for %s: %w", filepath.ToSlash(dir), err)
+	}
+	if schemaFile := tp.schemaFile(); schemaFile != "" {
+		if err := values.ValidateSchema(schemaFile, scope.values); err != nil {
+			return fmt.Errorf("values for %s: %w", filepath.ToSlash(dir), err)
+		}
+	}
+	if tp.cache != nil {
+		digest, err := tp.digestValues(scope.values)
+		if err != nil {
+			return err
+		}
+		scope.digest = digest
+	}
+	tp.scopes[dir] = scope
+	return nil
+}
@@ -0,0 +1,33 @@
+package processor
+
+import (
+	"github.com/menta2k/templater/internal/output"
+)
+
+// reportDryRun prints the files a dry run would have written, with their sizes.
+func (tp *TemplateProcessor) reportDryRun() {
+	writes := tp.dryRun.Writes()
+	counts := map[output.Change]int{}
+
+	tp.statusf("\nDry run, no files were written:\n")
+	for _, planned := range writes {
+		counts[planned.Change]++
+		switch planned.Change {
+		case output.Updated:
+			tp.statusf("  %-9s %s (%d bytes, was %d bytes)\n", planned.Change, planned.Location, planned.Size, planned.PreviousSize)
+		default:
+			tp.statusf("  %-9s %s (%d bytes)\n", planned.Change, planned.Location, planned.Size)
+		}
+	}
+	tp.statusf("%d file(s): %d to create, %d to update, %d unchanged\n",
+		len(writes), counts[output.Created], counts[output.Updated], counts[output.Unchanged])
+}
+
+// writeStatus returns the word reporting a written file in progress messages, or
+// "Would write" in a dry run, where nothing is written.
+func (tp *TemplateProcessor) writeStatus(written string) string {
+	if tp.dryRun != nil {
+		return "Would write"
+	}
+	return written
+}
@@ -0,0 +1,51 @@
+package processor
+
+import (
+	"path"
+	"path/filepath"
+
+	templatepkg "github.com/menta2k/templater/internal/template"
+)
+
+// Defaults of the Helm .Release object, as used by helm template.
+const (
+	DefaultReleaseName      = "release-name"
+	DefaultReleaseNamespace = "default"
+)
+
+// helmObjects returns the Helm builtin objects .Values, .Release, .Template and .Files
+// for the template at relativePath, so Helm chart templates render with few changes.
+func (tp *TemplateProcessor) helmObjects(relativePath string, values map[string]any) map[string]any {
+	name, namespace := tp.config.ReleaseName, tp.config.ReleaseNamespace
+	if name == "" {
+		name = DefaultReleaseName
+	}
+	if namespace == "" {
+		namespace = DefaultReleaseNamespace
+	}
+
+	// .Files follows the file functions, reading below the template directory
+	root := ""
+	if !tp.config.DisableFileFunctions {
+		root = tp.templateDir()
+	}
+
+	basePath := filepath.ToSlash(filepath.Base(tp.templateDir()))
+	return map[string]any{
+		"Values": values,
+		"Release": map[string]any{
+			"Name":      name,
+			"Namespace": namespace,
+			"Service":   "templater",
+			"IsInstall": true,
+			"IsUpgrade": false,
+			"Revision":  1,
+			"Time":      tp.started,
+		},
+		"Template": map[string]any{
+			"Name":     path.Join(basePath, filepath.ToSlash(relativePath)),
+			"BasePath": basePath,
+		},
+		"Files": templatepkg.NewFiles(root),
+	}
+}
@@ -0,0 +1,61 @@
+package processor
+
+import "sync"
+
+// memoryLimiter bounds the number of rendered bytes held in memory by concurrent workers.
+type memoryLimiter struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	max      int64
+	inFlight int64
+}
+
+// newMemoryLimiter creates a limiter for max bytes, or nil when max is not positive.
+func newMemoryLimiter(max int64) *memoryLimiter {
+	if max <= 0 {
+		return nil
+	}
+	l := &memoryLimiter{max: max}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// acquire reserves n bytes, waiting for other workers to flush their output while the
+// ceiling would be exceeded. A reservation larger than the ceiling is granted once
+// nothing else is in flight, so oversized templates still render one at a time.
+func (l *memoryLimiter) acquire(n int64) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.inFlight > 0 && l.inFlight+n > l.max {
+		l.cond.Wait()
+	}
+	l.inFlight += n
+}
+
+// grow extends a reservation once the actual rendered size is known. It never blocks,
+// since the memory is already in use.
+func (l *memoryLimiter) grow(n int64) {
+	if l == nil || n <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	l.inFlight += n
+	l.mu.Unlock()
+}
+
+// release returns n bytes to the limiter and wakes waiting workers.
+func (l *memoryLimiter) release(n int64) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	l.inFlight -= n
+	l.mu.Unlock()
+	l.cond.Broadcast()
+}
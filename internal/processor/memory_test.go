@@ -0,0 +1,70 @@
+package processor
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiterNil(t *testing.T) {
+	limiter := newMemoryLimiter(0)
+	if limiter != nil {
+		t.Fatal("Expected no limiter without a ceiling")
+	}
+
+	// A nil limiter never blocks
+	limiter.acquire(1 << 30)
+	limiter.grow(1 << 30)
+	limiter.release(1 << 30)
+}
+
+func TestMemoryLimiterCeiling(t *testing.T) {
+	limiter := newMemoryLimiter(100)
+
+	var (
+		wg      sync.WaitGroup
+		current atomic.Int64
+		peak    atomic.Int64
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limiter.acquire(40)
+			defer limiter.release(40)
+
+			now := current.Add(40)
+			for {
+				old := peak.Load()
+				if now <= old || peak.CompareAndSwap(old, now) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			current.Add(-40)
+		}()
+	}
+	wg.Wait()
+
+	if peak.Load() > 100 {
+		t.Errorf("Expected at most 100 bytes in flight, got %d", peak.Load())
+	}
+}
+
+func TestMemoryLimiterOversized(t *testing.T) {
+	limiter := newMemoryLimiter(10)
+
+	done := make(chan struct{})
+	go func() {
+		limiter.acquire(50)
+		limiter.release(50)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected an oversized reservation to be granted when nothing is in flight")
+	}
+}
@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/menta2k/templater/internal/cache"
+	templatepkg "github.com/menta2k/templater/internal/template"
+)
+
+// MetadataKey is the reserved key of the template metadata in the data of every template.
+const MetadataKey = "templater"
+
+// templateData returns the data file is executed with: its values or, with HelmCompat,
+// the Helm builtin objects, plus the template metadata under MetadataKey. Every template
+// gets a deep copy of the values, which it may change with functions such as set and
+// unset while concurrent workers render the others.
+func (tp *TemplateProcessor) templateData(file templatepkg.File, values map[string]any) map[string]any {
+	data := copyValues(values)
+	if data == nil {
+		data = map[string]any{}
+	}
+	if tp.config.HelmCompat {
+		data = tp.helmObjects(file.RelativePath, data)
+	}
+	data[MetadataKey] = tp.templateMetadata(file)
+	return data
+}
+
+// copyValues returns a deep copy of the maps and lists of values.
+func copyValues(values map[string]any) map[string]any {
+	if values == nil {
+		return nil
+	}
+	copied := make(map[string]any, len(values))
+	for key, value := range values {
+		copied[key] = copyValue(value)
+	}
+	return copied
+}
+
+// copyValue returns a deep copy of a value's maps and lists; other values are immutable
+// and shared.
+func copyValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return copyValues(v)
+	case map[any]any:
+		copied := make(map[any]any, len(v))
+		for key, item := range v {
+			copied[key] = copyValue(item)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(v))
+		for i, item := range v {
+			copied[i] = copyValue(item)
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+// checkMetadataKey reports an error when values define MetadataKey, which the template
+// metadata would hide. With HelmCompat, values live under .Values and cannot clash.
+func (tp *TemplateProcessor) checkMetadataKey(values map[string]any) error {
+	if tp.config.HelmCompat {
+		return nil
+	}
+	if _, ok := values[MetadataKey]; ok {
+		return fmt.Errorf("values define the reserved key %q, which holds the template metadata; rename the value", MetadataKey)
+	}
+	return nil
+}
+
+// templateMetadata describes the rendering of file, for "generated by" headers:
+// {{ .templater.path }} rendered by templater {{ .templater.version }}. The output is
+// empty while file names are rendered, as it is not known yet.
+func (tp *TemplateProcessor) templateMetadata(file templatepkg.File) map[string]any {
+	valuesFiles := []any{}
+	for _, path := range tp.templateValuesFiles(file.RelativePath) {
+		valuesFiles = append(valuesFiles, path)
+	}
+	return map[string]any{
+		"source":      file.SourcePath,
+		"path":        filepath.ToSlash(file.RelativePath),
+		"output":      file.OutputPath,
+		"valuesFiles": valuesFiles,
+		"version":     cache.ToolVersion(),
+	}
+}
+
+// templateValuesFiles returns the values files merged into the values of the template
+// at relativePath: the values file, the --env values file and its directory values files.
+func (tp *TemplateProcessor) templateValuesFiles(relativePath string) []string {
+	files := tp.valuesFiles
+	if scope := tp.scopes[filepath.Dir(relativePath)]; scope != nil {
+		files = append(files[:len(files):len(files)], scope.files...)
+	}
+	return files
+}
@@ -0,0 +1,146 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/menta2k/templater/internal/cache"
+	"github.com/menta2k/templater/internal/plugins"
+	templatepkg "github.com/menta2k/templater/internal/template"
+)
+
+// loadFuncPlugins loads the template functions of the configured Go plugins, Starlark
+// files, WebAssembly modules and process plugins, and exec and httpGet when commands or
+// hosts are allowed. Plugins listed later take precedence over earlier ones, Starlark
+// files over Go plugins, WebAssembly modules over both, process plugins over all three,
+// and all over exec, httpGet and the built-in functions. The Go plugin, Starlark and
+// WebAssembly files are digested for the render cache key, as they change the output.
+func (tp *TemplateProcessor) loadFuncPlugins() error {
+	pluginFuncs := template.FuncMap{}
+	var contents [][]byte
+	var names []string
+	var execAllowlist *plugins.ExecAllowlist
+	if len(tp.config.AllowExec) > 0 {
+		allowlist, err := plugins.NewExecAllowlist(tp.config.AllowExec)
+		if err != nil {
+			return err
+		}
+		if allowlist.Len() > 0 {
+			execAllowlist = allowlist
+			names = append(names, "exec")
+		}
+	}
+	var httpAllowlist *templatepkg.HTTPAllowlist
+	if len(tp.config.AllowHTTP) > 0 {
+		allowlist, err := templatepkg.NewHTTPAllowlist(tp.config.AllowHTTP)
+		if err != nil {
+			return err
+		}
+		if allowlist.Len() > 0 {
+			httpAllowlist = allowlist
+			names = append(names, "httpGet")
+		}
+	}
+
+	for _, path := range tp.config.FuncPlugins {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read function plugin %s: %w", path, err)
+		}
+		contents = append(contents, content)
+
+		goFuncs, err := plugins.LoadGoPlugin(path)
+		if err != nil {
+			return err
+		}
+		maps.Copy(pluginFuncs, goFuncs)
+	}
+
+	for _, path := range tp.config.StarlarkFiles {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read starlark file %s: %w", path, err)
+		}
+		contents = append(contents, content)
+
+		starlarkFuncs, err := templatepkg.LoadStarlark(path)
+		if err != nil {
+			return err
+		}
+		maps.Copy(pluginFuncs, starlarkFuncs)
+	}
+
+	var wasmPlugins []*plugins.WasmPlugin
+	for _, path := range tp.config.WasmPlugins {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read wasm plugin %s: %w", path, err)
+		}
+		contents = append(contents, content)
+
+		plugin, err := plugins.LoadWasmPlugin(path)
+		if err != nil {
+			return err
+		}
+		wasmPlugins = append(wasmPlugins, plugin)
+	}
+
+	var processPlugins []plugins.ProcessPlugin
+	for _, spec := range tp.config.ProcessPlugins {
+		plugin, err := plugins.ParseProcessPlugin(spec)
+		if err != nil {
+			return err
+		}
+		processPlugins = append(processPlugins, plugin)
+		names = append(names, regexp.QuoteMeta(plugin.Name))
+	}
+	if len(names) > 0 {
+		// Templates calling exec, httpGet or process plugins bypass the render cache
+		tp.pluginCalls = regexp.MustCompile(`\b(` + strings.Join(names, "|") + `)\b`)
+	}
+
+	// exec, httpGet, WebAssembly and process plugins stop once ctx is canceled
+	tp.bindFuncs = func(ctx context.Context) template.FuncMap {
+		funcs := template.FuncMap{}
+		if execAllowlist != nil {
+			funcs["exec"] = execAllowlist.Func(ctx)
+		}
+		if httpAllowlist != nil {
+			funcs["httpGet"] = httpAllowlist.Func(ctx)
+		}
+		maps.Copy(funcs, pluginFuncs)
+		for _, plugin := range wasmPlugins {
+			maps.Copy(funcs, plugin.Funcs(ctx))
+		}
+		for _, plugin := range processPlugins {
+			funcs[plugin.Name] = plugin.Func(ctx)
+		}
+		return funcs
+	}
+	tp.funcs = tp.bindFuncs(context.Background())
+	if len(contents) > 0 {
+		tp.pluginsDigest = cache.Key(contents...)
+	}
+	return nil
+}
+
+// newTemplate returns a template with the built-in and plugin functions, and env and
+// expandenv when allowed. With SortKeys the conversion functions write sorted keys.
+func (tp *TemplateProcessor) newTemplate(name string) *templatepkg.StrictTemplate {
+	st := templatepkg.NewStrictTemplate(name, tp.config.StrictMode)
+	if tp.config.SortKeys {
+		st.SortKeys()
+	}
+	if tp.config.AllowEnv {
+		st.AllowEnv(tp.config.AllowEnvPrefix)
+	}
+	if len(tp.funcs) > 0 {
+		st.Funcs(tp.funcs)
+	}
+	return st
+}
@@ -1,21 +1,52 @@
 package processor
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
 
+	"github.com/menta2k/templater/internal/cache"
 	"github.com/menta2k/templater/internal/config"
+	"github.com/menta2k/templater/internal/deps"
+	"github.com/menta2k/templater/internal/output"
+	"github.com/menta2k/templater/internal/providers"
+	"github.com/menta2k/templater/internal/retry"
+	"github.com/menta2k/templater/internal/telemetry"
 	templatepkg "github.com/menta2k/templater/internal/template"
 	"github.com/menta2k/templater/internal/values"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // TemplateProcessor handles template processing operations.
 type TemplateProcessor struct {
-	config       *config.Config
-	valuesLoader *values.Loader
+	config        *config.Config
+	valuesLoader  *values.Loader
+	writer        output.Writer
+	cache         cache.Store
+	valuesDigest  string
+	scopes        map[string]*valuesScope
+	baseValues    map[string]any
+	setLayers     []values.Layer
+	envKeys       []string
+	digestSkipped map[string]bool
+	helpers       []templatepkg.Helper
+	funcs         template.FuncMap
+	bindFuncs     func(context.Context) template.FuncMap
+	pluginsDigest string
+	pluginCalls   *regexp.Regexp
+	memory        *memoryLimiter
+	started       time.Time
+	valuesFiles   []string
+	combined      *strings.Builder
+	dryRun        *output.DryRunWriter
 }
 
 // NewTemplateProcessor creates a new template processor.
@@ -26,25 +57,116 @@ func NewTemplateProcessor(cfg *config.Config) *TemplateProcessor {
 	}
 }
 
+// SetWriter sends rendered output to w instead of writing files, for example an
+// output.MemoryWriter used with a mem:// output location. Programs outside this module use
+// the pkg/templater package.
+func (tp *TemplateProcessor) SetWriter(w output.Writer) {
+	tp.writer = w
+}
+
 // Process processes the template(s) with merged values.
 func (tp *TemplateProcessor) Process() error {
-	// Load values from YAML file
-	yamlValues, err := tp.valuesLoader.LoadYAMLValues(tp.config.ValuesFile)
-	if err != nil {
-		return fmt.Errorf("error loading YAML values: %w", err)
+	return tp.ProcessContext(context.Background())
+}
+
+// ProcessContext processes the template(s) with merged values, recording trace spans for
+// each stage as children of the span in ctx.
+func (tp *TemplateProcessor) ProcessContext(ctx context.Context) (err error) {
+	ctx, span := telemetry.Start(ctx, "templater.process", attribute.String("templater.template", tp.config.TemplateFile))
+	defer func() { telemetry.End(span, err) }()
+	tp.started = time.Now()
+
+	if err := tp.loadFuncPlugins(); err != nil {
+		return err
 	}
 
-	// Load values from environment variables
-	envValues := tp.valuesLoader.LoadEnvValues()
+	// Syntax checks need neither values nor outputs
+	if tp.config.ParseOnly {
+		return tp.parseOnly()
+	}
 
-	// Parse --set values
-	setValues, err := tp.valuesLoader.ParseSetValues(tp.config.SetValues)
+	allValues, err := tp.loadValues(ctx)
 	if err != nil {
-		return fmt.Errorf("error parsing set values: %w", err)
+		return err
+	}
+	if err := tp.checkMetadataKey(allValues); err != nil {
+		return err
+	}
+
+	// Check the merged values against the values schema before rendering anything
+	if schemaFile := tp.schemaFile(); schemaFile != "" {
+		_, schemaSpan := telemetry.Start(ctx, "templater.validate.schema", attribute.String("templater.schema", schemaFile))
+		err := values.ValidateSchema(schemaFile, allValues)
+		telemetry.End(schemaSpan, err)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The combined file replaces per-file output, which uploads and in-memory output
+	// would otherwise receive instead
+	if tp.config.CombineFile != "" && (output.IsHTTPURL(tp.config.OutputFile) || output.IsMemoryURL(tp.config.OutputFile)) {
+		return fmt.Errorf("--combine writes all templates to a single file and cannot be used with output %s", tp.config.OutputFile)
 	}
 
-	// Merge all values (--set values have highest precedence)
-	allValues := tp.valuesLoader.MergeValues(yamlValues, envValues, setValues, tp.config.Values)
+	// A dry run compares rendered files with the files on disk, which remote and
+	// standard output locations have none of
+	if tp.config.DryRun {
+		if tp.config.CombineFile == "" && (output.IsHTTPURL(tp.config.OutputFile) || output.IsStdout(tp.config.OutputFile)) {
+			return fmt.Errorf("--dry-run compares rendered files with files on disk and cannot be used with output %s", tp.config.OutputFile)
+		}
+		tp.dryRun = output.NewDryRunWriter()
+	}
+
+	// Send rendered output to the caller's writer, an HTTP endpoint or files
+	switch {
+	case tp.writer != nil:
+		// A writer chosen through SetWriter takes precedence
+	case output.IsHTTPURL(tp.config.OutputFile):
+		tp.writer, err = output.NewHTTPWriter(tp.config.OutputMethod, tp.config.OutputHeaders, tp.config.OutputRetries)
+		if err != nil {
+			return fmt.Errorf("error configuring HTTP output: %w", err)
+		}
+	case output.IsMemoryURL(tp.config.OutputFile):
+		return fmt.Errorf("output %s is kept in memory and requires a writer set with SetWriter", tp.config.OutputFile)
+	case tp.config.CombineFile != "":
+		tp.combined = &strings.Builder{}
+		tp.writer = output.NewStreamWriter(tp.combined)
+	case tp.dryRun != nil:
+		tp.writer = tp.dryRun
+	case output.IsStdout(tp.config.OutputFile):
+		tp.writer = output.NewStreamWriter(os.Stdout)
+	}
+
+	// Reuse rendered outputs for unchanged templates and values, as long as the build
+	// rendering them can be identified
+	if (tp.config.CacheDir != "" || tp.config.RemoteCache != "") && cache.BuildKey() != "" {
+		tp.digestSkipped, err = tp.unreferencedEnvironmentKeys()
+		if err != nil {
+			return err
+		}
+		tp.valuesDigest, err = tp.digestValues(allValues)
+		if err != nil {
+			return err
+		}
+		tp.cache, err = tp.newCacheStore()
+		if err != nil {
+			return fmt.Errorf("error configuring render cache: %w", err)
+		}
+	}
+
+	// Report every undefined reference up front instead of failing on the first one
+	if tp.config.StaticCheck {
+		_, validateSpan := telemetry.Start(ctx, "templater.validate")
+		err := tp.staticCheck(allValues)
+		telemetry.End(validateSpan, err)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Bound the rendered output held in memory by concurrent workers
+	tp.memory = newMemoryLimiter(tp.config.MaxMemory)
 
 	// Check if template is a directory or file
 	fileInfo, err := os.Stat(tp.config.TemplateFile)
@@ -54,17 +176,356 @@ func (tp *TemplateProcessor) Process() error {
 
 	if fileInfo.IsDir() {
 		// Process directory of templates
-		return tp.processDirectory(allValues)
+		err = tp.processDirectory(ctx, allValues)
 	} else {
 		// Process single template file
-		return tp.processSingleFile(allValues)
+		err = tp.processSingleFile(ctx, allValues)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Write the combined file only once every template rendered
+	if tp.combined != nil {
+		if tp.dryRun != nil {
+			err = tp.dryRun.Write(tp.config.CombineFile, []byte(tp.combined.String()))
+		} else {
+			err = tp.writeFile(tp.config.CombineFile, tp.combined.String())
+		}
+		if err != nil {
+			return err
+		}
+		if tp.dryRun == nil {
+			tp.statusf("Combined output written to: %s\n", tp.config.CombineFile)
+		}
+	}
+
+	if tp.dryRun != nil {
+		tp.reportDryRun()
+	}
+	return nil
+}
+
+// loadValues loads and merges the values from every configured source.
+func (tp *TemplateProcessor) loadValues(ctx context.Context) (_ map[string]any, err error) {
+	_, span := telemetry.Start(ctx, "templater.values.load", attribute.Int("templater.values_from", len(tp.config.ValuesFrom)))
+	defer func() { telemetry.End(span, err) }()
+
+	baseLayers, err := tp.loadBaseLayers()
+	if err != nil {
+		return nil, err
+	}
+	setLayers, err := tp.loadSetLayers()
+	if err != nil {
+		return nil, err
+	}
+
+	// Replace secret references such as gcp-sm://... with the secret payloads. The values
+	// below the --set layers are kept, since directory values files are merged over them
+	// before the --set layers are applied again
+	baseValues := tp.valuesLoader.MergeLayers(baseLayers)
+	if err := providers.ResolveReferences(baseValues); err != nil {
+		return nil, fmt.Errorf("error resolving secret references: %w", err)
+	}
+	tp.baseValues, tp.setLayers = baseValues, setLayers
+
+	allValues := tp.valuesLoader.MergeLayers(append([]values.Layer{{Values: baseValues}}, setLayers...))
+	if err := providers.ResolveReferences(allValues); err != nil {
+		return nil, fmt.Errorf("error resolving secret references: %w", err)
+	}
+
+	return allValues, nil
+}
+
+// LoadValues returns the merged values templates would be rendered with, including
+// resolved secret references, without rendering anything.
+func (tp *TemplateProcessor) LoadValues() (map[string]any, error) {
+	return tp.loadValues(context.Background())
+}
+
+// ExplainValues loads the values like a render would and reports which source each
+// merged value came from and which values it shadows. Secret references are reported
+// as written, without being resolved.
+func (tp *TemplateProcessor) ExplainValues() ([]values.Provenance, error) {
+	layers, err := tp.loadValueLayers()
+	if err != nil {
+		return nil, err
+	}
+	return values.Explain(layers, tp.valuesLoader.MergeLayers(layers)), nil
+}
+
+// loadValueLayers loads the values of every configured source, ordered from lowest to
+// highest precedence: the values file, external sources, dotenv files, environment
+// variables, then --set-json, --set, --set-string and --set-file values.
+func (tp *TemplateProcessor) loadValueLayers() ([]values.Layer, error) {
+	layers, err := tp.loadBaseLayers()
+	if err != nil {
+		return nil, err
+	}
+	setLayers, err := tp.loadSetLayers()
+	if err != nil {
+		return nil, err
 	}
+	return append(layers, setLayers...), nil
+}
+
+// loadBaseLayers loads the values of the sources below the --set flags: the values
+// file, external sources, dotenv files and environment variables.
+func (tp *TemplateProcessor) loadBaseLayers() ([]values.Layer, error) {
+	// Configure how lists present in several sources are merged
+	strategies, err := values.ParseMergeStrategies(tp.config.MergeStrategies)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing merge strategies: %w", err)
+	}
+	tp.valuesLoader.SetMergeStrategies(strategies)
+	tp.valuesLoader.SetPreciseNumbers(tp.config.PreciseNumbers)
+
+	// Load age identities used to decrypt !age values
+	if len(tp.config.AgeIdentities) > 0 {
+		identities, err := values.LoadAgeIdentities(tp.config.AgeIdentities)
+		if err != nil {
+			return nil, fmt.Errorf("error loading age identities: %w", err)
+		}
+		tp.valuesLoader.SetAgeIdentities(identities)
+	}
+
+	// Load values from the values file, then from the --env values file over it
+	valuesFile := tp.valuesFile()
+	yamlValues, err := tp.loadYAMLValues(valuesFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading YAML values: %w", err)
+	}
+	layers := []values.Layer{{Source: "values file " + valuesFile, Values: yamlValues}}
+	tp.valuesFiles = nil
+	if valuesFile != "" {
+		tp.valuesFiles = append(tp.valuesFiles, valuesFile)
+	}
+
+	if envFile := tp.environmentValuesFile(); envFile != "" {
+		tp.valuesFiles = append(tp.valuesFiles, envFile)
+		envFileValues, err := tp.loadYAMLValues(envFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading YAML values for environment %s: %w", tp.config.Environment, err)
+		}
+		layers = append(layers, values.Layer{Source: "values file " + envFile, Values: envFileValues})
+
+		// Templated values in either file may reference values of the other
+		if tp.config.TemplateValues {
+			layers = []values.Layer{{
+				Source: "values files " + valuesFile + ", " + envFile,
+				Values: tp.valuesLoader.MergeLayers(layers),
+			}}
+		}
+	}
+
+	// Render {{ }} expressions in the values file, which may reference its own values
+	if tp.config.TemplateValues {
+		if err := templatepkg.RenderValues(layers[0].Values, tp.config.StrictMode, tp.funcs); err != nil {
+			return nil, fmt.Errorf("error rendering templated values: %w", err)
+		}
+	}
+
+	// Load values from external sources such as SSM concurrently (override the YAML file)
+	if len(tp.config.ValuesFrom) > 0 {
+		sources, err := providers.LoadAll(tp.config.ValuesFrom, tp.config.ValuesFromTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("error loading values sources: %w", err)
+		}
+		for i, sourceValues := range sources {
+			layers = append(layers, values.Layer{Source: "values-from " + tp.config.ValuesFrom[i], Values: sourceValues})
+		}
+	}
+
+	// Load values from dotenv files (override the YAML file)
+	for _, envFile := range tp.config.EnvFiles {
+		envFileValues, err := tp.valuesLoader.LoadEnvFiles([]string{envFile})
+		if err != nil {
+			return nil, fmt.Errorf("error loading env files: %w", err)
+		}
+		layers = append(layers, values.Layer{Source: "env file " + envFile, Values: envFileValues})
+	}
+
+	// Load values from environment variables, optionally limited to a prefix
+	envValues, envNames := tp.valuesLoader.LoadPrefixedEnvValuesWithNames(tp.config.EnvPrefix)
+	envOrigins := make(map[string]string, len(envNames))
+	for key, name := range envNames {
+		envOrigins[key] = "env var " + name
+	}
+	tp.envKeys = tp.envKeys[:0]
+	for key := range envValues {
+		tp.envKeys = append(tp.envKeys, key)
+	}
+	return append(layers, values.Layer{Source: "environment", Values: envValues, Origins: envOrigins}), nil
+}
+
+// loadSetLayers parses the --set-json, --set, --set-string and --set-file values and the
+// values given in the config, which override every other source.
+func (tp *TemplateProcessor) loadSetLayers() ([]values.Layer, error) {
+	// Parse --set-json values (applied before the other --set flags)
+	setJSONValues, err := tp.valuesLoader.ParseSetJSONValues(tp.config.SetJSON)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing set-json values: %w", err)
+	}
+
+	// Parse --set values
+	setValues, err := tp.valuesLoader.ParseSetValues(tp.config.SetValues)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing set values: %w", err)
+	}
+
+	// Parse --set-string values (never type-converted, applied after --set)
+	setStringValues, err := tp.valuesLoader.ParseSetStringValues(tp.config.SetStrings)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing set-string values: %w", err)
+	}
+
+	// Parse --set-file values (file contents, applied last)
+	setFileValues, err := tp.valuesLoader.ParseSetFileValues(tp.config.SetFiles)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing set-file values: %w", err)
+	}
+
+	// --set values have the highest precedence
+	return []values.Layer{
+		{Source: "--set-json", Values: setJSONValues, Unset: true},
+		{Source: "--set", Values: setValues, Unset: true},
+		{Source: "--set-string", Values: setStringValues},
+		{Source: "--set-file", Values: setFileValues},
+		{Source: "config", Values: tp.config.Values},
+	}, nil
+}
+
+// loadYAMLValues loads a values file. With lazy values, only the top-level keys the
+// templates reference are decoded, unless a template uses the values as a whole.
+func (tp *TemplateProcessor) loadYAMLValues(path string) (map[string]any, error) {
+	// Schema validation needs every value, e.g. to check required properties, templated
+	// values may reference any other value, and Helm templates reference values below
+	// .Values rather than at the top level
+	if !tp.config.LazyValues || path == "" || tp.schemaFile() != "" || tp.config.TemplateValues || tp.config.HelmCompat {
+		return tp.valuesLoader.LoadYAMLValues(path)
+	}
+
+	keys, all, err := tp.referencedValueKeys()
+	if err != nil {
+		return nil, err
+	}
+	if all {
+		return tp.valuesLoader.LoadYAMLValues(path)
+	}
+	return tp.valuesLoader.LoadYAMLValuesSubset(path, keys)
+}
+
+// valuesFile returns the values file: the --values file or, with --env, the values.yaml
+// in the template directory when there is one.
+func (tp *TemplateProcessor) valuesFile() string {
+	if tp.config.ValuesFile != "" || tp.config.Environment == "" {
+		return tp.config.ValuesFile
+	}
+	path := filepath.Join(tp.templateDir(), "values.yaml")
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	return path
+}
+
+// environmentValuesFile returns the values file of the --env environment, named after
+// the values file with the environment before its extension (values.prod.yaml next to
+// values.yaml, values.prod.yaml.age next to values.yaml.age), or "" when there is none.
+func (tp *TemplateProcessor) environmentValuesFile() string {
+	if tp.config.Environment == "" {
+		return ""
+	}
+
+	base := tp.valuesFile()
+	if base == "" {
+		base = filepath.Join(tp.templateDir(), "values.yaml")
+	}
+	ext := filepath.Ext(base)
+	if values.IsAgeFile(base) {
+		// Keep the environment before the YAML extension: values.prod.yaml.age
+		ext = filepath.Ext(strings.TrimSuffix(base, ext)) + ext
+	}
+	path := strings.TrimSuffix(base, ext) + "." + tp.config.Environment + ext
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	return path
+}
+
+// templateDir returns the template directory, or the directory of a single template.
+func (tp *TemplateProcessor) templateDir() string {
+	if info, err := os.Stat(tp.config.TemplateFile); err == nil && info.IsDir() {
+		return tp.config.TemplateFile
+	}
+	return filepath.Dir(tp.config.TemplateFile)
+}
+
+// uncacheable reports whether the template, or a helper it may include, can call
+// functions whose output depends on more than the template and values: the file
+// functions and .Files, which read files, env and expandenv when allowed, httpGet, which
+// fetches URLs, exec and process plugins, which run programs, and functions such as now,
+// randAlphaNum or genCA and .Release.Time, which change on every render.
+func (tp *TemplateProcessor) uncacheable(templateContent string) bool {
+	sources := []string{templateContent}
+	for _, helper := range tp.helpers {
+		sources = append(sources, helper.Content)
+	}
+	for _, source := range sources {
+		if !tp.config.DisableFileFunctions && templatepkg.UsesFileFunctions(source) {
+			return true
+		}
+		if tp.config.HelmCompat && !tp.config.DisableFileFunctions && templatepkg.UsesFilesObject(source) {
+			return true
+		}
+		if tp.config.AllowEnv && templatepkg.UsesEnvFunctions(source) {
+			return true
+		}
+		if tp.pluginCalls != nil && tp.pluginCalls.MatchString(source) {
+			return true
+		}
+		if templatepkg.UsesNondeterministicFunctions(source) {
+			return true
+		}
+		if tp.config.HelmCompat && templatepkg.UsesReleaseTime(source) {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaFile returns the values schema to validate against: the --schema file, or the
+// values.schema.json next to the values file.
+func (tp *TemplateProcessor) schemaFile() string {
+	if tp.config.SkipSchema {
+		return ""
+	}
+	if tp.config.SchemaFile != "" {
+		return tp.config.SchemaFile
+	}
+	return values.FindSchema(tp.valuesFile())
+}
+
+// newCacheStore creates the configured render cache, layering the local cache under the remote one.
+func (tp *TemplateProcessor) newCacheStore() (cache.Store, error) {
+	var local cache.Store
+	if tp.config.CacheDir != "" {
+		local = cache.New(tp.config.CacheDir)
+	}
+	if tp.config.RemoteCache == "" {
+		return local, nil
+	}
+
+	remote, err := cache.NewRemoteStore(tp.config.RemoteCache, tp.config.RemoteCacheHeaders)
+	if err != nil {
+		return nil, err
+	}
+	return &cache.Tiered{Local: local, Remote: remote}, nil
 }
 
 // processTemplatePath processes a path that may contain template variables.
-func (tp *TemplateProcessor) processTemplatePath(pathTemplate string, allValues map[string]any) (string, error) {
+func (tp *TemplateProcessor) processTemplatePath(pathTemplate string, data any) (string, error) {
 	// Create strict template wrapper for path processing
-	strictTemplate := templatepkg.NewStrictTemplate("path", tp.config.StrictMode)
+	strictTemplate := tp.newTemplate("path")
 
 	// Parse path template
 	parsedTemplate, err := strictTemplate.ParseTemplate(pathTemplate)
@@ -73,7 +534,7 @@ func (tp *TemplateProcessor) processTemplatePath(pathTemplate string, allValues
 	}
 
 	// Execute path template with strict mode support
-	result, err := parsedTemplate.ExecuteTemplate(allValues)
+	result, err := parsedTemplate.ExecuteTemplate(data)
 	if err != nil {
 		// Check if it's a strict mode error
 		var strictErr *templatepkg.StrictModeError
@@ -87,38 +548,93 @@ func (tp *TemplateProcessor) processTemplatePath(pathTemplate string, allValues
 }
 
 // findTemplateFiles recursively finds all *.tpl files in a directory.
-func (tp *TemplateProcessor) findTemplateFiles(templateDir, outputDir string, allValues map[string]any) ([]templatepkg.File, error) {
-	var templateFiles []templatepkg.File
+func (tp *TemplateProcessor) findTemplateFiles(ctx context.Context, templateDir, outputDir string, allValues map[string]any) (templateFiles []templatepkg.File, err error) {
+	_, span := telemetry.Start(ctx, "templater.discover", attribute.String("templater.template_dir", templateDir))
+	defer func() {
+		span.SetAttributes(attribute.Int("templater.templates", len(templateFiles)))
+		telemetry.End(span, err)
+	}()
 
-	err := filepath.Walk(templateDir, func(path string, info os.FileInfo, err error) error {
+	ignore, err := templatepkg.LoadIgnoreRules(templateDir)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := templatepkg.NewPathFilter(tp.config.Include, tp.config.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.Walk(templateDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// Check if file has .tpl extension
-		if !info.IsDir() && strings.HasSuffix(strings.ToLower(info.Name()), ".tpl") {
-			// Calculate relative path from template directory
-			relativePath, err := filepath.Rel(templateDir, path)
-			if err != nil {
-				return fmt.Errorf("failed to calculate relative path: %w", err)
+		// Vendored packs are libraries for the templates, not output to render
+		if info.IsDir() && deps.IsVendorDir(templateDir, path) {
+			return filepath.SkipDir
+		}
+		// Paths listed in .templaterignore or filtered out are left out, with everything
+		// below them
+		if isExcluded(ignore, filter, templateDir, path, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
 			}
-
-			// Process the relative path as a template to handle templated directory names
-			processedRelativePath, err := tp.processTemplatePath(relativePath, allValues)
-			if err != nil {
-				return fmt.Errorf("failed to process path template '%s': %w", relativePath, err)
+			return nil
+		}
+		if info.IsDir() {
+			// Outputs below the template directory are not copied into themselves
+			if tp.config.CopyStatic && path != templateDir && isOutputDir(path, outputDir) {
+				return filepath.SkipDir
 			}
+			return nil
+		}
+
+		// Check if file has .tpl extension, or is copied verbatim
+		isTemplate := strings.HasSuffix(strings.ToLower(info.Name()), ".tpl")
+		if !isTemplate && !tp.config.CopyStatic {
+			return nil
+		}
+
+		// Calculate relative path from template directory
+		relativePath, err := filepath.Rel(templateDir, path)
+		if err != nil {
+			return fmt.Errorf("failed to calculate relative path: %w", err)
+		}
+
+		// Helpers only hold definitions for the other templates
+		if isTemplate && templatepkg.IsHelper(relativePath) {
+			return nil
+		}
+		// Values and schema files configure rendering rather than being part of the tree
+		if !isTemplate && !tp.isStaticFile(path, relativePath) {
+			return nil
+		}
 
-			// Create output path by replacing .tpl extension and joining with output directory
-			outputName := strings.TrimSuffix(processedRelativePath, ".tpl")
-			outputPath := filepath.Join(outputDir, outputName)
+		// Merge the values files of the template's directories over the values
+		if err := tp.loadDirectoryScope(templateDir, filepath.Dir(relativePath), allValues); err != nil {
+			return err
+		}
+		scopedValues, _ := tp.scopedValues(relativePath, allValues)
+
+		// Process the relative path as a template to handle templated directory names
+		processedRelativePath, err := tp.processTemplatePath(relativePath, tp.templateData(templatepkg.File{SourcePath: path, RelativePath: relativePath}, scopedValues))
+		if err != nil {
+			return fmt.Errorf("failed to process path template '%s': %w", relativePath, err)
+		}
 
-			templateFiles = append(templateFiles, templatepkg.File{
-				SourcePath:   path,
-				RelativePath: relativePath,
-				OutputPath:   outputPath,
-			})
+		// Create output path by replacing .tpl extension and joining with output directory
+		outputName := processedRelativePath
+		if isTemplate {
+			outputName = strings.TrimSuffix(processedRelativePath, ".tpl")
 		}
+		outputPath := joinOutputPath(outputDir, outputName)
+
+		templateFiles = append(templateFiles, templatepkg.File{
+			SourcePath:   path,
+			RelativePath: relativePath,
+			OutputPath:   outputPath,
+			Static:       !isTemplate,
+		})
 
 		return nil
 	})
@@ -129,6 +645,72 @@ func (tp *TemplateProcessor) findTemplateFiles(templateDir, outputDir string, al
 	return templateFiles, nil
 }
 
+// loadHelpers reads the helper templates (_*.tpl) anywhere in the template tree,
+// including vendored packs, whose helpers are libraries for the templates. Vendored
+// helpers are parsed first and the others in path order, so the tree's own definitions
+// take precedence over those of its packs. Helpers listed in .templaterignore or left out
+// by --include and --exclude are skipped, like templates.
+func loadHelpers(templateDir string, ignore *templatepkg.IgnoreRules, filter *templatepkg.PathFilter) ([]templatepkg.Helper, error) {
+	var vendored, helpers []templatepkg.Helper
+	err := filepath.Walk(templateDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if isExcluded(ignore, filter, templateDir, path, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || !templatepkg.IsHelper(path) {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(templateDir, path)
+		if err != nil {
+			return fmt.Errorf("failed to calculate relative path: %w", err)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read helper template: %w", err)
+		}
+
+		helper := templatepkg.Helper{Name: filepath.ToSlash(relativePath), Content: string(content)}
+		if first, _, _ := strings.Cut(helper.Name, "/"); first == deps.VendorDir && deps.IsVendorDir(templateDir, filepath.Join(templateDir, first)) {
+			vendored = append(vendored, helper)
+		} else {
+			helpers = append(helpers, helper)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error loading helper templates: %w", err)
+	}
+	return append(vendored, helpers...), nil
+}
+
+// isExcluded reports whether the file or directory at path is listed in the
+// .templaterignore file of templateDir or left out by --include and --exclude.
+func isExcluded(ignore *templatepkg.IgnoreRules, filter *templatepkg.PathFilter, templateDir, path string, isDir bool) bool {
+	if path == templateDir {
+		return false
+	}
+	relativePath, err := filepath.Rel(templateDir, path)
+	if err != nil {
+		return false
+	}
+	return ignore.Ignored(relativePath, isDir) || filter.Skipped(relativePath, isDir)
+}
+
+// joinOutputPath joins a rendered relative path onto the output directory, base URL or -
+// for standard output.
+func joinOutputPath(outputDir, outputName string) string {
+	if output.IsHTTPURL(outputDir) || output.IsMemoryURL(outputDir) || output.IsStdout(outputDir) {
+		return output.JoinURL(outputDir, outputName)
+	}
+	return filepath.Join(outputDir, outputName)
+}
+
 // ensureOutputDir creates the output directory structure for a file.
 func (tp *TemplateProcessor) ensureOutputDir(outputPath string) error {
 	outputDir := filepath.Dir(outputPath)
@@ -136,15 +718,80 @@ func (tp *TemplateProcessor) ensureOutputDir(outputPath string) error {
 }
 
 // processTemplateFile processes a single template file.
-func (tp *TemplateProcessor) processTemplateFile(templateFile templatepkg.File, allValues map[string]any) error {
+func (tp *TemplateProcessor) processTemplateFile(ctx context.Context, templateFile templatepkg.File, allValues map[string]any) (err error) {
+	if templateFile.Static {
+		return tp.copyStaticFile(ctx, templateFile)
+	}
+
+	ctx, span := telemetry.Start(ctx, "templater.render",
+		attribute.String("templater.template", templateFile.RelativePath),
+		attribute.String("templater.output", templateFile.OutputPath),
+	)
+	defer func() { telemetry.End(span, err) }()
+
+	allValues, valuesDigest := tp.scopedValues(templateFile.RelativePath, allValues)
+
 	// Load and parse template
 	templateContent, err := os.ReadFile(templateFile.SourcePath)
 	if err != nil {
 		return fmt.Errorf("failed to read template file %s: %w", templateFile.SourcePath, err)
 	}
 
-	// Create strict template wrapper
-	strictTemplate := templatepkg.NewStrictTemplate(filepath.Base(templateFile.SourcePath), tp.config.StrictMode)
+	// Reserve memory for the output, estimated from the template size until it is rendered
+	reserved := int64(len(templateContent))
+	tp.memory.acquire(reserved)
+	defer func() { tp.memory.release(reserved) }()
+
+	// Restore the output from the render cache when the inputs are unchanged
+	// Templates reading other files are rendered every time, as the key misses those files
+	var cacheKey string
+	if tp.cache != nil && !tp.uncacheable(string(templateContent)) {
+		parts := [][]byte{
+			[]byte(cache.BuildKey()),
+			[]byte(fmt.Sprint(tp.config.StrictMode, tp.config.SortKeys)),
+			templateContent,
+			[]byte(valuesDigest),
+			[]byte(tp.pluginsDigest),
+		}
+		for _, helper := range tp.helpers {
+			parts = append(parts, []byte(helper.Name), []byte(helper.Content))
+		}
+		// The template metadata, and with Helm compatibility .Release, are part of the data
+		parts = append(parts, []byte(templateFile.RelativePath), []byte(templateFile.OutputPath), []byte(strings.Join(tp.templateValuesFiles(templateFile.RelativePath), "\x00")))
+		if tp.config.HelmCompat {
+			parts = append(parts, []byte(tp.config.ReleaseName), []byte(tp.config.ReleaseNamespace))
+		}
+		cacheKey = cache.Key(parts...)
+		cached, ok, err := tp.cache.Get(cacheKey)
+		if err != nil {
+			// Fall back to rendering when the cache is unavailable
+			tp.statusf("Warning: %v\n", err)
+		}
+		span.SetAttributes(attribute.Bool("templater.cache_hit", ok))
+		if ok {
+			if err := tp.writeOutput(ctx, templateFile.OutputPath, string(cached)); err != nil {
+				return err
+			}
+			tp.statusf("%s: %s -> %s (cached)\n", tp.writeStatus("Restored"), templateFile.RelativePath, templateFile.OutputPath)
+			return nil
+		}
+	}
+
+	// Create strict template wrapper. Its exec, httpGet, WebAssembly and process plugin
+	// calls are canceled when this render returns, so an execution abandoned after
+	// RenderTimeout stops them
+	renderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	strictTemplate := tp.newTemplate(filepath.Base(templateFile.SourcePath))
+	if tp.bindFuncs != nil {
+		strictTemplate.Funcs(tp.bindFuncs(renderCtx))
+	}
+	if err := strictTemplate.ParseHelpers(tp.helpers); err != nil {
+		return err
+	}
+	if !tp.config.DisableFileFunctions {
+		strictTemplate.SetFileRoot(tp.templateDir())
+	}
 
 	// Parse template content
 	parsedTemplate, err := strictTemplate.ParseTemplate(string(templateContent))
@@ -152,83 +799,245 @@ func (tp *TemplateProcessor) processTemplateFile(templateFile templatepkg.File,
 		return fmt.Errorf("failed to parse template %s: %w", templateFile.SourcePath, err)
 	}
 
-	// Ensure output directory exists
-	err = tp.ensureOutputDir(templateFile.OutputPath)
-	if err != nil {
-		return fmt.Errorf("failed to create output directory for %s: %w", templateFile.OutputPath, err)
-	}
-
-	// Execute template with strict mode support
-	result, err := parsedTemplate.ExecuteTemplate(allValues)
+	// Execute template with strict mode support, isolated from panics and stuck executions
+	result, err := parsedTemplate.ExecuteIsolated(tp.templateData(templateFile, allValues), tp.config.RenderTimeout)
 	if err != nil {
 		// Check if it's a strict mode error
 		var strictErr *templatepkg.StrictModeError
 		if errors.As(err, &strictErr) {
 			return fmt.Errorf("strict mode error in %s: %s", templateFile.SourcePath, strictErr.Error())
 		}
+		// Attribute fail messages to the template, or the helper, that called fail
+		var failErr *templatepkg.FailError
+		if errors.As(err, &failErr) {
+			if failErr.Template == filepath.Base(templateFile.SourcePath) {
+				failErr.Template = templateFile.SourcePath
+			} else {
+				failErr.Template = filepath.Join(tp.templateDir(), filepath.FromSlash(failErr.Template))
+			}
+			return failErr
+		}
 		return fmt.Errorf("failed to execute template %s: %w", templateFile.SourcePath, err)
 	}
 
-	// Create output file
-	outputFile, err := os.Create(templateFile.OutputPath)
+	if rendered := int64(len(result)); rendered > reserved {
+		tp.memory.grow(rendered - reserved)
+		reserved = rendered
+	}
+
+	err = tp.writeOutput(ctx, templateFile.OutputPath, result)
 	if err != nil {
-		return fmt.Errorf("failed to create output file %s: %w", templateFile.OutputPath, err)
+		return err
+	}
+
+	if cacheKey != "" && tp.dryRun == nil {
+		// A cache failure only costs a re-render next time
+		if err := tp.cache.Put(cacheKey, []byte(result)); err != nil {
+			tp.statusf("Warning: %v\n", err)
+		}
 	}
-	defer outputFile.Close()
 
-	// Write result to file
-	_, err = outputFile.WriteString(result)
+	tp.statusf("%s: %s -> %s\n", tp.writeStatus("Processed"), templateFile.RelativePath, templateFile.OutputPath)
+	return nil
+}
+
+// outputLocation returns the output file, directory or URL, or - when rendered files are
+// printed to standard output or combined into one file.
+func (tp *TemplateProcessor) outputLocation() string {
+	if tp.config.CombineFile != "" {
+		return output.Stdout
+	}
+	return tp.config.OutputFile
+}
+
+// statusf prints a progress message, to standard error when rendered files are printed
+// to standard output.
+func (tp *TemplateProcessor) statusf(format string, args ...any) {
+	w := os.Stdout
+	if output.IsStdout(tp.config.OutputFile) {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, format, args...)
+}
+
+// writeOutput persists rendered content to disk or to the configured output writer.
+func (tp *TemplateProcessor) writeOutput(ctx context.Context, outputPath, content string) (err error) {
+	_, span := telemetry.Start(ctx, "templater.write",
+		attribute.String("templater.output", outputPath),
+		attribute.Int("templater.bytes", len(content)),
+	)
+	defer func() { telemetry.End(span, err) }()
+
+	if tp.writer != nil {
+		return tp.writer.Write(outputPath, []byte(content))
+	}
+	return tp.writeFile(outputPath, content)
+}
+
+// writeFile writes content to the file at outputPath, creating its directory.
+func (tp *TemplateProcessor) writeFile(outputPath, content string) error {
+	// Ensure output directory exists
+	err := tp.ensureOutputDir(outputPath)
 	if err != nil {
-		return fmt.Errorf("failed to write output file %s: %w", templateFile.OutputPath, err)
+		return fmt.Errorf("failed to create output directory for %s: %w", outputPath, err)
+	}
+
+	// Retry transient failures such as EAGAIN on network file systems
+	return retry.Do(retry.Default, func() error {
+		return createFile(outputPath, content)
+	}, retry.IsTransientIOError)
+}
+
+// createFile creates or truncates the output file and writes content to it.
+func createFile(outputPath, content string) error {
+	outputFile, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file %s: %w", outputPath, err)
+	}
+
+	_, err = outputFile.WriteString(content)
+	if closeErr := outputFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write output file %s: %w", outputPath, err)
 	}
 
-	fmt.Printf("Processed: %s -> %s\n", templateFile.RelativePath, templateFile.OutputPath)
 	return nil
 }
 
 // processDirectory processes all *.tpl files in a directory recursively.
-func (tp *TemplateProcessor) processDirectory(allValues map[string]any) error {
+func (tp *TemplateProcessor) processDirectory(ctx context.Context, allValues map[string]any) error {
 	templateDir := tp.config.TemplateFile
-	outputDir := tp.config.OutputFile
+	outputDir := tp.outputLocation()
+
+	if tp.config.CopyStatic && output.IsStdout(outputDir) {
+		return fmt.Errorf("--copy-static copies files into an output tree and cannot be used with standard output or --combine")
+	}
+
+	// Share the define blocks of helper templates (_helpers.tpl) with every template
+	ignore, err := templatepkg.LoadIgnoreRules(templateDir)
+	if err != nil {
+		return err
+	}
+	filter, err := templatepkg.NewPathFilter(tp.config.Include, tp.config.Exclude)
+	if err != nil {
+		return err
+	}
+	helpers, err := loadHelpers(templateDir, ignore, filter)
+	if err != nil {
+		return err
+	}
+	tp.helpers = helpers
 
 	// Find all template files (now with templated path processing)
-	templateFiles, err := tp.findTemplateFiles(templateDir, outputDir, allValues)
+	templateFiles, err := tp.findTemplateFiles(ctx, templateDir, outputDir, allValues)
 	if err != nil {
 		return err
 	}
 
-	if len(templateFiles) == 0 {
-		fmt.Printf("No *.tpl files found in directory: %s\n", templateDir)
+	statics := countStatic(templateFiles)
+	templates := len(templateFiles) - statics
+	if templates == 0 {
+		// An empty tree usually means a misconfigured path, which CI may want to catch
+		if tp.config.FailOnEmpty {
+			return fmt.Errorf("no *.tpl files found in directory: %s", templateDir)
+		}
+		tp.statusf("No *.tpl files found in directory: %s\n", templateDir)
+		if statics == 0 {
+			return nil
+		}
+	} else {
+		tp.statusf("Found %d template file(s) in directory: %s\n", templates, templateDir)
+	}
+	if statics > 0 {
+		tp.statusf("Found %d static file(s) to copy\n", statics)
+	}
+
+	// Process each template file
+	err = tp.processTemplateFiles(ctx, templateFiles, allValues)
+	if err != nil {
+		return err
+	}
+
+	if tp.combined != nil || tp.dryRun != nil {
+		// The combined file and planned writes are reported once every template rendered
+		tp.statusf("\nSuccessfully processed %d template file(s).\n", templates)
 		return nil
 	}
+	tp.statusf("\nSuccessfully processed %d template file(s). Output directory: %s\n", templates, outputDir)
+	return nil
+}
 
-	fmt.Printf("Found %d template file(s) in directory: %s\n", len(templateFiles), templateDir)
+// processTemplateFiles processes template files with the configured number of workers.
+// Each output is written as soon as it is rendered, releasing its memory reservation.
+func (tp *TemplateProcessor) processTemplateFiles(ctx context.Context, templateFiles []templatepkg.File, allValues map[string]any) error {
+	workers := tp.config.Workers
+	if workers > len(templateFiles) {
+		workers = len(templateFiles)
+	}
+	// Documents printed to standard output keep the order of the templates
+	if workers <= 1 || output.IsStdout(tp.outputLocation()) {
+		for _, templateFile := range templateFiles {
+			if err := tp.processTemplateFile(ctx, templateFile, allValues); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+		failed   atomic.Bool
+		jobs     = make(chan templatepkg.File)
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for templateFile := range jobs {
+				// Drain remaining jobs without rendering once a template has failed
+				if failed.Load() {
+					continue
+				}
+				if err := tp.processTemplateFile(ctx, templateFile, allValues); err != nil {
+					failed.Store(true)
+					errOnce.Do(func() { firstErr = err })
+				}
+			}
+		}()
+	}
 
-	// Process each template file
 	for _, templateFile := range templateFiles {
-		err := tp.processTemplateFile(templateFile, allValues)
-		if err != nil {
-			return err
-		}
+		jobs <- templateFile
 	}
+	close(jobs)
+	wg.Wait()
 
-	fmt.Printf("\nSuccessfully processed %d template file(s). Output directory: %s\n", len(templateFiles), outputDir)
-	return nil
+	return firstErr
 }
 
 // processSingleFile processes a single template file.
-func (tp *TemplateProcessor) processSingleFile(allValues map[string]any) error {
+func (tp *TemplateProcessor) processSingleFile(ctx context.Context, allValues map[string]any) error {
 	templateFile := templatepkg.File{
 		SourcePath:   tp.config.TemplateFile,
 		RelativePath: filepath.Base(tp.config.TemplateFile),
-		OutputPath:   tp.config.OutputFile,
+		OutputPath:   tp.outputLocation(),
+	}
+	if output.IsStdout(templateFile.OutputPath) {
+		// Name the document after the template, as in directory mode
+		templateFile.OutputPath = joinOutputPath(output.Stdout, strings.TrimSuffix(templateFile.RelativePath, ".tpl"))
 	}
 
-	err := tp.processTemplateFile(templateFile, allValues)
+	err := tp.processTemplateFile(ctx, templateFile, allValues)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("Template processed successfully. Output written to: %s\n", tp.config.OutputFile)
+	if tp.combined == nil && tp.dryRun == nil {
+		tp.statusf("Template processed successfully. Output written to: %s\n", tp.config.OutputFile)
+	}
 	return nil
 }
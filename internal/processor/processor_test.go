@@ -1,13 +1,30 @@
 package processor
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/menta2k/templater/internal/cache"
 	"github.com/menta2k/templater/internal/config"
+	"github.com/menta2k/templater/internal/output"
 	templatepkg "github.com/menta2k/templater/internal/template"
+	"github.com/menta2k/templater/internal/values"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestNewTemplateProcessor(t *testing.T) {
@@ -155,7 +172,7 @@ Upper: {{upper .app.name}}`
 		"debug": true,
 	}
 
-	err = processor.processTemplateFile(templateFile, values)
+	err = processor.processTemplateFile(context.Background(), templateFile, values)
 	if err != nil {
 		t.Errorf("processTemplateFile failed: %v", err)
 	}
@@ -219,7 +236,7 @@ func TestFindTemplateFiles(t *testing.T) {
 	outputDir := filepath.Join(tempDir, "output")
 	values := map[string]interface{}{"app": map[string]interface{}{"name": "myapp"}}
 
-	foundFiles, err := processor.findTemplateFiles(tempDir, outputDir, values)
+	foundFiles, err := processor.findTemplateFiles(context.Background(), tempDir, outputDir, values)
 	if err != nil {
 		t.Errorf("findTemplateFiles failed: %v", err)
 	}
@@ -241,6 +258,48 @@ func TestFindTemplateFiles(t *testing.T) {
 	}
 }
 
+func TestFindTemplateFilesSkipsVendoredPacks(t *testing.T) {
+	cfg := config.NewConfig("", "", "", []string{}, false, false)
+	processor := NewTemplateProcessor(cfg)
+
+	tempDir, err := os.MkdirTemp("", "test-templates-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"templater.yaml":               "dependencies: []\n",
+		"config.tpl":                   "config",
+		"vendor/labels/_labels.tpl":    "labels",
+		"nested/vendor/deployment.tpl": "deployment",
+	}
+	for file, content := range files {
+		fullPath := filepath.Join(tempDir, file)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", file, err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", file, err)
+		}
+	}
+
+	foundFiles, err := processor.findTemplateFiles(context.Background(), tempDir, filepath.Join(tempDir, "output"), map[string]any{})
+	if err != nil {
+		t.Fatalf("findTemplateFiles failed: %v", err)
+	}
+
+	var found []string
+	for _, file := range foundFiles {
+		found = append(found, filepath.ToSlash(file.RelativePath))
+	}
+	sort.Strings(found)
+	expected := []string{"config.tpl", "nested/vendor/deployment.tpl"}
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("Expected %v, got %v", expected, found)
+	}
+}
+
 func TestProcessSingleFile(t *testing.T) {
 	// Create a temporary directory for testing
 	tempDir, err := os.MkdirTemp("", "test-single-*")
@@ -547,3 +606,1958 @@ environment: production`,
 		})
 	}
 }
+
+func TestProcessDirectoryHTTPOutput(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-http-output-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templates := map[string]string{
+		"config.tpl":                   "Config for {{.app.name}}",
+		"{{.app.name}}/deployment.tpl": "Deploy {{.app.name}}",
+	}
+	for file, content := range templates {
+		fullPath := filepath.Join(tempDir, file)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", file, err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to create template file %s: %v", file, err)
+		}
+	}
+
+	uploads := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		uploads[r.Method+" "+r.URL.Path] = string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := config.NewConfig(tempDir, "", server.URL+"/bundles/", []string{"app.name=myapp"}, true, false)
+	err = NewTemplateProcessor(cfg).Process()
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"PUT /bundles/config":           "Config for myapp",
+		"PUT /bundles/myapp/deployment": "Deploy myapp",
+	}
+	for key, content := range expected {
+		if uploads[key] != content {
+			t.Errorf("Expected upload %s with %q, got %q", key, content, uploads[key])
+		}
+	}
+}
+
+func TestProcessWithEnvFiles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-env-file-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templatePath := filepath.Join(tempDir, "config.tpl")
+	valuesPath := filepath.Join(tempDir, "values.yaml")
+	envPath := filepath.Join(tempDir, ".env.production")
+	outputPath := filepath.Join(tempDir, "config")
+
+	files := map[string]string{
+		templatePath: "host={{.databaseHost}} level={{.logLevel}} name={{.appName}}",
+		valuesPath:   "databaseHost: yaml-host\nlogLevel: info\nappName: yaml-app\n",
+		envPath:      "DATABASE_HOST=env-host\nLOG_LEVEL=warn\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	cfg := config.NewConfig(templatePath, valuesPath, outputPath, []string{"logLevel=error"}, false, true)
+	cfg.EnvFiles = []string{envPath}
+	if err := NewTemplateProcessor(cfg).Process(); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+
+	expected := "host=env-host level=error name=yaml-app"
+	if string(content) != expected {
+		t.Errorf("Expected %s, got %s", expected, content)
+	}
+}
+
+func TestProcessWithSetStringValues(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-set-string-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templatePath := filepath.Join(tempDir, "config.tpl")
+	outputPath := filepath.Join(tempDir, "config")
+	if err := os.WriteFile(templatePath, []byte("{{.version}} {{kindOf .version}} {{.port}} {{kindOf .port}}"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	cfg := config.NewConfig(templatePath, "", outputPath, []string{"version=2.0,port=8080"}, false, false)
+	cfg.SetStrings = []string{"version=1.10"}
+	if err := NewTemplateProcessor(cfg).Process(); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+
+	expected := "1.10 string 8080 int"
+	if string(content) != expected {
+		t.Errorf("Expected %s, got %s", expected, content)
+	}
+}
+
+func TestProcessWithRenderCache(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-render-cache-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templatePath := filepath.Join(tempDir, "config.tpl")
+	outputPath := filepath.Join(tempDir, "config")
+	cacheDir := filepath.Join(tempDir, "cache")
+	if err := os.WriteFile(templatePath, []byte("{{.name}}-{{randAlphaNumSeeded .name 16}}"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	render := func(name string) string {
+		cfg := config.NewConfig(templatePath, "", outputPath, []string{"name=" + name}, false, false)
+		cfg.CacheDir = cacheDir
+		if err := NewTemplateProcessor(cfg).Process(); err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		content, err := os.ReadFile(outputPath)
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		return string(content)
+	}
+
+	render("web")
+	if err := os.Remove(outputPath); err != nil {
+		t.Fatalf("Failed to remove output: %v", err)
+	}
+
+	// Changing the cache entry makes it visible whether the template was rendered again
+	entries, err := filepath.Glob(filepath.Join(cacheDir, "*", "*"))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected 1 cache entry, got %v (%v)", entries, err)
+	}
+	if err := os.WriteFile(entries[0], []byte("web-cached"), 0o600); err != nil {
+		t.Fatalf("Failed to change cache entry: %v", err)
+	}
+	if restored := render("web"); restored != "web-cached" {
+		t.Errorf("Expected cached output web-cached, got %s", restored)
+	}
+
+	if changed := render("api"); !strings.HasPrefix(changed, "api-") {
+		t.Errorf("Expected output to be rendered again for new values, got %s", changed)
+	}
+}
+
+func TestProcessCacheIgnoresUnreferencedEnvironment(t *testing.T) {
+	tempDir := t.TempDir()
+	templatePath := filepath.Join(tempDir, "config.tpl")
+	outputPath := filepath.Join(tempDir, "config")
+	cacheDir := filepath.Join(tempDir, "cache")
+	if err := os.WriteFile(templatePath, []byte("{{ .name }}-{{ .templaterDigestRegion }}"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	render := func(build, region string) string {
+		t.Setenv("TEMPLATER_DIGEST_BUILD", build)
+		t.Setenv("TEMPLATER_DIGEST_REGION", region)
+		cfg := config.NewConfig(templatePath, "", outputPath, []string{"name=web"}, false, false)
+		cfg.CacheDir = cacheDir
+		if err := NewTemplateProcessor(cfg).Process(); err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		content, err := os.ReadFile(outputPath)
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		return string(content)
+	}
+
+	render("1", "eu")
+	entries, err := filepath.Glob(filepath.Join(cacheDir, "*", "*"))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected 1 cache entry, got %v (%v)", entries, err)
+	}
+	if err := os.WriteFile(entries[0], []byte("cached"), 0o600); err != nil {
+		t.Fatalf("Failed to change cache entry: %v", err)
+	}
+
+	// A variable no template reads does not invalidate cached output
+	if restored := render("2", "eu"); restored != "cached" {
+		t.Errorf("Expected cached output, got %s", restored)
+	}
+	if changed := render("2", "us"); changed != "web-us" {
+		t.Errorf("Expected output to be rendered again for a referenced variable, got %s", changed)
+	}
+}
+
+func TestProcessEmptyDirectory(t *testing.T) {
+	tests := []struct {
+		name        string
+		failOnEmpty bool
+		wantError   bool
+	}{
+		{name: "empty directory succeeds by default", failOnEmpty: false, wantError: false},
+		{name: "empty directory fails with fail-on-empty", failOnEmpty: true, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "test-empty-*")
+			if err != nil {
+				t.Fatalf("Failed to create temp dir: %v", err)
+			}
+			defer os.RemoveAll(tempDir)
+
+			// Files without the .tpl extension are not templates
+			if err := os.WriteFile(filepath.Join(tempDir, "README.md"), []byte("docs"), 0o644); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+
+			cfg := config.NewConfig(tempDir, "", filepath.Join(tempDir, "output"), []string{}, true, false)
+			cfg.FailOnEmpty = tt.failOnEmpty
+
+			err = NewTemplateProcessor(cfg).Process()
+			if tt.wantError && (err == nil || !strings.Contains(err.Error(), "no *.tpl files found")) {
+				t.Errorf("Expected no templates error, got %v", err)
+			}
+			if !tt.wantError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestProcessDirectoryMemoryOutput(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-memory-output-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := os.MkdirAll(filepath.Join(tempDir, "{{.app.name}}"), 0o755); err != nil {
+		t.Fatalf("Failed to create template dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "{{.app.name}}", "config.tpl"), []byte("name: {{ .app.name }}"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	memory := output.NewMemoryWriter()
+	cfg := config.NewConfig(tempDir, "", "mem://", []string{"app.name=web"}, true, true)
+	tp := NewTemplateProcessor(cfg)
+	tp.SetWriter(memory)
+	if err := tp.Process(); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	content, err := memory.FS().ReadFile("web/config")
+	if err != nil {
+		t.Fatalf("Expected rendered file in memory: %v", err)
+	}
+	if string(content) != "name: web" {
+		t.Errorf("Expected name: web, got %s", content)
+	}
+
+	// Memory output without a writer cannot be collected
+	if err := NewTemplateProcessor(cfg).Process(); err == nil {
+		t.Error("Expected error for mem:// output without a writer")
+	}
+}
+
+func TestProcessToStdout(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-stdout-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"deployment.yaml.tpl":   "kind: Deployment\nname: {{ .app.name }}",
+		"rbac/role.yaml.tpl":    "kind: Role\n",
+		"service.yaml.tpl":      "kind: Service",
+		"rbac/binding.yaml.tpl": "kind: RoleBinding",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(tempDir, name)), 0o755); err != nil {
+			t.Fatalf("Failed to create template dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write template: %v", err)
+		}
+	}
+
+	// Documents keep the template order, also with concurrent workers
+	var stream strings.Builder
+	cfg := config.NewConfig(tempDir, "", output.Stdout, []string{"app.name=web"}, true, true)
+	cfg.Workers = 4
+	tp := NewTemplateProcessor(cfg)
+	tp.SetWriter(output.NewStreamWriter(&stream))
+	if err := tp.Process(); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	expected := "--- # source: deployment.yaml\nkind: Deployment\nname: web\n" +
+		"--- # source: rbac/binding.yaml\nkind: RoleBinding\n" +
+		"--- # source: rbac/role.yaml\nkind: Role\n" +
+		"--- # source: service.yaml\nkind: Service\n"
+	if stream.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stream.String())
+	}
+
+	// A single template is named after its file
+	stream.Reset()
+	cfg = config.NewConfig(filepath.Join(tempDir, "service.yaml.tpl"), "", output.Stdout, nil, false, true)
+	tp = NewTemplateProcessor(cfg)
+	tp.SetWriter(output.NewStreamWriter(&stream))
+	if err := tp.Process(); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if expected := "--- # source: service.yaml\nkind: Service\n"; stream.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stream.String())
+	}
+}
+
+func TestProcessIsolatesValuesBetweenTemplates(t *testing.T) {
+	tempDir := t.TempDir()
+	for i := 0; i < 16; i++ {
+		// Every template changes the nested app map, which must not reach the others;
+		// run with -race to check concurrent workers do not share it
+		content := fmt.Sprintf(`{{ $_ := set .app "name" "t%d" }}{{ $_ := unset .app.labels "tier" }}{{ .app.name }}`, i)
+		if err := os.WriteFile(filepath.Join(tempDir, fmt.Sprintf("t%02d.tpl", i)), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write template: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "z.tpl"), []byte(`{{ .app.name }}:{{ .app.labels.tier }}`), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	outputDir := filepath.Join(t.TempDir(), "output")
+	cfg := config.NewConfig(tempDir, "", outputDir, []string{"app.name=web", "app.labels.tier=frontend"}, true, true)
+	cfg.Workers = 4
+	if err := NewTemplateProcessor(cfg).Process(); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	for i := 0; i < 16; i++ {
+		content, err := os.ReadFile(filepath.Join(outputDir, fmt.Sprintf("t%02d", i)))
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		if expected := fmt.Sprintf("t%d", i); string(content) != expected {
+			t.Errorf("Expected %q, got %q", expected, content)
+		}
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, "z"))
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if string(content) != "web:frontend" {
+		t.Errorf("Expected 'web:frontend', got %q", content)
+	}
+}
+
+func TestProcessWithCombine(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-combine-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templateDir := filepath.Join(tempDir, "templates")
+	if err := os.MkdirAll(filepath.Join(templateDir, "rbac"), 0o755); err != nil {
+		t.Fatalf("Failed to create template dir: %v", err)
+	}
+	files := map[string]string{
+		"deployment.yaml.tpl": "kind: Deployment\nname: {{ .app.name }}\n",
+		"rbac/role.yaml.tpl":  "kind: Role",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(templateDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write template: %v", err)
+		}
+	}
+
+	outputDir := filepath.Join(tempDir, "output")
+	combined := filepath.Join(tempDir, "bundle", "all.yaml")
+	cfg := config.NewConfig(templateDir, "", outputDir, []string{"app.name=web"}, true, true)
+	cfg.CombineFile = combined
+	cfg.Workers = 2
+	if err := NewTemplateProcessor(cfg).Process(); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	content, err := os.ReadFile(combined)
+	if err != nil {
+		t.Fatalf("Failed to read combined output: %v", err)
+	}
+	expected := "--- # source: deployment.yaml\nkind: Deployment\nname: web\n" +
+		"--- # source: rbac/role.yaml\nkind: Role\n"
+	if string(content) != expected {
+		t.Errorf("Expected %q, got %q", expected, content)
+	}
+	if _, err := os.Stat(outputDir); !os.IsNotExist(err) {
+		t.Errorf("Expected no output directory with --combine, got %v", err)
+	}
+
+	// Nothing is written when a template fails
+	if err := os.Remove(combined); err != nil {
+		t.Fatalf("Failed to remove combined output: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir, "broken.yaml.tpl"), []byte(`{{ fail "broken" }}`), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	if err := NewTemplateProcessor(cfg).Process(); err == nil {
+		t.Fatal("Expected error for failing template")
+	}
+	if _, err := os.Stat(combined); !os.IsNotExist(err) {
+		t.Errorf("Expected no combined output after a failure, got %v", err)
+	}
+
+	// Uploads would bypass the combined file, so the combination is refused
+	for _, location := range []string{"https://configs.example.com/app/", "mem://"} {
+		cfg.OutputFile = location
+		if err := NewTemplateProcessor(cfg).Process(); err == nil || !strings.Contains(err.Error(), "--combine") {
+			t.Errorf("Expected --combine error for output %s, got %v", location, err)
+		}
+	}
+}
+
+func TestProcessWithDryRun(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-dry-run-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templateDir := filepath.Join(tempDir, "templates")
+	outputDir := filepath.Join(tempDir, "output")
+	for _, dir := range []string{templateDir, outputDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(templateDir, "app.tpl"):  "name: {{ .app.name }}",
+		filepath.Join(templateDir, "new.tpl"):  "new",
+		filepath.Join(templateDir, "same.tpl"): "same",
+		filepath.Join(outputDir, "app"):        "name: old",
+		filepath.Join(outputDir, "same"):       "same",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	cfg := config.NewConfig(templateDir, "", outputDir, []string{"app.name=web"}, true, true)
+	cfg.DryRun = true
+	cfg.CacheDir = filepath.Join(tempDir, "cache")
+	tp := NewTemplateProcessor(cfg)
+
+	// Progress messages report planned writes rather than written files
+	statusFile, err := os.Create(filepath.Join(tempDir, "status"))
+	if err != nil {
+		t.Fatalf("Failed to create status file: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = statusFile
+	err = tp.Process()
+	os.Stdout = stdout
+	statusFile.Close()
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	status, err := os.ReadFile(filepath.Join(tempDir, "status"))
+	if err != nil {
+		t.Fatalf("Failed to read status: %v", err)
+	}
+	if !strings.Contains(string(status), "Would write: new.tpl -> ") || strings.Contains(string(status), "Processed:") {
+		t.Errorf("Expected only planned writes in status, got %q", status)
+	}
+
+	expected := []output.PlannedWrite{
+		{Location: filepath.Join(outputDir, "app"), Change: output.Updated, Size: 9, PreviousSize: 9},
+		{Location: filepath.Join(outputDir, "new"), Change: output.Created, Size: 3},
+		{Location: filepath.Join(outputDir, "same"), Change: output.Unchanged, Size: 4},
+	}
+	if writes := tp.dryRun.Writes(); !reflect.DeepEqual(writes, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, writes)
+	}
+
+	// Neither outputs nor cache entries are written
+	if content, _ := os.ReadFile(filepath.Join(outputDir, "app")); string(content) != "name: old" {
+		t.Errorf("Expected app to keep name: old, got %s", content)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "new")); !os.IsNotExist(err) {
+		t.Errorf("Expected no new output, got %v", err)
+	}
+	if entries, _ := os.ReadDir(cfg.CacheDir); len(entries) != 0 {
+		t.Errorf("Expected no cache entries, got %d", len(entries))
+	}
+
+	// Standard output has no files to compare with
+	cfg.OutputFile = output.Stdout
+	if err := NewTemplateProcessor(cfg).Process(); err == nil {
+		t.Error("Expected error for --dry-run with stdout output")
+	}
+}
+
+func TestProcessWithCopyStatic(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-copy-static-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templateDir := filepath.Join(tempDir, "project")
+	files := map[string]string{
+		"README.md.tpl":            "# {{ .app.name }}",
+		"{{.app.name}}/logo.svg":   "<svg/>",
+		"scripts/run.sh":           "#!/bin/sh\necho {{ not rendered }}\n",
+		"_helpers.tpl":             `{{ define "x" }}x{{ end }}`,
+		"values.schema.json":       `{"type": "object"}`,
+		"config/_values.yaml":      "app:\n  port: 8080\n",
+		"config/settings.json.tpl": `{"port": {{ .app.port }}}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(templateDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	if err := os.Chmod(filepath.Join(templateDir, "scripts/run.sh"), 0o755); err != nil {
+		t.Fatalf("Failed to chmod script: %v", err)
+	}
+
+	// An output directory inside the template directory is not copied into itself
+	outputDir := filepath.Join(templateDir, "dist")
+	cfg := config.NewConfig(templateDir, "", outputDir, []string{"app.name=shop"}, true, true)
+	cfg.CopyStatic = true
+	for run := 0; run < 2; run++ {
+		if err := NewTemplateProcessor(cfg).Process(); err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+	}
+
+	var outputs []string
+	err = filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			relative, _ := filepath.Rel(outputDir, path)
+			outputs = append(outputs, filepath.ToSlash(relative))
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to list outputs: %v", err)
+	}
+	expected := []string{"README.md", "config/settings.json", "scripts/run.sh", "shop/logo.svg"}
+	if !reflect.DeepEqual(outputs, expected) {
+		t.Errorf("Expected outputs %v, got %v", expected, outputs)
+	}
+
+	script := filepath.Join(outputDir, "scripts/run.sh")
+	if content, _ := os.ReadFile(script); string(content) != files["scripts/run.sh"] {
+		t.Errorf("Expected script copied verbatim, got %q", content)
+	}
+	info, err := os.Stat(script)
+	if err != nil {
+		t.Fatalf("Failed to stat script: %v", err)
+	}
+	if info.Mode().Perm() != 0o755 {
+		t.Errorf("Expected script to keep mode 0755, got %v", info.Mode().Perm())
+	}
+}
+
+func TestProcessWithIgnoreFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-ignore-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templateDir := filepath.Join(tempDir, "templates")
+	files := map[string]string{
+		".templaterignore":       "# work in progress\nwip-*.tpl\nthird_party/\n*.bak\n",
+		"app.tpl":                "name: {{ .app.name }}",
+		"wip-ingress.tpl":        "{{ broken",
+		"third_party/lib/x.tpl":  "{{ broken",
+		"k8s/service.tpl":        "kind: Service",
+		"k8s/service.yaml.bak":   "old",
+		"k8s/wip-deployment.tpl": "{{ broken",
+	}
+	for name, content := range files {
+		path := filepath.Join(templateDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	// Ignored templates are neither parsed nor rendered, and not copied as static files
+	outputDir := filepath.Join(tempDir, "output")
+	cfg := config.NewConfig(templateDir, "", outputDir, []string{"app.name=web"}, true, true)
+	cfg.ParseOnly = true
+	if err := NewTemplateProcessor(cfg).Process(); err != nil {
+		t.Fatalf("Parse-only failed: %v", err)
+	}
+	cfg.ParseOnly = false
+	cfg.CopyStatic = true
+	if err := NewTemplateProcessor(cfg).Process(); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	var outputs []string
+	err = filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			relative, _ := filepath.Rel(outputDir, path)
+			outputs = append(outputs, filepath.ToSlash(relative))
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to list outputs: %v", err)
+	}
+	expected := []string{"app", "k8s/service"}
+	if !reflect.DeepEqual(outputs, expected) {
+		t.Errorf("Expected outputs %v, got %v", expected, outputs)
+	}
+}
+
+func TestProcessIgnoresHelpersInIgnoredPaths(t *testing.T) {
+	templateDir := t.TempDir()
+	files := map[string]string{
+		".templaterignore":             "third_party/\n_broken.tpl\n",
+		"app.tpl":                      `{{ include "app.name" . }}`,
+		"_helpers.tpl":                 `{{ define "app.name" }}web{{ end }}`,
+		"_broken.tpl":                  `{{ define "broken" }}{{ broken`,
+		"third_party/lib/_helpers.tpl": `{{ define "lib" }}{{ broken`,
+	}
+	for name, content := range files {
+		path := filepath.Join(templateDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	// The broken helpers are ignored, so they never reach the parser
+	outputDir := filepath.Join(t.TempDir(), "output")
+	cfg := config.NewConfig(templateDir, "", outputDir, nil, true, true)
+	if err := NewTemplateProcessor(cfg).Process(); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "app"))
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if string(content) != "web" {
+		t.Errorf("Expected 'web', got %q", content)
+	}
+}
+
+func TestProcessWithIncludeExclude(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-include-exclude-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templateDir := filepath.Join(tempDir, "templates")
+	files := map[string]string{
+		"README.tpl":              "{{ broken",
+		"k8s/deployment.tpl":      `kind: Deployment{{ include "x" . }}`,
+		"k8s/apps/service.tpl":    "kind: Service",
+		"k8s/apps/legacy/old.tpl": "{{ broken",
+		"terraform/main.tf.tpl":   "{{ broken",
+		"k8s/_helpers.tpl":        `{{ define "x" }}x{{ end }}`,
+		// Helpers outside the selected paths are not loaded either
+		"k8s/apps/legacy/_helpers.tpl": `{{ define "legacy" }}{{ broken`,
+		"terraform/_helpers.tpl":       `{{ define "tf" }}{{ broken`,
+	}
+	for name, content := range files {
+		path := filepath.Join(templateDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	outputDir := filepath.Join(tempDir, "output")
+	cfg := config.NewConfig(templateDir, "", outputDir, nil, true, true)
+	cfg.Include = []string{"k8s/**"}
+	cfg.Exclude = []string{"**/legacy/*"}
+	cfg.ParseOnly = true
+	if err := NewTemplateProcessor(cfg).Process(); err != nil {
+		t.Fatalf("Parse-only failed: %v", err)
+	}
+	cfg.ParseOnly = false
+	if err := NewTemplateProcessor(cfg).Process(); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	var outputs []string
+	err = filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			relative, _ := filepath.Rel(outputDir, path)
+			outputs = append(outputs, filepath.ToSlash(relative))
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to list outputs: %v", err)
+	}
+	expected := []string{"k8s/apps/service", "k8s/deployment"}
+	if !reflect.DeepEqual(outputs, expected) {
+		t.Errorf("Expected outputs %v, got %v", expected, outputs)
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, "k8s", "deployment"))
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if string(content) != "kind: Deploymentx" {
+		t.Errorf("Expected 'kind: Deploymentx', got %q", content)
+	}
+
+	cfg.Exclude = []string{"[broken"}
+	if err := NewTemplateProcessor(cfg).Process(); err == nil {
+		t.Error("Expected error for invalid --exclude pattern")
+	}
+}
+
+func TestProcessDirectoryWithWorkers(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-workers-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templateDir := filepath.Join(tempDir, "templates")
+	outputDir := filepath.Join(tempDir, "output")
+	if err := os.MkdirAll(templateDir, 0o755); err != nil {
+		t.Fatalf("Failed to create template dir: %v", err)
+	}
+
+	const count = 20
+	for i := 0; i < count; i++ {
+		content := "file " + strconv.Itoa(i) + ": {{ .name }} {{ repeat 100 \"x\" }}"
+		if err := os.WriteFile(filepath.Join(templateDir, "f"+strconv.Itoa(i)+".tpl"), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write template: %v", err)
+		}
+	}
+
+	cfg := config.NewConfig(templateDir, "", outputDir, []string{"name=web"}, true, true)
+	cfg.Workers = 4
+	cfg.MaxMemory = 256
+	if err := NewTemplateProcessor(cfg).Process(); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	for i := 0; i < count; i++ {
+		content, err := os.ReadFile(filepath.Join(outputDir, "f"+strconv.Itoa(i)))
+		if err != nil {
+			t.Fatalf("Failed to read output %d: %v", i, err)
+		}
+		expected := "file " + strconv.Itoa(i) + ": web " + strings.Repeat("x", 100)
+		if string(content) != expected {
+			t.Errorf("Expected %s, got %s", expected, content)
+		}
+	}
+}
+
+func TestProcessDirectoryWithWorkersError(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-workers-error-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	for i, content := range []string{"{{ .name }}", "{{ .missing }}", "{{ .name }}"} {
+		if err := os.WriteFile(filepath.Join(tempDir, "f"+strconv.Itoa(i)+".tpl"), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write template: %v", err)
+		}
+	}
+
+	cfg := config.NewConfig(tempDir, "", filepath.Join(tempDir, "output"), []string{"name=web"}, true, true)
+	cfg.Workers = 3
+	if err := NewTemplateProcessor(cfg).Process(); err == nil {
+		t.Error("Expected strict mode error from a worker")
+	}
+}
+
+func TestProcessWithRenderTimeout(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-render-timeout-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templatePath := filepath.Join(tempDir, "events.tpl")
+	if err := os.WriteFile(templatePath, []byte("{{ range .events }}{{ . }}{{ end }}"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	cfg := config.NewConfig(templatePath, "", filepath.Join(tempDir, "events"), []string{}, false, false)
+	cfg.Values["events"] = make(chan string)
+	cfg.RenderTimeout = 20 * time.Millisecond
+
+	err = NewTemplateProcessor(cfg).Process()
+	var timeoutErr *templatepkg.TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Errorf("Expected a render timeout error, got %v", err)
+	}
+}
+
+func TestProcessRenderTimeoutCancelsRequests(t *testing.T) {
+	canceled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		close(canceled)
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	templatePath := filepath.Join(tempDir, "keys.tpl")
+	if err := os.WriteFile(templatePath, []byte(`{{ httpGet "`+server.URL+`/keys" }}`), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	cfg := config.NewConfig(templatePath, "", filepath.Join(tempDir, "keys"), []string{}, false, false)
+	cfg.AllowHTTP = []string{"127.0.0.1"}
+	cfg.RenderTimeout = 20 * time.Millisecond
+
+	err := NewTemplateProcessor(cfg).Process()
+	var timeoutErr *templatepkg.TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Errorf("Expected a render timeout error, got %v", err)
+	}
+
+	// The abandoned render does not keep waiting on the server
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Error("Expected the request of the abandoned render to be canceled")
+	}
+}
+
+func TestProcessWithValuesSchema(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-values-schema-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templatePath := filepath.Join(tempDir, "config.tpl")
+	valuesPath := filepath.Join(tempDir, "values.yaml")
+	files := map[string]string{
+		templatePath: "port: {{ .server.port }}",
+		valuesPath:   "server:\n  port: 8080\n",
+		filepath.Join(tempDir, "values.schema.json"): `{
+			"type": "object",
+			"properties": {
+				"server": {
+					"type": "object",
+					"additionalProperties": false,
+					"properties": {"port": {"type": "integer"}}
+				}
+			}
+		}`,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		setValues  []string
+		skipSchema bool
+		wantError  string
+	}{
+		{name: "valid values", setValues: []string{"server.port=9090"}},
+		{name: "typo in set key", setValues: []string{"server.prot=9090"}, wantError: ".server: additionalProperties 'prot' not allowed"},
+		{name: "wrong type", setValues: []string{"server.port=http"}, wantError: ".server.port: expected integer, but got string"},
+		{name: "skip schema", setValues: []string{"server.prot=9090"}, skipSchema: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig(templatePath, valuesPath, filepath.Join(tempDir, "config"), tt.setValues, false, false)
+			cfg.SkipSchema = tt.skipSchema
+
+			err := NewTemplateProcessor(cfg).Process()
+			if tt.wantError == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+			}
+		})
+	}
+}
+
+func TestProcessWithMergeStrategies(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-merge-strategy-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templatePath := filepath.Join(tempDir, "hosts.tpl")
+	valuesPath := filepath.Join(tempDir, "values.yaml")
+	outputPath := filepath.Join(tempDir, "hosts")
+	files := map[string]string{
+		templatePath: "{{ join \",\" .hosts }}",
+		valuesPath:   "hosts:\n  - a.example.com\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		strategies []string
+		expected   string
+		wantError  bool
+	}{
+		{name: "replace by default", expected: "b.example.com"},
+		{name: "append for one key", strategies: []string{"hosts=append"}, expected: "a.example.com,b.example.com"},
+		{name: "invalid strategy", strategies: []string{"hosts=union"}, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig(templatePath, valuesPath, outputPath, nil, false, false)
+			cfg.SetJSON = []string{`hosts=["b.example.com"]`}
+			cfg.MergeStrategies = tt.strategies
+
+			err := NewTemplateProcessor(cfg).Process()
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error for invalid merge strategy")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Process failed: %v", err)
+			}
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, content)
+			}
+		})
+	}
+}
+
+func TestLoadValues(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-load-values-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templatePath := filepath.Join(tempDir, "config.tpl")
+	valuesPath := filepath.Join(tempDir, "values.yaml")
+	if err := os.WriteFile(templatePath, []byte("name: {{ .app.name }}"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	if err := os.WriteFile(valuesPath, []byte("app:\n  name: web\n  port: 8080\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+
+	cfg := config.NewConfig(templatePath, valuesPath, filepath.Join(tempDir, "config"), []string{"app.name=api"}, false, false)
+	cfg.EnvPrefix = "TEMPLATER_LOAD_VALUES_TEST_"
+
+	merged, err := NewTemplateProcessor(cfg).LoadValues()
+	if err != nil {
+		t.Fatalf("LoadValues failed: %v", err)
+	}
+
+	expected := map[string]any{"app": map[string]any{"name": "api", "port": 8080}}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("Expected %v, got %v", expected, merged)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "config")); !os.IsNotExist(err) {
+		t.Errorf("Expected no output to be written, got %v", err)
+	}
+}
+
+func TestExplainValues(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-explain-values-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templatePath := filepath.Join(tempDir, "config.tpl")
+	valuesPath := filepath.Join(tempDir, "values.yaml")
+	envPath := filepath.Join(tempDir, ".env")
+	files := map[string]string{
+		templatePath: "name: {{ .app.name }}",
+		valuesPath:   "app:\n  name: web\n  port: 8080\nregion: us-east-1\n",
+		envPath:      "REGION=eu-west-1\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	t.Setenv("TEMPLATER_EXPLAIN_TIER", "gold")
+
+	cfg := config.NewConfig(templatePath, valuesPath, filepath.Join(tempDir, "config"), []string{"app.name=api"}, false, false)
+	cfg.EnvFiles = []string{envPath}
+	cfg.EnvPrefix = "TEMPLATER_EXPLAIN_"
+
+	explained, err := NewTemplateProcessor(cfg).ExplainValues()
+	if err != nil {
+		t.Fatalf("ExplainValues failed: %v", err)
+	}
+
+	expected := []values.Provenance{
+		{Path: ".app.name", Value: "api", Source: "--set", Shadowed: []values.ShadowedValue{{Source: "values file " + valuesPath, Value: "web"}}},
+		{Path: ".app.port", Value: 8080, Source: "values file " + valuesPath},
+		{Path: ".region", Value: "eu-west-1", Source: "env file " + envPath, Shadowed: []values.ShadowedValue{{Source: "values file " + valuesPath, Value: "us-east-1"}}},
+		{Path: ".tier", Value: "gold", Source: "env var TEMPLATER_EXPLAIN_TIER"},
+	}
+	if !reflect.DeepEqual(explained, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, explained)
+	}
+
+	// Explaining does not render anything
+	if _, err := os.Stat(filepath.Join(tempDir, "config")); !os.IsNotExist(err) {
+		t.Errorf("Expected no output to be written, got %v", err)
+	}
+}
+
+func TestProcessWithTemplatedValues(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-templated-values-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templatePath := filepath.Join(tempDir, "config.tpl")
+	valuesPath := filepath.Join(tempDir, "values.yaml")
+	outputPath := filepath.Join(tempDir, "config")
+	files := map[string]string{
+		templatePath: "url: {{ .app.url }}",
+		valuesPath:   "domain: example.com\napp:\n  name: web\n  url: \"https://{{ .app.name }}.{{ .domain }}\"\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		templateValues bool
+		setValues      []string
+		expected       string
+	}{
+		{name: "disabled", expected: "url: https://{{ .app.name }}.{{ .domain }}"},
+		{name: "enabled", templateValues: true, expected: "url: https://web.example.com"},
+		// Values are rendered before merging, so --set does not change them
+		{name: "set values apply afterwards", templateValues: true, setValues: []string{"app.name=api"}, expected: "url: https://web.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig(templatePath, valuesPath, outputPath, tt.setValues, false, true)
+			cfg.TemplateValues = tt.templateValues
+
+			if err := NewTemplateProcessor(cfg).Process(); err != nil {
+				t.Fatalf("Process failed: %v", err)
+			}
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, content)
+			}
+		})
+	}
+}
+
+func TestProcessContextTracing(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-tracing-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	for name, content := range map[string]string{"a.tpl": "{{ .name }}", "b.tpl": "{{ .name }}!"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write template: %v", err)
+		}
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	cfg := config.NewConfig(tempDir, "", filepath.Join(tempDir, "output"), []string{"name=web"}, true, true)
+	cfg.StaticCheck = true
+	if err := NewTemplateProcessor(cfg).ProcessContext(context.Background()); err != nil {
+		t.Fatalf("ProcessContext failed: %v", err)
+	}
+
+	counts := make(map[string]int)
+	var root sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		counts[span.Name()]++
+		if span.Name() == "templater.process" {
+			root = span
+		}
+	}
+
+	expected := map[string]int{
+		"templater.process":     1,
+		"templater.values.load": 1,
+		"templater.validate":    1,
+		"templater.discover":    1,
+		"templater.render":      2,
+		"templater.write":       2,
+	}
+	for name, count := range expected {
+		if counts[name] != count {
+			t.Errorf("Expected %d %s span(s), got %d", count, name, counts[name])
+		}
+	}
+
+	if root == nil {
+		t.Fatal("Expected a templater.process span")
+	}
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID() != root.SpanContext().TraceID() {
+			t.Errorf("Expected %s span to belong to the process trace", span.Name())
+		}
+	}
+}
+
+func BenchmarkProcessDirectory(b *testing.B) {
+	tempDir, err := os.MkdirTemp("", "bench-process-*")
+	if err != nil {
+		b.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templateDir := filepath.Join(tempDir, "templates")
+	if err := os.MkdirAll(templateDir, 0o755); err != nil {
+		b.Fatalf("Failed to create template dir: %v", err)
+	}
+	content := strings.Repeat("Line {{.app.name}} version {{.app.version}} {{ toJson .app }}\n", 200)
+	for i := 0; i < 50; i++ {
+		if err := os.WriteFile(filepath.Join(templateDir, "t"+strconv.Itoa(i)+".tpl"), []byte(content), 0o644); err != nil {
+			b.Fatalf("Failed to write template: %v", err)
+		}
+	}
+
+	cfg := config.NewConfig(templateDir, "", filepath.Join(tempDir, "output"), []string{"app.name=bench", "app.version=1.0.0"}, true, false)
+
+	// Silence per-file progress output
+	stdout := os.Stdout
+	os.Stdout, _ = os.Open(os.DevNull)
+	defer func() { os.Stdout = stdout }()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := NewTemplateProcessor(cfg).Process(); err != nil {
+			b.Fatalf("Process failed: %v", err)
+		}
+	}
+}
+
+func TestProcessWithLazyValues(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-lazy-values-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templatePath := filepath.Join(tempDir, "config.tpl")
+	valuesPath := filepath.Join(tempDir, "values.yaml")
+	outputPath := filepath.Join(tempDir, "config")
+	files := map[string]string{
+		templatePath: "{{ .app.name }}:{{ .app.port }}",
+		valuesPath:   "app:\n  name: web\n  port: 80\ngenerated: !!binary \"not base64!\"\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	cfg := config.NewConfig(templatePath, valuesPath, outputPath, []string{"app.port=8080"}, false, true)
+	cfg.LazyValues = true
+	if err := NewTemplateProcessor(cfg).Process(); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if string(content) != "web:8080" {
+		t.Errorf("Expected web:8080, got %s", content)
+	}
+}
+
+func TestProcessWithEnvironment(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-environment-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templateDir := filepath.Join(tempDir, "templates")
+	configDir := filepath.Join(tempDir, "config")
+	files := map[string]string{
+		filepath.Join(templateDir, "app.tpl"):          "{{ .app.name }}:{{ .app.replicas }}:{{ .app.url }}",
+		filepath.Join(templateDir, "values.yaml"):      "app:\n  name: web\n  replicas: 1\n  url: 'https://{{ .app.name }}.{{ .domain }}'\ndomain: dev.example.com\n",
+		filepath.Join(templateDir, "values.prod.yaml"): "app:\n  replicas: 3\ndomain: example.com\n",
+		filepath.Join(configDir, "app.yml"):            "app:\n  name: api\n  replicas: 2\n  url: none\n",
+		filepath.Join(configDir, "app.prod.yml"):       "app:\n  replicas: 6\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		valuesFile     string
+		environment    string
+		templateValues bool
+		expected       string
+	}{
+		{name: "no environment", expected: "<no value>:<no value>:<no value>"},
+		{name: "environment file", environment: "prod", expected: "web:3:https://{{ .app.name }}.{{ .domain }}"},
+		{name: "templated across files", environment: "prod", templateValues: true, expected: "web:3:https://web.example.com"},
+		{name: "environment without file", environment: "staging", expected: "web:1:https://{{ .app.name }}.{{ .domain }}"},
+		{name: "explicit values file", valuesFile: filepath.Join(configDir, "app.yml"), environment: "prod", expected: "api:6:none"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputDir := filepath.Join(tempDir, "output", strings.ReplaceAll(tt.name, " ", "-"))
+			cfg := config.NewConfig(templateDir, tt.valuesFile, outputDir, nil, true, false)
+			cfg.Environment = tt.environment
+			cfg.TemplateValues = tt.templateValues
+			if err := NewTemplateProcessor(cfg).Process(); err != nil {
+				t.Fatalf("Process failed: %v", err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(outputDir, "app"))
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, content)
+			}
+		})
+	}
+}
+
+func TestProcessWithDirectoryValues(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-directory-values-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templateDir := filepath.Join(tempDir, "templates")
+	valuesFile := filepath.Join(tempDir, "values.yaml")
+	files := map[string]string{
+		valuesFile:                            "name: global\nport: 80\n",
+		filepath.Join(templateDir, "app.tpl"): "{{ .name }}:{{ .port }}",
+		filepath.Join(templateDir, "services", "values.yaml"):              "name: services\nport: 8080\n",
+		filepath.Join(templateDir, "services", "worker.tpl"):               "{{ .name }}:{{ .port }}",
+		filepath.Join(templateDir, "services", "api", "_values.yaml"):      "name: api\n",
+		filepath.Join(templateDir, "services", "api", "values.yaml"):       "name: ignored\nport: 9090\n",
+		filepath.Join(templateDir, "services", "api", "{{ .name }}.tpl"):   "{{ .name }}:{{ .port }}",
+		filepath.Join(templateDir, "services", "api", "conf", "inner.tpl"): "{{ .name }}:{{ .port }}",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		skip     bool
+		set      []string
+		expected map[string]string
+	}{
+		{
+			name: "scoped values",
+			expected: map[string]string{
+				"app":                                   "global:80",
+				filepath.Join("services", "worker"):     "services:8080",
+				filepath.Join("services", "api", "api"): "api:9090",
+				filepath.Join("services", "api", "conf", "inner"): "api:9090",
+			},
+		},
+		{
+			// --set values override directory values like every other source
+			name: "set overrides",
+			set:  []string{"port=7000"},
+			expected: map[string]string{
+				"app":                                   "global:7000",
+				filepath.Join("services", "worker"):     "services:7000",
+				filepath.Join("services", "api", "api"): "api:7000",
+				filepath.Join("services", "api", "conf", "inner"): "api:7000",
+			},
+		},
+		{
+			name: "skipped",
+			skip: true,
+			expected: map[string]string{
+				"app":                               "global:80",
+				filepath.Join("services", "worker"): "global:80",
+				filepath.Join("services", "api", "global"): "global:80",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputDir := filepath.Join(tempDir, "output", tt.name)
+			cfg := config.NewConfig(templateDir, valuesFile, outputDir, tt.set, true, false)
+			cfg.StrictMode = true
+			cfg.StaticCheck = true
+			cfg.SkipDirectoryValues = tt.skip
+			if err := NewTemplateProcessor(cfg).Process(); err != nil {
+				t.Fatalf("Process failed: %v", err)
+			}
+
+			for name, expected := range tt.expected {
+				content, err := os.ReadFile(filepath.Join(outputDir, name))
+				if err != nil {
+					t.Fatalf("Failed to read output: %v", err)
+				}
+				if string(content) != expected {
+					t.Errorf("Expected %s to be %q, got %q", name, expected, content)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessDirectoryWithHelpers(t *testing.T) {
+	templateDir := t.TempDir()
+	outputDir := t.TempDir()
+	files := map[string]string{
+		"templater.yaml":            "dependencies: []\n",
+		"vendor/common/_labels.tpl": `{{ define "app.labels" }}vendored{{ end }}{{ define "common.name" }}{{ .app.name }}-svc{{ end }}`,
+		"_helpers.tpl":              `{{ define "app.labels" }}app: {{ .app.name }}{{ end }}`,
+		"deployment.tpl":            "labels:\n{{ include \"app.labels\" . | indent 2 }}",
+		"services/_ports.tpl":       `{{ define "app.port" }}{{ .app.port }}{{ end }}`,
+		"services/service.tpl":      `{{ include "common.name" . }}:{{ template "app.port" . }}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(templateDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	cfg := config.NewConfig(templateDir, "", outputDir, []string{"app.name=web", "app.port=80"}, true, true)
+	if err := NewTemplateProcessor(cfg).Process(); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"deployment":                         "labels:\n  app: web",
+		filepath.Join("services", "service"): "web-svc:80",
+	}
+	for name, content := range expected {
+		data, err := os.ReadFile(filepath.Join(outputDir, name))
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		if string(data) != content {
+			t.Errorf("Expected %s to be %q, got %q", name, content, data)
+		}
+	}
+	for _, helper := range []string{"_helpers", filepath.Join("services", "_ports")} {
+		if _, err := os.Stat(filepath.Join(outputDir, helper)); !os.IsNotExist(err) {
+			t.Errorf("Expected helper template %s not to be rendered, got %v", helper, err)
+		}
+	}
+}
+
+func TestProcessWithFail(t *testing.T) {
+	templateDir := t.TempDir()
+	files := map[string]string{
+		"_helpers.tpl":   "{{ define \"check\" }}{{ if lt .replicas 1 }}{{ fail \"replicas must be > 0\" }}{{ end }}{{ end }}",
+		"deployment.tpl": "{{ include \"check\" . }}replicas: {{ .replicas }}",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(templateDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	cfg := config.NewConfig(templateDir, "", t.TempDir(), []string{"replicas=0"}, true, false)
+	err := NewTemplateProcessor(cfg).Process()
+	expected := filepath.Join(templateDir, "_helpers.tpl") + ":1:46: replicas must be > 0"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error %q, got %v", expected, err)
+	}
+}
+
+func TestProcessWithFileFunctions(t *testing.T) {
+	templateDir := t.TempDir()
+	outputDir := t.TempDir()
+	scriptPath := filepath.Join(templateDir, "scripts", "init.sh")
+	if err := os.MkdirAll(filepath.Dir(scriptPath), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir, "job.yaml.tpl"), []byte("script: |\n{{ readFile \"scripts/init.sh\" | indent 2 }}"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	// The render cache must not restore output of a template whose files changed
+	cacheDir := t.TempDir()
+	for _, script := range []string{"echo one\n", "echo two\n"} {
+		if err := os.WriteFile(scriptPath, []byte(script), 0o644); err != nil {
+			t.Fatalf("Failed to write script: %v", err)
+		}
+		cfg := config.NewConfig(templateDir, "", outputDir, nil, true, false)
+		cfg.CacheDir = cacheDir
+		if err := NewTemplateProcessor(cfg).Process(); err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(outputDir, "job.yaml"))
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		if expected := "script: |\n  " + strings.ReplaceAll(script, "\n", "\n  "); string(data) != expected {
+			t.Errorf("Expected %q, got %q", expected, data)
+		}
+	}
+
+	cfg := config.NewConfig(templateDir, "", outputDir, nil, true, false)
+	cfg.DisableFileFunctions = true
+	if err := NewTemplateProcessor(cfg).Process(); err == nil || !strings.Contains(err.Error(), "file functions are disabled") {
+		t.Errorf("Expected disabled file functions error, got %v", err)
+	}
+}
+
+func TestProcessCacheSkipsNondeterministicTemplates(t *testing.T) {
+	templateDir := t.TempDir()
+	outputDir := t.TempDir()
+	files := map[string]string{
+		"stable.tpl":  "name: {{ .Values.name }}",
+		"time.tpl":    `{{ now | date "15:04:05.000000000" }}`,
+		"random.tpl":  "{{ randAlphaNum 32 }}",
+		"cert.tpl":    `{{ (genCA "ca" 1).Cert }}`,
+		"release.tpl": "{{ .Release.Time.UnixNano }}",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(templateDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	cacheDir := t.TempDir()
+	runs := []map[string]string{}
+	for i := 0; i < 2; i++ {
+		cfg := config.NewConfig(templateDir, "", outputDir, []string{"name=web"}, true, false)
+		cfg.CacheDir = cacheDir
+		cfg.HelmCompat = true
+		if err := NewTemplateProcessor(cfg).Process(); err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		outputs := map[string]string{}
+		for name := range files {
+			data, err := os.ReadFile(filepath.Join(outputDir, strings.TrimSuffix(name, ".tpl")))
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			outputs[name] = string(data)
+		}
+		runs = append(runs, outputs)
+	}
+
+	for name := range files {
+		if name == "stable.tpl" {
+			continue
+		}
+		if runs[0][name] == runs[1][name] {
+			t.Errorf("Expected %s to be rendered anew, got %q twice", name, runs[0][name])
+		}
+	}
+
+	// Only the deterministic template is cached
+	entries := 0
+	err := filepath.Walk(cacheDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			entries++
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to list cache entries: %v", err)
+	}
+	if entries != 1 {
+		t.Errorf("Expected 1 cache entry, got %d", entries)
+	}
+}
+
+func TestProcessWithMissingFuncPlugin(t *testing.T) {
+	templateDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(templateDir, "app.tpl"), []byte("name: {{ .name }}"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	pluginPath := filepath.Join(t.TempDir(), "missing.so")
+	cfg := config.NewConfig(templateDir, "", t.TempDir(), []string{"name=web"}, true, false)
+	cfg.FuncPlugins = []string{pluginPath}
+	err := NewTemplateProcessor(cfg).Process()
+	if err == nil || !strings.Contains(err.Error(), pluginPath) {
+		t.Errorf("Expected error for missing plugin %s, got %v", pluginPath, err)
+	}
+}
+
+func TestProcessWithInvalidWasmPlugin(t *testing.T) {
+	templateDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(templateDir, "app.tpl"), []byte("name: {{ .name }}"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	pluginPath := filepath.Join(t.TempDir(), "funcs.wasm")
+	if err := os.WriteFile(pluginPath, []byte("not wasm"), 0o644); err != nil {
+		t.Fatalf("Failed to write plugin: %v", err)
+	}
+	for _, path := range []string{pluginPath, filepath.Join(t.TempDir(), "missing.wasm")} {
+		cfg := config.NewConfig(templateDir, "", t.TempDir(), []string{"name=web"}, true, false)
+		cfg.WasmPlugins = []string{path}
+		err := NewTemplateProcessor(cfg).Process()
+		if err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("Expected error for plugin %s, got %v", path, err)
+		}
+	}
+}
+
+func TestProcessWithProcessPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not executable on windows")
+	}
+	templateDir := t.TempDir()
+	outputDir := t.TempDir()
+	scriptPath := filepath.Join(t.TempDir(), "region.sh")
+	if err := os.WriteFile(filepath.Join(templateDir, "app.yaml.tpl"), []byte("region: {{ region .name }}"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	// The render cache must not restore output of a template whose plugin output changed
+	cacheDir := t.TempDir()
+	for _, region := range []string{"eu-west-1", "us-east-1"} {
+		if err := os.WriteFile(scriptPath, []byte("#!/bin/sh\necho \"$1-"+region+"\"\n"), 0o755); err != nil {
+			t.Fatalf("Failed to write script: %v", err)
+		}
+		cfg := config.NewConfig(templateDir, "", outputDir, []string{"name=web"}, true, false)
+		cfg.CacheDir = cacheDir
+		cfg.ProcessPlugins = []string{"region=" + scriptPath}
+		if err := NewTemplateProcessor(cfg).Process(); err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(outputDir, "app.yaml"))
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		if expected := "region: web-" + region; string(data) != expected {
+			t.Errorf("Expected %q, got %q", expected, data)
+		}
+	}
+
+	cfg := config.NewConfig(templateDir, "", outputDir, nil, true, false)
+	cfg.ProcessPlugins = []string{"region"}
+	if err := NewTemplateProcessor(cfg).Process(); err == nil || !strings.Contains(err.Error(), "expected name=path") {
+		t.Errorf("Expected invalid plugin error, got %v", err)
+	}
+}
+
+func TestProcessWithStarlarkFiles(t *testing.T) {
+	templateDir := t.TempDir()
+	outputDir := t.TempDir()
+	starPath := filepath.Join(t.TempDir(), "naming.star")
+	if err := os.WriteFile(filepath.Join(templateDir, "app.yaml.tpl"), []byte("name: {{ resource_name .name }}"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	// Changing the Starlark file must invalidate cached output
+	cacheDir := t.TempDir()
+	for _, prefix := range []string{"prod", "staging"} {
+		star := "def resource_name(app):\n    return \"" + prefix + "-\" + app\n"
+		if err := os.WriteFile(starPath, []byte(star), 0o644); err != nil {
+			t.Fatalf("Failed to write starlark file: %v", err)
+		}
+		cfg := config.NewConfig(templateDir, "", outputDir, []string{"name=web"}, true, false)
+		cfg.CacheDir = cacheDir
+		cfg.StarlarkFiles = []string{starPath}
+		if err := NewTemplateProcessor(cfg).Process(); err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(outputDir, "app.yaml"))
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		if expected := "name: " + prefix + "-web"; string(data) != expected {
+			t.Errorf("Expected %q, got %q", expected, data)
+		}
+	}
+}
+
+func TestProcessWithAllowEnv(t *testing.T) {
+	templateDir := t.TempDir()
+	outputDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(templateDir, "app.yaml.tpl"), []byte(`region: {{ env "APP_REGION" }}`), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	// The render cache must not restore output of a template whose environment changed
+	cacheDir := t.TempDir()
+	for _, region := range []string{"eu-west-1", "us-east-1"} {
+		t.Setenv("APP_REGION", region)
+		cfg := config.NewConfig(templateDir, "", outputDir, nil, true, false)
+		cfg.CacheDir = cacheDir
+		cfg.AllowEnv = true
+		cfg.AllowEnvPrefix = "APP_"
+		if err := NewTemplateProcessor(cfg).Process(); err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(outputDir, "app.yaml"))
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		if expected := "region: " + region; string(data) != expected {
+			t.Errorf("Expected %q, got %q", expected, data)
+		}
+	}
+
+	cfg := config.NewConfig(templateDir, "", outputDir, nil, true, false)
+	cfg.AllowEnv = true
+	cfg.AllowEnvPrefix = "OTHER_"
+	if err := NewTemplateProcessor(cfg).Process(); err == nil || !strings.Contains(err.Error(), "APP_REGION is not allowed") {
+		t.Errorf("Expected disallowed variable error, got %v", err)
+	}
+
+	cfg = config.NewConfig(templateDir, "", outputDir, nil, true, false)
+	if err := NewTemplateProcessor(cfg).Process(); err == nil || !strings.Contains(err.Error(), `function "env" not defined`) {
+		t.Errorf("Expected undefined env error, got %v", err)
+	}
+}
+
+func TestProcessWithAllowExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not executable on windows")
+	}
+	templateDir := t.TempDir()
+	outputDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(templateDir, "app.yaml.tpl"), []byte(`region: {{ exec "sh" "-c" "cat \"$0\"" .file }}`), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	regionFile := filepath.Join(t.TempDir(), "region")
+
+	// The render cache must not restore output of a template whose command output changed
+	cacheDir := t.TempDir()
+	for _, region := range []string{"eu-west-1", "us-east-1"} {
+		if err := os.WriteFile(regionFile, []byte(region+"\n"), 0o644); err != nil {
+			t.Fatalf("Failed to write region file: %v", err)
+		}
+		cfg := config.NewConfig(templateDir, "", outputDir, []string{"file=" + regionFile}, true, false)
+		cfg.CacheDir = cacheDir
+		cfg.AllowExec = []string{"sh"}
+		if err := NewTemplateProcessor(cfg).Process(); err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(outputDir, "app.yaml"))
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		if expected := "region: " + region; string(data) != expected {
+			t.Errorf("Expected %q, got %q", expected, data)
+		}
+	}
+
+	cfg := config.NewConfig(templateDir, "", outputDir, []string{"file=" + regionFile}, true, false)
+	cfg.AllowExec = []string{"true"}
+	if err := NewTemplateProcessor(cfg).Process(); err == nil || !strings.Contains(err.Error(), "exec of sh is not allowed") {
+		t.Errorf("Expected disallowed command error, got %v", err)
+	}
+
+	cfg = config.NewConfig(templateDir, "", outputDir, nil, true, false)
+	if err := NewTemplateProcessor(cfg).Process(); err == nil || !strings.Contains(err.Error(), `function "exec" not defined`) {
+		t.Errorf("Expected undefined exec error, got %v", err)
+	}
+}
+
+func TestProcessWithAllowHTTP(t *testing.T) {
+	ranges := "10.0.0.0/8"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, ranges)
+	}))
+	defer server.Close()
+
+	templateDir := t.TempDir()
+	outputDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(templateDir, "app.yaml.tpl"), []byte(`allow: {{ httpGet .url }}`), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	// The render cache must not restore output of a template whose response changed
+	cacheDir := t.TempDir()
+	for _, ranges = range []string{"10.0.0.0/8", "192.168.0.0/16"} {
+		cfg := config.NewConfig(templateDir, "", outputDir, []string{"url=" + server.URL}, true, false)
+		cfg.CacheDir = cacheDir
+		cfg.AllowHTTP = []string{"127.0.0.1"}
+		if err := NewTemplateProcessor(cfg).Process(); err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(outputDir, "app.yaml"))
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		if expected := "allow: " + ranges; string(data) != expected {
+			t.Errorf("Expected %q, got %q", expected, data)
+		}
+	}
+
+	cfg := config.NewConfig(templateDir, "", outputDir, []string{"url=" + server.URL}, true, false)
+	cfg.AllowHTTP = []string{"example.com"}
+	if err := NewTemplateProcessor(cfg).Process(); err == nil || !strings.Contains(err.Error(), "is not allowed") {
+		t.Errorf("Expected disallowed host error, got %v", err)
+	}
+
+	cfg = config.NewConfig(templateDir, "", outputDir, nil, true, false)
+	if err := NewTemplateProcessor(cfg).Process(); err == nil || !strings.Contains(err.Error(), `function "httpGet" not defined`) {
+		t.Errorf("Expected undefined httpGet error, got %v", err)
+	}
+}
+
+func TestProcessWithSortKeys(t *testing.T) {
+	templateDir := t.TempDir()
+	outputDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(templateDir, "app.yaml.tpl"), []byte(`{{ toYaml .limits }}`), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	for _, tt := range []struct {
+		sortKeys bool
+		expected string
+	}{
+		{expected: "a2: 2\na10: 10"},
+		{sortKeys: true, expected: "a10: 10\na2: 2"},
+	} {
+		cfg := config.NewConfig(templateDir, "", outputDir, []string{"limits.a10=10", "limits.a2=2"}, true, false)
+		cfg.PreciseNumbers = true
+		cfg.SortKeys = tt.sortKeys
+		if err := NewTemplateProcessor(cfg).Process(); err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(outputDir, "app.yaml"))
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		if string(data) != tt.expected {
+			t.Errorf("Expected %q with sortKeys %v, got %q", tt.expected, tt.sortKeys, data)
+		}
+	}
+}
+
+func TestProcessWithHelmCompat(t *testing.T) {
+	templateDir := filepath.Join(t.TempDir(), "templates")
+	outputDir := t.TempDir()
+	files := map[string]string{
+		"_helpers.tpl":                      `{{ define "app.fullname" }}{{ .Release.Name }}-{{ .Values.app.name }}{{ end }}`,
+		"deploy/{{ .Values.app.name }}.tpl": "name: {{ include \"app.fullname\" . }}\nnamespace: {{ .Release.Namespace }}\nsource: {{ .Template.Name }}\nbase: {{ .Template.BasePath }}\ninstall: {{ .Release.IsInstall }}\nyear: {{ .Release.Time.Year }}",
+	}
+	for name, content := range files {
+		path := filepath.Join(templateDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	cfg := config.NewConfig(templateDir, "", outputDir, []string{"app.name=web"}, true, true)
+	cfg.HelmCompat = true
+	cfg.ReleaseName = "prod"
+	cfg.StaticCheck = true
+	if err := NewTemplateProcessor(cfg).Process(); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "deploy", "web"))
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	expected := fmt.Sprintf("name: prod-web\nnamespace: default\nsource: templates/deploy/{{ .Values.app.name }}.tpl\nbase: templates\ninstall: true\nyear: %d", time.Now().Year())
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+
+	// Without --helm-compat the values are the data
+	cfg = config.NewConfig(templateDir, "", outputDir, []string{"app.name=web"}, true, true)
+	if err := NewTemplateProcessor(cfg).Process(); err == nil {
+		t.Error("Expected .Values to be undefined without helm compatibility")
+	}
+}
+
+func TestProcessWithHelmFiles(t *testing.T) {
+	templateDir := t.TempDir()
+	outputDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(templateDir, "dashboards"), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir, "configmap.yaml.tpl"), []byte("data:\n  {{- (.Files.Glob \"dashboards/*.json\").AsConfig | nindent 2 }}"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	// The render cache must not restore output of a template whose files changed
+	cacheDir := t.TempDir()
+	for _, content := range []string{`{"a":1}`, `{"a":2}`} {
+		if err := os.WriteFile(filepath.Join(templateDir, "dashboards", "a.json"), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write asset: %v", err)
+		}
+		cfg := config.NewConfig(templateDir, "", outputDir, nil, true, false)
+		cfg.CacheDir = cacheDir
+		cfg.HelmCompat = true
+		if err := NewTemplateProcessor(cfg).Process(); err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(outputDir, "configmap.yaml"))
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		if expected := "data:\n  a.json: '" + content + "'"; string(data) != expected {
+			t.Errorf("Expected %q, got %q", expected, data)
+		}
+	}
+
+	cfg := config.NewConfig(templateDir, "", outputDir, nil, true, false)
+	cfg.HelmCompat = true
+	cfg.DisableFileFunctions = true
+	if err := NewTemplateProcessor(cfg).Process(); err == nil || !strings.Contains(err.Error(), "file functions are disabled") {
+		t.Errorf("Expected disabled file functions error, got %v", err)
+	}
+}
+
+func TestProcessWithTemplateMetadata(t *testing.T) {
+	templateDir := t.TempDir()
+	outputDir := t.TempDir()
+	valuesFile := filepath.Join(t.TempDir(), "values.yaml")
+	files := map[string]string{
+		"app/values.yaml":          "name: web\n",
+		"app/{{ .name }}.conf.tpl": "# Generated from {{ .templater.path }} by templater {{ .templater.version }}\n# output: {{ .templater.output }}\n# values: {{ join \",\" .templater.valuesFiles }}\nname: {{ .name }}",
+		"root.tpl":                 "{{ .templater.source }} {{ len .templater.valuesFiles }}",
+	}
+	for name, content := range files {
+		path := filepath.Join(templateDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	if err := os.WriteFile(valuesFile, []byte("name: default\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+
+	cfg := config.NewConfig(templateDir, valuesFile, outputDir, nil, true, true)
+	if err := NewTemplateProcessor(cfg).Process(); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "app", "web.conf"))
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	expected := fmt.Sprintf("# Generated from app/{{ .name }}.conf.tpl by templater %s\n# output: %s\n# values: %s,%s\nname: web",
+		cache.ToolVersion(), filepath.Join(outputDir, "app", "web.conf"), valuesFile, filepath.Join(templateDir, "app", "values.yaml"))
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+
+	data, err = os.ReadFile(filepath.Join(outputDir, "root"))
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if expected := filepath.Join(templateDir, "root.tpl") + " 1"; string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+
+	// Values may not use the reserved key, neither globally nor in a directory
+	cfg.SetValues = []string{"templater.owner=ops"}
+	if err := NewTemplateProcessor(cfg).Process(); err == nil || !strings.Contains(err.Error(), `reserved key "templater"`) {
+		t.Errorf("Expected error for values defining the reserved key, got %v", err)
+	}
+	cfg.SetValues = nil
+	if err := os.WriteFile(filepath.Join(templateDir, "app", "values.yaml"), []byte("templater: web\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+	if err := NewTemplateProcessor(cfg).Process(); err == nil || !strings.Contains(err.Error(), "values for app") {
+		t.Errorf("Expected error for directory values defining the reserved key, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/menta2k/templater/internal/deps"
+	"github.com/menta2k/templater/internal/output"
+	"github.com/menta2k/templater/internal/telemetry"
+	templatepkg "github.com/menta2k/templater/internal/template"
+	"github.com/menta2k/templater/internal/values"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// isStaticFile reports whether the file at path, without the template extension, is
+// copied into the output tree with --copy-static. Values files, the values schema, the
+// pack manifest and the ignore file configure rendering and are left out.
+func (tp *TemplateProcessor) isStaticFile(path, relativePath string) bool {
+	name := filepath.Base(relativePath)
+	if slices.Contains(directoryValuesFiles, name) || name == values.SchemaFileName {
+		return false
+	}
+	if relativePath == deps.ManifestFile || relativePath == deps.LockFile || relativePath == templatepkg.IgnoreFile {
+		return false
+	}
+	for _, valuesFile := range tp.valuesFiles {
+		if sameFile(path, valuesFile) {
+			return false
+		}
+	}
+	return true
+}
+
+// isOutputDir reports whether dir is the local output directory.
+func isOutputDir(dir, outputDir string) bool {
+	if output.IsHTTPURL(outputDir) || output.IsMemoryURL(outputDir) || output.IsStdout(outputDir) {
+		return false
+	}
+	return sameFile(dir, outputDir)
+}
+
+// sameFile reports whether two paths name the same file, comparing absolute paths.
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// countStatic returns the number of files copied verbatim.
+func countStatic(files []templatepkg.File) int {
+	count := 0
+	for _, file := range files {
+		if file.Static {
+			count++
+		}
+	}
+	return count
+}
+
+// copyStaticFile copies a file without the template extension verbatim into the output
+// tree, keeping its permissions when it is written to disk.
+func (tp *TemplateProcessor) copyStaticFile(ctx context.Context, file templatepkg.File) (err error) {
+	ctx, span := telemetry.Start(ctx, "templater.copy",
+		attribute.String("templater.source", file.RelativePath),
+		attribute.String("templater.output", file.OutputPath),
+	)
+	defer func() { telemetry.End(span, err) }()
+
+	info, err := os.Stat(file.SourcePath)
+	if err != nil {
+		return fmt.Errorf("failed to stat static file %s: %w", file.SourcePath, err)
+	}
+	reserved := info.Size()
+	tp.memory.acquire(reserved)
+	defer func() { tp.memory.release(reserved) }()
+
+	content, err := os.ReadFile(file.SourcePath)
+	if err != nil {
+		return fmt.Errorf("failed to read static file %s: %w", file.SourcePath, err)
+	}
+	if err := tp.writeOutput(ctx, file.OutputPath, string(content)); err != nil {
+		return err
+	}
+	if tp.writer == nil {
+		if err := os.Chmod(file.OutputPath, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to set permissions of %s: %w", file.OutputPath, err)
+		}
+	}
+
+	tp.statusf("%s: %s -> %s\n", tp.writeStatus("Copied"), file.RelativePath, file.OutputPath)
+	return nil
+}
@@ -0,0 +1,197 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/menta2k/templater/internal/deps"
+	templatepkg "github.com/menta2k/templater/internal/template"
+)
+
+// parseOnly parses every template and templated path without executing or writing
+// anything, reporting all syntax errors at once.
+func (tp *TemplateProcessor) parseOnly() error {
+	sources, isDir, err := tp.listTemplateSources()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, source := range sources {
+		if isDir {
+			relativePath, err := filepath.Rel(tp.config.TemplateFile, source)
+			if err != nil {
+				return fmt.Errorf("failed to calculate relative path: %w", err)
+			}
+			if _, err := tp.newTemplate("path").ParseTemplate(relativePath); err != nil {
+				errs = append(errs, fmt.Errorf("failed to parse path template '%s': %w", relativePath, err))
+			}
+		}
+
+		content, err := os.ReadFile(source)
+		if err != nil {
+			return fmt.Errorf("failed to read template file %s: %w", source, err)
+		}
+		if _, err := tp.newTemplate(filepath.Base(source)).ParseTemplate(string(content)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to parse template %s: %w", source, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d template(s) failed to parse:\n%w", len(errs), len(sources), errors.Join(errs...))
+	}
+
+	tp.statusf("Parsed %d template(s) without errors\n", len(sources))
+	return nil
+}
+
+// staticCheck extracts the value paths referenced by every template and templated path
+// and verifies them against the merged values in one pass, without executing anything.
+// Templates in subdirectories are checked against their directory values.
+func (tp *TemplateProcessor) staticCheck(allValues map[string]any) error {
+	sources, isDir, err := tp.listTemplateSources()
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	check := func(name, content string, data map[string]any) error {
+		refs, err := templatepkg.FindReferences(name, content)
+		if err != nil {
+			return fmt.Errorf("failed to parse template %s: %w", name, err)
+		}
+		for _, ref := range refs {
+			path := ref.Path
+			if tp.config.HelmCompat && len(path) > 1 && path[0] != "Values" {
+				// Only the values vary; fields of the other builtin objects are fixed
+				path = path[:1]
+			}
+			if !templatepkg.LookupPath(data, path) {
+				missing = append(missing, ref.String())
+			}
+		}
+		return nil
+	}
+
+	for _, source := range sources {
+		name, scopedValues := source, allValues
+		relativePath := filepath.Base(source)
+		if isDir {
+			relativePath, err = filepath.Rel(tp.config.TemplateFile, source)
+			if err != nil {
+				return fmt.Errorf("failed to calculate relative path: %w", err)
+			}
+			if err := tp.loadDirectoryScope(tp.config.TemplateFile, filepath.Dir(relativePath), allValues); err != nil {
+				return err
+			}
+			scopedValues, _ = tp.scopedValues(relativePath, allValues)
+			if err := check("path "+relativePath, relativePath, tp.templateData(templatepkg.File{SourcePath: source, RelativePath: relativePath}, scopedValues)); err != nil {
+				return err
+			}
+			name = relativePath
+		}
+
+		content, err := os.ReadFile(source)
+		if err != nil {
+			return fmt.Errorf("failed to read template file %s: %w", source, err)
+		}
+		if err := check(name, string(content), tp.templateData(templatepkg.File{SourcePath: source, RelativePath: relativePath}, scopedValues)); err != nil {
+			return err
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("static check found %d undefined value reference(s):\n  %s", len(missing), strings.Join(missing, "\n  "))
+	}
+	return nil
+}
+
+// referencedValueKeys returns the top-level value keys used by every template and
+// templated path, and whether any of them uses the values as a whole.
+func (tp *TemplateProcessor) referencedValueKeys() ([]string, bool, error) {
+	sources, isDir, err := tp.listTemplateSources()
+	if err != nil {
+		return nil, false, err
+	}
+
+	var keys []string
+	collect := func(name, content string) (bool, error) {
+		found, all, err := templatepkg.ReferencedTopLevelKeys(name, content)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse template %s: %w", name, err)
+		}
+		keys = append(keys, found...)
+		return all, nil
+	}
+
+	for _, source := range sources {
+		if isDir {
+			relativePath, err := filepath.Rel(tp.config.TemplateFile, source)
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to calculate relative path: %w", err)
+			}
+			if all, err := collect(relativePath, relativePath); err != nil || all {
+				return nil, all, err
+			}
+		}
+
+		content, err := os.ReadFile(source)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read template file %s: %w", source, err)
+		}
+		if all, err := collect(source, string(content)); err != nil || all {
+			return nil, all, err
+		}
+	}
+
+	return keys, false, nil
+}
+
+// listTemplateSources returns the template files to process and whether the template
+// path is a directory.
+func (tp *TemplateProcessor) listTemplateSources() ([]string, bool, error) {
+	templatePath := tp.config.TemplateFile
+	fileInfo, err := os.Stat(templatePath)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to stat template path: %w", err)
+	}
+	if !fileInfo.IsDir() {
+		return []string{templatePath}, false, nil
+	}
+
+	ignore, err := templatepkg.LoadIgnoreRules(templatePath)
+	if err != nil {
+		return nil, false, err
+	}
+	filter, err := templatepkg.NewPathFilter(tp.config.Include, tp.config.Exclude)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var sources []string
+	err = filepath.Walk(templatePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && deps.IsVendorDir(templatePath, path) {
+			return filepath.SkipDir
+		}
+		if isExcluded(ignore, filter, templatePath, path, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() && strings.HasSuffix(strings.ToLower(info.Name()), ".tpl") {
+			sources = append(sources, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("error walking template directory: %w", err)
+	}
+	return sources, true, nil
+}
@@ -0,0 +1,126 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/menta2k/templater/internal/config"
+)
+
+func TestParseOnly(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		wantError []string
+	}{
+		{
+			name: "valid tree",
+			files: map[string]string{
+				"config.tpl":                 "name: {{ .app.name | upper }}",
+				"srv/{{.app.name}}/site.tpl": "{{ range .hosts }}{{ . }}{{ end }}",
+			},
+		},
+		{
+			name: "all errors reported",
+			files: map[string]string{
+				"ok.tpl":            "{{ .name }}",
+				"broken.tpl":        "{{ if .enabled }}yes",
+				"unknown.tpl":       "{{ noSuchFunction .name }}",
+				"{{.app.name/x.tpl": "fine",
+			},
+			wantError: []string{"3 of 4 template(s)", "broken.tpl", "unknown.tpl", "path template"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "test-parse-only-*")
+			if err != nil {
+				t.Fatalf("Failed to create temp dir: %v", err)
+			}
+			defer os.RemoveAll(tempDir)
+
+			templateDir := filepath.Join(tempDir, "templates")
+			for name, content := range tt.files {
+				path := filepath.Join(templateDir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatalf("Failed to create dir: %v", err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatalf("Failed to write %s: %v", name, err)
+				}
+			}
+
+			outputDir := filepath.Join(tempDir, "output")
+			// A missing values file proves values are not loaded
+			cfg := config.NewConfig(templateDir, filepath.Join(tempDir, "missing.yaml"), outputDir, nil, true, true)
+			cfg.ParseOnly = true
+			err = NewTemplateProcessor(cfg).Process()
+
+			if len(tt.wantError) == 0 {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			} else {
+				if err == nil {
+					t.Fatal("Expected error but got none")
+				}
+				for _, expected := range tt.wantError {
+					if !strings.Contains(err.Error(), expected) {
+						t.Errorf("Expected error to contain %q, got %v", expected, err)
+					}
+				}
+			}
+
+			if _, err := os.Stat(outputDir); !os.IsNotExist(err) {
+				t.Error("Expected no output to be written")
+			}
+		})
+	}
+}
+
+func TestStaticCheck(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-static-check-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	templateDir := filepath.Join(tempDir, "templates")
+	files := map[string]string{
+		"config.tpl":            "name: {{ .app.name }}\nport: {{ .app.port }}\n",
+		"{{.region}}/infra.tpl": "{{ with .database }}{{ .host }}{{ end }}",
+	}
+	for name, content := range files {
+		path := filepath.Join(templateDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	outputDir := filepath.Join(tempDir, "output")
+	cfg := config.NewConfig(templateDir, "", outputDir, []string{"app.name=web"}, true, true)
+	cfg.StaticCheck = true
+	err = NewTemplateProcessor(cfg).Process()
+	if err == nil {
+		t.Fatal("Expected static check error")
+	}
+	for _, expected := range []string{"4 undefined", "config.tpl:2:13: .app.port", "path {{.region}}/infra.tpl:1:2: .region", ".database.host"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to contain %q, got %v", expected, err)
+		}
+	}
+	if _, err := os.Stat(outputDir); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be rendered when the static check fails")
+	}
+
+	cfg.SetValues = []string{"app.name=web", "app.port=80", "region=eu", "database.host=db"}
+	if err := NewTemplateProcessor(cfg).Process(); err != nil {
+		t.Fatalf("Expected static check to pass, got %v", err)
+	}
+}
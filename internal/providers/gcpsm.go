@@ -0,0 +1,147 @@
+package providers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"gopkg.in/yaml.v3"
+)
+
+// gcpSecretManagerScheme is the scheme of Secret Manager references.
+const gcpSecretManagerScheme = "gcp-sm"
+
+// gcpSecretManagerEndpoint is the base URL of the Secret Manager API.
+var gcpSecretManagerEndpoint = "https://secretmanager.googleapis.com"
+
+// gcpTokenSource returns the token source used to authorize Secret Manager requests.
+// It uses Application Default Credentials: GOOGLE_APPLICATION_CREDENTIALS, the gcloud
+// user credentials or the metadata server when running on Google Cloud.
+var gcpTokenSource = func(ctx context.Context) (oauth2.TokenSource, error) {
+	return google.DefaultTokenSource(ctx, "https://www.googleapis.com/auth/cloud-platform")
+}
+
+// gcpSecretReferencesAllowed is set with AllowGCPSecretReferences.
+var gcpSecretReferencesAllowed bool
+
+// AllowGCPSecretReferences enables resolving gcp-sm:// references in values. They fail
+// otherwise, since any values source could otherwise fetch secrets with the ambient
+// credentials. Loading a secret with --values-from needs no opt-in.
+func AllowGCPSecretReferences(allowed bool) {
+	gcpSecretReferencesAllowed = allowed
+}
+
+// loadGCPSecret loads values from a Secret Manager secret version, e.g.
+// gcp-sm://projects/my-project/secrets/app-config/versions/latest. A payload holding a
+// YAML or JSON mapping becomes the values; any other payload is stored under the
+// secret name.
+func loadGCPSecret(ctx context.Context, location string) (map[string]any, error) {
+	name, err := parseGCPSecretName(location)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := accessGCPSecret(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]any
+	if err := yaml.Unmarshal(payload, &values); err == nil && values != nil {
+		return values, nil
+	}
+
+	secret := strings.Split(name, "/")[3]
+	return map[string]any{secret: string(payload)}, nil
+}
+
+// resolveGCPSecret returns the payload of a gcp-sm:// reference found in a values file.
+func resolveGCPSecret(ctx context.Context, location string) (string, error) {
+	if !gcpSecretReferencesAllowed {
+		return "", fmt.Errorf("%s:// references fetch secrets with your Google Cloud credentials, which must be enabled with --allow-gcp-sm", gcpSecretManagerScheme)
+	}
+	name, err := parseGCPSecretName(location)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := accessGCPSecret(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return string(payload), nil
+}
+
+// parseGCPSecretName returns the secret version resource name of a gcp-sm:// reference.
+// The version defaults to latest.
+func parseGCPSecretName(location string) (string, error) {
+	name := strings.Trim(strings.TrimPrefix(location, gcpSecretManagerScheme+"://"), "/")
+	parts := strings.Split(name, "/")
+
+	if len(parts) == 4 {
+		parts = append(parts, "versions", "latest")
+	}
+	if len(parts) != 6 || parts[0] != "projects" || parts[2] != "secrets" || parts[4] != "versions" ||
+		parts[1] == "" || parts[3] == "" || parts[5] == "" {
+		return "", fmt.Errorf("invalid Secret Manager reference %s (expected gcp-sm://projects/<project>/secrets/<name>[/versions/<version>])", location)
+	}
+
+	return strings.Join(parts, "/"), nil
+}
+
+// accessGCPSecret returns the payload of a secret version.
+func accessGCPSecret(ctx context.Context, name string) ([]byte, error) {
+	tokens, err := gcpTokenSource(ctx)
+	if err != nil {
+		if !replaying {
+			return nil, fmt.Errorf("failed to find Google application default credentials: %w", err)
+		}
+		tokens = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "replay"})
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpSecretManagerEndpoint+"/v1/"+name+":access", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := oauth2.NewClient(context.WithValue(ctx, oauth2.HTTPClient, httpClient), tokens).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Secret Manager request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Secret Manager response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(data, &apiErr)
+		return nil, fmt.Errorf("failed to access secret %s: %s %s", name, resp.Status, apiErr.Error.Message)
+	}
+
+	var response struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode Secret Manager response: %w", err)
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(response.Payload.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secret payload: %w", err)
+	}
+	return payload, nil
+}
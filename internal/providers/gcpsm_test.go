@@ -0,0 +1,160 @@
+package providers
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// fakeSecretManager serves secret versions and swaps in a static access token.
+func fakeSecretManager(t *testing.T, secrets map[string]string) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/"), ":access")
+		payload, ok := secrets[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":404,"message":"Secret not found"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"` + name + `","payload":{"data":"` + base64.StdEncoding.EncodeToString([]byte(payload)) + `"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	endpoint, tokenSource := gcpSecretManagerEndpoint, gcpTokenSource
+	gcpSecretManagerEndpoint = server.URL
+	gcpTokenSource = func(ctx context.Context) (oauth2.TokenSource, error) {
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "test-token"}), nil
+	}
+	t.Cleanup(func() {
+		gcpSecretManagerEndpoint, gcpTokenSource = endpoint, tokenSource
+	})
+}
+
+func TestLoadGCPSecret(t *testing.T) {
+	fakeSecretManager(t, map[string]string{
+		"projects/p/secrets/app-config/versions/latest": "db:\n  host: db.internal\n",
+		"projects/p/secrets/app-json/versions/3":        `{"replicas": 2}`,
+		"projects/p/secrets/api-key/versions/latest":    "k3y",
+	})
+
+	tests := []struct {
+		name      string
+		location  string
+		expected  map[string]any
+		wantError bool
+	}{
+		{
+			name:     "YAML mapping",
+			location: "gcp-sm://projects/p/secrets/app-config/versions/latest",
+			expected: map[string]any{"db": map[string]any{"host": "db.internal"}},
+		},
+		{
+			name:     "JSON mapping with pinned version",
+			location: "gcp-sm://projects/p/secrets/app-json/versions/3",
+			expected: map[string]any{"replicas": 2},
+		},
+		{
+			name:     "plain payload with default version",
+			location: "gcp-sm://projects/p/secrets/api-key",
+			expected: map[string]any{"api-key": "k3y"},
+		},
+		{
+			name:      "missing secret",
+			location:  "gcp-sm://projects/p/secrets/missing",
+			wantError: true,
+		},
+		{
+			name:      "invalid reference",
+			location:  "gcp-sm://p/api-key",
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := Load(context.Background(), tt.location)
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(values, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, values)
+			}
+		})
+	}
+}
+
+func TestResolveReferences(t *testing.T) {
+	fakeSecretManager(t, map[string]string{
+		"projects/p/secrets/db-password/versions/latest": "s3cret",
+		"projects/p/secrets/token/versions/2":            "t0ken",
+	})
+	AllowGCPSecretReferences(true)
+	t.Cleanup(func() { AllowGCPSecretReferences(false) })
+
+	values := map[string]any{
+		"database": map[string]any{
+			"host":     "db.internal",
+			"password": "gcp-sm://projects/p/secrets/db-password/versions/latest",
+		},
+		"tokens": []any{"gcp-sm://projects/p/secrets/token/versions/2", "plain"},
+		"legacy": map[interface{}]interface{}{"password": "gcp-sm://projects/p/secrets/db-password"},
+		"url":    "https://example.com",
+		"port":   5432,
+	}
+	if err := ResolveReferences(values); err != nil {
+		t.Fatalf("ResolveReferences failed: %v", err)
+	}
+
+	expected := map[string]any{
+		"database": map[string]any{"host": "db.internal", "password": "s3cret"},
+		"tokens":   []any{"t0ken", "plain"},
+		"legacy":   map[interface{}]interface{}{"password": "s3cret"},
+		"url":      "https://example.com",
+		"port":     5432,
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+
+	err := ResolveReferences(map[string]any{"db": map[string]any{"password": "gcp-sm://projects/p/secrets/missing"}})
+	if err == nil || !strings.Contains(err.Error(), "db") || !strings.Contains(err.Error(), "Secret not found") {
+		t.Errorf("Expected error naming the key and the API message, got %v", err)
+	}
+}
+
+func TestGCPSecretReferencesMustBeAllowed(t *testing.T) {
+	fakeSecretManager(t, map[string]string{"projects/p/secrets/db-password/versions/latest": "s3cret"})
+
+	values := map[string]any{"password": "gcp-sm://projects/p/secrets/db-password"}
+	err := ResolveReferences(values)
+	if err == nil || !strings.Contains(err.Error(), "--allow-gcp-sm") {
+		t.Fatalf("Expected error mentioning --allow-gcp-sm, got %v", err)
+	}
+	if values["password"] != "gcp-sm://projects/p/secrets/db-password" {
+		t.Errorf("Expected reference to be left unresolved, got %q", values["password"])
+	}
+
+	// Loading values from a secret is requested explicitly and needs no opt-in
+	if _, err := Load(context.Background(), "gcp-sm://projects/p/secrets/db-password"); err != nil {
+		t.Errorf("Load failed: %v", err)
+	}
+}
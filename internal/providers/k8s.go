@@ -0,0 +1,382 @@
+package providers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// kubernetesScheme is the scheme of Kubernetes ConfigMap and Secret sources.
+const kubernetesScheme = "k8s"
+
+// kubeServiceAccountDir holds the credentials mounted into pods, used when no kubeconfig
+// is found while running inside a cluster.
+var kubeServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// kubeconfig is the subset of a kubeconfig file needed to reach the API server.
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string      `yaml:"name"`
+		Cluster kubeCluster `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Users []struct {
+		Name string   `yaml:"name"`
+		User kubeUser `yaml:"user"`
+	} `yaml:"users"`
+}
+
+// kubeCluster describes how to reach an API server.
+type kubeCluster struct {
+	Server                   string `yaml:"server"`
+	CertificateAuthority     string `yaml:"certificate-authority"`
+	CertificateAuthorityData string `yaml:"certificate-authority-data"`
+	InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+}
+
+// kubeUser holds the credentials presented to an API server.
+type kubeUser struct {
+	Token                 string    `yaml:"token"`
+	TokenFile             string    `yaml:"tokenFile"`
+	ClientCertificate     string    `yaml:"client-certificate"`
+	ClientCertificateData string    `yaml:"client-certificate-data"`
+	ClientKey             string    `yaml:"client-key"`
+	ClientKeyData         string    `yaml:"client-key-data"`
+	Username              string    `yaml:"username"`
+	Password              string    `yaml:"password"`
+	Exec                  yaml.Node `yaml:"exec"`
+	AuthProvider          yaml.Node `yaml:"auth-provider"`
+}
+
+// kubeClient calls the Kubernetes API of one cluster.
+type kubeClient struct {
+	server    string
+	namespace string
+	user      kubeUser
+	client    *http.Client
+}
+
+// loadKubernetes loads the data of a ConfigMap or Secret, e.g.
+// k8s://configmap/prod/app-config or k8s://secret/app-credentials?context=staging. Without
+// a namespace, the namespace of the context is used. Keys ending in .yaml, .yml or .json
+// whose content is a mapping are merged into the values; other keys are stored as strings.
+func loadKubernetes(ctx context.Context, location string) (map[string]any, error) {
+	kind, namespace, name, kubeContext, err := parseKubernetesLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newKubeClient(kubeContext)
+	if err != nil {
+		return nil, err
+	}
+	if namespace == "" {
+		namespace = client.namespace
+	}
+
+	resource := "configmaps"
+	if kind == "secret" {
+		resource = "secrets"
+	}
+	var object struct {
+		Data       map[string]string `json:"data"`
+		BinaryData map[string]string `json:"binaryData"`
+	}
+	path := "/api/v1/namespaces/" + url.PathEscape(namespace) + "/" + resource + "/" + url.PathEscape(name)
+	if err := client.get(ctx, path, &object); err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]string, len(object.Data)+len(object.BinaryData))
+	encoded := object.BinaryData
+	if kind == "secret" {
+		encoded = object.Data
+	} else {
+		for key, value := range object.Data {
+			data[key] = value
+		}
+	}
+	for key, value := range encoded {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode key %s of %s %s/%s: %w", key, kind, namespace, name, err)
+		}
+		data[key] = string(decoded)
+	}
+
+	return kubernetesValues(data), nil
+}
+
+// kubernetesValues converts ConfigMap or Secret data to values.
+func kubernetesValues(data map[string]string) map[string]any {
+	values := make(map[string]any, len(data))
+	for key, value := range data {
+		switch strings.ToLower(filepath.Ext(key)) {
+		case ".yaml", ".yml", ".json":
+			var mapping map[string]any
+			if err := yaml.Unmarshal([]byte(value), &mapping); err == nil && mapping != nil {
+				for k, v := range mapping {
+					values[k] = v
+				}
+				continue
+			}
+		}
+		values[key] = value
+	}
+	return values
+}
+
+// parseKubernetesLocation splits a k8s://<configmap|secret>/[namespace/]name[?context=c] location.
+func parseKubernetesLocation(location string) (kind, namespace, name, kubeContext string, err error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", "", "", "", fmt.Errorf("invalid Kubernetes location: %w", err)
+	}
+
+	parts := strings.Split(strings.Trim(u.Host+u.Path, "/"), "/")
+	kind = parts[0]
+	switch {
+	case kind != "configmap" && kind != "secret":
+	case len(parts) == 2 && parts[1] != "":
+		return kind, "", parts[1], u.Query().Get("context"), nil
+	case len(parts) == 3 && parts[1] != "" && parts[2] != "":
+		return kind, parts[1], parts[2], u.Query().Get("context"), nil
+	}
+	return "", "", "", "", fmt.Errorf("invalid Kubernetes location %s (expected %s://<configmap|secret>/[namespace/]name)", location, kubernetesScheme)
+}
+
+// newKubeClient configures a client from the kubeconfig in KUBECONFIG or ~/.kube/config,
+// using kubeContext or the current context. Inside a pod without a kubeconfig, the
+// mounted service account is used.
+func newKubeClient(kubeContext string) (*kubeClient, error) {
+	path := kubeconfigPath()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && os.Getenv("KUBERNETES_SERVICE_HOST") != "" && kubeContext == "" {
+		return inClusterKubeClient()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+
+	var config kubeconfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig %s: %w", path, err)
+	}
+
+	cluster, user, namespace, err := config.resolve(kubeContext)
+	if err != nil {
+		return nil, fmt.Errorf("%w in kubeconfig %s", err, path)
+	}
+
+	dir := filepath.Dir(path)
+	tlsConfig, err := kubeTLSConfig(cluster, user, dir)
+	if err != nil {
+		return nil, err
+	}
+	if user.TokenFile != "" && !filepath.IsAbs(user.TokenFile) {
+		user.TokenFile = filepath.Join(dir, user.TokenFile)
+	}
+
+	return &kubeClient{
+		server:    strings.TrimSuffix(cluster.Server, "/"),
+		namespace: namespace,
+		user:      user,
+		client:    &http.Client{Transport: transportWithTLS(tlsConfig)},
+	}, nil
+}
+
+// resolve returns the cluster, user and namespace of kubeContext, or of the current
+// context when kubeContext is empty.
+func (c *kubeconfig) resolve(kubeContext string) (kubeCluster, kubeUser, string, error) {
+	if kubeContext == "" {
+		kubeContext = c.CurrentContext
+	}
+
+	for _, ctx := range c.Contexts {
+		if ctx.Name != kubeContext {
+			continue
+		}
+
+		namespace := ctx.Context.Namespace
+		if namespace == "" {
+			namespace = "default"
+		}
+		var cluster kubeCluster
+		for _, named := range c.Clusters {
+			if named.Name == ctx.Context.Cluster {
+				cluster = named.Cluster
+			}
+		}
+		var user kubeUser
+		for _, named := range c.Users {
+			if named.Name == ctx.Context.User {
+				user = named.User
+			}
+		}
+
+		if cluster.Server == "" {
+			return kubeCluster{}, kubeUser{}, "", fmt.Errorf("context %q has no cluster server", kubeContext)
+		}
+		if !user.Exec.IsZero() || !user.AuthProvider.IsZero() {
+			return kubeCluster{}, kubeUser{}, "", fmt.Errorf("context %q uses an exec or auth-provider plugin, which is not supported (use a token or client certificate)", kubeContext)
+		}
+		return cluster, user, namespace, nil
+	}
+	return kubeCluster{}, kubeUser{}, "", fmt.Errorf("context %q not found", kubeContext)
+}
+
+// kubeTLSConfig returns the TLS configuration verifying the cluster and presenting the
+// user's client certificate. Relative file paths are resolved against dir.
+func kubeTLSConfig(cluster kubeCluster, user kubeUser, dir string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cluster.InsecureSkipTLSVerify} //nolint:gosec // explicitly requested by the kubeconfig
+
+	ca, err := kubeData(cluster.CertificateAuthorityData, cluster.CertificateAuthority, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cluster certificate authority: %w", err)
+	}
+	if len(ca) > 0 {
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("invalid cluster certificate authority")
+		}
+	}
+
+	cert, err := kubeData(user.ClientCertificateData, user.ClientCertificate, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client certificate: %w", err)
+	}
+	key, err := kubeData(user.ClientKeyData, user.ClientKey, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client key: %w", err)
+	}
+	if len(cert) > 0 {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{pair}
+	}
+
+	return tlsConfig, nil
+}
+
+// inClusterKubeClient configures a client from the service account mounted into a pod.
+func inClusterKubeClient() (*kubeClient, error) {
+	ca, err := os.ReadFile(filepath.Join(kubeServiceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account certificate authority: %w", err)
+	}
+	tlsConfig := &tls.Config{RootCAs: x509.NewCertPool()}
+	if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("invalid service account certificate authority")
+	}
+
+	namespace := "default"
+	if data, err := os.ReadFile(filepath.Join(kubeServiceAccountDir, "namespace")); err == nil {
+		namespace = strings.TrimSpace(string(data))
+	}
+
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if port == "" {
+		port = "443"
+	}
+	return &kubeClient{
+		server:    "https://" + strings.Trim(host, "[]") + ":" + port,
+		namespace: namespace,
+		user:      kubeUser{TokenFile: filepath.Join(kubeServiceAccountDir, "token")},
+		client:    &http.Client{Transport: transportWithTLS(tlsConfig)},
+	}, nil
+}
+
+// kubeconfigPath returns the first file in KUBECONFIG, or ~/.kube/config.
+func kubeconfigPath() string {
+	if paths := filepath.SplitList(os.Getenv("KUBECONFIG")); len(paths) > 0 && paths[0] != "" {
+		return paths[0]
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".kube", "config")
+	}
+	return filepath.Join(home, ".kube", "config")
+}
+
+// kubeData returns base64-encoded inline data, or the content of file relative to dir.
+func kubeData(data, file, dir string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file == "" {
+		return nil, nil
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	return os.ReadFile(file)
+}
+
+// get fetches an API object and decodes it into response.
+func (c *kubeClient) get(ctx context.Context, path string, response any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.server+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	token := c.user.Token
+	if c.user.TokenFile != "" {
+		data, err := os.ReadFile(c.user.TokenFile)
+		if err != nil && !replaying {
+			return fmt.Errorf("failed to read token file: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	switch {
+	case token != "":
+		req.Header.Set("Authorization", "Bearer "+token)
+	case c.user.Username != "":
+		req.SetBasicAuth(c.user.Username, c.user.Password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Kubernetes API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read Kubernetes API response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var status struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(data, &status)
+		return fmt.Errorf("failed to get %s: %s %s", path, resp.Status, status.Message)
+	}
+
+	if err := json.Unmarshal(data, response); err != nil {
+		return fmt.Errorf("failed to decode Kubernetes API response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package providers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeKubernetes serves ConfigMaps and Secrets over TLS and points KUBECONFIG at a
+// kubeconfig for it, whose current context uses the "apps" namespace.
+func fakeKubernetes(t *testing.T, objects map[string]any) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer kube-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"kind":"Status","message":"Unauthorized"}`))
+			return
+		}
+		object, ok := objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"kind":"Status","message":"configmaps \"missing\" not found"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(object)
+	}))
+	t.Cleanup(server.Close)
+
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	kubeconfig := `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+  - name: dev
+    cluster:
+      server: ` + server.URL + `
+      certificate-authority-data: ` + base64.StdEncoding.EncodeToString(ca) + `
+  - name: plugin
+    cluster:
+      server: ` + server.URL + `
+contexts:
+  - name: dev
+    context:
+      cluster: dev
+      user: dev
+      namespace: apps
+  - name: plugin
+    context:
+      cluster: plugin
+      user: plugin
+users:
+  - name: dev
+    user:
+      token: kube-token
+  - name: plugin
+    user:
+      exec:
+        command: aws
+`
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(kubeconfig), 0o600); err != nil {
+		t.Fatalf("Failed to write kubeconfig: %v", err)
+	}
+	t.Setenv("KUBECONFIG", path)
+	return server
+}
+
+func TestLoadKubernetes(t *testing.T) {
+	fakeKubernetes(t, map[string]any{
+		"/api/v1/namespaces/apps/configmaps/app-config": map[string]any{
+			"data": map[string]string{
+				"logLevel":    "debug",
+				"values.yaml": "db:\n  host: db.internal\n",
+				"notes.json":  "not json",
+			},
+			"binaryData": map[string]string{"banner": base64.StdEncoding.EncodeToString([]byte("hi"))},
+		},
+		"/api/v1/namespaces/prod/secrets/db": map[string]any{
+			"data": map[string]string{"password": base64.StdEncoding.EncodeToString([]byte("s3cret"))},
+		},
+	})
+
+	tests := []struct {
+		name      string
+		location  string
+		expected  map[string]any
+		wantError string
+	}{
+		{
+			name:     "configmap in the context namespace",
+			location: "k8s://configmap/app-config",
+			expected: map[string]any{
+				"logLevel":   "debug",
+				"db":         map[string]any{"host": "db.internal"},
+				"notes.json": "not json",
+				"banner":     "hi",
+			},
+		},
+		{
+			name:     "secret in an explicit namespace",
+			location: "k8s://secret/prod/db",
+			expected: map[string]any{"password": "s3cret"},
+		},
+		{
+			name:      "missing object",
+			location:  "k8s://configmap/apps/missing",
+			wantError: "not found",
+		},
+		{
+			name:      "unknown context",
+			location:  "k8s://configmap/app-config?context=prod",
+			wantError: `context "prod" not found`,
+		},
+		{
+			name:      "exec plugin",
+			location:  "k8s://configmap/app-config?context=plugin",
+			wantError: "not supported",
+		},
+		{
+			name:      "invalid kind",
+			location:  "k8s://deployment/apps/web",
+			wantError: "invalid Kubernetes location",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := Load(context.Background(), tt.location)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(values, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, values)
+			}
+		})
+	}
+}
+
+func TestLoadKubernetesInCluster(t *testing.T) {
+	server := fakeKubernetes(t, map[string]any{
+		"/api/v1/namespaces/jobs/configmaps/settings": map[string]any{"data": map[string]string{"mode": "batch"}},
+	})
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"ca.crt":    string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})),
+		"token":     "kube-token\n",
+		"namespace": "jobs",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	original := kubeServiceAccountDir
+	kubeServiceAccountDir = dir
+	t.Cleanup(func() { kubeServiceAccountDir = original })
+
+	host, port, _ := strings.Cut(strings.TrimPrefix(server.URL, "https://"), ":")
+	t.Setenv("KUBECONFIG", filepath.Join(dir, "missing"))
+	t.Setenv("KUBERNETES_SERVICE_HOST", host)
+	t.Setenv("KUBERNETES_SERVICE_PORT", port)
+
+	values, err := Load(context.Background(), "k8s://configmap/settings")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(values, map[string]any{"mode": "batch"}) {
+		t.Errorf("Expected in-cluster ConfigMap values, got %v", values)
+	}
+}
@@ -0,0 +1,60 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+// keyringScheme is the scheme of references to secrets in the OS keyring.
+const keyringScheme = "keyring"
+
+// keyringGet reads a secret from the macOS Keychain, the Windows Credential Manager or
+// the Secret Service (e.g. GNOME Keyring or KWallet) on Linux.
+var keyringGet = keyring.Get
+
+// keyringAllowed is set with AllowKeyring.
+var keyringAllowed bool
+
+// AllowKeyring enables resolving keyring:// references. They fail otherwise, since any
+// values source could otherwise read secrets from the user's keyring.
+func AllowKeyring(allowed bool) {
+	keyringAllowed = allowed
+}
+
+// resolveKeyring returns the secret of a keyring://<service>/<account> reference found
+// in a values file. Both parts may be percent-encoded, e.g. for spaces.
+func resolveKeyring(_ context.Context, location string) (string, error) {
+	if !keyringAllowed {
+		return "", fmt.Errorf("%s:// references read the OS keyring, which must be enabled with --allow-keyring", keyringScheme)
+	}
+	service, account, err := parseKeyringReference(location)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := keyringGet(service, account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", fmt.Errorf("no keyring secret for service %q and account %q", service, account)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read keyring secret for service %q and account %q: %w", service, account, err)
+	}
+	return secret, nil
+}
+
+// parseKeyringReference splits a keyring://<service>/<account> reference. The account
+// may contain further slashes.
+func parseKeyringReference(location string) (service, account string, err error) {
+	rawService, rawAccount, _ := strings.Cut(strings.TrimPrefix(location, keyringScheme+"://"), "/")
+	service, serviceErr := url.PathUnescape(rawService)
+	account, accountErr := url.PathUnescape(rawAccount)
+	if serviceErr != nil || accountErr != nil || service == "" || account == "" {
+		return "", "", fmt.Errorf("invalid keyring reference %s (expected keyring://<service>/<account>)", location)
+	}
+	return service, account, nil
+}
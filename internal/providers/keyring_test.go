@@ -0,0 +1,77 @@
+package providers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestResolveKeyringReferences(t *testing.T) {
+	keyring.MockInit()
+	AllowKeyring(true)
+	t.Cleanup(func() { AllowKeyring(false) })
+	for _, secret := range []struct{ service, account, password string }{
+		{"myapp", "db", "s3cret"},
+		{"my app", "dev@example.com", "t0ken"},
+		{"myapp", "team/ci", "c1"},
+	} {
+		if err := keyring.Set(secret.service, secret.account, secret.password); err != nil {
+			t.Fatalf("Failed to store keyring secret: %v", err)
+		}
+	}
+
+	values := map[string]any{
+		"database": map[string]any{"password": "keyring://myapp/db"},
+		"token":    "keyring://my%20app/dev@example.com",
+		"ci":       "keyring://myapp/team/ci",
+	}
+	if err := ResolveReferences(values); err != nil {
+		t.Fatalf("ResolveReferences failed: %v", err)
+	}
+	expected := map[string]any{
+		"database": map[string]any{"password": "s3cret"},
+		"token":    "t0ken",
+		"ci":       "c1",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+
+	tests := []struct {
+		name      string
+		reference string
+		wantError string
+	}{
+		{name: "missing secret", reference: "keyring://myapp/missing", wantError: `no keyring secret for service "myapp" and account "missing"`},
+		{name: "no account", reference: "keyring://myapp", wantError: "invalid keyring reference"},
+		{name: "no service", reference: "keyring:///db", wantError: "invalid keyring reference"},
+		{name: "invalid escape", reference: "keyring://myapp/%zz", wantError: "invalid keyring reference"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ResolveReferences(map[string]any{"secret": tt.reference})
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+			}
+		})
+	}
+}
+
+func TestKeyringMustBeAllowed(t *testing.T) {
+	keyring.MockInit()
+	if err := keyring.Set("myapp", "db", "s3cret"); err != nil {
+		t.Fatalf("Failed to store keyring secret: %v", err)
+	}
+
+	values := map[string]any{"password": "keyring://myapp/db"}
+	err := ResolveReferences(values)
+	if err == nil || !strings.Contains(err.Error(), "--allow-keyring") {
+		t.Fatalf("Expected error mentioning --allow-keyring, got %v", err)
+	}
+	if values["password"] != "keyring://myapp/db" {
+		t.Errorf("Expected reference to be left unresolved, got %q", values["password"])
+	}
+}
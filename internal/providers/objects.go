@@ -0,0 +1,249 @@
+package providers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/menta2k/templater/internal/awsauth"
+	"github.com/menta2k/templater/internal/cache"
+)
+
+// Schemes of values files kept in object storage.
+const (
+	s3Scheme  = "s3"
+	gcsScheme = "gs"
+)
+
+// objectSchemaFile is the schema downloaded with a values file when stored next to it.
+const objectSchemaFile = "values.schema.json"
+
+// gcsEndpoint is the base URL of the Cloud Storage JSON API.
+var gcsEndpoint = "https://storage.googleapis.com"
+
+// errObjectNotFound reports a missing object.
+var errObjectNotFound = errors.New("object not found")
+
+// IsObjectLocation reports whether location is an s3:// or gs:// object.
+func IsObjectLocation(location string) bool {
+	return strings.HasPrefix(location, s3Scheme+"://") || strings.HasPrefix(location, gcsScheme+"://")
+}
+
+// DefaultObjectDir returns the directory holding the values files downloaded by FetchValuesFile.
+func DefaultObjectDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate user cache directory: %w", err)
+	}
+	return filepath.Join(base, "templater", "objects"), nil
+}
+
+// FetchValuesFile downloads a values file stored as s3://bucket/key[?region=...] or
+// gs://bucket/object into its own directory below dir, and returns its local path. A
+// values.schema.json object next to it is downloaded too, so schema validation works as
+// for local files. Following policy, earlier downloads are reused, and objects whose ETag
+// did not change are not downloaded again. S3 uses the AWS default credential chain;
+// Cloud Storage uses Application Default Credentials, or none with STORAGE_EMULATOR_HOST.
+func FetchValuesFile(ctx context.Context, location, dir string, policy cache.SourcePolicy) (string, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("invalid object location %s: %w", location, err)
+	}
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || key == "" || strings.HasSuffix(key, "/") {
+		return "", fmt.Errorf("invalid object location %s (expected %s://bucket/key)", location, u.Scheme)
+	}
+
+	sum := sha256.Sum256([]byte(location))
+	localDir := filepath.Join(dir, hex.EncodeToString(sum[:8]))
+	localPath := filepath.Join(localDir, path.Base(key))
+	entryPath := localDir + ".json"
+
+	entry := cache.LoadSourceEntry(entryPath)
+	if _, err := os.Stat(localPath); err != nil {
+		entry = nil
+	}
+	cached, err := policy.UseCached(entry, location)
+	if err != nil {
+		return "", err
+	}
+	if cached {
+		return localPath, nil
+	}
+
+	get, err := objectGetter(ctx, location)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(localDir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create download directory: %w", err)
+	}
+	if err := downloadObject(get, u.Scheme, u.Host, key, localPath, entryPath); err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", location, err)
+	}
+
+	schemaPath := filepath.Join(localDir, objectSchemaFile)
+	schemaEntryPath := localDir + "." + objectSchemaFile
+	err = downloadObject(get, u.Scheme, u.Host, path.Join(path.Dir(key), objectSchemaFile), schemaPath, schemaEntryPath)
+	switch {
+	case errors.Is(err, errObjectNotFound):
+		for _, stale := range []string{schemaPath, schemaEntryPath} {
+			if err := os.Remove(stale); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return "", fmt.Errorf("failed to remove stale schema: %w", err)
+			}
+		}
+	case err != nil:
+		return "", fmt.Errorf("failed to download values schema for %s: %w", location, err)
+	}
+	return localPath, nil
+}
+
+// downloadObject writes bucket/key to localPath, unless the copy recorded at entryPath
+// has the object's current ETag, and records the ETag.
+func downloadObject(get objectGetFunc, scheme, bucket, key, localPath, entryPath string) error {
+	entry := cache.LoadSourceEntry(entryPath)
+	etag := ""
+	if _, err := os.Stat(localPath); err == nil && entry != nil {
+		etag = entry.Version
+	}
+
+	obj, err := get(bucket, key, etag)
+	if err != nil {
+		return err
+	}
+	if !obj.notModified {
+		if err := os.WriteFile(localPath, obj.data, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path.Base(key), err)
+		}
+		etag = obj.etag
+	}
+
+	entry = &cache.SourceEntry{Location: scheme + "://" + bucket + "/" + key, Version: etag, Fetched: time.Now()}
+	return entry.Save(entryPath)
+}
+
+// object is a downloaded object. notModified reports that it still has the ETag passed
+// to the download, and data is then empty.
+type object struct {
+	data        []byte
+	etag        string
+	notModified bool
+}
+
+// objectGetFunc downloads an object, conditionally when etag is set.
+type objectGetFunc func(bucket, key, etag string) (*object, error)
+
+// objectGetter returns a function downloading objects from the storage service of location.
+func objectGetter(ctx context.Context, location string) (objectGetFunc, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid object location %s: %w", location, err)
+	}
+
+	switch u.Scheme {
+	case s3Scheme:
+		creds, err := awsCredentials(ctx)
+		if err != nil {
+			return nil, err
+		}
+		region := u.Query().Get("region")
+		if region == "" {
+			region = awsauth.Region()
+		}
+		endpoint := awsauth.Endpoint("s3", region)
+		return func(bucket, key, etag string) (*object, error) {
+			segments := strings.Split(bucket+"/"+key, "/")
+			for i, segment := range segments {
+				segments[i] = awsauth.EscapeSegment(segment)
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/"+strings.Join(segments, "/"), nil)
+			if err != nil {
+				return nil, err
+			}
+			setIfNoneMatch(req, etag)
+			awsauth.Sign(req, awsauth.EmptyPayloadHash, creds, region, "s3", time.Now())
+			return getObject(httpClient, req)
+		}, nil
+	case gcsScheme:
+		endpoint, client := gcsEndpoint, httpClient
+		if emulator := os.Getenv("STORAGE_EMULATOR_HOST"); emulator != "" {
+			endpoint = strings.TrimSuffix(emulator, "/")
+			if !strings.Contains(endpoint, "://") {
+				endpoint = "http://" + endpoint
+			}
+		} else {
+			tokens, err := gcpTokenSource(ctx)
+			if err != nil {
+				if !replaying {
+					return nil, fmt.Errorf("failed to find Google application default credentials: %w", err)
+				}
+				tokens = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "replay"})
+			}
+			client = oauth2.NewClient(context.WithValue(ctx, oauth2.HTTPClient, httpClient), tokens)
+		}
+		return func(bucket, key, etag string) (*object, error) {
+			objectURL := endpoint + "/storage/v1/b/" + url.PathEscape(bucket) + "/o/" + url.PathEscape(key) + "?alt=media"
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, objectURL, nil)
+			if err != nil {
+				return nil, err
+			}
+			setIfNoneMatch(req, etag)
+			return getObject(client, req)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported object location %s (expected %s:// or %s://)", location, s3Scheme, gcsScheme)
+	}
+}
+
+// setIfNoneMatch makes req conditional on the object not having etag.
+func setIfNoneMatch(req *http.Request, etag string) {
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+}
+
+// getObject performs an object download, reporting 404 responses as errObjectNotFound.
+func getObject(client *http.Client, req *http.Request) (*object, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return &object{data: data, etag: resp.Header.Get("ETag")}, nil
+	case http.StatusNotModified:
+		return &object{notModified: true}, nil
+	case http.StatusNotFound:
+		return nil, errObjectNotFound
+	default:
+		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+}
+
+// awsCredentials resolves AWS credentials through the default chain. Replays fall back
+// to placeholder credentials, since recorded responses do not depend on them.
+func awsCredentials(ctx context.Context) (awsauth.Credentials, error) {
+	if replaying {
+		creds, _ := awsauth.CredentialsFromEnv()
+		return creds, nil
+	}
+	return awsauth.DefaultCredentials(ctx)
+}
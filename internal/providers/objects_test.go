@@ -0,0 +1,186 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/menta2k/templater/internal/cache"
+)
+
+func TestFetchValuesFile(t *testing.T) {
+	objects := map[string]string{
+		"/configs/prod/values.yaml":                         "replicas: 3\n",
+		"/configs/prod/values.schema.json":                  `{"type":"object"}`,
+		"/configs/staging/values.yaml":                      "replicas: 1\n",
+		"/storage/v1/b/configs/o/prod%2Fvalues.yaml":        "replicas: 5\n",
+		"/storage/v1/b/configs/o/prod%2Fvalues.schema.json": `{}`,
+		"/storage/v1/b/configs/o/team%20a%2Fvalues.yaml":    "team: a\n",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/storage/") {
+			if r.URL.Query().Get("alt") != "media" || r.Header.Get("Authorization") != "Bearer gcs-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		} else if !strings.Contains(r.Header.Get("Authorization"), "Credential=AKIDEXAMPLE/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		content, ok := objects[r.URL.EscapedPath()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_ENDPOINT_URL_S3", server.URL)
+	t.Setenv("STORAGE_EMULATOR_HOST", "")
+	endpoint, tokenSource := gcsEndpoint, gcpTokenSource
+	gcsEndpoint = server.URL
+	gcpTokenSource = func(ctx context.Context) (oauth2.TokenSource, error) {
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "gcs-token"}), nil
+	}
+	defer func() { gcsEndpoint, gcpTokenSource = endpoint, tokenSource }()
+
+	dir := t.TempDir()
+	tests := []struct {
+		name      string
+		location  string
+		expected  string
+		schema    string
+		wantError string
+	}{
+		{name: "s3 with schema", location: "s3://configs/prod/values.yaml", expected: "replicas: 3\n", schema: `{"type":"object"}`},
+		{name: "s3 without schema", location: "s3://configs/staging/values.yaml?region=eu-west-1", expected: "replicas: 1\n"},
+		{name: "gcs with schema", location: "gs://configs/prod/values.yaml", expected: "replicas: 5\n", schema: "{}"},
+		{name: "gcs escaped name", location: "gs://configs/team%20a/values.yaml", expected: "team: a\n"},
+		{name: "missing object", location: "s3://configs/dev/values.yaml", wantError: "object not found"},
+		{name: "no key", location: "gs://configs", wantError: "invalid object location"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := FetchValuesFile(context.Background(), tt.location, dir, cache.SourcePolicy{})
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read values file: %v", err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, content)
+			}
+
+			schema, err := os.ReadFile(filepath.Join(filepath.Dir(path), objectSchemaFile))
+			if tt.schema == "" {
+				if !os.IsNotExist(err) {
+					t.Errorf("Expected no schema, got %q (%v)", schema, err)
+				}
+			} else if string(schema) != tt.schema {
+				t.Errorf("Expected schema %q, got %q (%v)", tt.schema, schema, err)
+			}
+		})
+	}
+}
+
+func TestFetchValuesFileEmulator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/storage/v1/b/configs/o/values.yaml" || r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("emulated: true\n"))
+	}))
+	defer server.Close()
+
+	t.Setenv("STORAGE_EMULATOR_HOST", strings.TrimPrefix(server.URL, "http://"))
+	path, err := FetchValuesFile(context.Background(), "gs://configs/values.yaml", t.TempDir(), cache.SourcePolicy{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "emulated: true\n" {
+		t.Errorf("Expected emulator object, got %q", content)
+	}
+}
+
+func TestFetchValuesFileCache(t *testing.T) {
+	content, etag := "replicas: 3\n", `"v1"`
+	var downloads, revalidations int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/storage/v1/b/configs/o/values.yaml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			revalidations++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+	t.Setenv("STORAGE_EMULATOR_HOST", server.URL)
+
+	dir := t.TempDir()
+	location := "gs://configs/values.yaml"
+	if _, err := FetchValuesFile(context.Background(), location, dir, cache.SourcePolicy{Offline: true}); !errors.Is(err, cache.ErrNotCached) {
+		t.Fatalf("Expected offline fetch of an uncached object to fail, got %v", err)
+	}
+
+	tests := []struct {
+		name              string
+		policy            cache.SourcePolicy
+		change            bool
+		expected          string
+		wantDownloads     int
+		wantRevalidations int
+	}{
+		{name: "first fetch", expected: "replicas: 3\n", wantDownloads: 1},
+		{name: "within TTL", policy: cache.SourcePolicy{TTL: time.Hour}, change: true, expected: "replicas: 3\n", wantDownloads: 1},
+		{name: "offline", policy: cache.SourcePolicy{Offline: true}, expected: "replicas: 3\n", wantDownloads: 1},
+		{name: "changed", expected: "replicas: 5\n", wantDownloads: 2},
+		{name: "unchanged", expected: "replicas: 5\n", wantDownloads: 2, wantRevalidations: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.change {
+				content, etag = "replicas: 5\n", `"v2"`
+			}
+			path, err := FetchValuesFile(context.Background(), location, dir, tt.policy)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got, _ := os.ReadFile(path); string(got) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+			if downloads != tt.wantDownloads || revalidations != tt.wantRevalidations {
+				t.Errorf("Expected %d downloads and %d revalidations, got %d and %d",
+					tt.wantDownloads, tt.wantRevalidations, downloads, revalidations)
+			}
+		})
+	}
+}
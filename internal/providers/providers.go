@@ -0,0 +1,229 @@
+package providers
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/menta2k/templater/internal/fixtures"
+	"github.com/menta2k/templater/internal/retry"
+)
+
+// DefaultTimeout bounds each request to an external values source.
+const DefaultTimeout = 30 * time.Second
+
+// httpClient sends provider requests, retrying throttled and failed requests.
+var httpClient = &http.Client{Transport: &retry.Transport{Policy: retry.Default}}
+
+// replaying is set by Replay. Providers then use placeholder credentials when none are
+// configured, since recorded responses do not depend on them.
+var replaying bool
+
+// providers maps location schemes to the functions loading values from them.
+var providers = map[string]func(ctx context.Context, location string) (map[string]any, error){
+	"gcp-sm":         loadGCPSecret,
+	kubernetesScheme: loadKubernetes,
+	"ssm":            loadSSM,
+}
+
+// references maps schemes that may appear as string values inside values files to the
+// functions resolving them to a secret payload.
+var references = map[string]func(ctx context.Context, location string) (string, error){
+	bitwardenScheme:        resolveBitwarden,
+	gcpSecretManagerScheme: resolveGCPSecret,
+	keyringScheme:          resolveKeyring,
+	onePasswordScheme:      resolveOnePassword,
+}
+
+// Load loads values from an external source location such as ssm:///myapp/prod/.
+func Load(ctx context.Context, location string) (map[string]any, error) {
+	scheme, _, ok := strings.Cut(location, "://")
+	if !ok {
+		return nil, fmt.Errorf("invalid values source %s (expected scheme://location)", location)
+	}
+
+	load, ok := providers[scheme]
+	if !ok {
+		return nil, fmt.Errorf("unsupported values source '%s' (expected one of: %s)", scheme, Schemes())
+	}
+
+	values, err := load(ctx, location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load values from %s: %w", location, err)
+	}
+	return values, nil
+}
+
+// LoadAll loads every location concurrently, each bounded by timeout. The values are
+// returned in the order of locations so callers can merge them with a stable precedence;
+// every failing source is reported in the returned error.
+func LoadAll(locations []string, timeout time.Duration) ([]map[string]any, error) {
+	results := make([]map[string]any, len(locations))
+	errs := make([]error, len(locations))
+
+	var wg sync.WaitGroup
+	for i, location := range locations {
+		wg.Add(1)
+		go func(i int, location string) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			results[i], errs[i] = Load(ctx, location)
+		}(i, location)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Record saves every response received from external sources as a fixture in dir, so
+// later runs can use Replay to render without network access.
+func Record(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create fixtures directory: %w", err)
+	}
+	httpClient.Transport = &retry.Transport{Base: &fixtures.Recorder{Dir: dir}, Policy: retry.Default}
+	replaying = false
+	return nil
+}
+
+// Replay answers requests to external sources with the fixtures saved by Record in dir,
+// without network access or credentials. Requests that were not recorded fail.
+func Replay(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open fixtures directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("fixtures path %s is not a directory", dir)
+	}
+	httpClient.Transport = &fixtures.Replayer{Dir: dir}
+	replaying = true
+	return nil
+}
+
+// transportWithTLS returns the provider transport, with requests that reach the network
+// sent over connections using tlsConfig. Retries, recording and replaying still apply.
+func transportWithTLS(tlsConfig *tls.Config) http.RoundTripper {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.TLSClientConfig = tlsConfig
+	return withBase(httpClient.Transport, base)
+}
+
+// withBase returns a copy of rt sending requests through base instead of the default
+// transport.
+func withBase(rt, base http.RoundTripper) http.RoundTripper {
+	switch t := rt.(type) {
+	case nil:
+		return base
+	case *retry.Transport:
+		return &retry.Transport{Base: withBase(t.Base, base), Policy: t.Policy}
+	case *fixtures.Recorder:
+		return &fixtures.Recorder{Dir: t.Dir, Base: withBase(t.Base, base)}
+	default:
+		return rt
+	}
+}
+
+// Schemes returns the sorted, comma-separated names of the supported schemes.
+func Schemes() string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// ResolveReferences replaces string values that are secret references, such as
+// gcp-sm://projects/p/secrets/db-password/versions/latest, with the referenced secret.
+// Values are resolved in place, including inside nested maps and lists.
+func ResolveReferences(values map[string]any) error {
+	for key, value := range values {
+		resolved, err := resolveValue(value)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", key, err)
+		}
+		values[key] = resolved
+	}
+	return nil
+}
+
+// resolveValue returns value with every secret reference it contains resolved.
+func resolveValue(value any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		scheme, _, ok := strings.Cut(v, "://")
+		if !ok {
+			return v, nil
+		}
+		resolve, ok := references[scheme]
+		if !ok {
+			return v, nil
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+		return resolve(ctx, v)
+	case map[string]any:
+		return v, ResolveReferences(v)
+	case map[interface{}]interface{}:
+		for key, item := range v {
+			resolved, err := resolveValue(item)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve %v: %w", key, err)
+			}
+			v[key] = resolved
+		}
+		return v, nil
+	case []any:
+		for i, item := range v {
+			resolved, err := resolveValue(item)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve item %d: %w", i, err)
+			}
+			v[i] = resolved
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
+
+// setPath stores value at the nested path in values, creating intermediate maps.
+func setPath(values map[string]any, path []string, value any) error {
+	current := values
+	for i, segment := range path[:len(path)-1] {
+		next, exists := current[segment]
+		if !exists {
+			child := make(map[string]any)
+			current[segment] = child
+			current = child
+			continue
+		}
+
+		child, ok := next.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s is both a value and a parent of %s", strings.Join(path[:i+1], "/"), strings.Join(path, "/"))
+		}
+		current = child
+	}
+
+	last := path[len(path)-1]
+	if _, isMap := current[last].(map[string]any); isMap {
+		return fmt.Errorf("%s is both a value and a parent of other values", strings.Join(path, "/"))
+	}
+	current[last] = value
+	return nil
+}
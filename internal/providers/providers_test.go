@@ -0,0 +1,168 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoadUnknownScheme(t *testing.T) {
+	tests := []struct {
+		name     string
+		location string
+		expected string
+	}{
+		{name: "missing scheme", location: "/myapp/prod", expected: "expected scheme://location"},
+		{name: "unknown scheme", location: "vault://secret/app", expected: "unsupported values source 'vault'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(context.Background(), tt.location)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestLoadAll(t *testing.T) {
+	// Every source waits until all of them have started, so a sequential loader times out
+	var started sync.WaitGroup
+	started.Add(3)
+	providers["test"] = func(ctx context.Context, location string) (map[string]any, error) {
+		started.Done()
+		all := make(chan struct{})
+		go func() {
+			started.Wait()
+			close(all)
+		}()
+		select {
+		case <-all:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		name := strings.TrimPrefix(location, "test://")
+		switch name {
+		case "hang":
+			<-ctx.Done()
+			return nil, ctx.Err()
+		case "broken":
+			return nil, errors.New("backend unavailable")
+		default:
+			return map[string]any{"source": name}, nil
+		}
+	}
+	defer delete(providers, "test")
+
+	values, err := LoadAll([]string{"test://a", "test://b", "test://c"}, time.Second)
+	if err != nil {
+		t.Fatalf("LoadAll failed: %v", err)
+	}
+	expected := []map[string]any{{"source": "a"}, {"source": "b"}, {"source": "c"}}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+
+	started.Add(3)
+	_, err = LoadAll([]string{"test://a", "test://hang", "test://broken"}, 50*time.Millisecond)
+	if err == nil {
+		t.Fatal("Expected error from failing sources")
+	}
+	for _, expected := range []string{"test://hang", "deadline exceeded", "test://broken", "backend unavailable"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to contain %q, got %v", expected, err)
+		}
+	}
+}
+
+func TestSetPath(t *testing.T) {
+	values := make(map[string]any)
+	for path, value := range map[string]string{"db/host": "localhost", "db/port": "5432", "name": "web"} {
+		if err := setPath(values, strings.Split(path, "/"), value); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	expected := map[string]any{
+		"db":   map[string]any{"host": "localhost", "port": "5432"},
+		"name": "web",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+
+	if err := setPath(values, []string{"name", "first"}, "x"); err == nil {
+		t.Error("Expected error when a value is also a parent")
+	}
+	if err := setPath(values, []string{"db"}, "x"); err == nil {
+		t.Error("Expected error when a parent is also a value")
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-record-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	transport := httpClient.Transport
+	t.Cleanup(func() {
+		httpClient.Transport, replaying = transport, false
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Parameters":[{"Name":"/myapp/prod/db/host","Type":"String","Value":"db.internal"}]}`))
+	}))
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_ENDPOINT_URL_SSM", server.URL)
+
+	expected := map[string]any{"db": map[string]any{"host": "db.internal"}}
+	fixturesDir := filepath.Join(tempDir, "fixtures")
+
+	if err := Record(fixturesDir); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	values, err := Load(context.Background(), "ssm:///myapp/prod/")
+	if err != nil {
+		t.Fatalf("Load while recording failed: %v", err)
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+
+	// Replay without the service and without credentials
+	server.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+
+	if err := Replay(fixturesDir); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	values, err = Load(context.Background(), "ssm:///myapp/prod/")
+	if err != nil {
+		t.Fatalf("Load while replaying failed: %v", err)
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+
+	if _, err := Load(context.Background(), "ssm:///myapp/staging/"); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("Expected missing fixture error, got %v", err)
+	}
+
+	if err := Replay(filepath.Join(tempDir, "missing")); err == nil {
+		t.Error("Expected error for a missing fixtures directory")
+	}
+}
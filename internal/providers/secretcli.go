@@ -0,0 +1,153 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Schemes of references resolved by password manager CLIs.
+const (
+	onePasswordScheme = "op"
+	bitwardenScheme   = "bw"
+)
+
+// secretCLIs maps the schemes of password manager references to the CLI resolving them.
+var secretCLIs = map[string]string{
+	onePasswordScheme: "op",
+	bitwardenScheme:   "bw",
+}
+
+// allowedSecretCLIs holds the CLIs enabled with AllowSecretCLIs.
+var allowedSecretCLIs = map[string]bool{}
+
+// runSecretCLI runs a password manager CLI and returns its standard output.
+var runSecretCLI = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s %s failed: %w: %s", name, args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// AllowSecretCLIs enables resolving references with the named password manager CLIs: op
+// for 1Password op:// references and bw for Bitwarden bw:// references. Names may be
+// comma-separated. References for CLIs that are not allowed fail, since resolving them
+// runs a program with the user's vault session.
+func AllowSecretCLIs(names []string) error {
+	allowed := map[string]bool{}
+	for _, list := range names {
+		for _, name := range strings.Split(list, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if _, ok := secretCLIs[name]; !ok {
+				return fmt.Errorf("unsupported secret CLI '%s' (expected one of: %s)", name, secretCLINames())
+			}
+			allowed[name] = true
+		}
+	}
+	allowedSecretCLIs = allowed
+	return nil
+}
+
+// secretCLINames returns the sorted, comma-separated names of the supported CLIs.
+func secretCLINames() string {
+	names := make([]string, 0, len(secretCLIs))
+	for _, name := range secretCLIs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// checkSecretCLI reports an error unless the CLI resolving scheme is allowed.
+func checkSecretCLI(scheme string) error {
+	name := secretCLIs[scheme]
+	if !allowedSecretCLIs[name] {
+		return fmt.Errorf("%s:// references run the %s CLI, which must be enabled with --allow-secret-cli %s", scheme, name, name)
+	}
+	return nil
+}
+
+// resolveOnePassword returns the secret of an op://<vault>/<item>[/<section>]/<field>
+// reference found in a values file, read with the 1Password CLI.
+func resolveOnePassword(ctx context.Context, location string) (string, error) {
+	if err := checkSecretCLI(onePasswordScheme); err != nil {
+		return "", err
+	}
+	parts := strings.Split(strings.TrimPrefix(location, onePasswordScheme+"://"), "/")
+	if len(parts) < 3 || len(parts) > 4 {
+		return "", fmt.Errorf("invalid 1Password reference %s (expected op://<vault>/<item>[/<section>]/<field>)", location)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return "", fmt.Errorf("invalid 1Password reference %s (expected op://<vault>/<item>[/<section>]/<field>)", location)
+		}
+	}
+
+	out, err := runSecretCLI(ctx, "op", "read", "--no-newline", location)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// bitwardenFields are the item fields bw get reads directly.
+var bitwardenFields = map[string]bool{"password": true, "username": true, "totp": true, "notes": true, "uri": true}
+
+// resolveBitwarden returns the secret of a bw://<item>[/<field>] reference found in a
+// values file, read with the Bitwarden CLI. The item is a name or an id; the field
+// defaults to password and may also name a custom field of the item. Both may be
+// percent-encoded.
+func resolveBitwarden(ctx context.Context, location string) (string, error) {
+	if err := checkSecretCLI(bitwardenScheme); err != nil {
+		return "", err
+	}
+	rawItem, rawField, _ := strings.Cut(strings.TrimPrefix(location, bitwardenScheme+"://"), "/")
+	item, itemErr := url.PathUnescape(rawItem)
+	field, fieldErr := url.PathUnescape(rawField)
+	if itemErr != nil || fieldErr != nil || item == "" || strings.Contains(rawField, "/") {
+		return "", fmt.Errorf("invalid Bitwarden reference %s (expected bw://<item>[/<field>])", location)
+	}
+	if field == "" {
+		field = "password"
+	}
+
+	if bitwardenFields[field] {
+		out, err := runSecretCLI(ctx, "bw", "get", field, item)
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	}
+
+	out, err := runSecretCLI(ctx, "bw", "get", "item", item)
+	if err != nil {
+		return "", err
+	}
+	var fields struct {
+		Fields []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(out, &fields); err != nil {
+		return "", fmt.Errorf("failed to parse Bitwarden item %s: %w", item, err)
+	}
+	for _, f := range fields.Fields {
+		if f.Name == field {
+			return f.Value, nil
+		}
+	}
+	return "", fmt.Errorf("item %s has no Bitwarden field %q", item, field)
+}
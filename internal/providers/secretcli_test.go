@@ -0,0 +1,93 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeSecretCLIs answers op and bw invocations from a map keyed by the joined command
+// line, and allows both CLIs for the duration of the test.
+func fakeSecretCLIs(t *testing.T, outputs map[string]string) {
+	t.Helper()
+
+	original := runSecretCLI
+	runSecretCLI = func(_ context.Context, name string, args ...string) ([]byte, error) {
+		command := name + " " + strings.Join(args, " ")
+		out, ok := outputs[command]
+		if !ok {
+			return nil, errors.New(command + " failed: Not found.")
+		}
+		return []byte(out), nil
+	}
+	t.Cleanup(func() {
+		runSecretCLI = original
+		allowedSecretCLIs = map[string]bool{}
+	})
+	if err := AllowSecretCLIs([]string{"op,bw"}); err != nil {
+		t.Fatalf("AllowSecretCLIs failed: %v", err)
+	}
+}
+
+func TestResolveSecretCLIReferences(t *testing.T) {
+	fakeSecretCLIs(t, map[string]string{
+		"op read --no-newline op://Engineering/Postgres/password":  "pg-s3cret",
+		"op read --no-newline op://Engineering/Stripe/live/secret": "sk_live",
+		"bw get password smtp-relay":                               "smtp-s3cret",
+		"bw get username smtp-relay":                               "mailer",
+		"bw get item smtp relay":                                   `{"fields":[{"name":"api key","value":"k3y"}]}`,
+	})
+
+	tests := []struct {
+		name      string
+		reference string
+		expected  string
+		wantError string
+	}{
+		{name: "1password field", reference: "op://Engineering/Postgres/password", expected: "pg-s3cret"},
+		{name: "1password section", reference: "op://Engineering/Stripe/live/secret", expected: "sk_live"},
+		{name: "1password missing", reference: "op://Engineering/Missing/password", wantError: "Not found"},
+		{name: "1password without field", reference: "op://Engineering/Postgres", wantError: "invalid 1Password reference"},
+		{name: "bitwarden default field", reference: "bw://smtp-relay", expected: "smtp-s3cret"},
+		{name: "bitwarden standard field", reference: "bw://smtp-relay/username", expected: "mailer"},
+		{name: "bitwarden custom field", reference: "bw://smtp%20relay/api%20key", expected: "k3y"},
+		{name: "bitwarden missing custom field", reference: "bw://smtp%20relay/token", wantError: `no Bitwarden field "token"`},
+		{name: "bitwarden nested path", reference: "bw://smtp-relay/a/b", wantError: "invalid Bitwarden reference"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := map[string]any{"secret": tt.reference}
+			err := ResolveReferences(values)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if values["secret"] != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, values["secret"])
+			}
+		})
+	}
+}
+
+func TestSecretCLIsMustBeAllowed(t *testing.T) {
+	fakeSecretCLIs(t, map[string]string{"bw get password smtp-relay": "smtp-s3cret"})
+	if err := AllowSecretCLIs([]string{"op"}); err != nil {
+		t.Fatalf("AllowSecretCLIs failed: %v", err)
+	}
+
+	err := ResolveReferences(map[string]any{"secret": "bw://smtp-relay"})
+	if err == nil || !strings.Contains(err.Error(), "--allow-secret-cli bw") {
+		t.Errorf("Expected error asking to allow bw, got %v", err)
+	}
+
+	if err := AllowSecretCLIs([]string{"op", "pass"}); err == nil || !strings.Contains(err.Error(), "unsupported secret CLI 'pass'") {
+		t.Errorf("Expected error for an unsupported CLI, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/menta2k/templater/internal/awsauth"
+)
+
+// ssmParameter is a parameter returned by GetParametersByPath.
+type ssmParameter struct {
+	Name  string `json:"Name"`
+	Type  string `json:"Type"`
+	Value string `json:"Value"`
+}
+
+// ssmClient calls the SSM Parameter Store API.
+type ssmClient struct {
+	endpoint    string
+	region      string
+	credentials awsauth.Credentials
+}
+
+// loadSSM loads every parameter below a path, e.g. ssm:///myapp/prod/?region=eu-west-1.
+// The hierarchy below the path becomes nested values; SecureString parameters are
+// decrypted and StringList parameters become lists.
+func loadSSM(ctx context.Context, location string) (map[string]any, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSM location: %w", err)
+	}
+
+	prefix := "/" + strings.Trim(u.Host+u.Path, "/")
+
+	creds, err := awsCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	region := u.Query().Get("region")
+	if region == "" {
+		region = awsauth.Region()
+	}
+
+	client := &ssmClient{
+		endpoint:    awsauth.Endpoint("ssm", region),
+		region:      region,
+		credentials: creds,
+	}
+	parameters, err := client.getParametersByPath(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]any)
+	for _, parameter := range parameters {
+		relative := strings.Trim(strings.TrimPrefix(parameter.Name, prefix), "/")
+		if relative == "" {
+			continue
+		}
+
+		var value any = parameter.Value
+		if parameter.Type == "StringList" {
+			var items []any
+			for _, item := range strings.Split(parameter.Value, ",") {
+				items = append(items, item)
+			}
+			value = items
+		}
+
+		if err := setPath(values, strings.Split(relative, "/"), value); err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
+
+// getParametersByPath returns every parameter below path, following pagination.
+func (c *ssmClient) getParametersByPath(ctx context.Context, path string) ([]ssmParameter, error) {
+	var (
+		parameters []ssmParameter
+		nextToken  string
+	)
+
+	for {
+		request := map[string]any{
+			"Path":           path,
+			"Recursive":      true,
+			"WithDecryption": true,
+		}
+		if nextToken != "" {
+			request["NextToken"] = nextToken
+		}
+
+		var response struct {
+			Parameters []ssmParameter `json:"Parameters"`
+			NextToken  string         `json:"NextToken"`
+		}
+		if err := c.call(ctx, "GetParametersByPath", request, &response); err != nil {
+			return nil, err
+		}
+
+		parameters = append(parameters, response.Parameters...)
+		if response.NextToken == "" {
+			return parameters, nil
+		}
+		nextToken = response.NextToken
+	}
+}
+
+// call performs a signed SSM API request.
+func (c *ssmClient) call(ctx context.Context, action string, request, response any) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AmazonSSM."+action)
+	awsauth.Sign(req, awsauth.PayloadHash(body), c.credentials, c.region, "ssm", time.Now())
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("SSM %s request failed: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read SSM response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(data, &apiErr)
+		return fmt.Errorf("SSM %s failed: %s %s %s", action, resp.Status, apiErr.Type, apiErr.Message)
+	}
+
+	if err := json.Unmarshal(data, response); err != nil {
+		return fmt.Errorf("failed to decode SSM response: %w", err)
+	}
+	return nil
+}
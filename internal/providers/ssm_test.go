@@ -0,0 +1,86 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadSSM(t *testing.T) {
+	pages := map[string]string{
+		"": `{"Parameters":[
+			{"Name":"/myapp/prod/db/host","Type":"String","Value":"db.internal"},
+			{"Name":"/myapp/prod/db/password","Type":"SecureString","Value":"s3cret"}
+		],"NextToken":"page2"}`,
+		"page2": `{"Parameters":[
+			{"Name":"/myapp/prod/hosts","Type":"StringList","Value":"a.example.com,b.example.com"}
+		]}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "AmazonSSM.GetParametersByPath" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/ssm/aws4_request") {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"__type":"AccessDeniedException","message":"bad signature"}`))
+			return
+		}
+
+		var request struct {
+			Path           string
+			Recursive      bool
+			WithDecryption bool
+			NextToken      string
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Path != "/myapp/prod" || !request.Recursive || !request.WithDecryption {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(pages[request.NextToken]))
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_ENDPOINT_URL_SSM", server.URL)
+
+	values, err := Load(context.Background(), "ssm:///myapp/prod/?region=eu-west-1")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	expected := map[string]any{
+		"db":    map[string]any{"host": "db.internal", "password": "s3cret"},
+		"hosts": []any{"a.example.com", "b.example.com"},
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+
+	// The signature scope must match the region
+	if _, err := Load(context.Background(), "ssm:///myapp/prod/?region=us-east-1"); err == nil || !strings.Contains(err.Error(), "AccessDeniedException") {
+		t.Errorf("Expected API error to be reported, got %v", err)
+	}
+}
+
+func TestLoadSSMRequiresCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", "")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", "")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	if _, err := Load(context.Background(), "ssm:///myapp/prod/"); err == nil {
+		t.Error("Expected error without AWS credentials")
+	}
+}
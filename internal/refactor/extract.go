@@ -0,0 +1,79 @@
+package refactor
+
+import (
+	"fmt"
+	"strings"
+	"text/template/parse"
+)
+
+// ExtractPartial moves lines startLine..endLine (1-based, inclusive) of a template into a
+// named define block appended to the template, and replaces them with a call to it.
+// The rendered output is unchanged: the block is invoked with the current dot and must
+// not reference variables declared outside of it.
+func ExtractPartial(content string, startLine, endLine int, name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("partial name is required")
+	}
+	if strings.ContainsAny(name, "\"`\n") {
+		return "", fmt.Errorf("invalid partial name %q", name)
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if startLine < 1 || endLine < startLine || endLine > len(lines) {
+		return "", fmt.Errorf("invalid line range %d-%d (template has %d lines)", startLine, endLine, len(lines))
+	}
+
+	block := strings.Join(lines[startLine-1:endLine], "")
+	if err := validateBlock(block); err != nil {
+		return "", fmt.Errorf("lines %d-%d cannot be extracted: %w", startLine, endLine, err)
+	}
+
+	if err := checkDefined(content, name); err != nil {
+		return "", err
+	}
+
+	// The call keeps the block's trailing newline so the define body can omit it
+	body := strings.TrimSuffix(block, "\n")
+	call := fmt.Sprintf("{{ template %q . }}", name)
+	if strings.HasSuffix(block, "\n") {
+		call += "\n"
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.Join(lines[:startLine-1], ""))
+	b.WriteString(call)
+	b.WriteString(strings.Join(lines[endLine:], ""))
+	// Defines render nothing; the trim marker drops the newline that ends the file
+	fmt.Fprintf(&b, "{{ define %q }}%s{{ end -}}\n", name, body)
+
+	return b.String(), nil
+}
+
+// validateBlock checks that a block parses on its own, i.e. its actions are balanced and
+// it does not depend on variables declared outside of it.
+func validateBlock(block string) error {
+	tree := parse.New("partial")
+	tree.Mode = parse.SkipFuncCheck | parse.ParseComments
+	if _, err := tree.Parse(block, "", "", make(map[string]*parse.Tree)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkDefined ensures the template parses and does not already define name.
+func checkDefined(content, name string) error {
+	tree := parse.New("template")
+	tree.Mode = parse.SkipFuncCheck | parse.ParseComments
+
+	treeSet := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(content, "", "", treeSet); err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+	if _, exists := treeSet[name]; exists {
+		return fmt.Errorf("template already defines %q", name)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package refactor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestExtractPartial(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		start     int
+		end       int
+		partial   string
+		contains  []string
+		wantError bool
+	}{
+		{
+			name:     "middle block",
+			content:  "apiVersion: v1\nmetadata:\n  name: {{ .app.name }}\n  labels:\n    app: {{ .app.name }}\nspec: {}\n",
+			start:    2,
+			end:      5,
+			partial:  "metadata",
+			contains: []string{"apiVersion: v1\n{{ template \"metadata\" . }}\nspec: {}\n", "{{ define \"metadata\" }}metadata:"},
+		},
+		{
+			name:     "block with actions",
+			content:  "{{ range .items }}\n- {{ . }}\n{{ end }}\ndone\n",
+			start:    1,
+			end:      3,
+			partial:  "items",
+			contains: []string{"{{ template \"items\" . }}\ndone\n"},
+		},
+		{
+			name:     "last line without newline",
+			content:  "first\nsecond {{ .value }}",
+			start:    2,
+			end:      2,
+			partial:  "second",
+			contains: []string{"first\n{{ template \"second\" . }}{{ define"},
+		},
+		{
+			name:      "unbalanced block",
+			content:   "{{ if .enabled }}\nyes\n{{ end }}\n",
+			start:     1,
+			end:       2,
+			partial:   "broken",
+			wantError: true,
+		},
+		{
+			name:      "outer variable",
+			content:   "{{ $name := .app.name }}\nname: {{ $name }}\n",
+			start:     2,
+			end:       2,
+			partial:   "name",
+			wantError: true,
+		},
+		{
+			name:      "out of range",
+			content:   "one\ntwo\n",
+			start:     2,
+			end:       3,
+			partial:   "x",
+			wantError: true,
+		},
+		{
+			name:      "already defined",
+			content:   "one\n{{ define \"x\" }}{{ end }}",
+			start:     1,
+			end:       1,
+			partial:   "x",
+			wantError: true,
+		},
+	}
+
+	data := map[string]any{
+		"app":     map[string]any{"name": "web"},
+		"items":   []string{"a", "b"},
+		"value":   42,
+		"enabled": true,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ExtractPartial(tt.content, tt.start, tt.end, tt.partial)
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			for _, expected := range tt.contains {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected result to contain %q, got %q", expected, result)
+				}
+			}
+
+			// Extraction must not change the rendered output
+			if render(t, result, data) != render(t, tt.content, data) {
+				t.Errorf("Rendered output changed:\nbefore: %q\nafter:  %q", render(t, tt.content, data), render(t, result, data))
+			}
+		})
+	}
+}
+
+func render(t *testing.T, content string, data any) string {
+	t.Helper()
+
+	tmpl, err := template.New("test").Parse(content)
+	if err != nil {
+		t.Fatalf("Failed to parse %q: %v", content, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		t.Fatalf("Failed to render %q: %v", content, err)
+	}
+	return buf.String()
+}
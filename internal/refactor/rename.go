@@ -0,0 +1,254 @@
+package refactor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// edit replaces the byte range [start, end) of a template with text.
+type edit struct {
+	start int
+	end   int
+	text  string
+}
+
+// ParsePath converts a value reference such as ".app.name" into its segments.
+func ParsePath(ref string) ([]string, error) {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(ref, "$"), ".")
+	if trimmed == "" {
+		return nil, fmt.Errorf("invalid value path %s", ref)
+	}
+
+	segments := strings.Split(trimmed, ".")
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, fmt.Errorf("invalid value path %s", ref)
+		}
+	}
+	return segments, nil
+}
+
+// RenameKey rewrites every reference to oldPath (and its sub-keys) in a template to newPath.
+// References are resolved through the template AST, following the dot changes of `with`
+// blocks; fields whose dot cannot be resolved statically (inside `range` or `define`)
+// are left untouched unless they are rooted at `$`. It returns the updated template
+// and the number of rewritten references.
+func RenameKey(name, content string, oldPath, newPath []string) (string, int, error) {
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck | parse.ParseComments
+
+	treeSet := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(content, "", "", treeSet); err != nil {
+		return "", 0, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+
+	r := &renamer{content: content, oldPath: oldPath, newPath: newPath}
+	for treeName, t := range treeSet {
+		if t.Root == nil {
+			continue
+		}
+		// The dot of the main template is the values root; defined templates receive an unknown dot
+		var dot []string
+		if treeName == name {
+			dot = []string{}
+		}
+		r.walk(t.Root, dot)
+	}
+	if r.err != nil {
+		return "", 0, r.err
+	}
+
+	return applyEdits(content, r.edits), len(r.edits), nil
+}
+
+// renamer collects the edits needed to rename a value path.
+type renamer struct {
+	content string
+	oldPath []string
+	newPath []string
+	edits   []edit
+	seen    map[int]bool
+	err     error
+}
+
+// walk visits a node. dot is the absolute value path of ".", or nil when unknown.
+func (r *renamer) walk(node parse.Node, dot []string) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			r.walk(child, dot)
+		}
+	case *parse.ActionNode:
+		r.walk(n.Pipe, dot)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			r.walk(cmd, dot)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			r.walk(arg, dot)
+		}
+	case *parse.ChainNode:
+		r.walk(n.Node, dot)
+	case *parse.IfNode:
+		r.walk(n.Pipe, dot)
+		r.walk(n.List, dot)
+		r.walk(n.ElseList, dot)
+	case *parse.RangeNode:
+		r.walk(n.Pipe, dot)
+		r.walk(n.List, nil)
+		r.walk(n.ElseList, dot)
+	case *parse.WithNode:
+		r.walk(n.Pipe, dot)
+		r.walk(n.List, pipeDot(n.Pipe, dot))
+		r.walk(n.ElseList, dot)
+	case *parse.TemplateNode:
+		r.walk(n.Pipe, dot)
+	case *parse.FieldNode:
+		r.renameField(n, dot)
+	case *parse.VariableNode:
+		r.renameVariable(n)
+	}
+}
+
+// renameField rewrites a field reference relative to dot.
+func (r *renamer) renameField(field *parse.FieldNode, dot []string) {
+	if dot == nil {
+		return
+	}
+
+	absolute := append(append([]string{}, dot...), field.Ident...)
+	// Only rewrite when the renamed key lies within this field's own identifiers;
+	// otherwise the enclosing `with` pipeline carries the change.
+	if len(r.oldPath) <= len(dot) || !hasPrefix(absolute, r.oldPath) {
+		return
+	}
+
+	renamed := append(append([]string{}, r.newPath...), absolute[len(r.oldPath):]...)
+	replacement := "$." + strings.Join(renamed, ".")
+	if hasPrefix(renamed, dot) && len(renamed) > len(dot) {
+		replacement = "." + strings.Join(renamed[len(dot):], ".")
+	}
+
+	r.addEdit(int(field.Pos), "."+strings.Join(field.Ident, "."), replacement)
+}
+
+// renameVariable rewrites `$.path` references, which are always rooted at the values.
+func (r *renamer) renameVariable(variable *parse.VariableNode) {
+	if len(variable.Ident) < 2 || variable.Ident[0] != "$" || !hasPrefix(variable.Ident[1:], r.oldPath) {
+		return
+	}
+
+	renamed := append(append([]string{}, r.newPath...), variable.Ident[1+len(r.oldPath):]...)
+	r.addEdit(int(variable.Pos), "$."+strings.Join(variable.Ident[1:], "."), "$."+strings.Join(renamed, "."))
+}
+
+// addEdit records a replacement of original, located around pos in the template source.
+func (r *renamer) addEdit(pos int, original, replacement string) {
+	start := locate(r.content, pos, original)
+	if start < 0 {
+		if r.err == nil {
+			r.err = fmt.Errorf("could not locate reference %s at offset %d", original, pos)
+		}
+		return
+	}
+
+	if r.seen == nil {
+		r.seen = make(map[int]bool)
+	}
+	if r.seen[start] {
+		return
+	}
+	r.seen[start] = true
+	r.edits = append(r.edits, edit{start: start, end: start + len(original), text: replacement})
+}
+
+// locate finds the occurrence of text whose span covers pos.
+func locate(content string, pos int, text string) int {
+	from := pos - len(text)
+	if from < 0 {
+		from = 0
+	}
+	for from <= pos && from < len(content) {
+		i := strings.Index(content[from:], text)
+		if i < 0 {
+			return -1
+		}
+		start := from + i
+		if start > pos {
+			return -1
+		}
+		if start+len(text) >= pos && !isIdentChar(content, start+len(text)) {
+			return start
+		}
+		from = start + 1
+	}
+	return -1
+}
+
+// isIdentChar reports whether the byte at i continues an identifier.
+func isIdentChar(content string, i int) bool {
+	if i >= len(content) {
+		return false
+	}
+	c := content[i]
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// pipeDot returns the absolute path of a `with` pipeline made of a single field, or nil.
+func pipeDot(pipe *parse.PipeNode, dot []string) []string {
+	if pipe == nil || len(pipe.Decl) > 0 || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return nil
+	}
+
+	switch arg := pipe.Cmds[0].Args[0].(type) {
+	case *parse.FieldNode:
+		if dot == nil {
+			return nil
+		}
+		return append(append([]string{}, dot...), arg.Ident...)
+	case *parse.VariableNode:
+		if arg.Ident[0] == "$" {
+			return append([]string{}, arg.Ident[1:]...)
+		}
+	case *parse.DotNode:
+		return dot
+	}
+	return nil
+}
+
+// applyEdits applies non-overlapping edits to content.
+func applyEdits(content string, edits []edit) string {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var b strings.Builder
+	last := 0
+	for _, e := range edits {
+		b.WriteString(content[last:e.start])
+		b.WriteString(e.text)
+		last = e.end
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// hasPrefix reports whether path starts with prefix.
+func hasPrefix(path, prefix []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,136 @@
+package refactor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		name      string
+		ref       string
+		expected  string
+		wantError bool
+	}{
+		{name: "dotted reference", ref: ".app.name", expected: "app,name"},
+		{name: "without leading dot", ref: "app.name", expected: "app,name"},
+		{name: "root variable", ref: "$.app", expected: "app"},
+		{name: "empty", ref: ".", wantError: true},
+		{name: "empty segment", ref: ".app..name", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParsePath(tt.ref)
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if strings.Join(result, ",") != tt.expected {
+				t.Errorf("Expected %s, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestRenameKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		oldPath   string
+		newPath   string
+		expected  string
+		count     int
+		wantError bool
+	}{
+		{
+			name:     "simple field",
+			content:  "name: {{ .app.name }}",
+			oldPath:  "app.name",
+			newPath:  "service.name",
+			expected: "name: {{ .service.name }}",
+			count:    1,
+		},
+		{
+			name:     "sub-keys and pipelines",
+			content:  "{{ .app.name.first | upper }} {{ if .app.name }}{{ default \"x\" .app.name.last }}{{ end }}",
+			oldPath:  "app.name",
+			newPath:  "service.name",
+			expected: "{{ .service.name.first | upper }} {{ if .service.name }}{{ default \"x\" .service.name.last }}{{ end }}",
+			count:    3,
+		},
+		{
+			name:     "root variable",
+			content:  "{{ range .items }}{{ $.app.name }}{{ .app.name }}{{ end }}",
+			oldPath:  "app.name",
+			newPath:  "service.name",
+			expected: "{{ range .items }}{{ $.service.name }}{{ .app.name }}{{ end }}",
+			count:    1,
+		},
+		{
+			name:     "relative field inside with",
+			content:  "{{ with .app }}{{ .name }}{{ .version }}{{ end }}",
+			oldPath:  "app.name",
+			newPath:  "app.fullName",
+			expected: "{{ with .app }}{{ .fullName }}{{ .version }}{{ end }}",
+			count:    1,
+		},
+		{
+			name:     "moved out of with scope",
+			content:  "{{ with .app }}{{ .name }}{{ else }}{{ .name }}{{ end }}",
+			oldPath:  "app.name",
+			newPath:  "service.name",
+			expected: "{{ with .app }}{{ $.service.name }}{{ else }}{{ .name }}{{ end }}",
+			count:    1,
+		},
+		{
+			name:     "renamed with pipeline",
+			content:  "{{ with .app }}{{ .name }}{{ end }}",
+			oldPath:  "app",
+			newPath:  "service",
+			expected: "{{ with .service }}{{ .name }}{{ end }}",
+			count:    1,
+		},
+		{
+			name:     "similar names untouched",
+			content:  "{{ .application.name }} {{ .app.names }} {{ \".app.name\" }}",
+			oldPath:  "app.name",
+			newPath:  "service.name",
+			expected: "{{ .application.name }} {{ .app.names }} {{ \".app.name\" }}",
+			count:    0,
+		},
+		{
+			name:      "invalid template",
+			content:   "{{ .app.name ",
+			oldPath:   "app.name",
+			newPath:   "service.name",
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, count, err := RenameKey("test", tt.content, strings.Split(tt.oldPath, "."), strings.Split(tt.newPath, "."))
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+			if count != tt.count {
+				t.Errorf("Expected %d references, got %d", tt.count, count)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package refactor
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/menta2k/templater/internal/values"
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// Change describes a file touched by a refactoring.
+type Change struct {
+	Path       string
+	NewPath    string
+	References int
+}
+
+// RenameKeyInDir rewrites references to oldPath in every *.tpl file below dir, including
+// templated directory and file names. Files are only modified when dryRun is false.
+func RenameKeyInDir(dir string, oldPath, newPath []string, dryRun bool) ([]Change, error) {
+	var changes []Change
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(strings.ToLower(info.Name()), ".tpl") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read template: %w", err)
+		}
+
+		updated, count, err := RenameKey(path, string(content), oldPath, newPath)
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		renamedPath, pathCount, err := RenameKey(relativePath, relativePath, oldPath, newPath)
+		if err != nil {
+			return err
+		}
+
+		if count == 0 && pathCount == 0 {
+			return nil
+		}
+
+		change := Change{Path: path, References: count + pathCount}
+		if pathCount > 0 {
+			change.NewPath = filepath.Join(dir, renamedPath)
+		}
+		changes = append(changes, change)
+
+		if dryRun {
+			return nil
+		}
+		if count > 0 {
+			if err := os.WriteFile(path, []byte(updated), info.Mode().Perm()); err != nil {
+				return fmt.Errorf("failed to write %s: %w", path, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return changes, nil
+	}
+
+	// Move templated paths once the walk is over so renamed directories are not revisited
+	for _, change := range changes {
+		if change.NewPath == "" {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(change.NewPath), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.Rename(change.Path, change.NewPath); err != nil {
+			return nil, fmt.Errorf("failed to rename %s: %w", change.Path, err)
+		}
+	}
+	removeEmptyDirs(dir, changes)
+
+	return changes, nil
+}
+
+// removeEmptyDirs removes directories left empty after moving templated paths.
+func removeEmptyDirs(root string, changes []Change) {
+	var dirs []string
+	for _, change := range changes {
+		if change.NewPath == "" {
+			continue
+		}
+		for dir := filepath.Dir(change.Path); dir != root && dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	// Deepest directories first
+	sort.Slice(dirs, func(i, j int) bool { return len(dirs[i]) > len(dirs[j]) })
+	for _, dir := range dirs {
+		// os.Remove fails on non-empty directories, which are kept
+		_ = os.Remove(dir)
+	}
+}
+
+// RenameValuesKey moves oldPath to newPath in a values document, preserving comments.
+// It reports whether the document contained the key.
+func RenameValuesKey(data []byte, oldPath, newPath []string) ([]byte, bool, error) {
+	var doc yaml3.Node
+	if err := yaml3.Unmarshal(data, &doc); err != nil {
+		return nil, false, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if !hasValuesKey(&doc, oldPath) {
+		return data, false, nil
+	}
+
+	plan := []values.Migration{{
+		Rules: []values.MigrationRule{{
+			Move: &values.MoveRule{From: strings.Join(oldPath, "."), To: strings.Join(newPath, ".")},
+		}},
+	}}
+	migrated, err := values.MigrateYAML(data, plan)
+	if err != nil {
+		return nil, false, err
+	}
+	return migrated, !bytes.Equal(migrated, data), nil
+}
+
+// hasValuesKey reports whether the document has a value at path.
+func hasValuesKey(doc *yaml3.Node, path []string) bool {
+	if doc.Kind != yaml3.DocumentNode || len(doc.Content) == 0 {
+		return false
+	}
+
+	node := doc.Content[0]
+	for _, segment := range path {
+		if node.Kind != yaml3.MappingNode {
+			return false
+		}
+		var next *yaml3.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == segment {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return false
+		}
+		node = next
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package refactor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenameKeyInDir(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-refactor-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"config.tpl":                 "name: {{ .app.name }}\n",
+		"static.tpl":                 "port: {{ .port }}\n",
+		"srv/{{.app.name}}/site.tpl": "server_name {{ .app.name }};\n",
+		"README.md":                  "{{ .app.name }}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	changes, err := RenameKeyInDir(tempDir, []string{"app", "name"}, []string{"service", "name"}, false)
+	if err != nil {
+		t.Fatalf("RenameKeyInDir failed: %v", err)
+	}
+	if len(changes) != 2 {
+		t.Errorf("Expected 2 changed files, got %d", len(changes))
+	}
+
+	expected := map[string]string{
+		"config.tpl":                     "name: {{ .service.name }}\n",
+		"static.tpl":                     "port: {{ .port }}\n",
+		"srv/{{.service.name}}/site.tpl": "server_name {{ .service.name }};\n",
+		"README.md":                      "{{ .app.name }}\n",
+	}
+	for name, content := range expected {
+		data, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Errorf("Failed to read %s: %v", name, err)
+			continue
+		}
+		if string(data) != content {
+			t.Errorf("Expected %s to contain %q, got %q", name, content, string(data))
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, "srv", "{{.app.name}}")); !os.IsNotExist(err) {
+		t.Error("Expected old templated directory to be removed")
+	}
+}
+
+func TestRenameValuesKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		changed  bool
+		contains []string
+	}{
+		{
+			name:     "key present",
+			input:    "# Application settings\napp:\n  # Display name\n  name: web\n  port: 8080\n",
+			changed:  true,
+			contains: []string{"# Display name", "service:\n  # Display name\n  name: web", "port: 8080"},
+		},
+		{
+			name:     "key missing",
+			input:    "other: value\n",
+			changed:  false,
+			contains: []string{"other: value\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, changed, err := RenameValuesKey([]byte(tt.input), []string{"app", "name"}, []string{"service", "name"})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if changed != tt.changed {
+				t.Errorf("Expected changed=%v, got %v", tt.changed, changed)
+			}
+			for _, expected := range tt.contains {
+				if !strings.Contains(string(result), expected) {
+					t.Errorf("Expected result to contain %q, got %q", expected, string(result))
+				}
+			}
+		})
+	}
+}
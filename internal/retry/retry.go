@@ -0,0 +1,158 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// maxRetryAfter caps how long a server may ask us to wait before retrying.
+const maxRetryAfter = 30 * time.Second
+
+// Policy bounds the attempts made for an operation that may fail transiently.
+type Policy struct {
+	// Retries is the number of attempts made after the first one fails.
+	Retries int
+	// Backoff is the wait before the first retry, doubled before each further retry.
+	Backoff time.Duration
+}
+
+// Default is the policy used for remote fetches and file writes.
+var Default = Policy{Retries: 3, Backoff: 500 * time.Millisecond}
+
+// Do calls fn until it succeeds, fails with an error retryable does not accept, or the
+// retries are exhausted. The final error notes the number of attempts when fn was retried.
+func Do(policy Policy, fn func() error, retryable func(error) bool) error {
+	backoff := policy.Backoff
+
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if attempt >= policy.Retries || !retryable(err) {
+			if attempt > 0 {
+				return fmt.Errorf("%w (gave up after %d attempts)", err, attempt+1)
+			}
+			return err
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// IsTransientIOError reports whether err is a temporary condition worth retrying, such
+// as EAGAIN or a stale file handle on network file systems, or a network timeout.
+func IsTransientIOError(err error) bool {
+	for _, errno := range []syscall.Errno{syscall.EAGAIN, syscall.EINTR, syscall.EBUSY, syscall.ESTALE, syscall.ETIMEDOUT} {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsTransientStatus reports whether an HTTP status code signals a temporary failure:
+// throttling (429) or a server error.
+func IsTransientStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// Transport is an http.RoundTripper that retries requests failing with network errors
+// or answered with a transient status. Requests whose body cannot be replayed are sent
+// once. When retries are exhausted, the last response is returned to the caller.
+type Transport struct {
+	Base   http.RoundTripper
+	Policy Policy
+}
+
+// NewClient returns an HTTP client retrying transient failures with the default policy.
+// The timeout bounds the whole exchange, including retries.
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: &Transport{Policy: Default}}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	backoff := t.Policy.Backoff
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			body, err := rewind(req)
+			if err != nil {
+				return nil, err
+			}
+			req = body
+		}
+
+		resp, err := base.RoundTrip(req)
+		last := attempt >= t.Policy.Retries || !replayable
+		switch {
+		case err != nil:
+			if last || req.Context().Err() != nil {
+				if attempt > 0 {
+					return nil, fmt.Errorf("%w (gave up after %d attempts)", err, attempt+1)
+				}
+				return nil, err
+			}
+		case !IsTransientStatus(resp.StatusCode) || last:
+			return resp, nil
+		}
+
+		wait := backoff
+		if resp != nil {
+			if after, ok := retryAfter(resp); ok {
+				wait = after
+			}
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// rewind returns a copy of req with a fresh body for another attempt.
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay request body: %w", err)
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
+
+// retryAfter returns the delay requested by a Retry-After header in seconds, capped.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	wait := time.Duration(seconds) * time.Second
+	if wait > maxRetryAfter {
+		wait = maxRetryAfter
+	}
+	return wait, true
+}
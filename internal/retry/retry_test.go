@@ -0,0 +1,130 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+)
+
+func TestDo(t *testing.T) {
+	transient := fmt.Errorf("write failed: %w", &os.PathError{Op: "write", Path: "out", Err: syscall.EAGAIN})
+
+	tests := []struct {
+		name         string
+		failures     int
+		err          error
+		wantAttempts int
+		wantError    string
+	}{
+		{name: "succeeds first time", wantAttempts: 1},
+		{name: "recovers from transient errors", failures: 2, err: transient, wantAttempts: 3},
+		{name: "gives up after retries", failures: 10, err: transient, wantAttempts: 4, wantError: "gave up after 4 attempts"},
+		{name: "permanent error is not retried", failures: 10, err: os.ErrPermission, wantAttempts: 1, wantError: "permission denied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := Do(Policy{Retries: 3}, func() error {
+				attempts++
+				if attempts <= tt.failures {
+					return tt.err
+				}
+				return nil
+			}, IsTransientIOError)
+
+			if attempts != tt.wantAttempts {
+				t.Errorf("Expected %d attempts, got %d", tt.wantAttempts, attempts)
+			}
+			if tt.wantError == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+			}
+		})
+	}
+}
+
+func TestIsTransientIOError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{&os.PathError{Op: "open", Path: "out", Err: syscall.EAGAIN}, true},
+		{&os.PathError{Op: "write", Path: "out", Err: syscall.ESTALE}, true},
+		{os.ErrNotExist, false},
+		{errors.New("disk full"), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTransientIOError(tt.err); got != tt.expected {
+			t.Errorf("IsTransientIOError(%v) = %v, expected %v", tt.err, got, tt.expected)
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantStatus   int
+		wantAttempts int32
+	}{
+		{name: "throttled then accepted", statuses: []int{http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusOK}, wantStatus: http.StatusOK, wantAttempts: 3},
+		{name: "client error is returned", statuses: []int{http.StatusNotFound}, wantStatus: http.StatusNotFound, wantAttempts: 1},
+		{name: "last response after retries", statuses: []int{500, 500, 500, 500, 500}, wantStatus: http.StatusInternalServerError, wantAttempts: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := attempts.Add(1)
+				if body, _ := io.ReadAll(r.Body); string(body) != "payload" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: &Transport{Policy: Policy{Retries: 3}}}
+			resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if attempts.Load() != tt.wantAttempts {
+				t.Errorf("Expected %d attempts, got %d", tt.wantAttempts, attempts.Load())
+			}
+		})
+	}
+}
+
+func TestTransportNetworkError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	client := &http.Client{Transport: &Transport{Policy: Policy{Retries: 2}}}
+	_, err := client.Get(url)
+	if err == nil || !strings.Contains(err.Error(), "gave up after 3 attempts") {
+		t.Errorf("Expected error noting the attempts, got %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetupExportsSpans(t *testing.T) {
+	var exports atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		exports.Add(1)
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	defer server.Close()
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", server.URL)
+	previous := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(previous)
+
+	shutdown, err := Setup(context.Background())
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	_, span := Start(context.Background(), "test")
+	End(span, nil)
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if exports.Load() == 0 {
+		t.Error("Expected spans to be exported on shutdown")
+	}
+}
+
+func TestEndRecordsError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	tests := []struct {
+		name   string
+		err    error
+		status codes.Code
+	}{
+		{name: "success", status: codes.Unset},
+		{name: "failure", err: errors.New("render failed"), status: codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, span := Start(context.Background(), tt.name)
+			End(span, tt.err)
+		})
+	}
+
+	ended := recorder.Ended()
+	if len(ended) != len(tests) {
+		t.Fatalf("Expected %d spans, got %d", len(tests), len(ended))
+	}
+	for i, tt := range tests {
+		if ended[i].Status().Code != tt.status {
+			t.Errorf("Expected status %v for %s, got %v", tt.status, tt.name, ended[i].Status().Code)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package template
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// Compression functions. Compressed data is base64 encoded, as templates render text.
+
+// gzipBase64 compresses s with gzip and returns it base64 encoded, for payloads such as
+// CloudFormation UserData: {{ include "userdata" . | gzipBase64 }}. The gzip header
+// carries no name or time, so equal input renders equal output.
+func gzipBase64(s string) string {
+	var b bytes.Buffer
+	w, _ := gzip.NewWriterLevel(&b, gzip.BestCompression)
+	return compressBase64(&b, w, s)
+}
+
+// gunzipBase64 decodes base64 encoded gzip data.
+func gunzipBase64(s string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode gzip data: %w", err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress gzip data: %w", err)
+	}
+	return decompress(r, "gzip")
+}
+
+// zlibBase64 compresses s with zlib and returns it base64 encoded.
+func zlibBase64(s string) string {
+	var b bytes.Buffer
+	w, _ := zlib.NewWriterLevel(&b, zlib.BestCompression)
+	return compressBase64(&b, w, s)
+}
+
+// unzlibBase64 decodes base64 encoded zlib data.
+func unzlibBase64(s string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode zlib data: %w", err)
+	}
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress zlib data: %w", err)
+	}
+	return decompress(r, "zlib")
+}
+
+// compressBase64 writes s through w into b and returns b base64 encoded.
+func compressBase64(b *bytes.Buffer, w io.WriteCloser, s string) string {
+	if _, err := io.WriteString(w, s); err != nil {
+		// Swallow errors inside of a template.
+		return ""
+	}
+	if err := w.Close(); err != nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(b.Bytes())
+}
+
+// decompress reads all of r, reporting errors as corrupt data of format.
+func decompress(r io.ReadCloser, format string) (string, error) {
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress %s data: %w", format, err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,64 @@
+package template
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompression(t *testing.T) {
+	input := strings.Repeat("#!/bin/bash\necho hello\n", 20)
+
+	tests := []struct {
+		name       string
+		compress   func(string) string
+		decompress func(string) (string, error)
+		prefix     string
+	}{
+		{name: "gzip", compress: gzipBase64, decompress: gunzipBase64, prefix: "H4sIAAAAAAAC/"},
+		{name: "zlib", compress: zlibBase64, decompress: unzlibBase64, prefix: "eNp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed := tt.compress(input)
+			if !strings.HasPrefix(compressed, tt.prefix) {
+				t.Errorf("Expected %q to start with %q", compressed, tt.prefix)
+			}
+			if len(compressed) >= len(input) {
+				t.Errorf("Expected compressed output shorter than %d bytes, got %d", len(input), len(compressed))
+			}
+			if again := tt.compress(input); again != compressed {
+				t.Errorf("Expected equal output for equal input, got %q and %q", compressed, again)
+			}
+
+			result, err := tt.decompress(compressed)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != input {
+				t.Errorf("Expected %q, got %q", input, result)
+			}
+
+			if _, err := tt.decompress("not base64!"); err == nil {
+				t.Error("Expected error for invalid base64")
+			}
+			if _, err := tt.decompress("aGVsbG8="); err == nil {
+				t.Error("Expected error for uncompressed data")
+			}
+		})
+	}
+}
+
+func TestCompressionInTemplate(t *testing.T) {
+	tmpl, err := NewStrictTemplate("test", false).ParseTemplate(`{{ .script | gzipBase64 | gunzipBase64 }}`)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	result, err := tmpl.ExecuteTemplate(map[string]any{"script": "echo hi"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != "echo hi" {
+		t.Errorf("Expected %q, got %q", "echo hi", result)
+	}
+}
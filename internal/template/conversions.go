@@ -2,12 +2,22 @@ package template
 
 import (
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode/utf16"
 
 	"github.com/BurntSushi/toml"
 	yaml3 "gopkg.in/yaml.v3"
 	"sigs.k8s.io/yaml"
+
+	"github.com/menta2k/templater/internal/values"
 )
 
 // YAML conversion functions.
@@ -18,7 +28,7 @@ func toYAML(v any) string {
 	// Convert map[interface{}]interface{} to map[string]any if needed
 	converted := convertMapKeys(v)
 
-	data, err := yaml.Marshal(converted)
+	data, err := marshalYAML(converted)
 	if err != nil {
 		// Swallow errors inside of a template.
 		return ""
@@ -30,19 +40,42 @@ func toYAML(v any) string {
 // It will panic if there is an error.
 func mustToYAML(v any) string {
 	converted := convertMapKeys(v)
-	data, err := yaml.Marshal(converted)
+	data, err := marshalYAML(converted)
 	if err != nil {
 		panic(err)
 	}
 	return strings.TrimSuffix(string(data), "\n")
 }
 
+// marshalYAML marshals v through JSON like sigs.k8s.io/yaml, except that values holding
+// precise numbers are encoded with yaml.v3, since the JSON round trip loses their literals.
+func marshalYAML(v any) ([]byte, error) {
+	if !values.HasNumbers(v) {
+		return yaml.Marshal(v)
+	}
+
+	data := getBuffer()
+	defer putBuffer(data)
+
+	encoder := yaml3.NewEncoder(data)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(values.EncodeYAMLNumbers(v)); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(data.String()), nil
+}
+
 // toYAMLPretty takes an interface, marshals it to pretty yaml, and returns a string.
 func toYAMLPretty(v any) string {
-	var data bytes.Buffer
-	encoder := yaml3.NewEncoder(&data)
+	data := getBuffer()
+	defer putBuffer(data)
+
+	encoder := yaml3.NewEncoder(data)
 	encoder.SetIndent(2)
-	err := encoder.Encode(v)
+	err := encoder.Encode(values.EncodeYAMLNumbers(v))
 	if err != nil {
 		// Swallow errors inside of a template.
 		return ""
@@ -74,13 +107,15 @@ func fromYAMLArray(str string) []any {
 
 // toJSON takes an interface, marshals it to json, and returns a string.
 func toJSON(v any) string {
-	converted := convertMapKeys(v)
-	data, err := json.Marshal(converted)
-	if err != nil {
+	data := getBuffer()
+	defer putBuffer(data)
+
+	// Encoder escapes HTML like json.Marshal, but writes into the pooled buffer
+	if err := json.NewEncoder(data).Encode(convertMapKeys(v)); err != nil {
 		// Swallow errors inside of a template.
 		return ""
 	}
-	return string(data)
+	return strings.TrimSuffix(data.String(), "\n")
 }
 
 // mustToJSON takes an interface, marshals it to json, and returns a string.
@@ -119,7 +154,8 @@ func fromJSONArray(str string) []any {
 // toTOML takes an interface, marshals it to toml, and returns a string.
 func toTOML(v any) string {
 	converted := convertMapKeys(v)
-	b := bytes.NewBuffer(nil)
+	b := getBuffer()
+	defer putBuffer(b)
 	e := toml.NewEncoder(b)
 	err := e.Encode(converted)
 	if err != nil {
@@ -138,6 +174,605 @@ func fromTOML(str string) map[string]any {
 	return m
 }
 
+// CSV conversion functions.
+
+// toCSV takes a list of maps, marshals it to CSV with a header row of every key in
+// sorted order, and returns a string.
+func toCSV(rows any) string {
+	return toCSVColumns(nil, rows)
+}
+
+// toCSVColumns takes a list of column names and a list of maps, and marshals the maps
+// to CSV with those columns in that order. Keys not listed are left out, and missing
+// keys give empty cells. Without columns, every key is written in sorted order. Rows
+// that are lists are written as they are.
+func toCSVColumns(columns []any, rows any) string {
+	header, records, ok := rowRecords(columns, rows)
+	if !ok {
+		// Swallow errors inside of a template.
+		return ""
+	}
+
+	b := getBuffer()
+	defer putBuffer(b)
+	w := csv.NewWriter(b)
+	if len(header) > 0 {
+		_ = w.Write(header)
+	}
+	_ = w.WriteAll(records)
+	if w.Error() != nil {
+		// Swallow errors inside of a template.
+		return ""
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// rowRecords converts a list of maps to records of cells in the given columns, or in
+// every key in sorted order without columns, and returns the header and the records.
+// Rows that are lists are converted as they are. It reports false when rows is not a list.
+func rowRecords(columns []any, rows any) ([]string, [][]string, bool) {
+	items, ok := convertMapKeys(rows).([]any)
+	if !ok {
+		return nil, nil, false
+	}
+
+	header := make([]string, 0, len(columns))
+	for _, column := range columns {
+		header = append(header, fmt.Sprint(column))
+	}
+	if len(header) == 0 {
+		keys := make(map[string]any)
+		for _, item := range items {
+			if m, ok := item.(map[string]any); ok {
+				maps.Copy(keys, m)
+			}
+		}
+		header = sortedKeys(keys)
+	}
+
+	records := make([][]string, 0, len(items))
+	for _, item := range items {
+		var record []string
+		switch x := item.(type) {
+		case map[string]any:
+			for _, column := range header {
+				record = append(record, csvCell(x[column]))
+			}
+		case []any:
+			for _, cell := range x {
+				record = append(record, csvCell(cell))
+			}
+		default:
+			record = []string{csvCell(x)}
+		}
+		records = append(records, record)
+	}
+	return header, records, true
+}
+
+// csvCell formats a value as a CSV cell, with nested values as JSON.
+func csvCell(value any) string {
+	switch value.(type) {
+	case nil:
+		return ""
+	case map[string]any, []any:
+		return toJSON(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// fromCSV converts a CSV document into a []any of maps, keyed by the header row.
+func fromCSV(str string) []any {
+	records, err := csv.NewReader(strings.NewReader(str)).ReadAll()
+	if err != nil {
+		return []any{err.Error()}
+	}
+
+	rows := []any{}
+	for i := 1; i < len(records); i++ {
+		row := make(map[string]any, len(records[0]))
+		for j, column := range records[0] {
+			row[column] = records[i][j]
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Dotenv conversion functions.
+
+// toDotenv takes a flat map, marshals it to KEY=value lines sorted by key, and returns a
+// string. Values are double quoted and escaped when they hold spaces, quotes or other
+// special characters.
+func toDotenv(v any) string {
+	m, ok := convertMapKeys(v).(map[string]any)
+	if !ok {
+		// Swallow errors inside of a template.
+		return ""
+	}
+
+	b := getBuffer()
+	defer putBuffer(b)
+	for _, key := range sortedKeys(m) {
+		switch m[key].(type) {
+		case map[string]any, []any:
+			// Swallow errors inside of a template.
+			return ""
+		}
+		if key == "" || strings.ContainsAny(key, " \t\r\n=#") {
+			return ""
+		}
+		b.WriteString(key + "=" + values.FormatDotenvValue(m[key]) + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// fromDotenv converts dotenv text into a map[string]any of strings. Comments, export
+// prefixes and single or double quoted values are supported.
+func fromDotenv(str string) map[string]any {
+	vars, err := values.ParseDotenv(str)
+	if err != nil {
+		return map[string]any{"Error": err.Error()}
+	}
+
+	m := make(map[string]any, len(vars))
+	for key, value := range vars {
+		m[key] = value
+	}
+	return m
+}
+
+// Java properties conversion functions.
+
+// toProperties takes a map, flattens it into key=value lines with dotted keys (a.b.c)
+// and list indexes in brackets (hosts[0]), and returns a string. Keys are sorted, and
+// keys and values are escaped like java.util.Properties.store, including \uXXXX for
+// non-ASCII characters, so the output loads with any encoding.
+func toProperties(v any) string {
+	m, ok := convertMapKeys(v).(map[string]any)
+	if !ok {
+		// Swallow errors inside of a template.
+		return ""
+	}
+
+	properties := make(map[string]any)
+	flattenProperties(properties, "", m)
+
+	b := getBuffer()
+	defer putBuffer(b)
+	for _, key := range sortedKeys(properties) {
+		value := properties[key]
+		if value == nil {
+			value = ""
+		}
+		b.WriteString(escapeProperty(key, true) + "=" + escapeProperty(fmt.Sprint(value), false) + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// flattenProperties adds the scalars of value to properties under dotted keys.
+func flattenProperties(properties map[string]any, key string, value any) {
+	switch x := value.(type) {
+	case map[string]any:
+		for k, v := range x {
+			if key != "" {
+				k = key + "." + k
+			}
+			flattenProperties(properties, k, v)
+		}
+	case []any:
+		for i, item := range x {
+			flattenProperties(properties, key+"["+strconv.Itoa(i)+"]", item)
+		}
+	default:
+		properties[key] = value
+	}
+}
+
+// escapeProperty escapes a property key or value. Spaces are escaped throughout keys
+// but only at the start of values, where they would otherwise be skipped.
+func escapeProperty(s string, isKey bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch r {
+		case ' ':
+			if isKey || i == 0 {
+				b.WriteString(`\ `)
+			} else {
+				b.WriteRune(r)
+			}
+		case '\\', '=', ':', '#', '!':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			if r < 0x20 || r > 0x7e {
+				for _, unit := range utf16.Encode([]rune{r}) {
+					fmt.Fprintf(&b, `\u%04X`, unit)
+				}
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+// XML conversion functions. Elements map to keys, repeated elements to lists, and
+// following the xmltodict convention, attributes to "@name" keys and the text of an
+// element that also has attributes or children to a "#text" key.
+
+// xmlAttrPrefix and xmlTextKey mark attributes and text content in XML values.
+const (
+	xmlAttrPrefix = "@"
+	xmlTextKey    = "#text"
+)
+
+// toXML takes a map whose keys are the top-level elements, marshals it to indented XML
+// without a declaration, and returns a string. Keys are written in sorted order.
+func toXML(v any) string {
+	m, ok := convertMapKeys(v).(map[string]any)
+	if !ok {
+		// Swallow errors inside of a template.
+		return ""
+	}
+
+	b := getBuffer()
+	defer putBuffer(b)
+	for _, name := range sortedKeys(m) {
+		if err := writeXMLElement(b, name, m[name], 0); err != nil {
+			// Swallow errors inside of a template.
+			return ""
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// writeXMLElement writes value as one element per list item, or as a single element.
+func writeXMLElement(b *bytes.Buffer, name string, value any, depth int) error {
+	if !validXMLName(name) {
+		return fmt.Errorf("invalid XML element name %q", name)
+	}
+	if items, ok := value.([]any); ok {
+		for _, item := range items {
+			if err := writeXMLElement(b, name, item, depth); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	indent := strings.Repeat("  ", depth)
+	b.WriteString(indent + "<" + name)
+
+	m, isMap := value.(map[string]any)
+	if !isMap {
+		if value == nil {
+			b.WriteString("/>\n")
+			return nil
+		}
+		b.WriteString(">" + escapeXMLText(value) + "</" + name + ">\n")
+		return nil
+	}
+
+	var children []string
+	for _, key := range sortedKeys(m) {
+		attr, isAttr := strings.CutPrefix(key, xmlAttrPrefix)
+		switch {
+		case isAttr:
+			if !validXMLName(attr) {
+				return fmt.Errorf("invalid XML attribute name %q", attr)
+			}
+			b.WriteString(" " + attr + `="`)
+			xml.EscapeText(b, []byte(fmt.Sprint(m[key])))
+			b.WriteString(`"`)
+		case key != xmlTextKey:
+			children = append(children, key)
+		}
+	}
+
+	text, hasText := m[xmlTextKey]
+	switch {
+	case len(children) == 0 && !hasText:
+		b.WriteString("/>\n")
+		return nil
+	case len(children) == 0:
+		b.WriteString(">" + escapeXMLText(text) + "</" + name + ">\n")
+		return nil
+	}
+
+	b.WriteString(">\n")
+	if hasText {
+		b.WriteString(indent + "  " + escapeXMLText(text) + "\n")
+	}
+	for _, child := range children {
+		if err := writeXMLElement(b, child, m[child], depth+1); err != nil {
+			return err
+		}
+	}
+	b.WriteString(indent + "</" + name + ">\n")
+	return nil
+}
+
+// escapeXMLText escapes a value for use as element text, keeping line breaks and tabs,
+// which xml.EscapeText turns into character references.
+func escapeXMLText(value any) string {
+	b := getBuffer()
+	defer putBuffer(b)
+	_ = xml.EscapeText(b, []byte(fmt.Sprint(value)))
+	return xmlWhitespaceUnescaper.Replace(b.String())
+}
+
+// xmlWhitespaceUnescaper restores the whitespace escaped by xml.EscapeText.
+var xmlWhitespaceUnescaper = strings.NewReplacer("&#xA;", "\n", "&#x9;", "\t")
+
+// validXMLName reports whether name can be used as an element or attribute name.
+func validXMLName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, " \t\r\n<>&\"'=/") &&
+		!strings.HasPrefix(name, xmlAttrPrefix) && name != xmlTextKey
+}
+
+// fromXML converts an XML document into a map[string]any keyed by its root element.
+// Elements holding only text become strings, and names keep their namespace prefix
+// as written (xsi:schemaLocation).
+func fromXML(str string) map[string]any {
+	m, err := parseXML(str)
+	if err != nil {
+		return map[string]any{"Error": err.Error()}
+	}
+	return m
+}
+
+// xmlElement is an element being decoded by parseXML.
+type xmlElement struct {
+	name   string
+	values map[string]any
+	text   strings.Builder
+}
+
+// parseXML decodes an XML document with the conventions of toXML.
+func parseXML(str string) (map[string]any, error) {
+	decoder := xml.NewDecoder(strings.NewReader(str))
+	root := &xmlElement{values: make(map[string]any)}
+	stack := []*xmlElement{root}
+
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		current := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			if len(stack) == 1 && len(root.values) > 0 {
+				return nil, fmt.Errorf("XML document has more than one root element")
+			}
+			element := &xmlElement{name: xmlTokenName(t.Name), values: make(map[string]any)}
+			for _, attr := range t.Attr {
+				element.values[xmlAttrPrefix+xmlTokenName(attr.Name)] = attr.Value
+			}
+			stack = append(stack, element)
+		case xml.EndElement:
+			if len(stack) == 1 || current.name != xmlTokenName(t.Name) {
+				return nil, fmt.Errorf("unexpected end element </%s>", xmlTokenName(t.Name))
+			}
+			stack = stack[:len(stack)-1]
+			addRepeatedValue(stack[len(stack)-1].values, current.name, current.value())
+		case xml.CharData:
+			current.text.Write(t)
+		}
+	}
+
+	if len(stack) > 1 {
+		return nil, fmt.Errorf("unexpected end of XML document in <%s>", stack[len(stack)-1].name)
+	}
+	if len(root.values) == 0 {
+		return nil, fmt.Errorf("XML document has no root element")
+	}
+	return root.values, nil
+}
+
+// value returns the decoded element: its text, or a map of its attributes and children.
+func (e *xmlElement) value() any {
+	text := strings.TrimSpace(e.text.String())
+	if len(e.values) == 0 {
+		return text
+	}
+	if text != "" {
+		e.values[xmlTextKey] = text
+	}
+	return e.values
+}
+
+// addRepeatedValue adds a value to values, turning repeated names into a list.
+func addRepeatedValue(values map[string]any, name string, value any) {
+	existing, ok := values[name]
+	if !ok {
+		values[name] = value
+		return
+	}
+	if items, ok := existing.([]any); ok {
+		values[name] = append(items, value)
+		return
+	}
+	values[name] = []any{existing, value}
+}
+
+// xmlTokenName returns a raw token name with its namespace prefix.
+func xmlTokenName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// INI conversion functions. Top-level scalars are written before any section, maps
+// become [section]s, nested maps [section.subsection]s, and lists repeated keys, as in
+// systemd units.
+
+// toINI takes a map, marshals it to INI, and returns a string. Sections and keys are
+// written in sorted order.
+func toINI(v any) string {
+	m, ok := convertMapKeys(v).(map[string]any)
+	if !ok {
+		// Swallow errors inside of a template.
+		return ""
+	}
+
+	b := getBuffer()
+	defer putBuffer(b)
+	if err := writeINISection(b, "", m); err != nil {
+		// Swallow errors inside of a template.
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(b.String(), "\n"), "\n")
+}
+
+// writeINISection writes the keys of m, then its maps as sections below name.
+func writeINISection(b *bytes.Buffer, name string, m map[string]any) error {
+	var sections []string
+	for _, key := range sortedKeys(m) {
+		if strings.ContainsAny(key, "=[]\n") || strings.TrimSpace(key) == "" {
+			return fmt.Errorf("invalid INI key %q", key)
+		}
+		switch value := m[key].(type) {
+		case map[string]any:
+			sections = append(sections, key)
+		case []any:
+			for _, item := range value {
+				if err := writeINIValue(b, key, item); err != nil {
+					return err
+				}
+			}
+		default:
+			if err := writeINIValue(b, key, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, key := range sections {
+		section := key
+		if name != "" {
+			section = name + "." + key
+		}
+		if hasINIValues(m[key].(map[string]any)) {
+			b.WriteString("\n[" + section + "]\n")
+		}
+		if err := writeINISection(b, section, m[key].(map[string]any)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasINIValues reports whether a section has keys of its own, rather than only
+// subsections, and so needs a header.
+func hasINIValues(m map[string]any) bool {
+	for _, value := range m {
+		if _, ok := value.(map[string]any); !ok {
+			return true
+		}
+	}
+	return len(m) == 0
+}
+
+// writeINIValue writes a key = value line.
+func writeINIValue(b *bytes.Buffer, key string, value any) error {
+	switch value.(type) {
+	case map[string]any, []any:
+		return fmt.Errorf("INI key %s cannot hold nested values", key)
+	case nil:
+		value = ""
+	}
+	text := fmt.Sprint(value)
+	if strings.ContainsAny(text, "\r\n") {
+		return fmt.Errorf("INI value of %s spans several lines", key)
+	}
+	b.WriteString(strings.TrimSpace(key + " = " + text))
+	b.WriteString("\n")
+	return nil
+}
+
+// fromINI converts an INI document into a map[string]any. Keys before any section are
+// top-level, [a.b] sections nest, repeated keys become lists, lines starting with ; or
+// # are comments, and surrounding double quotes are removed from values.
+func fromINI(str string) map[string]any {
+	m, err := parseINI(str)
+	if err != nil {
+		return map[string]any{"Error": err.Error()}
+	}
+	return m
+}
+
+// parseINI decodes an INI document with the conventions of toINI.
+func parseINI(str string) (map[string]any, error) {
+	root := make(map[string]any)
+	section := root
+	for i, line := range strings.Split(str, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "["):
+			name, ok := strings.CutSuffix(line, "]")
+			name = strings.TrimSpace(strings.TrimPrefix(name, "["))
+			if !ok || name == "" {
+				return nil, fmt.Errorf("line %d: invalid section header %s", i+1, line)
+			}
+			section = root
+			for _, part := range strings.Split(name, ".") {
+				child, ok := section[part].(map[string]any)
+				if !ok {
+					if _, exists := section[part]; exists {
+						return nil, fmt.Errorf("line %d: section %s conflicts with key %s", i+1, name, part)
+					}
+					child = make(map[string]any)
+					section[part] = child
+				}
+				section = child
+			}
+		default:
+			key, value, ok := strings.Cut(line, "=")
+			key = strings.TrimSpace(key)
+			if !ok || key == "" {
+				return nil, fmt.Errorf("line %d: expected key = value, got %s", i+1, line)
+			}
+			value = strings.TrimSpace(value)
+			if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+				value = value[1 : len(value)-1]
+			}
+			if _, isSection := section[key].(map[string]any); isSection {
+				return nil, fmt.Errorf("line %d: key %s conflicts with a section", i+1, key)
+			}
+			addRepeatedValue(section, key, value)
+		}
+	}
+	return root, nil
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // convertMapKeys recursively converts map[interface{}]interface{} to map[string]any.
 // This is needed because YAML unmarshaling creates interface{} keys which cause.
 // JSON marshaling to fail.
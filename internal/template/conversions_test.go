@@ -1,6 +1,8 @@
 package template
 
 import (
+	"encoding/json"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -370,3 +372,484 @@ func TestFromTOML(t *testing.T) {
 		})
 	}
 }
+
+func TestToCSV(t *testing.T) {
+	rows := []any{
+		map[interface{}]interface{}{"name": "Ada", "email": "ada@example.com", "roles": []any{"admin"}},
+		map[string]any{"name": "Grace, Rear Admiral", "team": "navy", "email": nil},
+	}
+
+	tests := []struct {
+		name     string
+		columns  []any
+		rows     any
+		expected string
+	}{
+		{
+			name:     "sorted header",
+			rows:     rows,
+			expected: "email,name,roles,team\nada@example.com,Ada,\"[\"\"admin\"\"]\",\n,\"Grace, Rear Admiral\",,navy",
+		},
+		{
+			name:     "selected columns",
+			columns:  []any{"name", "email", "missing"},
+			rows:     rows,
+			expected: "name,email,missing\nAda,ada@example.com,\n\"Grace, Rear Admiral\",,",
+		},
+		{
+			name:     "list rows",
+			columns:  []any{"x", "y"},
+			rows:     []any{[]any{1, 2}, []any{3, 4}},
+			expected: "x,y\n1,2\n3,4",
+		},
+		{name: "not a list", rows: "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := toCSVColumns(tt.columns, tt.rows)
+			if result != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFromCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []any
+	}{
+		{
+			name:  "header and rows",
+			input: "name,email\nAda,ada@example.com\n\"Grace, Rear Admiral\",\n",
+			expected: []any{
+				map[string]any{"name": "Ada", "email": "ada@example.com"},
+				map[string]any{"name": "Grace, Rear Admiral", "email": ""},
+			},
+		},
+		{name: "header only", input: "name,email\n", expected: []any{}},
+		{name: "ragged rows", input: "a,b\n1\n", expected: []any{"record on line 2: wrong number of fields"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := fromCSV(tt.input)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestToDotenv(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    any
+		expected string
+	}{
+		{
+			name: "flat map",
+			input: map[interface{}]interface{}{
+				"DATABASE_URL": "postgres://db:5432/shop",
+				"GREETING":     `say "hi"` + "\n",
+				"PORT":         8080,
+				"DEBUG":        false,
+				"EMPTY":        nil,
+			},
+			expected: "DATABASE_URL=postgres://db:5432/shop\nDEBUG=false\nEMPTY=\nGREETING=\"say \\\"hi\\\"\\n\"\nPORT=8080",
+		},
+		{name: "nested map", input: map[string]any{"APP": map[string]any{"NAME": "web"}}},
+		{name: "invalid key", input: map[string]any{"MY KEY": "a"}},
+		{name: "not a map", input: "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := toDotenv(tt.input)
+			if result != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFromDotenv(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]any
+		hasError bool
+	}{
+		{
+			name:     "round trip",
+			input:    toDotenv(map[string]any{"GREETING": "say \"hi\"\n", "PORT": 8080}),
+			expected: map[string]any{"GREETING": "say \"hi\"\n", "PORT": "8080"},
+		},
+		{
+			name:     "comments and export",
+			input:    "# database\nexport DB_HOST=db.internal\nDB_PASS='p@ss word' # secret\n",
+			expected: map[string]any{"DB_HOST": "db.internal", "DB_PASS": "p@ss word"},
+		},
+		{name: "invalid line", input: "NOT A VARIABLE", hasError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := fromDotenv(tt.input)
+			if tt.hasError {
+				if _, exists := result["Error"]; !exists {
+					t.Errorf("Expected Error key in result, got %v", result)
+				}
+				return
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestToProperties(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    any
+		expected string
+	}{
+		{
+			name: "nested map",
+			input: map[string]any{
+				"spring": map[interface{}]interface{}{
+					"datasource": map[string]any{"url": "jdbc:postgresql://db:5432/shop", "password": nil},
+					"profiles":   map[string]any{"active": []any{"prod", "metrics"}},
+				},
+				"server": map[string]any{"port": 8080},
+			},
+			expected: `server.port=8080
+spring.datasource.password=
+spring.datasource.url=jdbc\:postgresql\://db\:5432/shop
+spring.profiles.active[0]=prod
+spring.profiles.active[1]=metrics`,
+		},
+		{
+			name: "escaping",
+			input: map[string]any{
+				"greeting message": " Hello, wörld! #1 = \\o/\n\tbye 🎉",
+			},
+			expected: `greeting\ message=\ Hello, w\u00F6rld\! \#1 \= \\o/\n\tbye \uD83C\uDF89`,
+		},
+		{name: "not a map", input: []any{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := toProperties(tt.input)
+			if result != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestToINI(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    any
+		expected string
+	}{
+		{
+			name: "systemd unit",
+			input: map[string]any{
+				"Unit": map[interface{}]interface{}{"Description": "API server", "After": "network.target"},
+				"Service": map[string]any{
+					"ExecStartPre": []any{"/bin/mkdir -p /run/api", "/bin/chown api /run/api"},
+					"ExecStart":    "/usr/bin/api --port=8080",
+					"Restart":      "always",
+				},
+				"Install": map[string]any{"WantedBy": "multi-user.target"},
+			},
+			expected: `[Install]
+WantedBy = multi-user.target
+
+[Service]
+ExecStart = /usr/bin/api --port=8080
+ExecStartPre = /bin/mkdir -p /run/api
+ExecStartPre = /bin/chown api /run/api
+Restart = always
+
+[Unit]
+After = network.target
+Description = API server`,
+		},
+		{
+			name: "global keys and subsections",
+			input: map[string]any{
+				"engine":  "On",
+				"memory":  nil,
+				"session": map[string]any{"redis": map[string]any{"host": "cache", "port": 6379}},
+				"opcache": map[string]any{"enable": true},
+			},
+			expected: "engine = On\nmemory =\n\n[opcache]\nenable = true\n\n[session.redis]\nhost = cache\nport = 6379",
+		},
+		{name: "multi-line value", input: map[string]any{"a": "b\nc"}},
+		{name: "nested list", input: map[string]any{"a": []any{[]any{1}}}},
+		{name: "not a map", input: "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := toINI(tt.input)
+			if result != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFromINI(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]any
+		hasError bool
+	}{
+		{
+			name: "php.ini",
+			input: `; PHP settings
+engine = On
+error_log = "/var/log/php errors.log"
+
+[opcache]
+# tuned for production
+opcache.enable=1
+
+[session.redis]
+host = cache
+save_path = tcp://cache:6379?weight=1
+extension = redis
+extension = igbinary
+`,
+			expected: map[string]any{
+				"engine":    "On",
+				"error_log": "/var/log/php errors.log",
+				"opcache":   map[string]any{"opcache.enable": "1"},
+				"session": map[string]any{"redis": map[string]any{
+					"host":      "cache",
+					"save_path": "tcp://cache:6379?weight=1",
+					"extension": []any{"redis", "igbinary"},
+				}},
+			},
+		},
+		{name: "missing equals", input: "[a]\nkey", hasError: true},
+		{name: "unterminated section", input: "[a\nkey = 1", hasError: true},
+		{name: "section conflicts with key", input: "a = 1\n[a]\nb = 2", hasError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := fromINI(tt.input)
+			if tt.hasError {
+				if _, exists := result["Error"]; !exists {
+					t.Errorf("Expected Error key in result, got %v", result)
+				}
+				return
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestToXML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    any
+		expected string
+	}{
+		{
+			name: "nested elements and attributes",
+			input: map[string]any{
+				"configuration": map[interface{}]interface{}{
+					"@scan": true,
+					"appender": map[string]any{
+						"@name":   "STDOUT",
+						"encoder": map[string]any{"pattern": "%d <%thread> %msg%n"},
+					},
+					"root": map[string]any{"@level": "INFO"},
+				},
+			},
+			expected: `<configuration scan="true">
+  <appender name="STDOUT">
+    <encoder>
+      <pattern>%d &lt;%thread&gt; %msg%n</pattern>
+    </encoder>
+  </appender>
+  <root level="INFO"/>
+</configuration>`,
+		},
+		{
+			name: "repeated elements",
+			input: map[string]any{
+				"servers": map[string]any{"server": []any{"a", map[string]any{"@port": 8080, "#text": "b"}}},
+			},
+			expected: "<servers>\n  <server>a</server>\n  <server port=\"8080\">b</server>\n</servers>",
+		},
+		{
+			name:     "fragment",
+			input:    map[string]any{"a": 1, "b": nil},
+			expected: "<a>1</a>\n<b/>",
+		},
+		{
+			name:     "multi-line text",
+			input:    map[string]any{"script": "a && b\n\tc"},
+			expected: "<script>a &amp;&amp; b\n\tc</script>",
+		},
+		{name: "invalid name", input: map[string]any{"bad name": 1}},
+		{name: "not a map", input: []any{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := toXML(tt.input)
+			if result != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFromXML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]any
+		hasError bool
+	}{
+		{
+			name: "web.xml",
+			input: `<?xml version="1.0" encoding="UTF-8"?>
+<!-- deployment descriptor -->
+<web-app xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" version="4.0">
+  <display-name>shop</display-name>
+  <servlet>
+    <servlet-name>api</servlet-name>
+    <load-on-startup>1</load-on-startup>
+  </servlet>
+  <servlet>
+    <servlet-name>admin</servlet-name>
+  </servlet>
+  <description lang="en"><![CDATA[Shop & co]]></description>
+  <distributable/>
+</web-app>`,
+			expected: map[string]any{
+				"web-app": map[string]any{
+					"@xmlns:xsi":   "http://www.w3.org/2001/XMLSchema-instance",
+					"@version":     "4.0",
+					"display-name": "shop",
+					"servlet": []any{
+						map[string]any{"servlet-name": "api", "load-on-startup": "1"},
+						map[string]any{"servlet-name": "admin"},
+					},
+					"description":   map[string]any{"@lang": "en", "#text": "Shop & co"},
+					"distributable": "",
+				},
+			},
+		},
+		{name: "mismatched tags", input: "<a><b></a>", hasError: true},
+		{name: "unclosed", input: "<a><b></b>", hasError: true},
+		{name: "two roots", input: "<a/><b/>", hasError: true},
+		{name: "empty", input: "  ", hasError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := fromXML(tt.input)
+			if tt.hasError {
+				if _, exists := result["Error"]; !exists {
+					t.Errorf("Expected Error key in result, got %v", result)
+				}
+				return
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestXMLRoundTrip(t *testing.T) {
+	input := `<project xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <dependencies>
+    <dependency scope="test">
+      <artifactId>junit</artifactId>
+    </dependency>
+    <dependency>
+      <artifactId>slf4j</artifactId>
+    </dependency>
+  </dependencies>
+</project>`
+	if result := toXML(fromXML(input)); result != input {
+		t.Errorf("Expected round trip to restore the document.\nExpected:\n%s\nGot:\n%s", input, result)
+	}
+}
+
+// benchmarkValues is a moderately sized document used by the conversion benchmarks.
+func benchmarkValues() map[string]any {
+	items := make([]any, 0, 50)
+	for i := 0; i < 50; i++ {
+		items = append(items, map[string]any{"name": "item", "index": i, "enabled": i%2 == 0})
+	}
+	return map[string]any{
+		"app":   map[string]any{"name": "benchmark-app", "version": "1.0.0", "replicas": 3},
+		"items": items,
+	}
+}
+
+func BenchmarkToJSON(b *testing.B) {
+	values := benchmarkValues()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = toJSON(values)
+	}
+}
+
+func BenchmarkToYAMLPretty(b *testing.B) {
+	values := benchmarkValues()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = toYAMLPretty(values)
+	}
+}
+
+func BenchmarkToTOML(b *testing.B) {
+	values := benchmarkValues()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = toTOML(values)
+	}
+}
+
+func TestToYAMLPreciseNumbers(t *testing.T) {
+	input := map[string]any{
+		"accountId": json.Number("123456789012345678901234"),
+		"limits":    map[string]any{"ratio": json.Number("1.10"), "max": json.Number("1e3")},
+		"ports":     []any{json.Number("80")},
+		"version":   "1.10",
+	}
+	expected := "accountId: 123456789012345678901234\nlimits:\n  max: 1e3\n  ratio: 1.10\nports:\n  - 80\nversion: \"1.10\""
+
+	if result := toYAML(input); result != expected {
+		t.Errorf("Expected toYaml %q, got %q", expected, result)
+	}
+	if result := mustToYAML(input); result != expected {
+		t.Errorf("Expected mustToYaml %q, got %q", expected, result)
+	}
+	if result := toYAMLPretty(input); result != expected {
+		t.Errorf("Expected toYamlPretty %q, got %q", expected, result)
+	}
+	if result := toJSON(input); result != `{"accountId":123456789012345678901234,"limits":{"max":1e3,"ratio":1.10},"ports":[80],"version":"1.10"}` {
+		t.Errorf("Expected toJson to keep number literals, got %q", result)
+	}
+}
@@ -0,0 +1,93 @@
+package template
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Encryption functions. Sprig's encryptAES and decryptAES use unauthenticated AES-CBC
+// with a password as key; these use AES-GCM with a proper key, as most applications
+// decrypting such fields expect.
+
+// encryptAESGCM encrypts plaintext with AES-GCM and returns the random 12-byte nonce
+// followed by the ciphertext and tag, base64 encoded. The key is base64 encoded and
+// 16, 24 or 32 bytes long, selecting AES-128, AES-192 or AES-256:
+// {{ .db.password | encryptAESGCM .aesKey }}.
+func encryptAESGCM(key, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptAESGCM decrypts the output of encryptAESGCM with the same key.
+func decryptAESGCM(key, ciphertext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode ciphertext: %w", err)
+	}
+	if len(data) < gcm.NonceSize()+gcm.Overhead() {
+		return "", errors.New("failed to decrypt: ciphertext too short")
+	}
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// newGCM returns an AES-GCM cipher for a base64 encoded key.
+func newGCM(key string) (cipher.AEAD, error) {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AES key: %w", err)
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AES key (expected 16, 24 or 32 bytes, base64 encoded): %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// Password hashing functions. They replace Sprig's bcrypt and htpasswd, which render
+// error messages in place of the hash instead of failing.
+
+// bcryptHash returns the bcrypt hash of password with the default cost.
+func bcryptHash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password with bcrypt: %w", err)
+	}
+	return string(hash), nil
+}
+
+// htpasswd returns an htpasswd line for username with the bcrypt hash of password, as
+// used by Ingress basic authentication: {{ htpasswd .auth.user .auth.password | b64enc }}.
+func htpasswd(username, password string) (string, error) {
+	if username == "" || strings.ContainsAny(username, ":\n") {
+		return "", fmt.Errorf("invalid htpasswd username %q", username)
+	}
+	hash, err := bcryptHash(password)
+	if err != nil {
+		return "", err
+	}
+	return username + ":" + hash, nil
+}
@@ -0,0 +1,176 @@
+package template
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAESGCM(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	otherKey := base64.StdEncoding.EncodeToString([]byte("fedcba9876543210"))
+
+	encrypted, err := encryptAESGCM(key, "s3cret")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if again, _ := encryptAESGCM(key, "s3cret"); again == encrypted {
+		t.Error("Expected a random nonce per encryption")
+	}
+	if data, _ := base64.StdEncoding.DecodeString(encrypted); len(data) != 12+len("s3cret")+16 {
+		t.Errorf("Expected nonce, ciphertext and tag, got %d bytes", len(data))
+	}
+
+	tests := []struct {
+		name       string
+		key        string
+		ciphertext string
+		expected   string
+		wantError  string
+	}{
+		{name: "round trip", key: key, ciphertext: encrypted, expected: "s3cret"},
+		{name: "wrong key", key: otherKey, ciphertext: encrypted, wantError: "failed to decrypt"},
+		{name: "tampered", key: key, ciphertext: base64.StdEncoding.EncodeToString(append(make([]byte, 12), make([]byte, 20)...)), wantError: "failed to decrypt"},
+		{name: "too short", key: key, ciphertext: "AAAA", wantError: "ciphertext too short"},
+		{name: "invalid key length", key: base64.StdEncoding.EncodeToString([]byte("short")), ciphertext: encrypted, wantError: "expected 16, 24 or 32 bytes"},
+		{name: "key not base64", key: "not base64!", ciphertext: encrypted, wantError: "invalid AES key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := decryptAESGCM(tt.key, tt.ciphertext)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestAESGCMInTemplate(t *testing.T) {
+	tmpl, err := NewStrictTemplate("test", false).ParseTemplate(`{{ .password | encryptAESGCM .key | decryptAESGCM .key }}`)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	data := map[string]any{"password": "hunter2", "key": base64.StdEncoding.EncodeToString(make([]byte, 16))}
+	result, err := tmpl.ExecuteTemplate(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != "hunter2" {
+		t.Errorf("Expected %q, got %q", "hunter2", result)
+	}
+}
+
+func TestHtpasswd(t *testing.T) {
+	tests := []struct {
+		name      string
+		template  string
+		user      string
+		password  string
+		wantError string
+	}{
+		{name: "htpasswd", template: `{{ htpasswd .user .password }}`, user: "admin", password: "s3cret"},
+		{name: "bcrypt", template: `{{ bcrypt .password }}`, password: "s3cret"},
+		{name: "invalid username", template: `{{ htpasswd .user .password }}`, user: "ad:min", password: "s3cret", wantError: "invalid htpasswd username"},
+		{name: "password too long", template: `{{ bcrypt .password }}`, password: strings.Repeat("x", 73), wantError: "failed to hash password"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := NewStrictTemplate("test", false).ParseTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("Failed to parse template: %v", err)
+			}
+
+			result, err := tmpl.ExecuteTemplate(map[string]any{"user": tt.user, "password": tt.password})
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			hash := result
+			if tt.user != "" {
+				user, rest, ok := strings.Cut(result, ":")
+				if !ok || user != tt.user {
+					t.Fatalf("Expected a line for %s, got %q", tt.user, result)
+				}
+				hash = rest
+			}
+			if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(tt.password)); err != nil {
+				t.Errorf("Expected %q to be a bcrypt hash of the password: %v", hash, err)
+			}
+		})
+	}
+}
+
+func TestCertificateFunctions(t *testing.T) {
+	tmpl, err := NewStrictTemplate("test", true).ParseTemplate(`
+{{- $ca := genCA "webhook-ca" 365 -}}
+{{- $cert := genSignedCert "webhook.system.svc" (list "10.0.0.1") (list "webhook.system.svc" "webhook") 30 $ca -}}
+{{- $self := genSelfSignedCert "dev.local" nil (list "dev.local") 1 -}}
+{{ $ca.Cert }}{{ $cert.Cert }}{{ $self.Cert }}{{ $cert.Key | b64enc | b64dec }}`)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	result, err := tmpl.ExecuteTemplate(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var certs []*x509.Certificate
+	var keys int
+	for rest := []byte(result); ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			keys++
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatalf("Failed to parse certificate: %v", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) != 3 || keys != 1 {
+		t.Fatalf("Expected 3 certificates and 1 key, got %d and %d", len(certs), keys)
+	}
+
+	ca, leaf, self := certs[0], certs[1], certs[2]
+	if !ca.IsCA {
+		t.Error("Expected genCA to create a CA certificate")
+	}
+	if err := leaf.CheckSignatureFrom(ca); err != nil {
+		t.Errorf("Expected the signed certificate to be signed by the CA: %v", err)
+	}
+	if len(leaf.DNSNames) != 2 || leaf.DNSNames[0] != "webhook.system.svc" || len(leaf.IPAddresses) != 1 || leaf.IPAddresses[0].String() != "10.0.0.1" {
+		t.Errorf("Expected the requested SANs, got %v and %v", leaf.DNSNames, leaf.IPAddresses)
+	}
+	if days := leaf.NotAfter.Sub(leaf.NotBefore).Hours() / 24; days < 29 || days > 31 {
+		t.Errorf("Expected a validity of 30 days, got %.1f", days)
+	}
+	if err := self.CheckSignature(self.SignatureAlgorithm, self.RawTBSCertificate, self.Signature); err != nil || self.Subject.CommonName != "dev.local" {
+		t.Errorf("Expected a self-signed certificate for dev.local, got %v (%v)", self.Subject, err)
+	}
+}
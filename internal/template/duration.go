@@ -0,0 +1,92 @@
+package template
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// dayUnitPattern matches day and week components of a duration, which Go does not parse.
+var dayUnitPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)([dw])`)
+
+// parseDuration converts a duration from values to a time.Duration, whose methods give
+// other units: {{ (parseDuration .timeout).Minutes }}. Strings use Go's syntax plus d and
+// w for days and weeks ("1h30m", "7d"), and numbers are seconds.
+func parseDuration(v any) (time.Duration, error) {
+	switch d := v.(type) {
+	case time.Duration:
+		return d, nil
+	case string:
+		return parseDurationString(d)
+	}
+
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return time.Duration(value.Int()) * time.Second, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return time.Duration(value.Uint()) * time.Second, nil
+	case reflect.Float32, reflect.Float64:
+		return time.Duration(value.Float() * float64(time.Second)), nil
+	default:
+		return 0, fmt.Errorf("cannot parse %v (%T) as a duration", v, v)
+	}
+}
+
+// parseDurationString parses a Go duration string that may use d and w units.
+func parseDurationString(s string) (time.Duration, error) {
+	expanded := dayUnitPattern.ReplaceAllStringFunc(s, func(component string) string {
+		match := dayUnitPattern.FindStringSubmatch(component)
+		hours, _ := strconv.ParseFloat(match[1], 64)
+		hours *= 24
+		if match[2] == "w" {
+			hours *= 7
+		}
+		return strconv.FormatFloat(hours, 'f', -1, 64) + "h"
+	})
+	d, err := time.ParseDuration(expanded)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// toSeconds returns a duration as whole seconds, truncating fractions: {{ toSeconds "1h30m" }}
+// is 5400.
+func toSeconds(v any) (int64, error) {
+	d, err := parseDuration(v)
+	if err != nil {
+		return 0, err
+	}
+	return int64(d / time.Second), nil
+}
+
+// toMilliseconds returns a duration as whole milliseconds.
+func toMilliseconds(v any) (int64, error) {
+	d, err := parseDuration(v)
+	if err != nil {
+		return 0, err
+	}
+	return int64(d / time.Millisecond), nil
+}
+
+// durationRoundFunc wraps Sprig's durationRound so it also rounds time.Duration values
+// and strings with d and w units, which it would treat as zero. Other values, such as
+// times and nanosecond counts, keep Sprig's behavior.
+func durationRoundFunc(sprigRound func(any) string) func(any) string {
+	return func(v any) string {
+		switch d := v.(type) {
+		case time.Duration:
+			return sprigRound(int64(d))
+		case string:
+			parsed, err := parseDurationString(d)
+			if err != nil {
+				return sprigRound(d)
+			}
+			return sprigRound(int64(parsed))
+		}
+		return sprigRound(v)
+	}
+}
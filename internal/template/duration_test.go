@@ -0,0 +1,56 @@
+package template
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDurationFunctions(t *testing.T) {
+	data := map[string]any{
+		"timeout":   "1h30m",
+		"retention": "7d",
+		"interval":  90,
+		"grace":     2.5,
+	}
+
+	tests := []struct {
+		name      string
+		template  string
+		expected  string
+		wantError string
+	}{
+		{name: "toSeconds", template: `{{ toSeconds .timeout }}`, expected: "5400"},
+		{name: "toSeconds days", template: `{{ toSeconds .retention }} {{ toSeconds "1w1d12h" }} {{ toSeconds "1.5d" }}`, expected: "604800 734400 129600"},
+		{name: "toSeconds number", template: `{{ toSeconds .interval }}`, expected: "90"},
+		{name: "toSeconds truncates", template: `{{ toSeconds "1500ms" }} {{ toSeconds .grace }}`, expected: "1 2"},
+		{name: "toMilliseconds", template: `{{ toMilliseconds "2s" }} {{ toMilliseconds .grace }}`, expected: "2000 2500"},
+		{name: "parseDuration units", template: `{{ (parseDuration .timeout).Minutes }} {{ parseDuration .interval }}`, expected: "90 1m30s"},
+		{name: "durationRound parsed", template: `{{ parseDuration "26h" | durationRound }} {{ durationRound .retention }}`, expected: "1d 7d"},
+		{name: "durationRound sprig", template: `{{ durationRound "2h10m5s" }}`, expected: "2h"},
+		{name: "invalid", template: `{{ toSeconds "soon" }}`, wantError: `invalid duration "soon"`},
+		{name: "invalid type", template: `{{ toSeconds true }}`, wantError: "cannot parse true (bool) as a duration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := NewStrictTemplate("test", false).ParseTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("Failed to parse template: %v", err)
+			}
+
+			result, err := tmpl.ExecuteTemplate(data)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
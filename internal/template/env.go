@@ -0,0 +1,66 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// envFunctionPattern matches calls of env and expandenv in template source, but not
+// fields such as .env.
+var envFunctionPattern = regexp.MustCompile(`(^|[^.\w])(env|expandenv)\b`)
+
+// UsesEnvFunctions reports whether template source may call env or expandenv, whose
+// output depends on the environment besides the template and values.
+func UsesEnvFunctions(content string) bool {
+	return envFunctionPattern.MatchString(content)
+}
+
+// AllowEnv makes the env and expandenv functions, which are removed from templates by
+// default, available again. With a prefix, only variables whose names start with it can
+// be read; reading others fails rendering.
+func (st *StrictTemplate) AllowEnv(prefix string) {
+	env := envFunctions{prefix: prefix}
+	st.Template.Funcs(template.FuncMap{
+		"env":       env.env,
+		"expandenv": env.expandenv,
+	})
+}
+
+// envFunctions reads environment variables whose names start with prefix.
+type envFunctions struct {
+	prefix string
+}
+
+// env returns the value of the variable name, or "" when it is not set.
+func (e envFunctions) env(name string) (string, error) {
+	if err := e.check(name); err != nil {
+		return "", err
+	}
+	return os.Getenv(name), nil
+}
+
+// expandenv replaces $VAR and ${VAR} in s with the values of the variables.
+func (e envFunctions) expandenv(s string) (string, error) {
+	var err error
+	expanded := os.Expand(s, func(name string) string {
+		if checkErr := e.check(name); checkErr != nil {
+			if err == nil {
+				err = checkErr
+			}
+			return ""
+		}
+		return os.Getenv(name)
+	})
+	return expanded, err
+}
+
+// check reports variables outside the allowed prefix.
+func (e envFunctions) check(name string) error {
+	if !strings.HasPrefix(name, e.prefix) {
+		return fmt.Errorf("environment variable %s is not allowed (only %s* variables can be read)", name, e.prefix)
+	}
+	return nil
+}
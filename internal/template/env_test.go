@@ -0,0 +1,77 @@
+package template
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAllowEnv(t *testing.T) {
+	t.Setenv("APP_REGION", "eu-west-1")
+	t.Setenv("APP_NAME", "web")
+	t.Setenv("SECRET_TOKEN", "s3cr3t")
+
+	tests := []struct {
+		name      string
+		prefix    string
+		template  string
+		expected  string
+		wantError string
+	}{
+		{name: "env", template: `{{ env "APP_REGION" }}`, expected: "eu-west-1"},
+		{name: "unset", template: `{{ env "APP_MISSING" | default "none" }}`, expected: "none"},
+		{name: "expandenv", template: `{{ expandenv "${APP_NAME}.$APP_REGION" }}`, expected: "web.eu-west-1"},
+		{name: "no prefix", template: `{{ env "SECRET_TOKEN" }}`, expected: "s3cr3t"},
+		{name: "prefix", prefix: "APP_", template: `{{ env "APP_NAME" }}`, expected: "web"},
+		{name: "outside prefix", prefix: "APP_", template: `{{ env "SECRET_TOKEN" }}`, wantError: "environment variable SECRET_TOKEN is not allowed (only APP_* variables can be read)"},
+		{name: "expandenv outside prefix", prefix: "APP_", template: `{{ expandenv "$APP_NAME:$SECRET_TOKEN" }}`, wantError: "SECRET_TOKEN is not allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := NewStrictTemplate("test", false)
+			st.AllowEnv(tt.prefix)
+			tmpl, err := st.ParseTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("Failed to parse template: %v", err)
+			}
+
+			result, err := tmpl.ExecuteTemplate(nil)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+
+	// Without AllowEnv the functions do not exist
+	if _, err := NewStrictTemplate("test", false).ParseTemplate(`{{ env "APP_NAME" }}`); err == nil {
+		t.Error("Expected env to be undefined by default")
+	}
+}
+
+func TestUsesEnvFunctions(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected bool
+	}{
+		{content: `{{ env "HOME" }}`, expected: true},
+		{content: `{{ expandenv "$HOME" }}`, expected: true},
+		{content: `{{(env "HOME")}}`, expected: true},
+		{content: `{{ .env }} {{ $.env }} {{ .Values.env }}`},
+		{content: `{{ environment }}`},
+	}
+
+	for _, tt := range tests {
+		if got := UsesEnvFunctions(tt.content); got != tt.expected {
+			t.Errorf("UsesEnvFunctions(%q) = %v, want %v", tt.content, got, tt.expected)
+		}
+	}
+}
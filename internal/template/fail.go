@@ -0,0 +1,60 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"text/template"
+)
+
+// execErrorPattern extracts the template name and position from execution errors.
+var execErrorPattern = regexp.MustCompile(`^template: (.+?):(\d+):(\d+): `)
+
+// FailError reports a template aborted with the fail function.
+type FailError struct {
+	Template string // Name of the template calling fail, such as a helper
+	Line     int
+	Column   int
+	Message  string
+}
+
+func (e *FailError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Template, e.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.Template, e.Line, e.Column, e.Message)
+}
+
+// failMessage is the error returned by fail, located by asFailError.
+type failMessage string
+
+func (m failMessage) Error() string { return string(m) }
+
+// fail aborts rendering with msg: {{ if lt .replicas 1 }}{{ fail "replicas must be > 0" }}{{ end }}.
+func fail(msg string) (string, error) {
+	return "", failMessage(msg)
+}
+
+// asFailError returns the *FailError for an execution error raised by fail, or nil.
+func asFailError(err error, name string) *FailError {
+	var msg failMessage
+	if !errors.As(err, &msg) {
+		return nil
+	}
+
+	// The innermost execution error locates the fail call, also inside included templates
+	failErr := &FailError{Template: name, Message: string(msg)}
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		execErr, ok := e.(template.ExecError)
+		if !ok {
+			continue
+		}
+		if match := execErrorPattern.FindStringSubmatch(execErr.Error()); match != nil {
+			failErr.Template = match[1]
+			failErr.Line, _ = strconv.Atoi(match[2])
+			failErr.Column, _ = strconv.Atoi(match[3])
+		}
+	}
+	return failErr
+}
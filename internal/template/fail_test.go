@@ -0,0 +1,86 @@
+package template
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFail(t *testing.T) {
+	helpers := []Helper{{
+		Name:    "_helpers.tpl",
+		Content: "{{ define \"app.check\" }}\n{{- if not .name }}{{ fail \"name is required\" }}{{ end }}\n{{- end }}",
+	}}
+
+	tests := []struct {
+		name       string
+		template   string
+		data       map[string]any
+		strictMode bool
+		expected   string
+		wantError  *FailError
+	}{
+		{
+			name:     "condition not met",
+			template: `{{ if lt .replicas 1 }}{{ fail "replicas must be > 0" }}{{ end }}ok`,
+			data:     map[string]any{"replicas": 2},
+			expected: "ok",
+		},
+		{
+			name:      "fail",
+			template:  "replicas: {{ .replicas }}\n{{ if lt .replicas 1 }}{{ fail \"replicas must be > 0\" }}{{ end }}",
+			data:      map[string]any{"replicas": 0},
+			wantError: &FailError{Template: "app.tpl", Line: 2, Column: 26, Message: "replicas must be > 0"},
+		},
+		{
+			name:       "strict mode",
+			template:   `{{ fail "map has no entry for key" }}`,
+			strictMode: true,
+			wantError:  &FailError{Template: "app.tpl", Line: 1, Column: 3, Message: "map has no entry for key"},
+		},
+		{
+			name:      "in a helper",
+			template:  `{{ include "app.check" . }}`,
+			data:      map[string]any{},
+			wantError: &FailError{Template: "_helpers.tpl", Line: 2, Column: 22, Message: "name is required"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := NewStrictTemplate("app.tpl", tt.strictMode)
+			if err := tmpl.ParseHelpers(helpers); err != nil {
+				t.Fatalf("Failed to parse helpers: %v", err)
+			}
+			parsed, err := tmpl.ParseTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("Failed to parse template: %v", err)
+			}
+
+			result, err := parsed.ExecuteTemplate(tt.data)
+			if tt.wantError != nil {
+				var failErr *FailError
+				if !errors.As(err, &failErr) {
+					t.Fatalf("Expected FailError, got %T: %v", err, err)
+				}
+				if *failErr != *tt.wantError {
+					t.Errorf("Expected %+v, got %+v", *tt.wantError, *failErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFailErrorMessage(t *testing.T) {
+	err := &FailError{Template: "templates/app.tpl", Line: 3, Column: 7, Message: "replicas must be > 0"}
+	expected := "templates/app.tpl:3:7: replicas must be > 0"
+	if err.Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, err.Error())
+	}
+}
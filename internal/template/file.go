@@ -5,4 +5,5 @@ type File struct {
 	SourcePath   string // Full path to the source template file
 	RelativePath string // Relative path from the template directory
 	OutputPath   string // Full path for the output file
+	Static       bool   // Copied verbatim instead of rendered, with --copy-static
 }
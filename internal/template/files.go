@@ -0,0 +1,184 @@
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// fileFunctionPattern matches calls of the file functions in template source.
+var fileFunctionPattern = regexp.MustCompile(`\b(readFile|fileExists|glob|sha256file|b64file|includeFile)\b`)
+
+// UsesFileFunctions reports whether template source may call one of the file functions,
+// whose output depends on files besides the template and values.
+func UsesFileFunctions(content string) bool {
+	return fileFunctionPattern.MatchString(content)
+}
+
+// SetFileRoot makes the file functions (readFile, fileExists, glob, sha256file, b64file
+// and includeFile) available to the template, reading files below root. Paths are relative
+// to root and may not escape it, neither with .. nor through symbolic links. Without a
+// root the functions fail.
+func (st *StrictTemplate) SetFileRoot(root string) {
+	files := fileFunctions{root: root}
+	st.Template.Funcs(template.FuncMap{
+		"readFile":    files.readFile,
+		"fileExists":  files.fileExists,
+		"glob":        files.glob,
+		"sha256file":  files.sha256file,
+		"b64file":     files.b64file,
+		"includeFile": files.includeFile(st.Template),
+	})
+}
+
+// fileFunctions implements the file functions for one root directory.
+type fileFunctions struct {
+	root string
+}
+
+// readFile returns the content of a file below the root.
+func (f fileFunctions) readFile(path string) (string, error) {
+	data, err := f.read(path)
+	return string(data), err
+}
+
+// sha256file returns the hex encoded SHA-256 checksum of a file below the root.
+func (f fileFunctions) sha256file(path string) (string, error) {
+	data, err := f.read(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// b64file returns the standard base64 encoding of a file below the root, for binary
+// content such as certificates in Secrets: {{ b64file "certs/ca.pem" }}.
+func (f fileFunctions) b64file(path string) (string, error) {
+	data, err := f.read(path)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// read returns the content of a file below the root.
+func (f fileFunctions) read(path string) ([]byte, error) {
+	full, err := f.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(full)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// includeFile returns the includeFile function of tmpl, which renders another template
+// file below the root with data, like include does for named templates. Its define
+// blocks join the namespace of tmpl. Piped to sha256sum it yields a checksum that
+// changes with the rendered file: {{ includeFile "configmap.yaml.tpl" . | sha256sum }}.
+func (f fileFunctions) includeFile(tmpl *template.Template) func(string, any) (string, error) {
+	depth := 0
+	return func(path string, data any) (string, error) {
+		if depth >= maxIncludeDepth {
+			return "", fmt.Errorf("includeFile %q nested more than %d levels deep", path, maxIncludeDepth)
+		}
+		depth++
+		defer func() { depth-- }()
+
+		content, err := f.readFile(path)
+		if err != nil {
+			return "", err
+		}
+		if _, err := tmpl.New(path).Parse(content); err != nil {
+			return "", fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		buf := getBuffer()
+		defer putBuffer(buf)
+		if err := tmpl.ExecuteTemplate(buf, path, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+}
+
+// fileExists reports whether a regular file exists below the root.
+func (f fileFunctions) fileExists(path string) (bool, error) {
+	full, err := f.resolve(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	info, err := os.Stat(full)
+	return err == nil && info.Mode().IsRegular(), nil
+}
+
+// glob returns the sorted paths, relative to the root, of the files matching pattern.
+func (f fileFunctions) glob(pattern string) ([]string, error) {
+	if err := checkLocalPath(pattern); err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(filepath.Join(f.root, filepath.FromSlash(pattern)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+	}
+
+	paths := []string{}
+	for _, match := range matches {
+		relative, err := filepath.Rel(f.root, match)
+		if err != nil {
+			continue
+		}
+		relative = filepath.ToSlash(relative)
+		if _, err := f.resolve(relative); err != nil {
+			continue
+		}
+		paths = append(paths, relative)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// resolve returns the local path of a file below the root, following symbolic links
+// only as long as they stay below it.
+func (f fileFunctions) resolve(path string) (string, error) {
+	if f.root == "" {
+		return "", fmt.Errorf("file functions are disabled")
+	}
+	if err := checkLocalPath(path); err != nil {
+		return "", err
+	}
+
+	root, err := filepath.EvalSymlinks(f.root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve template root: %w", err)
+	}
+	full, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(path)))
+	if err != nil {
+		return "", err
+	}
+	if relative, err := filepath.Rel(root, full); err != nil || !filepath.IsLocal(relative) {
+		return "", fmt.Errorf("path %s resolves outside the template directory", path)
+	}
+	return full, nil
+}
+
+// checkLocalPath rejects absolute paths and paths escaping the root with "..".
+func checkLocalPath(path string) error {
+	if path == "" || !filepath.IsLocal(filepath.FromSlash(path)) || strings.HasPrefix(path, "/") {
+		return fmt.Errorf("path %q must be relative to the template directory", path)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileFunctions(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "templates")
+	files := map[string]string{
+		"templates/scripts/init.sh":       "#!/bin/sh\necho init\n",
+		"templates/dashboards/a.json":     `{"a":1}`,
+		"templates/dashboards/b.json":     `{"b":2}`,
+		"templates/dashboards/c.yaml":     "c: 3\n",
+		"secret.txt":                      "secret",
+		"templates/certs/ca.der":          "\x30\x82\xff\x00",
+		"templates/configmap.yaml.tpl":    `{{ define "cm.name" }}{{ .name }}{{ end }}name: {{ template "cm.name" . }}`,
+		"templates/loop.tpl":              `{{ includeFile "loop.tpl" . }}`,
+		"templates/dashboards/sub/d.json": `{"d":4}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(base, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(base, "secret.txt"), filepath.Join(root, "dashboards", "z.json")); err != nil {
+		t.Skipf("Symbolic links unsupported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "scripts", "init.sh"), filepath.Join(root, "init.sh")); err != nil {
+		t.Fatalf("Failed to create symbolic link: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		template  string
+		root      string
+		expected  string
+		wantError string
+	}{
+		{
+			name:     "readFile",
+			template: "init: |\n{{ readFile \"scripts/init.sh\" | indent 2 }}",
+			root:     root,
+			expected: "init: |\n  #!/bin/sh\n  echo init\n  ",
+		},
+		{
+			name:     "symbolic link inside the root",
+			template: `{{ readFile "init.sh" | trim }}`,
+			root:     root,
+			expected: "#!/bin/sh\necho init",
+		},
+		{
+			name:     "fileExists",
+			template: `{{ fileExists "scripts/init.sh" }} {{ fileExists "missing.sh" }} {{ fileExists "scripts" }}`,
+			root:     root,
+			expected: "true false false",
+		},
+		{
+			name:     "glob",
+			template: `{{ range glob "dashboards/*.json" }}{{ . }}={{ readFile . }} {{ end }}`,
+			root:     root,
+			expected: `dashboards/a.json={"a":1} dashboards/b.json={"b":2} `,
+		},
+		{
+			name:     "glob without matches",
+			template: `{{ glob "*.txt" | len }}`,
+			root:     root,
+			expected: "0",
+		},
+		{
+			name:     "sha256file",
+			template: `{{ sha256file "scripts/init.sh" }}`,
+			root:     root,
+			expected: "1e9c8c43fc85e1f59d6b98c99d6428b3511e67762e5998effa4745524e934d69",
+		},
+		{
+			name:     "b64file",
+			template: `{{ b64file "certs/ca.der" }}`,
+			root:     root,
+			expected: "MIL/AA==",
+		},
+		{
+			name:      "b64file outside the root",
+			template:  `{{ b64file "../secret.txt" }}`,
+			root:      root,
+			wantError: "must be relative to the template directory",
+		},
+		{
+			name:     "checksum of includeFile",
+			template: `{{ includeFile "configmap.yaml.tpl" (dict "name" "web") | sha256sum }}`,
+			root:     root,
+			expected: "a8cf5244709c43523c8a47bc54c14a53924e4c64577bd7b8186eaf5690efbf65",
+		},
+		{
+			name:     "includeFile defines",
+			template: `{{ includeFile "configmap.yaml.tpl" (dict "name" "web") }} {{ include "cm.name" (dict "name" "api") }}`,
+			root:     root,
+			expected: "name: web api",
+		},
+		{
+			name:      "includeFile recursion",
+			template:  `{{ includeFile "loop.tpl" . }}`,
+			root:      root,
+			wantError: "nested more than 1000 levels deep",
+		},
+		{
+			name:      "parent directory",
+			template:  `{{ readFile "../secret.txt" }}`,
+			root:      root,
+			wantError: "must be relative to the template directory",
+		},
+		{
+			name:      "absolute path",
+			template:  `{{ readFile "/etc/passwd" }}`,
+			root:      root,
+			wantError: "must be relative to the template directory",
+		},
+		{
+			name:      "symbolic link outside the root",
+			template:  `{{ readFile "dashboards/z.json" }}`,
+			root:      root,
+			wantError: "resolves outside the template directory",
+		},
+		{
+			name:      "fileExists through a symbolic link outside the root",
+			template:  `{{ fileExists "dashboards/z.json" }}`,
+			root:      root,
+			wantError: "resolves outside the template directory",
+		},
+		{
+			name:      "glob outside the root",
+			template:  `{{ glob "../*.txt" }}`,
+			root:      root,
+			wantError: "must be relative to the template directory",
+		},
+		{
+			name:      "missing file",
+			template:  `{{ readFile "missing.sh" }}`,
+			root:      root,
+			wantError: "no such file",
+		},
+		{
+			name:      "disabled",
+			template:  `{{ readFile "scripts/init.sh" }}`,
+			wantError: "file functions are disabled",
+		},
+		{
+			name:      "includeFile disabled",
+			template:  `{{ includeFile "configmap.yaml.tpl" . }}`,
+			wantError: "file functions are disabled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := NewStrictTemplate("test", false)
+			if tt.root != "" {
+				tmpl.SetFileRoot(tt.root)
+			}
+			parsed, err := tmpl.ParseTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("Failed to parse template: %v", err)
+			}
+
+			result, err := parsed.ExecuteTemplate(nil)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestUsesFileFunctions(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected bool
+	}{
+		{content: `{{ readFile "a.sh" }}`, expected: true},
+		{content: `{{ range glob "*.json" }}{{ end }}`, expected: true},
+		{content: `{{ if fileExists "a" }}{{ end }}`, expected: true},
+		{content: `{{ includeFile "cm.tpl" . | sha256sum }}`, expected: true},
+		{content: `{{ sha256file "a.sh" }}`, expected: true},
+		{content: `{{ b64file "ca.pem" }}`, expected: true},
+		{content: `{{ .globals.name }}`, expected: false},
+	}
+
+	for _, tt := range tests {
+		if got := UsesFileFunctions(tt.content); got != tt.expected {
+			t.Errorf("UsesFileFunctions(%q) = %v, expected %v", tt.content, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package template
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// PathFilter selects the files of a template directory with --include and --exclude glob
+// patterns, relative to the directory, in which ** matches any number of directories. A
+// nil *PathFilter selects every file.
+type PathFilter struct {
+	include [][]string
+	exclude [][]string
+}
+
+// NewPathFilter returns the filter for the include and exclude patterns, or nil when
+// there are none.
+func NewPathFilter(include, exclude []string) (*PathFilter, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil, nil
+	}
+	filter := &PathFilter{}
+	var err error
+	if filter.include, err = splitPatterns(include); err != nil {
+		return nil, fmt.Errorf("invalid --include pattern: %w", err)
+	}
+	if filter.exclude, err = splitPatterns(exclude); err != nil {
+		return nil, fmt.Errorf("invalid --exclude pattern: %w", err)
+	}
+	return filter, nil
+}
+
+// splitPatterns checks glob patterns and splits them into path segments.
+func splitPatterns(patterns []string) ([][]string, error) {
+	var split [][]string
+	for _, pattern := range patterns {
+		if err := checkLocalPath(pattern); err != nil {
+			return nil, err
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%s: %w", pattern, err)
+		}
+		split = append(split, strings.Split(path.Clean(pattern), "/"))
+	}
+	return split, nil
+}
+
+// Skipped reports whether the file or directory at relativePath is left out. Files must
+// match an include pattern, when there are any, and no exclude pattern; directories are
+// only skipped, with everything below them, when they match an exclude pattern.
+func (f *PathFilter) Skipped(relativePath string, isDir bool) bool {
+	if f == nil {
+		return false
+	}
+	segments := strings.Split(filepath.ToSlash(relativePath), "/")
+	for _, pattern := range f.exclude {
+		if matchGlob(pattern, segments) {
+			return true
+		}
+	}
+	if isDir || len(f.include) == 0 {
+		return false
+	}
+	for _, pattern := range f.include {
+		if matchGlob(pattern, segments) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package template
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPathFilter(t *testing.T) {
+	filter, err := NewPathFilter([]string{"k8s/**", "*.tpl"}, []string{"**/legacy/*", "k8s/tmp"})
+	if err != nil {
+		t.Fatalf("NewPathFilter failed: %v", err)
+	}
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		skipped bool
+	}{
+		{path: "app.tpl"},
+		{path: "k8s/deployment.tpl"},
+		{path: "k8s/apps/web/service.tpl"},
+		{path: "docs/readme.tpl", skipped: true},
+		{path: "docs", isDir: true},
+		{path: "k8s/legacy/old.tpl", skipped: true},
+		{path: "k8s/legacy", isDir: true},
+		{path: "k8s/tmp", isDir: true, skipped: true},
+	}
+	for _, tt := range tests {
+		if got := filter.Skipped(tt.path, tt.isDir); got != tt.skipped {
+			t.Errorf("Skipped(%q, %v) = %v, expected %v", tt.path, tt.isDir, got, tt.skipped)
+		}
+	}
+
+	// Without patterns every file is selected
+	filter, err = NewPathFilter(nil, nil)
+	if err != nil || filter != nil || filter.Skipped("any/file.tpl", false) {
+		t.Errorf("Expected no filter without patterns, got %+v (%v)", filter, err)
+	}
+}
+
+func TestPathFilterInvalid(t *testing.T) {
+	tests := []struct {
+		include, exclude []string
+		wantError        string
+	}{
+		{include: []string{"[broken"}, wantError: "invalid --include pattern"},
+		{exclude: []string{"../outside/*"}, wantError: "must be relative to the template directory"},
+		{exclude: []string{"/abs/*"}, wantError: "invalid --exclude pattern"},
+	}
+	for _, tt := range tests {
+		if _, err := NewPathFilter(tt.include, tt.exclude); err == nil || !strings.Contains(err.Error(), tt.wantError) {
+			t.Errorf("NewPathFilter(%v, %v): expected error containing %q, got %v", tt.include, tt.exclude, tt.wantError, err)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package template
 
 import (
+	"errors"
+	"fmt"
 	"maps"
 	"text/template"
 
@@ -23,23 +25,124 @@ func GetTemplateFuncs() template.FuncMap {
 		"toYaml":        toYAML,
 		"mustToYaml":    mustToYAML,
 		"toYamlPretty":  toYAMLPretty,
+		"toYamlSorted":  toYAMLSorted,
 		"fromYaml":      fromYAML,
 		"fromYamlArray": fromYAMLArray,
 
 		// JSON functions
 		"toJson":        toJSON,
 		"mustToJson":    mustToJSON,
+		"toJsonSorted":  toJSONSorted,
 		"fromJson":      fromJSON,
 		"fromJsonArray": fromJSONArray,
 
 		// TOML functions
-		"toToml":   toTOML,
-		"fromToml": fromTOML,
+		"toToml":       toTOML,
+		"toTomlSorted": toTOMLSorted,
+		"fromToml":     fromTOML,
+
+		// CSV functions
+		"toCsv":        toCSV,
+		"toCsvColumns": toCSVColumns,
+		"fromCsv":      fromCSV,
+
+		// Table functions
+		"table":        table,
+		"tableColumns": tableColumns,
+
+		// Markdown functions
+		"markdownToHtml": markdownToHTML,
+
+		// Dotenv functions
+		"toDotenv":   toDotenv,
+		"fromDotenv": fromDotenv,
+
+		// Java properties functions
+		"toProperties": toProperties,
+
+		// INI functions
+		"toIni":   toINI,
+		"fromIni": fromINI,
+
+		// XML functions
+		"toXml":   toXML,
+		"fromXml": fromXML,
+
+		// Compression functions
+		"gzipBase64":   gzipBase64,
+		"gunzipBase64": gunzipBase64,
+		"zlibBase64":   zlibBase64,
+		"unzlibBase64": unzlibBase64,
+
+		// Encryption functions
+		"encryptAESGCM": encryptAESGCM,
+		"decryptAESGCM": decryptAESGCM,
+
+		// Password hashing functions
+		"bcrypt":   bcryptHash,
+		"htpasswd": htpasswd,
+
+		// Seeded random functions
+		"randAlphaNumSeeded": randAlphaNumSeeded,
+		"randAlphaSeeded":    randAlphaSeeded,
+		"randNumericSeeded":  randNumericSeeded,
+		"randAsciiSeeded":    randAsciiSeeded,
+		"uuidv5":             uuidv5,
+
+		// Key generation functions
+		"genSSHKeyPair": genSSHKeyPair,
+
+		// Duration functions
+		"parseDuration":  parseDuration,
+		"toSeconds":      toSeconds,
+		"toMilliseconds": toMilliseconds,
+		"durationRound":  durationRoundFunc(f["durationRound"].(func(interface{}) string)),
+
+		// Humanize functions
+		"humanizeBytes":   humanizeBytes,
+		"humanizeIBytes":  humanizeIBytes,
+		"humanizeNumber":  humanizeNumber,
+		"humanizeOrdinal": humanizeOrdinal,
+
+		// URL functions
+		"urlParse":    urlParse,
+		"urlJoin":     urlJoin,
+		"urlQuery":    urlQuery,
+		"urlJoinPath": urlJoinPath,
+
+		// Network functions
+		"cidrSubnet": cidrSubnet,
+		"cidrHost":   cidrHost,
+		"ipAdd":      ipAdd,
+		"inCIDR":     inCIDR,
+
+		// Query functions
+		"jq":      jq,
+		"getPath": getPath,
+		"setPath": setPath,
+
+		// Scripting functions
+		"starlark": runStarlark,
+
+		// Guard functions
+		"required": required,
+		"fail":     fail,
+
+		// File functions are bound to the template directory by SetFileRoot
+		"readFile":    fileFunctions{}.readFile,
+		"fileExists":  fileFunctions{}.fileExists,
+		"glob":        fileFunctions{}.glob,
+		"sha256file":  fileFunctions{}.sha256file,
+		"b64file":     fileFunctions{}.b64file,
+		"includeFile": fileFunctions{}.includeFile(nil),
+
+		// include is bound to its template by NewStrictTemplate
+		"include": func(name string, _ any) (string, error) {
+			return "", fmt.Errorf("include %q: no template namespace available", name)
+		},
 
 		// Placeholder functions for advanced features
-		"include":  func(string, any) string { return notImplementedStr },
-		"tpl":      func(string, any) any { return notImplementedStr },
-		"required": func(string, any) (any, error) { return notImplementedStr, nil },
+		"tpl": func(string, any) any { return notImplementedStr },
 		"lookup": func(string, string, string, string) (map[string]any, error) {
 			return map[string]any{}, nil
 		},
@@ -50,3 +153,15 @@ func GetTemplateFuncs() template.FuncMap {
 
 	return f
 }
+
+// required returns value, or fails rendering with msg when value is nil or an empty
+// string, regardless of strict mode: {{ required "app.image is required" .app.image }}.
+func required(msg string, value any) (any, error) {
+	if value == nil {
+		return nil, errors.New(msg)
+	}
+	if s, ok := value.(string); ok && s == "" {
+		return nil, errors.New(msg)
+	}
+	return value, nil
+}
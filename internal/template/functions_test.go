@@ -21,7 +21,7 @@ func TestGetTemplateFuncs(t *testing.T) {
 	customFuncs := []string{
 		"toYaml", "mustToYaml", "toYamlPretty", "fromYaml", "fromYamlArray",
 		"toJson", "mustToJson", "fromJson", "fromJsonArray",
-		"toToml", "fromToml",
+		"toToml", "fromToml", "include", "required",
 	}
 	for _, funcName := range customFuncs {
 		if _, exists := funcs[funcName]; !exists {
@@ -30,7 +30,7 @@ func TestGetTemplateFuncs(t *testing.T) {
 	}
 
 	// Test that placeholder functions are present
-	placeholderFuncs := []string{"include", "tpl", "required", "lookup"}
+	placeholderFuncs := []string{"tpl", "lookup"}
 	for _, funcName := range placeholderFuncs {
 		if _, exists := funcs[funcName]; !exists {
 			t.Errorf("Expected placeholder function %s to be present in template funcs", funcName)
@@ -143,3 +143,46 @@ Repeat: {{repeat 3 "x"}}
 		t.Error("Sprig repeat function did not work correctly")
 	}
 }
+
+func TestRequired(t *testing.T) {
+	tests := []struct {
+		name       string
+		template   string
+		data       map[string]any
+		strictMode bool
+		expected   string
+		wantError  bool
+	}{
+		{name: "present", template: `{{ required "name is required" .name }}`, data: map[string]any{"name": "api"}, expected: "api"},
+		{name: "zero number", template: `{{ required "port is required" .port }}`, data: map[string]any{"port": 0}, expected: "0"},
+		{name: "false", template: `{{ required "flag is required" .flag }}`, data: map[string]any{"flag": false}, expected: "false"},
+		{name: "piped", template: `{{ .name | required "name is required" | upper }}`, data: map[string]any{"name": "api"}, expected: "API"},
+		{name: "missing", template: `{{ required "name is required" .name }}`, data: map[string]any{}, wantError: true},
+		{name: "nil", template: `{{ required "name is required" .name }}`, data: map[string]any{"name": nil}, wantError: true},
+		{name: "empty string", template: `{{ required "name is required" .name }}`, data: map[string]any{"name": ""}, wantError: true},
+		{name: "empty string in strict mode", template: `{{ required "name is required" .name }}`, data: map[string]any{"name": ""}, strictMode: true, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := NewStrictTemplate("test", tt.strictMode).ParseTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("Failed to parse template: %v", err)
+			}
+
+			result, err := tmpl.ExecuteTemplate(tt.data)
+			if tt.wantError {
+				if err == nil || !strings.Contains(err.Error(), "is required") {
+					t.Errorf("Expected the required message, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package template
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// filesObjectPattern matches uses of the .Files object in template source.
+var filesObjectPattern = regexp.MustCompile(`\.Files\b`)
+
+// UsesFilesObject reports whether template source may use .Files, whose output depends
+// on files besides the template and values.
+func UsesFilesObject(content string) bool {
+	return filesObjectPattern.MatchString(content)
+}
+
+// Files is Helm's .Files object for the files below a template directory, such as
+// static assets bundled into ConfigMaps:
+// {{ (.Files.Glob "dashboards/*.json").AsConfig | nindent 2 }}. It follows the root rules
+// of the file functions, and fails when they are disabled.
+type Files struct {
+	files fileFunctions
+	// paths are the files selected by Glob; nil means every file below the root
+	paths []string
+}
+
+// NewFiles returns the Files object for root. An empty root disables it.
+func NewFiles(root string) Files {
+	return Files{files: fileFunctions{root: root}}
+}
+
+// Get returns the content of the file at name, relative to the root, or "" when there
+// is no such file.
+func (f Files) Get(name string) (string, error) {
+	data, err := f.GetBytes(name)
+	return string(data), err
+}
+
+// GetBytes returns the content of the file at name as bytes, or nil when there is no
+// such file.
+func (f Files) GetBytes(name string) ([]byte, error) {
+	full, err := f.files.resolve(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(full)
+}
+
+// Lines returns the lines of the file at name, without line endings.
+func (f Files) Lines(name string) ([]string, error) {
+	content, err := f.Get(name)
+	if err != nil || content == "" {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(content, "\r\n", "\n"), "\n"), "\n"), nil
+}
+
+// Glob returns the files whose paths match pattern, in which ** matches any number of
+// directories: {{ .Files.Glob "config/**/*.yaml" }}.
+func (f Files) Glob(pattern string) (Files, error) {
+	if err := checkLocalPath(pattern); err != nil {
+		return Files{}, err
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return Files{}, err
+	}
+
+	all, err := f.list()
+	if err != nil {
+		return Files{}, err
+	}
+	selected := []string{}
+	for _, name := range all {
+		if matchGlob(strings.Split(pattern, "/"), strings.Split(name, "/")) {
+			selected = append(selected, name)
+		}
+	}
+	return Files{files: f.files, paths: selected}, nil
+}
+
+// Paths returns the sorted paths of the files, relative to the root, for iterating over
+// them: {{ range (.Files.Glob "scripts/*").Paths }}.
+func (f Files) Paths() ([]string, error) {
+	return f.list()
+}
+
+// AsConfig returns the files as a YAML map from file names to contents, for the data of
+// a ConfigMap. Files in different directories with the same name overwrite each other,
+// as in Helm.
+func (f Files) AsConfig() (string, error) {
+	return f.asMap(func(data []byte) string { return string(data) })
+}
+
+// AsSecrets returns the files as a YAML map from file names to base64 encoded contents,
+// for the data of a Secret.
+func (f Files) AsSecrets() (string, error) {
+	return f.asMap(base64.StdEncoding.EncodeToString)
+}
+
+// asMap returns the files as a YAML map from file names to encoded contents.
+func (f Files) asMap(encode func([]byte) string) (string, error) {
+	names, err := f.list()
+	if err != nil {
+		return "", err
+	}
+	m := map[string]string{}
+	for _, name := range names {
+		data, err := f.files.read(name)
+		if err != nil {
+			return "", err
+		}
+		m[path.Base(name)] = encode(data)
+	}
+	if len(m) == 0 {
+		return "", nil
+	}
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// list returns the sorted paths of the selected files, relative to the root.
+func (f Files) list() ([]string, error) {
+	if f.paths != nil {
+		return f.paths, nil
+	}
+	if f.files.root == "" {
+		return nil, fmt.Errorf("file functions are disabled")
+	}
+	root, err := filepath.EvalSymlinks(f.files.root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve template root: %w", err)
+	}
+
+	var names []string
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		relative, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		relative = filepath.ToSlash(relative)
+		// Symbolic links are only followed while they stay below the root
+		if full, err := f.files.resolve(relative); err == nil {
+			if info, err := os.Stat(full); err == nil && info.Mode().IsRegular() {
+				names = append(names, relative)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// matchGlob reports whether the path segments match the pattern segments, where a **
+// segment matches any number of path segments.
+func matchGlob(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchGlob(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchGlob(pattern[1:], segments[1:])
+}
@@ -0,0 +1,74 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "templates")
+	for name, content := range map[string]string{
+		"templates/dashboards/a.json":     `{"a":1}`,
+		"templates/dashboards/sub/b.json": `{"b":2}`,
+		"templates/dashboards/c.yaml":     "c: 3\n",
+		"templates/hosts.txt":             "web\r\ndb\n",
+		"templates/deploy.yaml.tpl":       "kind: Deployment",
+		"secret.txt":                      "secret",
+	} {
+		path := filepath.Join(base, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		template  string
+		root      string
+		expected  string
+		wantError string
+	}{
+		{name: "Get", template: `{{ .Files.Get "dashboards/a.json" }}`, root: root, expected: `{"a":1}`},
+		{name: "Get missing", template: `[{{ .Files.Get "missing.txt" }}]`, root: root, expected: "[]"},
+		{name: "GetBytes", template: `{{ .Files.GetBytes "dashboards/a.json" | len }}`, root: root, expected: "7"},
+		{name: "Lines", template: `{{ range .Files.Lines "hosts.txt" }}<{{ . }}>{{ end }}`, root: root, expected: "<web><db>"},
+		{name: "Paths", template: `{{ range (.Files.Glob "**/*.json").Paths }}{{ . }} {{ end }}`, root: root, expected: "dashboards/a.json dashboards/sub/b.json "},
+		{name: "all Paths", template: `{{ .Files.Paths | len }}`, root: root, expected: "5"},
+		{name: "Glob AsConfig", template: `{{ (.Files.Glob "dashboards/*.json").AsConfig }}`, root: root, expected: "a.json: '{\"a\":1}'"},
+		{name: "Glob double star", template: `{{ (.Files.Glob "**/*.json").AsConfig }}`, root: root, expected: "a.json: '{\"a\":1}'\nb.json: '{\"b\":2}'"},
+		{name: "Glob of Glob", template: `{{ ((.Files.Glob "dashboards/**").Glob "**/*.yaml").AsConfig }}`, root: root, expected: "c.yaml: |\n  c: 3"},
+		{name: "AsSecrets", template: `{{ (.Files.Glob "dashboards/c.*").AsSecrets }}`, root: root, expected: "c.yaml: YzogMwo="},
+		{name: "Glob no match", template: `[{{ (.Files.Glob "*.png").AsConfig }}]`, root: root, expected: "[]"},
+		{name: "escape", template: `{{ .Files.Get "../secret.txt" }}`, root: root, wantError: "must be relative to the template directory"},
+		{name: "disabled", template: `{{ .Files.Get "hosts.txt" }}`, wantError: "file functions are disabled"},
+		{name: "disabled Glob", template: `{{ .Files.Glob "*" }}`, wantError: "file functions are disabled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := NewStrictTemplate("test", false).ParseTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("Failed to parse template: %v", err)
+			}
+			result, err := tmpl.ExecuteTemplate(map[string]any{"Files": NewFiles(tt.root)})
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package template
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// maxIncludeDepth bounds nested include calls, so a template including itself fails
+// instead of recursing until the stack overflows.
+const maxIncludeDepth = 1000
+
+// Helper is a helper template file, such as _helpers.tpl, whose define blocks are shared
+// with the other templates instead of being rendered itself.
+type Helper struct {
+	Name    string // Path of the helper file relative to the template directory
+	Content string
+}
+
+// IsHelper reports whether a template file is a helper: a .tpl file whose name starts
+// with an underscore.
+func IsHelper(path string) bool {
+	name := filepath.Base(path)
+	return strings.HasPrefix(name, "_") && strings.HasSuffix(strings.ToLower(name), ".tpl")
+}
+
+// ParseHelpers adds the define blocks of helpers to the template's namespace, making
+// them available to the template and to include.
+func (st *StrictTemplate) ParseHelpers(helpers []Helper) error {
+	for _, helper := range helpers {
+		if _, err := st.Template.New(helper.Name).Parse(helper.Content); err != nil {
+			return fmt.Errorf("failed to parse helper template %s: %w", helper.Name, err)
+		}
+	}
+	return nil
+}
+
+// includeFunc returns the include function of tmpl, which executes the named template
+// of its namespace with data and returns the output, so unlike the template action it
+// can be piped: {{ include "app.labels" . | nindent 4 }}.
+func includeFunc(tmpl *template.Template) func(string, any) (string, error) {
+	depth := 0
+	return func(name string, data any) (string, error) {
+		if depth >= maxIncludeDepth {
+			return "", fmt.Errorf("include %q nested more than %d levels deep", name, maxIncludeDepth)
+		}
+		depth++
+		defer func() { depth-- }()
+
+		buf := getBuffer()
+		defer putBuffer(buf)
+		if err := tmpl.ExecuteTemplate(buf, name, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+}
@@ -0,0 +1,103 @@
+package template
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInclude(t *testing.T) {
+	helpers := []Helper{{
+		Name: "_helpers.tpl",
+		Content: `{{- define "app.labels" -}}
+app: {{ .name }}
+tier: {{ .tier | default "web" }}
+{{- end }}
+{{- define "app.loop" }}{{ include "app.loop" . }}{{ end }}`,
+	}}
+
+	tests := []struct {
+		name       string
+		template   string
+		data       map[string]any
+		strictMode bool
+		expected   string
+		wantError  string
+	}{
+		{
+			name:     "piped",
+			template: "metadata:\n  labels:\n{{ include \"app.labels\" . | indent 4 }}",
+			data:     map[string]any{"name": "api"},
+			expected: "metadata:\n  labels:\n    app: api\n    tier: web",
+		},
+		{
+			name:     "defined in the template",
+			template: `{{ define "local" }}{{ .name | upper }}{{ end }}{{ include "local" . | quote }}`,
+			data:     map[string]any{"name": "api"},
+			expected: `"API"`,
+		},
+		{
+			name:      "unknown template",
+			template:  `{{ include "missing" . }}`,
+			wantError: `no template "missing"`,
+		},
+		{
+			name:      "recursion",
+			template:  `{{ include "app.loop" . }}`,
+			wantError: "nested more than 1000 levels deep",
+		},
+		{
+			name:       "strict mode",
+			template:   `{{ include "app.labels" . }}`,
+			data:       map[string]any{"tier": "db"},
+			strictMode: true,
+			wantError:  "undefined variable 'name'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := NewStrictTemplate("test", tt.strictMode)
+			if err := tmpl.ParseHelpers(helpers); err != nil {
+				t.Fatalf("Failed to parse helpers: %v", err)
+			}
+			parsed, err := tmpl.ParseTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("Failed to parse template: %v", err)
+			}
+
+			result, err := parsed.ExecuteTemplate(tt.data)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestIsHelper(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{path: "_helpers.tpl", expected: true},
+		{path: filepath.Join("charts", "_labels.TPL"), expected: true},
+		{path: "helpers.tpl"},
+		{path: "_notes.txt"},
+		{path: filepath.Join("_dir", "app.tpl")},
+	}
+
+	for _, tt := range tests {
+		if got := IsHelper(tt.path); got != tt.expected {
+			t.Errorf("IsHelper(%q) = %v, expected %v", tt.path, got, tt.expected)
+		}
+	}
+}
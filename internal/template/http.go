@@ -0,0 +1,174 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/menta2k/templater/internal/retry"
+)
+
+// HTTPTimeout bounds each httpGet request, including retries.
+var HTTPTimeout = 30 * time.Second
+
+// maxHTTPResponseSize caps the body httpGet reads, as it is meant for small documents
+// such as public keys and IP lists.
+const maxHTTPResponseSize = 1 << 20
+
+// HTTPAllowlist holds the hosts the httpGet template function may fetch from, and the
+// responses fetched so far, so a URL used by many templates is fetched once per run.
+type HTTPAllowlist struct {
+	hosts  map[string]bool
+	client *http.Client
+
+	mu        sync.Mutex
+	responses map[string]*httpResponse
+}
+
+// httpResponse is the response to a URL, which is complete once done is closed.
+type httpResponse struct {
+	done chan struct{}
+	body string
+	err  error
+}
+
+// NewHTTPAllowlist parses the --allow-http hosts, which may be comma-separated. A host
+// such as example.com allows any port; example.com:8443 only that port.
+func NewHTTPAllowlist(hosts []string) (*HTTPAllowlist, error) {
+	a := &HTTPAllowlist{hosts: map[string]bool{}, responses: map[string]*httpResponse{}}
+	for _, list := range hosts {
+		for _, host := range strings.Split(list, ",") {
+			host = strings.ToLower(strings.TrimSpace(host))
+			if host == "" {
+				continue
+			}
+			if strings.ContainsAny(host, "/?#@") {
+				return nil, fmt.Errorf("invalid allowed host %s (expected a host name such as example.com)", host)
+			}
+			a.hosts[host] = true
+		}
+	}
+
+	a.client = retry.NewClient(HTTPTimeout)
+	a.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return a.check(req.URL)
+	}
+	return a, nil
+}
+
+// Len returns the number of allowed hosts.
+func (a *HTTPAllowlist) Len() int {
+	return len(a.hosts)
+}
+
+// Func returns the httpGet template function. {{ httpGet "https://example.com/keys" }}
+// returns the body of an http or https URL on an allowed host; other hosts, including
+// redirects to them, and responses other than 2xx fail rendering. Requests still in
+// flight when ctx is canceled are stopped.
+func (a *HTTPAllowlist) Func(ctx context.Context) func(rawURL string) (string, error) {
+	return func(rawURL string) (string, error) {
+		for {
+			// Only the first caller fetches a URL; concurrent callers wait for its response,
+			// while fetches of other URLs proceed
+			a.mu.Lock()
+			response, fetching := a.responses[rawURL]
+			if !fetching {
+				response = &httpResponse{done: make(chan struct{})}
+				a.responses[rawURL] = response
+			}
+			a.mu.Unlock()
+
+			if fetching {
+				select {
+				case <-response.done:
+				case <-ctx.Done():
+					return "", fmt.Errorf("httpGet %s: %w", rawURL, ctx.Err())
+				}
+				// A fetch stopped by the context of another render is not our failure
+				if errors.Is(response.err, context.Canceled) && ctx.Err() == nil {
+					continue
+				}
+			} else {
+				response.body, response.err = a.get(ctx, rawURL)
+				if response.err != nil {
+					// Failed fetches are retried by later calls
+					a.mu.Lock()
+					delete(a.responses, rawURL)
+					a.mu.Unlock()
+				}
+				close(response.done)
+			}
+
+			if response.err != nil {
+				return "", fmt.Errorf("httpGet %s: %w", rawURL, response.err)
+			}
+			return response.body, nil
+		}
+	}
+}
+
+// get fetches rawURL after checking its host.
+func (a *HTTPAllowlist) get(ctx context.Context, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("unsupported scheme '%s' (expected http or https)", u.Scheme)
+	}
+	if err := a.check(u); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponseSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(body) > maxHTTPResponseSize {
+		return "", fmt.Errorf("response larger than %d bytes", maxHTTPResponseSize)
+	}
+	return string(body), nil
+}
+
+// check reports URLs whose host is not allowed.
+func (a *HTTPAllowlist) check(u *url.URL) error {
+	host := strings.ToLower(u.Hostname())
+	if a.hosts[host] || (u.Port() != "" && a.hosts[net.JoinHostPort(host, u.Port())]) {
+		return nil
+	}
+	return fmt.Errorf("host %s is not allowed (allowed hosts: %s)", u.Host, a.names())
+}
+
+// names returns the sorted, comma-separated allowed hosts.
+func (a *HTTPAllowlist) names() string {
+	names := make([]string, 0, len(a.hosts))
+	for host := range a.hosts {
+		names = append(names, host)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
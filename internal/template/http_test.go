@@ -0,0 +1,187 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPAllowlist(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/keys":
+			fmt.Fprint(w, "ssh-ed25519 AAAA deploy")
+		case "/redirect":
+			http.Redirect(w, r, strings.Replace("http://"+r.Host, "127.0.0.1", "localhost", 1)+"/keys", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	tests := []struct {
+		name      string
+		hosts     []string
+		url       string
+		expected  string
+		wantError string
+	}{
+		{name: "allowed host", hosts: []string{"example.com,127.0.0.1"}, url: server.URL + "/keys", expected: "ssh-ed25519 AAAA deploy"},
+		{name: "allowed host and port", hosts: []string{u.Host}, url: server.URL + "/keys", expected: "ssh-ed25519 AAAA deploy"},
+		{name: "other port", hosts: []string{"127.0.0.1:1"}, url: server.URL + "/keys", wantError: "host " + u.Host + " is not allowed (allowed hosts: 127.0.0.1:1)"},
+		{name: "other host", hosts: []string{"example.com"}, url: server.URL + "/keys", wantError: "is not allowed"},
+		{name: "redirect to other host", hosts: []string{"127.0.0.1"}, url: server.URL + "/redirect", wantError: "host localhost:" + u.Port() + " is not allowed"},
+		{name: "not found", hosts: []string{"127.0.0.1"}, url: server.URL + "/missing", wantError: "unexpected status 404 Not Found"},
+		{name: "scheme", hosts: []string{"127.0.0.1"}, url: "file:///etc/passwd", wantError: "unsupported scheme 'file'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowlist, err := NewHTTPAllowlist(tt.hosts)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			result, err := allowlist.Func(context.Background())(tt.url)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+
+	// Responses are fetched once per allowlist
+	allowlist, err := NewHTTPAllowlist([]string{"127.0.0.1"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	requests = 0
+	for range 3 {
+		if _, err := allowlist.Func(context.Background())(server.URL + "/keys"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Expected 1 request, got %d", requests)
+	}
+
+	if _, err := NewHTTPAllowlist([]string{"https://example.com"}); err == nil || !strings.Contains(err.Error(), "invalid allowed host") {
+		t.Errorf("Expected invalid host error, got %v", err)
+	}
+}
+
+func TestHTTPAllowlistConcurrentFetches(t *testing.T) {
+	var slowRequests atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			slowRequests.Add(1)
+			<-release
+		}
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	defer server.Close()
+
+	allowlist, err := NewHTTPAllowlist([]string{"127.0.0.1"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	httpGet := allowlist.Func(context.Background())
+
+	// Concurrent calls for the same URL share one request
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if body, err := httpGet(server.URL + "/slow"); err != nil || body != "/slow" {
+				t.Errorf("Expected /slow, got %q, %v", body, err)
+			}
+		}()
+	}
+
+	// Other URLs are fetched while the slow one is pending
+	fast := make(chan error, 1)
+	go func() {
+		_, err := httpGet(server.URL + "/fast")
+		fast <- err
+	}()
+	select {
+	case err := <-fast:
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Expected /fast to be fetched while /slow is pending")
+	}
+
+	close(release)
+	wg.Wait()
+	if n := slowRequests.Load(); n != 1 {
+		t.Errorf("Expected 1 request for /slow, got %d", n)
+	}
+}
+
+func TestHTTPAllowlistCanceledFetch(t *testing.T) {
+	started := make(chan struct{})
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			close(started)
+			<-r.Context().Done()
+			return
+		}
+		fmt.Fprint(w, "keys")
+	}))
+	defer server.Close()
+
+	allowlist, err := NewHTTPAllowlist([]string{"127.0.0.1"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	abandoned := make(chan error, 1)
+	go func() {
+		_, err := allowlist.Func(ctx)(server.URL)
+		abandoned <- err
+	}()
+	<-started
+
+	// A caller waiting on a fetch whose render is canceled fetches the URL itself
+	waiting := make(chan string, 1)
+	go func() {
+		body, err := allowlist.Func(context.Background())(server.URL)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		waiting <- body
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	if err := <-abandoned; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected canceled fetch, got %v", err)
+	}
+	if body := <-waiting; body != "keys" {
+		t.Errorf("Expected keys, got %q", body)
+	}
+}
@@ -0,0 +1,117 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// siPrefixes are the prefixes of humanizeNumber and humanizeBytes, in steps of 1000.
+var siPrefixes = []string{"", "k", "M", "G", "T", "P", "E"}
+
+// humanizeBytes formats a size in bytes with decimal units: 82854982 is "82.9 MB".
+func humanizeBytes(v any) (string, error) {
+	n, err := numberValue(v)
+	if err != nil {
+		return "", err
+	}
+	value, prefix := scaleNumber(n, 1000, siPrefixes)
+	return formatScaled(value) + " " + prefix + "B", nil
+}
+
+// humanizeIBytes formats a size in bytes with binary units: 82854982 is "79 MiB".
+func humanizeIBytes(v any) (string, error) {
+	n, err := numberValue(v)
+	if err != nil {
+		return "", err
+	}
+	value, prefix := scaleNumber(n, 1024, []string{"", "Ki", "Mi", "Gi", "Ti", "Pi", "Ei"})
+	return formatScaled(value) + " " + prefix + "B", nil
+}
+
+// humanizeNumber formats a number with an SI suffix: 1234567 is "1.2M".
+func humanizeNumber(v any) (string, error) {
+	n, err := numberValue(v)
+	if err != nil {
+		return "", err
+	}
+	value, prefix := scaleNumber(n, 1000, siPrefixes)
+	return formatScaled(value) + prefix, nil
+}
+
+// humanizeOrdinal formats an integer as an English ordinal: 1 is "1st", 12 is "12th".
+func humanizeOrdinal(v any) (string, error) {
+	n, err := numberValue(v)
+	if err != nil {
+		return "", err
+	}
+	if n != math.Trunc(n) {
+		return "", fmt.Errorf("humanizeOrdinal: %v is not an integer", v)
+	}
+
+	i := int64(n)
+	last := i % 100
+	if last < 0 {
+		last = -last
+	}
+	suffix := "th"
+	switch {
+	case last >= 11 && last <= 13:
+	case last%10 == 1:
+		suffix = "st"
+	case last%10 == 2:
+		suffix = "nd"
+	case last%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.FormatInt(i, 10) + suffix, nil
+}
+
+// scaleNumber divides n by base until it is below base, returning the result and the
+// prefix of the number of divisions. Values rounding up to base move to the next prefix.
+func scaleNumber(n, base float64, prefixes []string) (float64, string) {
+	i := 0
+	for i < len(prefixes)-1 && math.Abs(n) >= base {
+		n /= base
+		i++
+	}
+	if i < len(prefixes)-1 && math.Abs(math.Round(n*10)/10) >= base {
+		n /= base
+		i++
+	}
+	return n, prefixes[i]
+}
+
+// formatScaled formats a scaled number with at most one decimal.
+func formatScaled(n float64) string {
+	return strings.TrimSuffix(strconv.FormatFloat(n, 'f', 1, 64), ".0")
+}
+
+// numberValue converts a number from values, or a string holding one, to a float64.
+func numberValue(v any) (float64, error) {
+	switch n := v.(type) {
+	case json.Number:
+		return n.Float64()
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		if err != nil {
+			return 0, fmt.Errorf("cannot parse %q as a number", n)
+		}
+		return f, nil
+	}
+
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), nil
+	default:
+		return 0, fmt.Errorf("cannot use %v (%T) as a number", v, v)
+	}
+}
@@ -0,0 +1,56 @@
+package template
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestHumanize(t *testing.T) {
+	tests := []struct {
+		name      string
+		fn        func(any) (string, error)
+		input     any
+		expected  string
+		wantError bool
+	}{
+		{name: "bytes", fn: humanizeBytes, input: 82854982, expected: "82.9 MB"},
+		{name: "bytes small", fn: humanizeBytes, input: 512, expected: "512 B"},
+		{name: "bytes exact", fn: humanizeBytes, input: int64(2000000000), expected: "2 GB"},
+		{name: "bytes rounding up", fn: humanizeBytes, input: 999999, expected: "1 MB"},
+		{name: "ibytes", fn: humanizeIBytes, input: 82854982, expected: "79 MiB"},
+		{name: "ibytes kibibyte", fn: humanizeIBytes, input: uint(1536), expected: "1.5 KiB"},
+		{name: "number", fn: humanizeNumber, input: 1234567, expected: "1.2M"},
+		{name: "number small", fn: humanizeNumber, input: 999, expected: "999"},
+		{name: "number fraction", fn: humanizeNumber, input: 12.345, expected: "12.3"},
+		{name: "number negative", fn: humanizeNumber, input: -45000, expected: "-45k"},
+		{name: "number precise", fn: humanizeNumber, input: json.Number("3000000000"), expected: "3G"},
+		{name: "number string", fn: humanizeNumber, input: "2500", expected: "2.5k"},
+		{name: "number invalid", fn: humanizeNumber, input: "lots", wantError: true},
+		{name: "number invalid type", fn: humanizeNumber, input: []any{1}, wantError: true},
+		{name: "ordinal 1", fn: humanizeOrdinal, input: 1, expected: "1st"},
+		{name: "ordinal 2", fn: humanizeOrdinal, input: 22, expected: "22nd"},
+		{name: "ordinal 3", fn: humanizeOrdinal, input: 103, expected: "103rd"},
+		{name: "ordinal teens", fn: humanizeOrdinal, input: 112, expected: "112th"},
+		{name: "ordinal zero", fn: humanizeOrdinal, input: 0, expected: "0th"},
+		{name: "ordinal negative", fn: humanizeOrdinal, input: -21, expected: "-21st"},
+		{name: "ordinal fraction", fn: humanizeOrdinal, input: 1.5, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.fn(tt.input)
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("Expected error for %v", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
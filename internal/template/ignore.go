@@ -0,0 +1,116 @@
+package template
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFile lists paths below a template directory that are left out of discovery, in
+// gitignore syntax.
+const IgnoreFile = ".templaterignore"
+
+// IgnoreRules are the patterns of an ignore file. A nil *IgnoreRules ignores nothing.
+type IgnoreRules struct {
+	patterns []ignorePattern
+}
+
+// ignorePattern is one line of an ignore file.
+type ignorePattern struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+}
+
+// LoadIgnoreRules reads the .templaterignore file in root, returning nil rules when there
+// is none.
+func LoadIgnoreRules(root string) (*IgnoreRules, error) {
+	file, err := os.Open(filepath.Join(root, IgnoreFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", IgnoreFile, err)
+	}
+	defer file.Close()
+
+	rules := &IgnoreRules{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		pattern, ok := parseIgnorePattern(scanner.Text())
+		if !ok {
+			continue
+		}
+		for _, segment := range pattern.segments {
+			if _, err := path.Match(segment, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern on line %d of %s: %w", line, IgnoreFile, err)
+			}
+		}
+		rules.patterns = append(rules.patterns, pattern)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", IgnoreFile, err)
+	}
+	return rules, nil
+}
+
+// parseIgnorePattern parses a line of an ignore file, reporting false for blank lines
+// and comments.
+func parseIgnorePattern(line string) (ignorePattern, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	// Trailing spaces are ignored unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignorePattern{}, false
+	}
+
+	var pattern ignorePattern
+	if strings.HasPrefix(line, "!") {
+		pattern.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		pattern.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignorePattern{}, false
+	}
+
+	// Patterns without a slash match at any depth, others relative to the root
+	if !strings.Contains(line, "/") {
+		line = "**/" + line
+	}
+	pattern.segments = strings.Split(strings.TrimPrefix(line, "/"), "/")
+	return pattern, true
+}
+
+// Ignored reports whether the file or directory at relativePath, relative to the root,
+// is ignored. As in git, the last matching pattern decides, and paths below an ignored
+// directory are expected to be skipped with it.
+func (r *IgnoreRules) Ignored(relativePath string, isDir bool) bool {
+	if r == nil {
+		return false
+	}
+	segments := strings.Split(filepath.ToSlash(relativePath), "/")
+
+	ignored := false
+	for _, pattern := range r.patterns {
+		if pattern.dirOnly && !isDir {
+			continue
+		}
+		if matchGlob(pattern.segments, segments) {
+			ignored = !pattern.negate
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,78 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreRules(t *testing.T) {
+	root := t.TempDir()
+	content := strings.Join([]string{
+		"# editor junk",
+		"*.swp",
+		"*~",
+		"",
+		"vendor/",
+		"/wip-*.tpl",
+		"docs/**/draft.tpl",
+		"legacy",
+		"!legacy.tpl",
+		`\#literal.tpl`,
+	}, "\n")
+	if err := os.WriteFile(filepath.Join(root, IgnoreFile), []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write ignore file: %v", err)
+	}
+
+	rules, err := LoadIgnoreRules(root)
+	if err != nil {
+		t.Fatalf("LoadIgnoreRules failed: %v", err)
+	}
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{path: "app.tpl.swp", ignored: true},
+		{path: "k8s/deploy.tpl~", ignored: true},
+		{path: "k8s/deploy.tpl"},
+		{path: "vendor", isDir: true, ignored: true},
+		{path: "k8s/vendor", isDir: true, ignored: true},
+		{path: "vendor", isDir: false},
+		{path: "wip-ingress.tpl", ignored: true},
+		{path: "k8s/wip-ingress.tpl"},
+		{path: "docs/draft.tpl", ignored: true},
+		{path: "docs/a/b/draft.tpl", ignored: true},
+		{path: "docs/final.tpl"},
+		{path: "k8s/legacy", isDir: true, ignored: true},
+		{path: "legacy.tpl"},
+		{path: "#literal.tpl", ignored: true},
+	}
+	for _, tt := range tests {
+		if got := rules.Ignored(tt.path, tt.isDir); got != tt.ignored {
+			t.Errorf("Ignored(%q, %v) = %v, expected %v", tt.path, tt.isDir, got, tt.ignored)
+		}
+	}
+}
+
+func TestIgnoreRulesMissing(t *testing.T) {
+	rules, err := LoadIgnoreRules(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadIgnoreRules failed: %v", err)
+	}
+	if rules != nil || rules.Ignored("app.tpl", false) {
+		t.Errorf("Expected no rules without an ignore file, got %+v", rules)
+	}
+}
+
+func TestIgnoreRulesInvalid(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, IgnoreFile), []byte("ok.tpl\n[broken\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write ignore file: %v", err)
+	}
+	if _, err := LoadIgnoreRules(root); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected error for line 2, got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/itchyny/gojq"
+)
+
+// jq evaluates a jq query against data: {{ jq ".items[].name" .data }}. A query yielding
+// a single result returns it as is, one yielding none returns nil, and one yielding
+// several returns them as a list; wrap the query in [ ] to always get a list. As with
+// env in templates, the query cannot read environment variables.
+func jq(query string, data any) (any, error) {
+	parsed, err := gojq.Parse(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq query %q: %w", query, err)
+	}
+	code, err := gojq.Compile(parsed, gojq.WithEnvironLoader(func() []string { return nil }))
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq query %q: %w", query, err)
+	}
+
+	input, err := jqInput(data)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []any{}
+	iter := code.Run(input)
+	for {
+		result, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := result.(error); ok {
+			return nil, fmt.Errorf("jq query %q failed: %w", query, err)
+		}
+		results = append(results, result)
+	}
+
+	switch len(results) {
+	case 0:
+		return nil, nil
+	case 1:
+		return results[0], nil
+	default:
+		return results, nil
+	}
+}
+
+// jqInput converts data to the plain JSON types gojq works on. The conversion copies
+// data, which gojq would otherwise modify while normalizing numbers.
+func jqInput(data any) (any, error) {
+	encoded, err := json.Marshal(convertMapKeys(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert jq input: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+
+	var input any
+	if err := decoder.Decode(&input); err != nil {
+		return nil, fmt.Errorf("failed to convert jq input: %w", err)
+	}
+	return input, nil
+}
@@ -0,0 +1,64 @@
+package template
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJq(t *testing.T) {
+	data := map[string]any{
+		"items": []any{
+			map[interface{}]interface{}{"name": "api", "port": 8080, "tags": []any{"web"}},
+			map[string]any{"name": "db", "port": int64(5432)},
+		},
+		"big": uint64(18446744073709551615),
+	}
+
+	tests := []struct {
+		name      string
+		template  string
+		expected  string
+		wantError string
+	}{
+		{name: "list", template: `{{ jq ".items[].name" . | join "," }}`, expected: "api,db"},
+		{name: "single result", template: `{{ jq ".items[0].name" . | upper }}`, expected: "API"},
+		{name: "always a list", template: `{{ jq "[.items[] | select(.port > 6000) | .name]" . | toJson }}`, expected: `["api"]`},
+		{name: "no result", template: `{{ jq ".items[] | select(.port > 9000)" . | toJson }}`, expected: "null"},
+		{name: "object", template: `{{ jq ".items[0] | {name, tags}" . | toJson }}`, expected: `{"name":"api","tags":["web"]}`},
+		{name: "numbers", template: `{{ jq ".items | map(.port) | add" . }} {{ jq ".big" . }}`, expected: "13512 18446744073709551615"},
+		{name: "environment hidden", template: `{{ jq "env | length" . }} {{ jq "$ENV | length" . }}`, expected: "0 0"},
+		{name: "invalid query", template: `{{ jq ".items[" . }}`, wantError: "invalid jq query"},
+		{name: "query error", template: `{{ jq ".items | keys | .[] | ascii_downcase" . }}`, wantError: "jq query"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := NewStrictTemplate("test", false).ParseTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("Failed to parse template: %v", err)
+			}
+
+			result, err := tmpl.ExecuteTemplate(data)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+
+	// The query must not modify the values it reads
+	if _, err := jq(".items[1].port", data); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if port, ok := data["items"].([]any)[1].(map[string]any)["port"].(int64); !ok || port != 5432 {
+		t.Errorf("Expected the input to stay unchanged, got %#v", data["items"])
+	}
+}
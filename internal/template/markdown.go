@@ -0,0 +1,24 @@
+package template
+
+import (
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// markdown converts GitHub Flavored Markdown. Raw HTML in the input is omitted and
+// links with dangerous schemes such as javascript: are dropped, as the markdown usually
+// comes from values.
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// markdownToHTML converts a markdown string to an HTML fragment.
+func markdownToHTML(s string) string {
+	b := getBuffer()
+	defer putBuffer(b)
+	if err := markdown.Convert([]byte(s), b); err != nil {
+		// Swallow errors inside of a template.
+		return ""
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,28 @@
+package template
+
+import "testing"
+
+func TestMarkdownToHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "inline", input: "Hello **world** and `code`", expected: "<p>Hello <strong>world</strong> and <code>code</code></p>"},
+		{name: "heading and list", input: "# Notes\n\n- one\n- two", expected: "<h1>Notes</h1>\n<ul>\n<li>one</li>\n<li>two</li>\n</ul>"},
+		{name: "link", input: "[docs](https://example.com/docs)", expected: `<p><a href="https://example.com/docs">docs</a></p>`},
+		{name: "table", input: "| a | b |\n|---|---|\n| 1 | 2 |", expected: "<table>\n<thead>\n<tr>\n<th>a</th>\n<th>b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>1</td>\n<td>2</td>\n</tr>\n</tbody>\n</table>"},
+		{name: "strikethrough", input: "~~old~~ new", expected: "<p><del>old</del> new</p>"},
+		{name: "raw HTML omitted", input: "<script>alert(1)</script>\n\ntext", expected: "<!-- raw HTML omitted -->\n<p>text</p>"},
+		{name: "dangerous link", input: "[x](javascript:alert(1))", expected: `<p><a href="">x</a></p>`},
+		{name: "empty", input: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := markdownToHTML(tt.input); result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package template
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+)
+
+// Network functions, following Terraform's cidrsubnet and cidrhost for IPv4 and IPv6.
+
+// cidrSubnet returns subnet netnum of prefix, extended by newbits bits:
+// {{ cidrSubnet "10.0.0.0/16" 8 2 }} is 10.0.2.0/24.
+func cidrSubnet(prefix string, newbits, netnum int) (string, error) {
+	p, err := parsePrefix(prefix)
+	if err != nil {
+		return "", err
+	}
+	bits := p.Bits() + newbits
+	if newbits < 0 || bits > p.Addr().BitLen() {
+		return "", fmt.Errorf("cidrSubnet: cannot extend %s by %d bits", p, newbits)
+	}
+	if netnum < 0 || big.NewInt(int64(netnum)).BitLen() > newbits {
+		return "", fmt.Errorf("cidrSubnet: %d does not fit in %d bits for %s", netnum, newbits, p)
+	}
+
+	offset := new(big.Int).Lsh(big.NewInt(int64(netnum)), uint(p.Addr().BitLen()-bits))
+	addr, err := offsetAddr(p.Addr(), offset)
+	if err != nil {
+		return "", err
+	}
+	return netip.PrefixFrom(addr, bits).String(), nil
+}
+
+// cidrHost returns address hostnum of prefix, counting from the end when negative:
+// {{ cidrHost "10.0.1.0/24" 5 }} is 10.0.1.5 and {{ cidrHost "10.0.1.0/24" -2 }} 10.0.1.254.
+func cidrHost(prefix string, hostnum int) (string, error) {
+	p, err := parsePrefix(prefix)
+	if err != nil {
+		return "", err
+	}
+	size := new(big.Int).Lsh(big.NewInt(1), uint(p.Addr().BitLen()-p.Bits()))
+	offset := big.NewInt(int64(hostnum))
+	if hostnum < 0 {
+		offset.Add(offset, size)
+	}
+	if offset.Sign() < 0 || offset.Cmp(size) >= 0 {
+		return "", fmt.Errorf("cidrHost: host %d is out of range for %s", hostnum, p)
+	}
+
+	addr, err := offsetAddr(p.Addr(), offset)
+	if err != nil {
+		return "", err
+	}
+	return addr.String(), nil
+}
+
+// ipAdd returns ip moved by n addresses, which may be negative: {{ ipAdd "10.0.0.1" 5 }}.
+func ipAdd(ip string, n int) (string, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return "", fmt.Errorf("invalid IP address %q: %w", ip, err)
+	}
+	result, err := offsetAddr(addr, big.NewInt(int64(n)))
+	if err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
+
+// inCIDR reports whether ip is in prefix: {{ if inCIDR "10.0.0.0/8" .peer.ip }}.
+func inCIDR(prefix, ip string) (bool, error) {
+	p, err := parsePrefix(prefix)
+	if err != nil {
+		return false, err
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false, fmt.Errorf("invalid IP address %q: %w", ip, err)
+	}
+	return p.Contains(addr.Unmap()), nil
+}
+
+// parsePrefix parses a CIDR prefix, clearing the host bits of its address.
+func parsePrefix(prefix string) (netip.Prefix, error) {
+	p, err := netip.ParsePrefix(prefix)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid CIDR prefix %q: %w", prefix, err)
+	}
+	return p.Masked(), nil
+}
+
+// offsetAddr returns addr moved by offset addresses, failing when the result leaves the
+// address space of addr.
+func offsetAddr(addr netip.Addr, offset *big.Int) (netip.Addr, error) {
+	raw := addr.AsSlice()
+	value := new(big.Int).SetBytes(raw)
+	value.Add(value, offset)
+	if value.Sign() < 0 || value.BitLen() > addr.BitLen() {
+		return netip.Addr{}, fmt.Errorf("address %s moved by %s is out of range", addr, offset)
+	}
+
+	value.FillBytes(raw)
+	result, _ := netip.AddrFromSlice(raw)
+	return result, nil
+}
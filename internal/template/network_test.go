@@ -0,0 +1,55 @@
+package template
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNetworkFunctions(t *testing.T) {
+	tests := []struct {
+		name      string
+		template  string
+		expected  string
+		wantError string
+	}{
+		{name: "cidrSubnet", template: `{{ cidrSubnet "10.0.0.0/16" 8 2 }}`, expected: "10.0.2.0/24"},
+		{name: "cidrSubnet host bits", template: `{{ cidrSubnet "10.0.7.9/16" 4 15 }}`, expected: "10.0.240.0/20"},
+		{name: "cidrSubnet IPv6", template: `{{ cidrSubnet "fd00::/48" 16 258 }}`, expected: "fd00:0:0:102::/64"},
+		{name: "cidrSubnet range", template: `{{ range $i := until 3 }}{{ cidrSubnet "172.16.0.0/12" 4 $i }} {{ end }}`, expected: "172.16.0.0/16 172.17.0.0/16 172.18.0.0/16 "},
+		{name: "cidrSubnet netnum too large", template: `{{ cidrSubnet "10.0.0.0/16" 8 256 }}`, wantError: "does not fit in 8 bits"},
+		{name: "cidrSubnet too long", template: `{{ cidrSubnet "10.0.0.0/30" 4 0 }}`, wantError: "cannot extend"},
+		{name: "cidrHost", template: `{{ cidrHost "10.0.1.0/24" 5 }}`, expected: "10.0.1.5"},
+		{name: "cidrHost from the end", template: `{{ cidrHost "10.0.1.0/24" -2 }}`, expected: "10.0.1.254"},
+		{name: "cidrHost IPv6", template: `{{ cidrHost "fd00:1::/64" 17 }}`, expected: "fd00:1::11"},
+		{name: "cidrHost out of range", template: `{{ cidrHost "10.0.1.0/24" 256 }}`, wantError: "out of range"},
+		{name: "ipAdd", template: `{{ ipAdd "10.0.0.250" 10 }}`, expected: "10.0.1.4"},
+		{name: "ipAdd negative", template: `{{ ipAdd "10.0.1.0" -1 }}`, expected: "10.0.0.255"},
+		{name: "ipAdd overflow", template: `{{ ipAdd "255.255.255.255" 1 }}`, wantError: "out of range"},
+		{name: "inCIDR", template: `{{ inCIDR "10.0.0.0/8" "10.1.2.3" }} {{ inCIDR "10.0.0.0/8" "192.168.0.1" }} {{ inCIDR "fd00::/8" "fd12::1" }}`, expected: "true false true"},
+		{name: "invalid prefix", template: `{{ cidrHost "10.0.0.0" 1 }}`, wantError: "invalid CIDR prefix"},
+		{name: "invalid address", template: `{{ inCIDR "10.0.0.0/8" "10.0.0" }}`, wantError: "invalid IP address"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := NewStrictTemplate("test", false).ParseTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("Failed to parse template: %v", err)
+			}
+
+			result, err := tmpl.ExecuteTemplate(nil)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package template
+
+import "regexp"
+
+// nondeterministicFunctionPattern matches calls of functions returning something new on
+// every render: the current time, random values, generated keys and certificates, and
+// salted hashes. The seeded random functions and uuidv5 are stable and not matched.
+var nondeterministicFunctionPattern = regexp.MustCompile(`\b(now|ago|randAlphaNum|randAlpha|randNumeric|randAscii|randInt|randBytes|shuffle|uuidv4|genCA|genCAWithKey|genPrivateKey|genSelfSignedCert|genSelfSignedCertWithKey|genSignedCert|genSignedCertWithKey|genSSHKeyPair|bcrypt|htpasswd|encryptAESGCM)\b`)
+
+// releaseTimePattern matches uses of .Release.Time, which is the time of the run.
+var releaseTimePattern = regexp.MustCompile(`\.Release\.Time\b`)
+
+// UsesNondeterministicFunctions reports whether template source may call a function
+// whose output changes on every render, so it must not be restored from a cache.
+func UsesNondeterministicFunctions(content string) bool {
+	return nondeterministicFunctionPattern.MatchString(content)
+}
+
+// UsesReleaseTime reports whether template source may use .Release.Time.
+func UsesReleaseTime(content string) bool {
+	return releaseTimePattern.MatchString(content)
+}
@@ -0,0 +1,201 @@
+package template
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathSegment is a map key or a list index of a value path.
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parsePath splits a value path such as "a.b[2].c" into its segments. A leading dot is
+// optional, negative indexes count from the end of a list, and keys containing dots or
+// brackets can be quoted: servers["api.example.com"].port. An empty path or "." is the
+// value itself.
+func parsePath(path string) ([]pathSegment, error) {
+	var segments []pathSegment
+	rest := strings.TrimPrefix(path, ".")
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, `["`):
+			end := strings.Index(rest, `"]`)
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: unterminated quoted key", path)
+			}
+			segments = append(segments, pathSegment{key: rest[2:end]})
+			rest = rest[end+2:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: unterminated index", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %q: index %q is not a number", path, rest[1:end])
+			}
+			segments = append(segments, pathSegment{index: index, isIndex: true})
+			rest = rest[end+1:]
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid path %q: empty key", path)
+			}
+			segments = append(segments, pathSegment{key: rest[:end]})
+			rest = rest[end:]
+		}
+
+		if strings.HasPrefix(rest, ".") {
+			rest = rest[1:]
+			if rest == "" || strings.HasPrefix(rest, "[") {
+				return nil, fmt.Errorf("invalid path %q: empty key", path)
+			}
+		}
+	}
+	return segments, nil
+}
+
+// formatPath returns the path of segments, for error messages.
+func formatPath(segments []pathSegment) string {
+	var b strings.Builder
+	for _, segment := range segments {
+		switch {
+		case segment.isIndex:
+			fmt.Fprintf(&b, "[%d]", segment.index)
+		case strings.ContainsAny(segment.key, ".[]"):
+			fmt.Fprintf(&b, `["%s"]`, segment.key)
+		default:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(segment.key)
+		}
+	}
+	return b.String()
+}
+
+// parentPath describes the value holding segments[i], for error messages.
+func parentPath(segments []pathSegment, i int) string {
+	if i == 0 {
+		return "the value"
+	}
+	return formatPath(segments[:i])
+}
+
+// listIndex resolves a possibly negative index into a list of length n.
+func listIndex(index, n int) int {
+	if index < 0 {
+		return n + index
+	}
+	return index
+}
+
+// getPath returns the value at path in data, or nil when it does not exist, like dig
+// but with a path built at render time: {{ getPath (printf "envs.%s.replicas" .env) . }}.
+func getPath(path string, data any) (any, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := data
+	for _, segment := range segments {
+		if segment.isIndex {
+			list, ok := current.([]any)
+			if !ok {
+				return nil, nil
+			}
+			index := listIndex(segment.index, len(list))
+			if index < 0 || index >= len(list) {
+				return nil, nil
+			}
+			current = list[index]
+			continue
+		}
+
+		switch m := current.(type) {
+		case map[string]any:
+			current = m[segment.key]
+		case map[interface{}]interface{}:
+			current = m[segment.key]
+		default:
+			return nil, nil
+		}
+	}
+	return current, nil
+}
+
+// setPath returns a copy of data with value set at path, creating missing maps on the
+// way. Only the maps and lists along the path are copied, so the values shared between
+// templates are never modified: {{ $config := setPath "server.port" 8080 .config }}. An
+// index equal to the length of a list appends to it.
+func setPath(path string, value any, data any) (any, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return setPathValue(data, segments, 0, value)
+}
+
+// setPathValue returns a copy of current with value set at segments[i:].
+func setPathValue(current any, segments []pathSegment, i int, value any) (any, error) {
+	if i == len(segments) {
+		return value, nil
+	}
+	segment := segments[i]
+
+	if segment.isIndex {
+		list, ok := current.([]any)
+		if !ok && current != nil {
+			return nil, fmt.Errorf("cannot set %s: %s is not a list", formatPath(segments), parentPath(segments, i))
+		}
+		index := listIndex(segment.index, len(list))
+		if index < 0 || index > len(list) {
+			return nil, fmt.Errorf("cannot set %s: index %d out of range for a list of %d items", formatPath(segments), segment.index, len(list))
+		}
+
+		copied := make([]any, len(list), len(list)+1)
+		copy(copied, list)
+		var child any
+		if index == len(list) {
+			copied = append(copied, nil)
+		} else {
+			child = list[index]
+		}
+		child, err := setPathValue(child, segments, i+1, value)
+		if err != nil {
+			return nil, err
+		}
+		copied[index] = child
+		return copied, nil
+	}
+
+	copied := make(map[string]any)
+	switch m := current.(type) {
+	case nil:
+	case map[string]any:
+		for key, v := range m {
+			copied[key] = v
+		}
+	case map[interface{}]interface{}:
+		for key, v := range m {
+			copied[fmt.Sprint(key)] = v
+		}
+	default:
+		return nil, fmt.Errorf("cannot set %s: %s is not a map", formatPath(segments), parentPath(segments, i))
+	}
+
+	child, err := setPathValue(copied[segment.key], segments, i+1, value)
+	if err != nil {
+		return nil, err
+	}
+	copied[segment.key] = child
+	return copied, nil
+}
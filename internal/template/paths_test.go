@@ -0,0 +1,132 @@
+package template
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetPath(t *testing.T) {
+	data := map[string]any{
+		"a": map[interface{}]interface{}{
+			"b": []any{"x", "y", map[string]any{"c": "deep"}},
+		},
+		"hosts": map[string]any{"api.example.com": map[string]any{"port": 443}},
+		"env":   "prod",
+	}
+
+	tests := []struct {
+		path      string
+		expected  any
+		wantError bool
+	}{
+		{path: "a.b[2].c", expected: "deep"},
+		{path: ".a.b[0]", expected: "x"},
+		{path: "a.b[-1].c", expected: "deep"},
+		{path: `hosts["api.example.com"].port`, expected: 443},
+		{path: "env", expected: "prod"},
+		{path: "a.missing.c", expected: nil},
+		{path: "a.b[5]", expected: nil},
+		{path: "env.name", expected: nil},
+		{path: "a.b[x]", wantError: true},
+		{path: "a..b", wantError: true},
+		{path: "a.b[1", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result, err := getPath(tt.path, data)
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("Expected error for path %q", tt.path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestSetPath(t *testing.T) {
+	tests := []struct {
+		name      string
+		template  string
+		expected  string
+		wantError string
+	}{
+		{
+			name:     "nested key",
+			template: `{{ setPath "app.port" 9090 .values | toJson }}`,
+			expected: `{"app":{"name":"web","port":9090},"list":["a","b"]}`,
+		},
+		{
+			name:     "new maps",
+			template: `{{ setPath "db.primary.host" "pg" .values | toJson }}`,
+			expected: `{"app":{"name":"web","port":8080},"db":{"primary":{"host":"pg"}},"list":["a","b"]}`,
+		},
+		{
+			name:     "list item",
+			template: `{{ setPath "list[-1]" "z" .values | toJson }}`,
+			expected: `{"app":{"name":"web","port":8080},"list":["a","z"]}`,
+		},
+		{
+			name:     "append",
+			template: `{{ setPath "list[2]" "c" .values | toJson }}`,
+			expected: `{"app":{"name":"web","port":8080},"list":["a","b","c"]}`,
+		},
+		{
+			name:     "values unchanged",
+			template: `{{ $_ := setPath "app.port" 1 .values }}{{ $_ := setPath "list[0]" "q" .values }}{{ .values | toJson }}`,
+			expected: `{"app":{"name":"web","port":8080},"list":["a","b"]}`,
+		},
+		{
+			name:     "path built at render time",
+			template: `{{ getPath (printf "app.%s" .field) (setPath (printf "app.%s" .field) "api" .values) }}`,
+			expected: "api",
+		},
+		{
+			name:      "through a scalar",
+			template:  `{{ setPath "app.name.first" "x" .values }}`,
+			wantError: "cannot set app.name.first: app.name is not a map",
+		},
+		{
+			name:      "index out of range",
+			template:  `{{ setPath "list[5]" "x" .values }}`,
+			wantError: "index 5 out of range",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := map[string]any{
+				"values": map[string]any{
+					"app":  map[interface{}]interface{}{"name": "web", "port": 8080},
+					"list": []any{"a", "b"},
+				},
+				"field": "name",
+			}
+			tmpl, err := NewStrictTemplate("test", false).ParseTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("Failed to parse template: %v", err)
+			}
+
+			result, err := tmpl.ExecuteTemplate(data)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
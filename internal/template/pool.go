@@ -0,0 +1,29 @@
+package template
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBuffer is the largest buffer returned to the pool. Larger buffers are left
+// to the garbage collector so one huge render does not pin its memory for the process.
+const maxPooledBuffer = 1 << 20
+
+// bufferPool recycles buffers used for template execution and format conversions.
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer resets buf and returns it to the pool.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
@@ -0,0 +1,187 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+	"text/template/parse"
+)
+
+// Reference is a value path used by a template.
+type Reference struct {
+	Path     []string
+	Location string // name:line:col of the reference
+}
+
+// FindReferences statically extracts the value paths a template references, without
+// executing it. Fields are resolved through `with` blocks; fields whose dot cannot be
+// determined statically (inside `range` bodies and `define` blocks) are skipped, while
+// the pipelines those blocks iterate over are still reported. References in every
+// branch are returned, whether or not the branch would be taken.
+func FindReferences(name, content string) ([]Reference, error) {
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck
+
+	treeSet := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(content, "", "", treeSet); err != nil {
+		return nil, err
+	}
+
+	main, ok := treeSet[name]
+	if !ok || main.Root == nil {
+		return nil, nil
+	}
+
+	f := &referenceFinder{tree: main}
+	f.walk(main.Root, []string{})
+	return f.refs, nil
+}
+
+// ReferencedTopLevelKeys returns the top-level value keys a template may read. all is
+// true when the template uses the root values as a whole (for example `toYaml .`,
+// `index . "key"` or `$`), in which case every key may be needed.
+func ReferencedTopLevelKeys(name, content string) (keys []string, all bool, err error) {
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck
+
+	treeSet := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(content, "", "", treeSet); err != nil {
+		return nil, false, err
+	}
+
+	main, ok := treeSet[name]
+	if !ok || main.Root == nil {
+		return nil, false, nil
+	}
+
+	f := &referenceFinder{tree: main}
+	f.walk(main.Root, []string{})
+
+	seen := make(map[string]bool)
+	for _, ref := range f.refs {
+		if len(ref.Path) > 0 && !seen[ref.Path[0]] {
+			seen[ref.Path[0]] = true
+			keys = append(keys, ref.Path[0])
+		}
+	}
+	return keys, f.wholeRoot, nil
+}
+
+// referenceFinder collects value references from a template tree.
+type referenceFinder struct {
+	tree      *parse.Tree
+	refs      []Reference
+	wholeRoot bool
+}
+
+// walk visits a node. dot is the absolute value path of ".", or nil when unknown.
+func (f *referenceFinder) walk(node parse.Node, dot []string) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			f.walk(child, dot)
+		}
+	case *parse.ActionNode:
+		f.walk(n.Pipe, dot)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			f.walk(cmd, dot)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			f.walk(arg, dot)
+		}
+	case *parse.ChainNode:
+		f.walk(n.Node, dot)
+	case *parse.IfNode:
+		f.walk(n.Pipe, dot)
+		f.walk(n.List, dot)
+		f.walk(n.ElseList, dot)
+	case *parse.RangeNode:
+		f.walk(n.Pipe, dot)
+		f.walk(n.List, nil)
+		f.walk(n.ElseList, dot)
+	case *parse.WithNode:
+		f.walk(n.Pipe, dot)
+		f.walk(n.List, withDot(n.Pipe, dot))
+		f.walk(n.ElseList, dot)
+	case *parse.TemplateNode:
+		f.walk(n.Pipe, dot)
+	case *parse.FieldNode:
+		if dot != nil {
+			f.add(n, append(append([]string{}, dot...), n.Ident...))
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			f.add(n, append([]string{}, n.Ident[1:]...))
+		} else if len(n.Ident) == 1 && n.Ident[0] == "$" {
+			f.wholeRoot = true
+		}
+	case *parse.DotNode:
+		if dot != nil && len(dot) == 0 {
+			f.wholeRoot = true
+		}
+	}
+}
+
+// add records a reference to path at node.
+func (f *referenceFinder) add(node parse.Node, path []string) {
+	location, _ := f.tree.ErrorContext(node)
+	f.refs = append(f.refs, Reference{Path: path, Location: location})
+}
+
+// withDot returns the absolute path of a `with` pipeline made of a single field, or nil.
+func withDot(pipe *parse.PipeNode, dot []string) []string {
+	if pipe == nil || len(pipe.Decl) > 0 || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return nil
+	}
+
+	switch arg := pipe.Cmds[0].Args[0].(type) {
+	case *parse.FieldNode:
+		if dot != nil {
+			return append(append([]string{}, dot...), arg.Ident...)
+		}
+	case *parse.VariableNode:
+		if arg.Ident[0] == "$" {
+			return append([]string{}, arg.Ident[1:]...)
+		}
+	case *parse.DotNode:
+		return dot
+	}
+	return nil
+}
+
+// LookupPath reports whether values contain a value at path. Nested maps may use
+// string or interface keys, as produced by the YAML decoders.
+func LookupPath(values map[string]any, path []string) bool {
+	var current any = values
+	for _, segment := range path {
+		switch m := current.(type) {
+		case map[string]any:
+			value, ok := m[segment]
+			if !ok {
+				return false
+			}
+			current = value
+		case map[interface{}]interface{}:
+			value, ok := m[segment]
+			if !ok {
+				return false
+			}
+			current = value
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the reference as it appears in a template.
+func (r Reference) String() string {
+	return fmt.Sprintf("%s: .%s", r.Location, strings.Join(r.Path, "."))
+}
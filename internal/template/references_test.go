@@ -0,0 +1,142 @@
+package template
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindReferences(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		expected  []string
+		wantError bool
+	}{
+		{
+			name:     "fields and pipelines",
+			content:  "{{ .app.name | upper }} {{ default 1 .replicas }}",
+			expected: []string{"app.name", "replicas"},
+		},
+		{
+			name:     "with scope",
+			content:  "{{ with .database }}{{ .host }}:{{ .port }}{{ end }}",
+			expected: []string{"database", "database.host", "database.port"},
+		},
+		{
+			name:     "range body skipped",
+			content:  "{{ range .hosts }}{{ .name }}{{ $.domain }}{{ end }}",
+			expected: []string{"hosts", "domain"},
+		},
+		{
+			name:     "branches",
+			content:  "{{ if .enabled }}{{ .on }}{{ else }}{{ .off }}{{ end }}",
+			expected: []string{"enabled", "on", "off"},
+		},
+		{
+			name:     "define blocks skipped",
+			content:  "{{ define \"x\" }}{{ .inner }}{{ end }}{{ template \"x\" .outer }}",
+			expected: []string{"outer"},
+		},
+		{
+			name:      "invalid template",
+			content:   "{{ .name ",
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs, err := FindReferences("test", tt.content)
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var paths []string
+			for _, ref := range refs {
+				paths = append(paths, strings.Join(ref.Path, "."))
+			}
+			if strings.Join(paths, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected %v, got %v", tt.expected, paths)
+			}
+		})
+	}
+}
+
+func TestReferenceLocation(t *testing.T) {
+	refs, err := FindReferences("config.tpl", "line one\nname: {{ .app.name }}")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(refs) != 1 {
+		t.Fatalf("Expected 1 reference, got %d", len(refs))
+	}
+	if !strings.HasPrefix(refs[0].String(), "config.tpl:2:") || !strings.HasSuffix(refs[0].String(), ": .app.name") {
+		t.Errorf("Unexpected reference %s", refs[0])
+	}
+}
+
+func TestLookupPath(t *testing.T) {
+	values := map[string]any{
+		"app":   map[interface{}]interface{}{"name": "web", "tls": map[string]any{"enabled": false}},
+		"port":  8080,
+		"empty": nil,
+	}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"app.name", true},
+		{"app.tls.enabled", true},
+		{"port", true},
+		{"empty", true},
+		{"app.missing", false},
+		{"port.number", false},
+		{"missing", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if result := LookupPath(values, strings.Split(tt.path, ".")); result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestReferencedTopLevelKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+		all      bool
+	}{
+		{name: "fields", content: "{{ .app.name }} {{ .app.port }} {{ $.region }}", expected: "app,region"},
+		{name: "with and range", content: "{{ with .db }}{{ .host }}{{ end }}{{ range .hosts }}{{ . }}{{ end }}", expected: "db,hosts"},
+		{name: "whole values", content: "{{ .app.name }}{{ toYaml . }}", all: true},
+		{name: "index on root", content: "{{ index . \"app\" }}", all: true},
+		{name: "root variable", content: "{{ range .items }}{{ $ }}{{ end }}", all: true},
+		{name: "template call with root", content: "{{ define \"x\" }}{{ .a }}{{ end }}{{ template \"x\" . }}", all: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, all, err := ReferencedTopLevelKeys("test", tt.content)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if all != tt.all {
+				t.Errorf("Expected all=%v, got %v", tt.all, all)
+			}
+			if !tt.all && strings.Join(keys, ",") != tt.expected {
+				t.Errorf("Expected %s, got %v", tt.expected, keys)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package template
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// PanicError represents a panic raised while executing a template.
+type PanicError struct {
+	Template string
+	Value    any
+	Stack    []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("template '%s' panicked: %v", e.Template, e.Value)
+}
+
+// TimeoutError represents a template execution abandoned after its timeout.
+type TimeoutError struct {
+	Template string
+	Timeout  time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("template '%s' did not finish within %s", e.Template, e.Timeout)
+}
+
+// ExecuteIsolated executes the template in its own goroutine. A panic is reported as a
+// *PanicError instead of crashing the process, and with a positive timeout an execution
+// that has not finished in time is abandoned with a *TimeoutError; see runIsolated.
+func (st *StrictTemplate) ExecuteIsolated(data any, timeout time.Duration) (string, error) {
+	return runIsolated(st.Template.Name(), timeout, func() (string, error) {
+		return st.ExecuteTemplate(data)
+	})
+}
+
+// runIsolated runs execute in a new goroutine, recovering panics and enforcing timeout.
+// Go cannot stop a goroutine from outside, so a timed-out execution is abandoned rather
+// than stopped: it keeps running, and holding its memory, until it returns on its own.
+// Callers cancel the context of functions doing I/O once this returns, so those calls
+// fail fast; a template looping without calling any function runs until the process exits.
+func runIsolated(name string, timeout time.Duration, execute func() (string, error)) (string, error) {
+	type outcome struct {
+		result string
+		err    error
+	}
+
+	// Buffered so an abandoned execution can still finish and exit
+	done := make(chan outcome, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome{err: &PanicError{Template: name, Value: r, Stack: debug.Stack()}}
+			}
+		}()
+		result, err := execute()
+		done <- outcome{result: result, err: err}
+	}()
+
+	if timeout <= 0 {
+		o := <-done
+		return o.result, o.err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-timer.C:
+		return "", &TimeoutError{Template: name, Timeout: timeout}
+	}
+}
@@ -0,0 +1,88 @@
+package template
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecuteIsolated(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		data      map[string]any
+		timeout   time.Duration
+		expected  string
+		wantError string
+	}{
+		{
+			name:     "renders normally",
+			content:  "Hello {{ .name }}",
+			data:     map[string]any{"name": "World"},
+			expected: "Hello World",
+		},
+		{
+			name:     "renders within timeout",
+			content:  "{{ .name | upper }}",
+			data:     map[string]any{"name": "web"},
+			timeout:  time.Second,
+			expected: "WEB",
+		},
+		{
+			name:      "must function failure is reported as an error",
+			content:   "{{ .name | mustToJson }}",
+			data:      map[string]any{"name": func() {}},
+			wantError: "mustToJson",
+		},
+		{
+			name:      "stuck execution is abandoned",
+			content:   "{{ range .events }}{{ . }}{{ end }}",
+			data:      map[string]any{"events": make(chan int)},
+			timeout:   20 * time.Millisecond,
+			wantError: "did not finish within 20ms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := NewStrictTemplate("test", false).ParseTemplate(tt.content)
+			if err != nil {
+				t.Fatalf("Failed to parse template: %v", err)
+			}
+
+			result, err := tmpl.ExecuteIsolated(tt.data, tt.timeout)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestRunIsolatedRecoversPanic(t *testing.T) {
+	_, err := runIsolated("broken.tpl", 0, func() (string, error) {
+		var values map[string]any
+		values["key"] = "value"
+		return "", nil
+	})
+
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("Expected *PanicError, got %v", err)
+	}
+	if panicErr.Template != "broken.tpl" || len(panicErr.Stack) == 0 {
+		t.Errorf("Expected template name and stack, got %q with %d byte stack", panicErr.Template, len(panicErr.Stack))
+	}
+	if !strings.Contains(err.Error(), "template 'broken.tpl' panicked: assignment to entry in nil map") {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Character sets of the seeded random functions, matching Sprig's unseeded ones.
+const (
+	alphaChars    = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	numericChars  = "0123456789"
+	alphaNumChars = alphaChars + numericChars
+)
+
+// asciiChars are the printable ASCII characters, including the space.
+var asciiChars = func() string {
+	chars := make([]byte, 0, 95)
+	for c := byte(' '); c <= '~'; c++ {
+		chars = append(chars, c)
+	}
+	return string(chars)
+}()
+
+// seededString returns count characters of chars chosen by a stream of SHA-256 blocks
+// over the seed, so equal seeds give equal strings across renders and machines. The
+// function name separates the streams of functions sharing a character set.
+func seededString(name string, chars string, seed any, count int) (string, error) {
+	if count < 0 {
+		return "", fmt.Errorf("%s: count must not be negative, got %d", name, count)
+	}
+
+	// Reject bytes above the largest multiple of len(chars) to avoid bias
+	limit := 256 - 256%len(chars)
+	result := make([]byte, 0, count)
+	var block [sha256.Size]byte
+	for counter := uint64(0); len(result) < count; counter++ {
+		h := sha256.New()
+		fmt.Fprintf(h, "%s\x00%v\x00", name, seed)
+		h.Write(binary.BigEndian.AppendUint64(nil, counter))
+		h.Sum(block[:0])
+		for _, b := range block {
+			if int(b) < limit && len(result) < count {
+				result = append(result, chars[int(b)%len(chars)])
+			}
+		}
+	}
+	return string(result), nil
+}
+
+// randAlphaNumSeeded returns count letters and digits derived from seed, like randAlphaNum
+// but stable across renders: {{ randAlphaNumSeeded (print .secrets.seed "/db") 24 }}.
+func randAlphaNumSeeded(seed any, count int) (string, error) {
+	return seededString("randAlphaNumSeeded", alphaNumChars, seed, count)
+}
+
+// randAlphaSeeded returns count letters derived from seed.
+func randAlphaSeeded(seed any, count int) (string, error) {
+	return seededString("randAlphaSeeded", alphaChars, seed, count)
+}
+
+// randNumericSeeded returns count digits derived from seed.
+func randNumericSeeded(seed any, count int) (string, error) {
+	return seededString("randNumericSeeded", numericChars, seed, count)
+}
+
+// randAsciiSeeded returns count printable ASCII characters derived from seed.
+func randAsciiSeeded(seed any, count int) (string, error) {
+	return seededString("randAsciiSeeded", asciiChars, seed, count)
+}
+
+// uuidNamespaces are the predefined namespaces of RFC 9562 that uuidv5 accepts by name.
+var uuidNamespaces = map[string]uuid.UUID{
+	"dns":  uuid.NameSpaceDNS,
+	"url":  uuid.NameSpaceURL,
+	"oid":  uuid.NameSpaceOID,
+	"x500": uuid.NameSpaceX500,
+}
+
+// uuidv5 returns the name-based UUID (version 5) of name in namespace, which is a UUID or
+// one of dns, url, oid and x500. Unlike uuidv4 it is the same on every render:
+// {{ uuidv5 "dns" "api.example.com" }} is always 3fe130ee-f13a-52d5-81c1-e165d84d790c.
+func uuidv5(namespace string, name any) (string, error) {
+	ns, ok := uuidNamespaces[strings.ToLower(namespace)]
+	if !ok {
+		parsed, err := uuid.Parse(namespace)
+		if err != nil {
+			return "", fmt.Errorf("uuidv5: invalid namespace '%s' (expected a UUID or one of dns, url, oid, x500)", namespace)
+		}
+		ns = parsed
+	}
+	return uuid.NewSHA1(ns, []byte(fmt.Sprint(name))).String(), nil
+}
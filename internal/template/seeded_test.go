@@ -0,0 +1,104 @@
+package template
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSeededRandom(t *testing.T) {
+	tests := []struct {
+		name  string
+		fn    func(any, int) (string, error)
+		chars string
+	}{
+		{name: "randAlphaNumSeeded", fn: randAlphaNumSeeded, chars: alphaNumChars},
+		{name: "randAlphaSeeded", fn: randAlphaSeeded, chars: alphaChars},
+		{name: "randNumericSeeded", fn: randNumericSeeded, chars: numericChars},
+		{name: "randAsciiSeeded", fn: randAsciiSeeded, chars: asciiChars},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, err := tt.fn("prod/db", 100)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(first) != 100 {
+				t.Errorf("Expected 100 characters, got %d", len(first))
+			}
+			for _, c := range first {
+				if !strings.ContainsRune(tt.chars, c) {
+					t.Errorf("Unexpected character %q in %q", c, first)
+				}
+			}
+
+			if again, _ := tt.fn("prod/db", 100); again != first {
+				t.Errorf("Expected equal output for equal seeds, got %q and %q", first, again)
+			}
+			if shorter, _ := tt.fn("prod/db", 10); shorter != first[:10] {
+				t.Errorf("Expected a prefix of the longer output, got %q", shorter)
+			}
+			if other, _ := tt.fn("prod/cache", 100); other == first {
+				t.Error("Expected different output for different seeds")
+			}
+			if _, err := tt.fn("prod/db", -1); err == nil {
+				t.Error("Expected error for a negative count")
+			}
+		})
+	}
+
+	// Functions with overlapping character sets produce unrelated output
+	alphaNum, _ := randAlphaNumSeeded("seed", 32)
+	ascii, _ := randAsciiSeeded("seed", 32)
+	if alphaNum == ascii {
+		t.Error("Expected different functions to produce different output")
+	}
+}
+
+func TestSeededRandomStable(t *testing.T) {
+	// Changing the derivation would churn every generated secret
+	tmpl, err := NewStrictTemplate("test", false).ParseTemplate(`{{ randAlphaNumSeeded .seed 16 }} {{ randNumericSeeded 42 6 }}`)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	result, err := tmpl.ExecuteTemplate(map[string]any{"seed": "app"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "VZgP1CWyvMgEb4JC 311408"; result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+}
+
+func TestUUIDv5(t *testing.T) {
+	tests := []struct {
+		namespace string
+		name      any
+		expected  string
+		wantError string
+	}{
+		{namespace: "dns", name: "python.org", expected: "886313e1-3b8a-5372-9b90-0c9aee199e5d"},
+		{namespace: "DNS", name: "api.example.com", expected: "3fe130ee-f13a-52d5-81c1-e165d84d790c"},
+		{namespace: "6ba7b810-9dad-11d1-80b4-00c04fd430c8", name: "python.org", expected: "886313e1-3b8a-5372-9b90-0c9aee199e5d"},
+		{namespace: "url", name: 42, expected: "5c2b23de-4bad-58ee-a4b3-f22f3b9cfd7d"},
+		{namespace: "tenants", name: "acme", wantError: "uuidv5: invalid namespace 'tenants'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			result, err := uuidv5(tt.namespace, tt.name)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package template
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"text/template"
+
+	yaml3 "gopkg.in/yaml.v3"
+	"sigs.k8s.io/yaml"
+
+	"github.com/menta2k/templater/internal/values"
+)
+
+// Sorted conversion functions. Maps are already encoded with sorted keys by toYaml and
+// toJson, but values holding precise numbers are encoded with yaml.v3, which orders keys
+// such as a2 and a10 numerically, and structs returned by functions keep their field
+// order. The sorted variants order every key byte-wise, whatever the value holds.
+
+// SortKeys makes toYaml, mustToYaml, toYamlPretty, toJson, mustToJson and toToml behave
+// like their sorted variants, for --sort-keys.
+func (st *StrictTemplate) SortKeys() {
+	st.Template.Funcs(template.FuncMap{
+		"toYaml":       toYAMLSorted,
+		"mustToYaml":   mustToYAMLSorted,
+		"toYamlPretty": toYAMLPrettySorted,
+		"toJson":       toJSONSorted,
+		"mustToJson":   mustToJSONSorted,
+		"toToml":       toTOMLSorted,
+	})
+}
+
+// toYAMLSorted marshals v to YAML with keys in sorted order, and returns "" on error like
+// toYaml.
+func toYAMLSorted(v any) string {
+	data, err := marshalYAMLSorted(v)
+	if err != nil {
+		// Swallow errors inside of a template.
+		return ""
+	}
+	return strings.TrimSuffix(string(data), "\n")
+}
+
+// mustToYAMLSorted marshals v to YAML with keys in sorted order. It will panic if there
+// is an error.
+func mustToYAMLSorted(v any) string {
+	data, err := marshalYAMLSorted(v)
+	if err != nil {
+		panic(err)
+	}
+	return strings.TrimSuffix(string(data), "\n")
+}
+
+// marshalYAMLSorted marshals v like marshalYAML, with keys in sorted order.
+func marshalYAMLSorted(v any) ([]byte, error) {
+	plain, err := plainValue(convertMapKeys(v))
+	if err != nil {
+		return nil, err
+	}
+	if !values.HasNumbers(plain) {
+		// Encoded through JSON, which sorts keys byte-wise
+		return yaml.Marshal(plain)
+	}
+	return encodeSortedYAML(plain)
+}
+
+// toYAMLPrettySorted marshals v to pretty YAML like toYamlPretty, with keys in sorted
+// order.
+func toYAMLPrettySorted(v any) string {
+	plain, err := plainValue(convertMapKeys(v))
+	if err != nil {
+		return ""
+	}
+	data, err := encodeSortedYAML(plain)
+	if err != nil {
+		// Swallow errors inside of a template.
+		return ""
+	}
+	return strings.TrimSuffix(string(data), "\n")
+}
+
+// encodeSortedYAML encodes v with yaml.v3, building the mapping nodes itself so keys are
+// in byte-wise order.
+func encodeSortedYAML(v any) ([]byte, error) {
+	node, err := sortedYAMLNode(values.EncodeYAMLNumbers(v))
+	if err != nil {
+		return nil, err
+	}
+
+	data := getBuffer()
+	defer putBuffer(data)
+
+	encoder := yaml3.NewEncoder(data)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(data.String()), nil
+}
+
+// sortedYAMLNode returns the YAML node of v, with mapping keys in sorted order.
+func sortedYAMLNode(v any) (*yaml3.Node, error) {
+	switch x := v.(type) {
+	case *yaml3.Node:
+		return x, nil
+	case map[string]any:
+		node := &yaml3.Node{Kind: yaml3.MappingNode, Tag: "!!map"}
+		for _, key := range sortedKeys(x) {
+			keyNode := &yaml3.Node{}
+			if err := keyNode.Encode(key); err != nil {
+				return nil, err
+			}
+			valueNode, err := sortedYAMLNode(x[key])
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, keyNode, valueNode)
+		}
+		return node, nil
+	case []any:
+		node := &yaml3.Node{Kind: yaml3.SequenceNode, Tag: "!!seq"}
+		for _, item := range x {
+			itemNode, err := sortedYAMLNode(item)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, itemNode)
+		}
+		return node, nil
+	default:
+		node := &yaml3.Node{}
+		if err := node.Encode(v); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+}
+
+// toJSONSorted marshals v to JSON like toJson, with keys in sorted order.
+func toJSONSorted(v any) string {
+	plain, err := plainValue(convertMapKeys(v))
+	if err != nil {
+		return ""
+	}
+	return toJSON(plain)
+}
+
+// mustToJSONSorted marshals v to JSON with keys in sorted order. It will panic if there
+// is an error.
+func mustToJSONSorted(v any) string {
+	plain, err := plainValue(convertMapKeys(v))
+	if err != nil {
+		panic(err)
+	}
+	return mustToJSON(plain)
+}
+
+// toTOMLSorted marshals v to TOML like toToml, with keys in sorted order. As TOML
+// requires, the keys of a table are written before its subtables.
+func toTOMLSorted(v any) string {
+	plain, err := plainValue(convertMapKeys(v))
+	if err != nil {
+		return err.Error()
+	}
+	return toTOML(plain)
+}
+
+// plainValue returns v built only from maps with string keys, slices and scalars, so
+// encoders sort all of its keys. Structs and other typed values are converted through
+// JSON, keeping their numbers precise.
+func plainValue(v any) (any, error) {
+	switch x := v.(type) {
+	case nil, string, bool, json.Number, *yaml3.Node:
+		return v, nil
+	case map[string]any:
+		converted := make(map[string]any, len(x))
+		for key, value := range x {
+			plain, err := plainValue(value)
+			if err != nil {
+				return nil, err
+			}
+			converted[key] = plain
+		}
+		return converted, nil
+	case []any:
+		converted := make([]any, len(x))
+		for i, item := range x {
+			plain, err := plainValue(item)
+			if err != nil {
+				return nil, err
+			}
+			converted[i] = plain
+		}
+		return converted, nil
+	}
+
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Struct, reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Array:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		decoder := json.NewDecoder(strings.NewReader(string(data)))
+		decoder.UseNumber()
+		var plain any
+		if err := decoder.Decode(&plain); err != nil {
+			return nil, err
+		}
+		return plain, nil
+	default:
+		return v, nil
+	}
+}
@@ -0,0 +1,64 @@
+package template
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSortedConversions(t *testing.T) {
+	type service struct {
+		Name  string `json:"name"`
+		Port  int    `json:"port"`
+		Alias string `json:"alias"`
+	}
+	numbers := map[string]any{"a10": json.Number("1.50"), "a2": json.Number("2"), "B": "upper"}
+
+	tests := []struct {
+		name     string
+		fn       func(any) string
+		value    any
+		expected string
+	}{
+		{name: "toYamlSorted numbers", fn: toYAMLSorted, value: numbers, expected: "B: upper\na10: 1.50\na2: 2"},
+		{name: "toYamlSorted struct", fn: toYAMLSorted, value: service{Name: "web", Port: 8080, Alias: "www"}, expected: "alias: www\nname: web\nport: 8080"},
+		{name: "toYamlSorted nested", fn: toYAMLSorted, value: map[string]any{"z": []any{map[string]any{"b": true, "a": nil}}, "y": "x"}, expected: "\"y\": x\nz:\n- a: null\n  b: true"},
+		{name: "toJsonSorted struct", fn: toJSONSorted, value: []any{service{Name: "web", Port: 8080}}, expected: `[{"alias":"","name":"web","port":8080}]`},
+		{name: "toJsonSorted map keys", fn: toJSONSorted, value: map[interface{}]interface{}{"b": 1, "a": 2}, expected: `{"a":2,"b":1}`},
+		{name: "toTomlSorted struct", fn: toTOMLSorted, value: map[string]any{"svc": service{Name: "web", Port: 8080, Alias: "www"}, "debug": true}, expected: "debug = true\n\n[svc]\n  alias = \"www\"\n  name = \"web\"\n  port = 8080\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.fn(tt.value); result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+
+	// yaml.v3 alone would order the keys numerically
+	if result := toYAMLPrettySorted(numbers); result != "B: upper\na10: 1.50\na2: 2" {
+		t.Errorf("Unexpected toYamlPretty output %q", result)
+	}
+	if result := toYAML(numbers); result != "B: upper\na2: 2\na10: 1.50" {
+		t.Errorf("Unexpected toYaml output %q", result)
+	}
+}
+
+func TestSortKeys(t *testing.T) {
+	st := NewStrictTemplate("test", false)
+	st.SortKeys()
+	tmpl, err := st.ParseTemplate(`{{ toYaml . }}|{{ toJson .svc }}`)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	result, err := tmpl.ExecuteTemplate(map[string]any{"svc": struct {
+		Port int    `json:"port"`
+		Name string `json:"name"`
+	}{Port: 80, Name: "web"}, "a10": json.Number("1"), "a2": json.Number("2")})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "a10: 1\na2: 2\nsvc:\n  name: web\n  port: 80|{\"name\":\"web\",\"port\":80}"; result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+}
@@ -0,0 +1,65 @@
+package template
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// sshRSABits is the size of generated RSA keys, the ssh-keygen default.
+const sshRSABits = 3072
+
+// SSHKeyPair is an SSH key pair generated by genSSHKeyPair.
+type SSHKeyPair struct {
+	PublicKey  string // authorized_keys format, with the comment if given
+	PrivateKey string // OpenSSH PEM format, unencrypted
+}
+
+// genSSHKeyPair generates an ed25519 or rsa key pair, with an optional comment:
+// {{ $key := genSSHKeyPair "ed25519" "deploy@ci" }}{{ $key.PublicKey }}.
+func genSSHKeyPair(keyType string, comment ...string) (*SSHKeyPair, error) {
+	if len(comment) > 1 {
+		return nil, fmt.Errorf("genSSHKeyPair takes at most one comment, got %d", len(comment))
+	}
+
+	var private crypto.PrivateKey
+	var public crypto.PublicKey
+	switch keyType {
+	case "ed25519":
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ed25519 key: %w", err)
+		}
+		private, public = priv, pub
+	case "rsa":
+		priv, err := rsa.GenerateKey(rand.Reader, sshRSABits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate rsa key: %w", err)
+		}
+		private, public = priv, &priv.PublicKey
+	default:
+		return nil, fmt.Errorf("unsupported SSH key type %q (expected ed25519 or rsa)", keyType)
+	}
+
+	keyComment := strings.Join(comment, "")
+	block, err := ssh.MarshalPrivateKey(private, keyComment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode SSH private key: %w", err)
+	}
+	sshPublic, err := ssh.NewPublicKey(public)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode SSH public key: %w", err)
+	}
+
+	authorized := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(sshPublic)), "\n")
+	if keyComment != "" {
+		authorized += " " + keyComment
+	}
+	return &SSHKeyPair{PublicKey: authorized, PrivateKey: string(pem.EncodeToMemory(block))}, nil
+}
@@ -0,0 +1,276 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"text/template"
+
+	starlarkjson "go.starlark.net/lib/json"
+	starlarkmath "go.starlark.net/lib/math"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// StarlarkMaxSteps bounds the computation of a single Starlark function call or file, so
+// a runaway loop fails rendering instead of hanging it.
+var StarlarkMaxSteps uint64 = 10_000_000
+
+// starlarkMain is the function an inline starlark program is called through.
+const starlarkMain = "main"
+
+// starlarkOptions enables the Starlark features Bazel rules do not need but template
+// logic may: sets, while loops, top-level statements and recursion. Execution steps are
+// bounded, so none of them can hang rendering.
+var starlarkOptions = &syntax.FileOptions{
+	Set:             true,
+	While:           true,
+	TopLevelControl: true,
+	Recursion:       true,
+}
+
+// starlarkPredeclared are the modules available to Starlark code besides the built-ins.
+var starlarkPredeclared = starlark.StringDict{
+	"json": starlarkjson.Module,
+	"math": starlarkmath.Module,
+}
+
+// inlinePrograms caches the main functions of inline programs by source, since a
+// template may call the same program many times.
+var inlinePrograms sync.Map
+
+// LoadStarlark executes a .star file and returns its top-level functions as template
+// functions, except those whose names start with an underscore. The file cannot load
+// other files or access the file system, network or environment.
+func LoadStarlark(path string) (template.FuncMap, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read starlark file %s: %w", path, err)
+	}
+	// Syntax and evaluation errors are reported with the file and position
+	globals, err := execStarlark(path, src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load starlark file: %w", err)
+	}
+
+	funcs := template.FuncMap{}
+	for name, value := range globals {
+		fn, ok := value.(*starlark.Function)
+		if !ok || strings.HasPrefix(name, "_") {
+			continue
+		}
+		funcs[name] = starlarkFunc(fn)
+	}
+	if len(funcs) == 0 {
+		return nil, fmt.Errorf("starlark file %s defines no functions", path)
+	}
+	return funcs, nil
+}
+
+// runStarlark runs an inline Starlark program defining a main function and returns the
+// result of calling main with args, such as {{ starlark "def main(x): return x * 2" 21 }}.
+func runStarlark(src string, args ...any) (any, error) {
+	fn, ok := inlinePrograms.Load(src)
+	if !ok {
+		globals, err := execStarlark("starlark", src)
+		if err != nil {
+			return nil, err
+		}
+		main, ok := globals[starlarkMain].(*starlark.Function)
+		if !ok {
+			return nil, fmt.Errorf("starlark program must define a %s function", starlarkMain)
+		}
+		fn, _ = inlinePrograms.LoadOrStore(src, main)
+	}
+	return starlarkFunc(fn.(*starlark.Function))(args...)
+}
+
+// execStarlark executes a Starlark file and returns its frozen globals.
+func execStarlark(filename string, src any) (starlark.StringDict, error) {
+	thread := newStarlarkThread(filename)
+	globals, err := starlark.ExecFileOptions(starlarkOptions, thread, filename, src, starlarkPredeclared)
+	if err != nil {
+		return nil, starlarkError(err)
+	}
+	return globals, nil
+}
+
+// starlarkFunc adapts a Starlark function to a template function. Each call runs on its
+// own thread; the function's globals are frozen, so calls may run concurrently.
+func starlarkFunc(fn *starlark.Function) func(args ...any) (any, error) {
+	return func(args ...any) (any, error) {
+		tuple := make(starlark.Tuple, len(args))
+		for i, arg := range args {
+			value, err := toStarlark(arg)
+			if err != nil {
+				return nil, fmt.Errorf("%s: argument %d: %w", fn.Name(), i+1, err)
+			}
+			tuple[i] = value
+		}
+
+		result, err := starlark.Call(newStarlarkThread(fn.Name()), fn, tuple, nil)
+		if err != nil {
+			return nil, starlarkError(err)
+		}
+		value, err := fromStarlark(result)
+		if err != nil {
+			return nil, fmt.Errorf("%s: result: %w", fn.Name(), err)
+		}
+		return value, nil
+	}
+}
+
+// newStarlarkThread returns a thread with bounded execution steps, where load and print
+// are unavailable.
+func newStarlarkThread(name string) *starlark.Thread {
+	thread := &starlark.Thread{
+		Name:  name,
+		Print: func(*starlark.Thread, string) {},
+	}
+	thread.SetMaxExecutionSteps(StarlarkMaxSteps)
+	return thread
+}
+
+// starlarkError reports evaluation errors with their Starlark backtrace.
+func starlarkError(err error) error {
+	if evalErr, ok := err.(*starlark.EvalError); ok {
+		return fmt.Errorf("%s", strings.TrimSpace(evalErr.Backtrace()))
+	}
+	return err
+}
+
+// toStarlark converts a template value to a Starlark value. Maps may have keys of any
+// type Starlark can hash; json.Number values become ints or floats.
+func toStarlark(v any) (starlark.Value, error) {
+	switch value := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return starlark.MakeInt64(i), nil
+		}
+		if i, ok := new(big.Int).SetString(value.String(), 10); ok {
+			return starlark.MakeBigInt(i), nil
+		}
+		f, err := value.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", value)
+		}
+		return starlark.Float(f), nil
+	case *big.Int:
+		return starlark.MakeBigInt(value), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return starlark.Bool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return starlark.MakeInt64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return starlark.MakeUint64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return starlark.Float(rv.Float()), nil
+	case reflect.String:
+		return starlark.String(rv.String()), nil
+	case reflect.Slice, reflect.Array:
+		elems := make([]starlark.Value, rv.Len())
+		for i := range elems {
+			elem, err := toStarlark(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = elem
+		}
+		return starlark.NewList(elems), nil
+	case reflect.Map:
+		dict := starlark.NewDict(rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key, err := toStarlark(iter.Key().Interface())
+			if err != nil {
+				return nil, err
+			}
+			value, err := toStarlark(iter.Value().Interface())
+			if err != nil {
+				return nil, err
+			}
+			if err := dict.SetKey(key, value); err != nil {
+				return nil, err
+			}
+		}
+		return dict, nil
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return starlark.None, nil
+		}
+		return toStarlark(rv.Elem().Interface())
+	default:
+		return nil, fmt.Errorf("unsupported value of type %T", v)
+	}
+}
+
+// fromStarlark converts a Starlark value to a template value: ints become int (or
+// *big.Int when out of range), lists, tuples and sets []any, and dicts map[string]any.
+func fromStarlark(v starlark.Value) (any, error) {
+	switch value := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(value), nil
+	case starlark.Int:
+		if i, ok := value.Int64(); ok && int64(int(i)) == i {
+			return int(i), nil
+		}
+		return value.BigInt(), nil
+	case starlark.Float:
+		return float64(value), nil
+	case starlark.String:
+		return string(value), nil
+	case starlark.Bytes:
+		return string(value), nil
+	case starlark.Indexable:
+		list := make([]any, value.Len())
+		for i := range list {
+			elem, err := fromStarlark(value.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			list[i] = elem
+		}
+		return list, nil
+	case *starlark.Set:
+		var list []any
+		iter := value.Iterate()
+		defer iter.Done()
+		var elem starlark.Value
+		for iter.Next(&elem) {
+			item, err := fromStarlark(elem)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, nil
+	case *starlark.Dict:
+		result := make(map[string]any, value.Len())
+		for _, item := range value.Items() {
+			key, ok := item[0].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("dict key %s is a %s, expected a string", item[0], item[0].Type())
+			}
+			elem, err := fromStarlark(item[1])
+			if err != nil {
+				return nil, err
+			}
+			result[string(key)] = elem
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("unsupported starlark value of type %s", v.Type())
+	}
+}
@@ -0,0 +1,123 @@
+package template
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStarlark(t *testing.T) {
+	data := map[string]any{
+		"replicas": 3,
+		"size":     json.Number("12"),
+		"app": map[interface{}]interface{}{
+			"name":  "web",
+			"ports": []any{8080, 9090},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		template  string
+		expected  string
+		wantError string
+	}{
+		{name: "arguments", template: `{{ starlark "def main(x): return x * 2" .replicas }}`, expected: "6"},
+		{name: "json numbers", template: `{{ starlark "def main(x): return x + 1" .size }}`, expected: "13"},
+		{name: "result used by templates", template: `{{ add (starlark "def main(): return 40") 2 }}`, expected: "42"},
+		{
+			name:     "dicts and lists",
+			template: `{{ starlark "def main(app): return {'name': app['name'].upper(), 'ports': [p + 1 for p in app['ports']]}" .app | toJson }}`,
+			expected: `{"name":"WEB","ports":[8081,9091]}`,
+		},
+		{name: "modules", template: `{{ starlark "def main(x): return json.encode({'v': math.sqrt(x)})" 16 }}`, expected: `{"v":4}`},
+		{name: "no main", template: `{{ starlark "x = 1" }}`, wantError: "must define a main function"},
+		{name: "syntax error", template: `{{ starlark "def main(:" }}`, wantError: "starlark:1:11: got ':', want ')'"},
+		{name: "runtime error", template: `{{ starlark "def main(x): return x['missing']" .app }}`, wantError: `key "missing" not in dict`},
+		{name: "unsupported result", template: `{{ starlark "def main(): return main" }}`, wantError: "unsupported starlark value of type function"},
+		{name: "non-string key", template: `{{ starlark "def main(): return {1: 2}" }}`, wantError: "expected a string"},
+		{name: "runaway loop", template: `{{ starlark "def main():\n  while True:\n    pass" }}`, wantError: "too many steps"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := NewStrictTemplate("test", false).ParseTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("Failed to parse template: %v", err)
+			}
+
+			result, err := tmpl.ExecuteTemplate(data)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestLoadStarlark(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"naming.star": `
+def _prefix(env):
+    return {"prod": "p", "staging": "s"}.get(env, "d")
+
+def resource_name(env, app):
+    return "%s-%s" % (_prefix(env), app)
+
+def shard(key, count):
+    return hash(key) % count
+
+LIMIT = 10
+`,
+		"empty.star":  "LIMIT = 10\n",
+		"broken.star": "def f(:\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	funcs, err := LoadStarlark(filepath.Join(dir, "naming.star"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(funcs) != 2 || funcs["resource_name"] == nil || funcs["shard"] == nil {
+		t.Fatalf("Expected resource_name and shard, got %v", funcs)
+	}
+
+	st := NewStrictTemplate("test", true)
+	st.Funcs(funcs)
+	tmpl, err := st.ParseTemplate(`{{ resource_name .env "api" }}`)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	result, err := tmpl.ExecuteTemplate(map[string]any{"env": "prod"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != "p-api" {
+		t.Errorf("Expected %q, got %q", "p-api", result)
+	}
+
+	for name, wantError := range map[string]string{
+		"empty.star":   "defines no functions",
+		"broken.star":  "broken.star:1:8",
+		"missing.star": "failed to read starlark file",
+	} {
+		if _, err := LoadStarlark(filepath.Join(dir, name)); err == nil || !strings.Contains(err.Error(), wantError) {
+			t.Errorf("%s: expected error containing %q, got %v", name, wantError, err)
+		}
+	}
+}
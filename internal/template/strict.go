@@ -25,6 +25,7 @@ type StrictTemplate struct {
 // NewStrictTemplate creates a new template wrapper with strict mode support.
 func NewStrictTemplate(name string, strictMode bool) *StrictTemplate {
 	tmpl := template.New(name).Funcs(GetTemplateFuncs())
+	tmpl.Funcs(template.FuncMap{"include": includeFunc(tmpl)})
 
 	// In strict mode, we need to add a custom function that catches undefined variables
 	if strictMode {
@@ -51,15 +52,21 @@ func (st *StrictTemplate) ParseTemplate(content string) (*StrictTemplate, error)
 	}, nil
 }
 
-// ExecuteTemplate executes the template with strict mode validation.
+// ExecuteTemplate executes the template with strict mode validation. A template calling
+// fail returns a *FailError.
 func (st *StrictTemplate) ExecuteTemplate(data any) (string, error) {
-	var result strings.Builder
+	// Execute into a pooled buffer; only the final string is allocated per render
+	result := getBuffer()
+	defer putBuffer(result)
 
 	if st.StrictMode {
 		// In strict mode, template execution will fail with "missingkey=error" option
 		// if any undefined variables are encountered
-		err := st.Template.Execute(&result, data)
+		err := st.Template.Execute(result, data)
 		if err != nil {
+			if failErr := asFailError(err, st.Template.Name()); failErr != nil {
+				return "", failErr
+			}
 			// Check if it's a missing key error and wrap it appropriately
 			if strings.Contains(err.Error(), "map has no entry for key") ||
 				strings.Contains(err.Error(), "can't evaluate field") {
@@ -72,8 +79,11 @@ func (st *StrictTemplate) ExecuteTemplate(data any) (string, error) {
 		}
 	} else {
 		// Normal mode - missing keys will be replaced with "<no value>"
-		err := st.Template.Execute(&result, data)
+		err := st.Template.Execute(result, data)
 		if err != nil {
+			if failErr := asFailError(err, st.Template.Name()); failErr != nil {
+				return "", failErr
+			}
 			return "", err
 		}
 	}
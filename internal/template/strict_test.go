@@ -241,3 +241,24 @@ func TestStrictModeWithSprigFunctions(t *testing.T) {
 		})
 	}
 }
+
+func BenchmarkExecuteTemplate(b *testing.B) {
+	content := strings.Repeat("Line {{.counter}}: {{.app.name}} version {{.app.version}}\n", 200)
+	values := map[string]any{
+		"app":     map[string]any{"name": "benchmark-app", "version": "1.0.0"},
+		"counter": 42,
+	}
+
+	tmpl, err := NewStrictTemplate("bench", true).ParseTemplate(content)
+	if err != nil {
+		b.Fatalf("Failed to parse template: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := tmpl.ExecuteTemplate(values); err != nil {
+			b.Fatalf("Failed to execute template: %v", err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// tableCellReplacer keeps cells on one line, as tabs and newlines would break alignment.
+var tableCellReplacer = strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ")
+
+// Table functions.
+
+// table takes a list of maps and renders it as an aligned text table with a header row
+// of every key in sorted order, underlined with dashes.
+func table(rows any) string {
+	return tableColumns(nil, rows)
+}
+
+// tableColumns takes a list of column names and a list of maps, and renders the maps as
+// an aligned text table with those columns in that order, like toCsvColumns:
+// {{ tableColumns (list "name" "port") .services }}.
+func tableColumns(columns []any, rows any) string {
+	header, records, ok := rowRecords(columns, rows)
+	if !ok {
+		// Swallow errors inside of a template.
+		return ""
+	}
+
+	b := getBuffer()
+	defer putBuffer(b)
+	w := tabwriter.NewWriter(b, 0, 0, 2, ' ', 0)
+	if len(header) > 0 {
+		underline := make([]string, len(header))
+		for i, column := range header {
+			underline[i] = strings.Repeat("-", utf8.RuneCountInString(column))
+		}
+		writeTableRow(w, header)
+		writeTableRow(w, underline)
+	}
+	for _, record := range records {
+		writeTableRow(w, record)
+	}
+	if err := w.Flush(); err != nil {
+		// Swallow errors inside of a template.
+		return ""
+	}
+
+	// Drop the padding of empty cells at the end of rows
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeTableRow writes the cells of a row separated by tabs, for the tabwriter to align.
+func writeTableRow(w *tabwriter.Writer, cells []string) {
+	for i, cell := range cells {
+		if i > 0 {
+			fmt.Fprint(w, "\t")
+		}
+		fmt.Fprint(w, tableCellReplacer.Replace(cell))
+	}
+	fmt.Fprint(w, "\n")
+}
@@ -0,0 +1,57 @@
+package template
+
+import "testing"
+
+func TestTable(t *testing.T) {
+	rows := []any{
+		map[string]any{"name": "api", "port": 8080, "owner": "team-a"},
+		map[interface{}]interface{}{"name": "database", "port": 5432, "tags": []any{"db"}},
+		map[string]any{"name": "cäche", "notes": "line one\nline two"},
+	}
+
+	tests := []struct {
+		name     string
+		columns  []any
+		rows     any
+		expected string
+	}{
+		{
+			name: "all columns",
+			rows: rows,
+			expected: "name      notes              owner   port  tags\n" +
+				"----      -----              -----   ----  ----\n" +
+				"api                          team-a  8080\n" +
+				"database                             5432  [\"db\"]\n" +
+				"cäche     line one line two",
+		},
+		{
+			name:    "selected columns",
+			columns: []any{"port", "name"},
+			rows:    rows,
+			expected: "port  name\n" +
+				"----  ----\n" +
+				"8080  api\n" +
+				"5432  database\n" +
+				"      cäche",
+		},
+		{
+			name:     "list rows",
+			columns:  []any{"key", "value"},
+			rows:     []any{[]any{"a", 1}, []any{"longer", 2}},
+			expected: "key     value\n---     -----\na       1\nlonger  2",
+		},
+		{
+			name:     "not a list",
+			rows:     "text",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tableColumns(tt.columns, tt.rows); result != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package template
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// URL functions. urlParse and urlJoin replace Sprig's, which panic on invalid input,
+// keeping their keys and adding port and params.
+
+// urlParse splits a URL into a map with the keys scheme, userinfo, host, hostname,
+// port, path, query, params, opaque and fragment. params holds the decoded query
+// parameters, with repeated ones as lists: {{ (urlParse .db.url).params.sslmode }}.
+func urlParse(s string) (map[string]any, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", s, err)
+	}
+
+	params := make(map[string]any)
+	for name, values := range u.Query() {
+		for _, value := range values {
+			addRepeatedValue(params, name, value)
+		}
+	}
+	userinfo := ""
+	if u.User != nil {
+		userinfo = u.User.String()
+	}
+	return map[string]any{
+		"scheme":   u.Scheme,
+		"userinfo": userinfo,
+		"host":     u.Host,
+		"hostname": u.Hostname(),
+		"port":     u.Port(),
+		"path":     u.Path,
+		"query":    u.RawQuery,
+		"params":   params,
+		"opaque":   u.Opaque,
+		"fragment": u.Fragment,
+	}, nil
+}
+
+// urlJoin builds a URL from a map with the keys of urlParse. When params is present it
+// replaces query, so parameters can be changed on a parsed URL:
+// {{ $u := urlParse .url }}{{ $_ := set $u.params "sslmode" "require" }}{{ urlJoin $u }}.
+// hostname and port are only used when host is empty.
+func urlJoin(parts map[string]any) (string, error) {
+	part := func(key string) string {
+		if value, ok := parts[key]; ok && value != nil {
+			return fmt.Sprint(value)
+		}
+		return ""
+	}
+
+	u := url.URL{
+		Scheme:   part("scheme"),
+		Host:     part("host"),
+		Path:     part("path"),
+		RawQuery: part("query"),
+		Opaque:   part("opaque"),
+		Fragment: part("fragment"),
+	}
+	if u.Host == "" && part("hostname") != "" {
+		u.Host = part("hostname")
+		if port := part("port"); port != "" {
+			u.Host += ":" + port
+		}
+	}
+	if userinfo := part("userinfo"); userinfo != "" {
+		parsed, err := url.Parse("scheme://" + userinfo + "@host")
+		if err != nil {
+			return "", fmt.Errorf("invalid URL userinfo: %w", err)
+		}
+		u.User = parsed.User
+	}
+	if params, ok := convertMapKeys(parts["params"]).(map[string]any); ok {
+		u.RawQuery = urlQuery(params)
+	}
+	return u.String(), nil
+}
+
+// urlQuery encodes a map as a URL query string sorted by key, with lists as repeated
+// parameters: {{ urlQuery (dict "q" .search "tag" (list "a" "b")) }} is q=...&tag=a&tag=b.
+func urlQuery(params map[string]any) string {
+	values := url.Values{}
+	for name, value := range params {
+		switch value := value.(type) {
+		case []any:
+			for _, item := range value {
+				values.Add(name, fmt.Sprint(item))
+			}
+		case nil:
+			values.Add(name, "")
+		default:
+			values.Add(name, fmt.Sprint(value))
+		}
+	}
+	return values.Encode()
+}
+
+// urlJoinPath appends path elements to the path of a base URL, cleaning ./ and ../ and
+// duplicate slashes: {{ urlJoinPath .api.base "v1" "users" .id }}.
+func urlJoinPath(base string, elements ...any) (string, error) {
+	parts := make([]string, len(elements))
+	for i, element := range elements {
+		parts[i] = fmt.Sprint(element)
+	}
+	joined, err := url.JoinPath(base, parts...)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", base, err)
+	}
+	return joined, nil
+}
@@ -0,0 +1,142 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// templatedValue is a string value containing {{ }} expressions.
+type templatedValue struct {
+	path   []string
+	source string
+	set    func(string)
+	state  int // 0 pending, 1 rendering, 2 rendered
+}
+
+// RenderValues evaluates string values containing {{ }} expressions, with the values
+// themselves as the template data, and replaces them with the result. Besides the
+// template functions, env and expandenv are available. A value may reference other
+// templated values: those are rendered first. Dependencies are found statically, so
+// references inside range or define bodies are not followed. A value that depends on
+// itself, directly or through others, is reported as a cycle. funcs are added to the
+// template functions, such as those of function plugins.
+func RenderValues(values map[string]any, strictMode bool, funcs template.FuncMap) error {
+	var templated []*templatedValue
+	collectTemplatedValues(values, nil, &templated)
+	sort.Slice(templated, func(i, j int) bool {
+		return valuePath(templated[i].path) < valuePath(templated[j].path)
+	})
+
+	var render func(value *templatedValue, chain []string) error
+	render = func(value *templatedValue, chain []string) error {
+		name := valuePath(value.path)
+		switch value.state {
+		case 2:
+			return nil
+		case 1:
+			return fmt.Errorf("cycle in templated values: %s -> %s", strings.Join(chain, " -> "), name)
+		}
+		value.state = 1
+		chain = append(chain, name)
+
+		refs, err := FindReferences(name, value.source)
+		if err != nil {
+			return fmt.Errorf("failed to parse templated value %s: %w", name, err)
+		}
+		_, wholeRoot, _ := ReferencedTopLevelKeys(name, value.source)
+
+		for _, other := range templated {
+			if other == value && wholeRoot {
+				continue
+			}
+			if wholeRoot || dependsOn(refs, other.path) {
+				if err := render(other, chain); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Values files are written by whoever runs templater, so unlike templates they
+		// may read the environment
+		st := NewStrictTemplate(name, strictMode)
+		st.Funcs(template.FuncMap{"env": os.Getenv, "expandenv": os.ExpandEnv})
+		if len(funcs) > 0 {
+			st.Funcs(funcs)
+		}
+		tmpl, err := st.ParseTemplate(value.source)
+		if err != nil {
+			return fmt.Errorf("failed to parse templated value %s: %w", name, err)
+		}
+		result, err := tmpl.ExecuteTemplate(values)
+		if err != nil {
+			return fmt.Errorf("failed to render templated value %s: %w", name, err)
+		}
+
+		value.set(result)
+		value.state = 2
+		return nil
+	}
+
+	for _, value := range templated {
+		if err := render(value, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectTemplatedValues appends every string value below v containing {{ to templated.
+func collectTemplatedValues(v any, path []string, templated *[]*templatedValue) {
+	add := func(key string, item any, set func(string)) {
+		itemPath := append(append([]string{}, path...), key)
+		if s, ok := item.(string); ok {
+			if strings.Contains(s, "{{") {
+				*templated = append(*templated, &templatedValue{path: itemPath, source: s, set: set})
+			}
+			return
+		}
+		collectTemplatedValues(item, itemPath, templated)
+	}
+
+	switch x := v.(type) {
+	case map[string]any:
+		for key, item := range x {
+			key := key
+			add(key, item, func(s string) { x[key] = s })
+		}
+	case map[interface{}]interface{}:
+		for key, item := range x {
+			key := key
+			add(fmt.Sprint(key), item, func(s string) { x[key] = s })
+		}
+	case []any:
+		for i, item := range x {
+			i := i
+			add(strconv.Itoa(i), item, func(s string) { x[i] = s })
+		}
+	}
+}
+
+// dependsOn reports whether one of refs reads the value at path: the reference is the
+// path itself, one of its parents, or a path below it.
+func dependsOn(refs []Reference, path []string) bool {
+	for _, ref := range refs {
+		n := min(len(ref.Path), len(path))
+		if n == 0 {
+			continue
+		}
+		if strings.Join(ref.Path[:n], "\x00") == strings.Join(path[:n], "\x00") {
+			return true
+		}
+	}
+	return false
+}
+
+// valuePath formats a value path as it appears in templates, e.g. .app.url.
+func valuePath(path []string) string {
+	return "." + strings.Join(path, ".")
+}
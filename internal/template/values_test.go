@@ -0,0 +1,109 @@
+package template
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestRenderValues(t *testing.T) {
+	t.Setenv("TEMPLATER_TEST_REGION", "eu-west-1")
+
+	tests := []struct {
+		name      string
+		values    map[string]any
+		funcs     template.FuncMap
+		expected  map[string]any
+		wantError string
+	}{
+		{
+			name: "references and env vars",
+			values: map[string]any{
+				"domain": "example.com",
+				"region": `{{ env "TEMPLATER_TEST_REGION" }}`,
+				"app": map[interface{}]interface{}{
+					"name": "web",
+					"url":  "https://{{ .app.name }}.{{ .domain }}",
+				},
+			},
+			expected: map[string]any{
+				"domain": "example.com",
+				"region": "eu-west-1",
+				"app": map[interface{}]interface{}{
+					"name": "web",
+					"url":  "https://web.example.com",
+				},
+			},
+		},
+		{
+			name: "templated values are rendered before values referencing them",
+			values: map[string]any{
+				"a":     "{{ .b }}/a",
+				"b":     "{{ .c.host }}/b",
+				"c":     map[string]any{"host": "db", "port": 5432},
+				"hosts": []any{"{{ .a }}", "static"},
+			},
+			expected: map[string]any{
+				"a":     "db/b/a",
+				"b":     "db/b",
+				"c":     map[string]any{"host": "db", "port": 5432},
+				"hosts": []any{"db/b/a", "static"},
+			},
+		},
+		{
+			name: "values without expressions are unchanged",
+			values: map[string]any{
+				"plain":  "no templates here",
+				"number": 3,
+			},
+			expected: map[string]any{
+				"plain":  "no templates here",
+				"number": 3,
+			},
+		},
+		{
+			name:     "additional functions",
+			values:   map[string]any{"team": "Platform", "center": "{{ costCenter .team }}"},
+			funcs:    template.FuncMap{"costCenter": func(team string) string { return "cc-" + strings.ToLower(team) }},
+			expected: map[string]any{"team": "Platform", "center": "cc-platform"},
+		},
+		{
+			name: "cycle",
+			values: map[string]any{
+				"a": "{{ .b }}",
+				"b": "{{ .c }}",
+				"c": "{{ .a }}",
+			},
+			wantError: "cycle in templated values: .a -> .b -> .c -> .a",
+		},
+		{
+			name:      "self reference",
+			values:    map[string]any{"a": "x{{ .a }}"},
+			wantError: "cycle in templated values: .a -> .a",
+		},
+		{
+			name:      "undefined reference",
+			values:    map[string]any{"a": "{{ .missing }}"},
+			wantError: "undefined variable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RenderValues(tt.values, true, tt.funcs)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.values, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, tt.values)
+			}
+		})
+	}
+}
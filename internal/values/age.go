@@ -0,0 +1,277 @@
+package values
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// AgeTag marks a YAML scalar holding an age encrypted, ASCII-armored value.
+const AgeTag = "!age"
+
+// AgeFileExt is the extension of values files encrypted as a whole with age, such as
+// values.yaml.age.
+const AgeFileExt = ".age"
+
+// AgeIdentityEnv names the environment variable holding the path of an age identity
+// file, used when no identity file is given explicitly.
+const AgeIdentityEnv = "AGE_IDENTITY"
+
+// ParseAgeRecipients parses age public keys (age1...).
+func ParseAgeRecipients(keys []string) ([]age.Recipient, error) {
+	recipients := make([]age.Recipient, 0, len(keys))
+	for _, key := range keys {
+		recipient, err := age.ParseX25519Recipient(strings.TrimSpace(key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipient %s: %w", key, err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, nil
+}
+
+// LoadAgeIdentities reads age identities (AGE-SECRET-KEY-...) from identity files.
+func LoadAgeIdentities(identityFiles []string) ([]age.Identity, error) {
+	var identities []age.Identity
+	for _, identityFile := range identityFiles {
+		file, err := os.Open(identityFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open age identity file: %w", err)
+		}
+
+		parsed, err := age.ParseIdentities(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse age identity file %s: %w", identityFile, err)
+		}
+		identities = append(identities, parsed...)
+	}
+	return identities, nil
+}
+
+// EncryptYAML encrypts every scalar whose dotted path matches one of the patterns.
+// Patterns use path.Match syntax, where "*" also matches dots (e.g. "*.password").
+// Comments and layout of the document are preserved. It returns the number of encrypted values.
+func EncryptYAML(data []byte, recipients []age.Recipient, patterns []string) ([]byte, int, error) {
+	if len(recipients) == 0 {
+		return nil, 0, fmt.Errorf("at least one age recipient is required")
+	}
+
+	count := 0
+	result, err := transformScalars(data, func(valuePath string, node *yaml3.Node) error {
+		if node.Tag == AgeTag || !matchesAny(valuePath, patterns) {
+			return nil
+		}
+
+		ciphertext, err := encryptScalar(node, recipients)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt %s: %w", valuePath, err)
+		}
+
+		*node = yaml3.Node{
+			Kind:        yaml3.ScalarNode,
+			Tag:         AgeTag,
+			Style:       yaml3.LiteralStyle,
+			Value:       ciphertext,
+			HeadComment: node.HeadComment,
+			LineComment: node.LineComment,
+			FootComment: node.FootComment,
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return result, count, nil
+}
+
+// DecryptYAML decrypts every !age value in the document, restoring the original scalars.
+// It returns the number of decrypted values.
+func DecryptYAML(data []byte, identities []age.Identity) ([]byte, int, error) {
+	count := 0
+	result, err := transformScalars(data, func(valuePath string, node *yaml3.Node) error {
+		if node.Tag != AgeTag {
+			return nil
+		}
+		if len(identities) == 0 {
+			return fmt.Errorf("value %s is age encrypted but no age identity was provided", valuePath)
+		}
+
+		plain, err := decryptScalar(node.Value, identities)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", valuePath, err)
+		}
+
+		plain.HeadComment = node.HeadComment
+		plain.LineComment = node.LineComment
+		plain.FootComment = node.FootComment
+		*node = *plain
+		count++
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return result, count, nil
+}
+
+// IsAgeFile reports whether path names a values file encrypted as a whole with age.
+func IsAgeFile(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), AgeFileExt)
+}
+
+// DecryptFile decrypts a file encrypted as a whole with age, ASCII-armored or binary.
+func DecryptFile(data []byte, identities []age.Identity) ([]byte, error) {
+	if len(identities) == 0 {
+		return nil, fmt.Errorf("file is age encrypted but no age identity was provided")
+	}
+
+	var ciphertext io.Reader = bytes.NewReader(data)
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); bytes.HasPrefix(trimmed, []byte(armor.Header)) {
+		ciphertext = armor.NewReader(bytes.NewReader(trimmed))
+	}
+	reader, err := age.Decrypt(ciphertext, identities...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(reader)
+}
+
+// HasAgeValues reports whether a YAML document appears to contain !age encrypted values.
+func HasAgeValues(data []byte) bool {
+	return bytes.Contains(data, []byte(AgeTag))
+}
+
+// encryptScalar encrypts the YAML encoding of a scalar node so its type survives a round trip.
+func encryptScalar(node *yaml3.Node, recipients []age.Recipient) (string, error) {
+	scalar := &yaml3.Node{Kind: yaml3.ScalarNode, Tag: node.Tag, Style: node.Style, Value: node.Value}
+	plaintext, err := yaml3.Marshal(scalar)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	armored := armor.NewWriter(&buf)
+	writer, err := age.Encrypt(armored, recipients...)
+	if err != nil {
+		return "", err
+	}
+	if _, err := writer.Write(plaintext); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	if err := armored.Close(); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// decryptScalar decrypts an armored value back into its original scalar node.
+func decryptScalar(ciphertext string, identities []age.Identity) (*yaml3.Node, error) {
+	reader, err := age.Decrypt(armor.NewReader(strings.NewReader(ciphertext)), identities...)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml3.Node
+	if err := yaml3.Unmarshal(plaintext, &doc); err != nil {
+		return nil, fmt.Errorf("invalid decrypted value: %w", err)
+	}
+	if len(doc.Content) != 1 || doc.Content[0].Kind != yaml3.ScalarNode {
+		return nil, fmt.Errorf("decrypted value is not a scalar")
+	}
+
+	return doc.Content[0], nil
+}
+
+// transformScalars applies fn to every scalar value in a YAML document and re-encodes it.
+func transformScalars(data []byte, fn func(valuePath string, node *yaml3.Node) error) ([]byte, error) {
+	var doc yaml3.Node
+	if err := yaml3.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if doc.Kind == 0 {
+		return data, nil
+	}
+
+	if err := walkScalars(&doc, "", fn); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml3.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// walkScalars visits scalar values with their dotted paths.
+func walkScalars(node *yaml3.Node, valuePath string, fn func(string, *yaml3.Node) error) error {
+	switch node.Kind {
+	case yaml3.DocumentNode:
+		for _, child := range node.Content {
+			if err := walkScalars(child, valuePath, fn); err != nil {
+				return err
+			}
+		}
+	case yaml3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := walkScalars(node.Content[i+1], joinPath(valuePath, node.Content[i].Value), fn); err != nil {
+				return err
+			}
+		}
+	case yaml3.SequenceNode:
+		for i, child := range node.Content {
+			if err := walkScalars(child, joinPath(valuePath, strconv.Itoa(i)), fn); err != nil {
+				return err
+			}
+		}
+	case yaml3.ScalarNode:
+		return fn(valuePath, node)
+	case yaml3.AliasNode:
+		// Aliases point at nodes that are visited where they are defined
+	}
+	return nil
+}
+
+// matchesAny reports whether the dotted path matches any of the patterns.
+func matchesAny(valuePath string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, valuePath); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// joinPath joins a parent path and a key.
+func joinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
@@ -0,0 +1,231 @@
+package values
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+func TestEncryptDecryptYAML(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("Failed to generate identity: %v", err)
+	}
+
+	input := `# Database settings
+database:
+  host: localhost
+  password: s3cret # rotate yearly
+  port: 5432
+users:
+  - name: admin
+    password: "1234"
+`
+	encrypted, count, err := EncryptYAML([]byte(input), []age.Recipient{identity.Recipient()}, []string{"*.password"})
+	if err != nil {
+		t.Fatalf("EncryptYAML failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 encrypted values, got %d", count)
+	}
+
+	text := string(encrypted)
+	if strings.Contains(text, "s3cret") || strings.Contains(text, `"1234"`) {
+		t.Errorf("Expected secrets to be encrypted, got:\n%s", text)
+	}
+	for _, fragment := range []string{"# Database settings", "host: localhost", "password: !age |", "# rotate yearly"} {
+		if !strings.Contains(text, fragment) {
+			t.Errorf("Expected encrypted document to contain %q, got:\n%s", fragment, text)
+		}
+	}
+
+	// Encrypting again must not double-encrypt
+	_, again, err := EncryptYAML(encrypted, []age.Recipient{identity.Recipient()}, []string{"*.password"})
+	if err != nil || again != 0 {
+		t.Errorf("Expected no values to be re-encrypted, got %d (%v)", again, err)
+	}
+
+	decrypted, count, err := DecryptYAML(encrypted, []age.Identity{identity})
+	if err != nil {
+		t.Fatalf("DecryptYAML failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 decrypted values, got %d", count)
+	}
+	if string(decrypted) != input {
+		t.Errorf("Expected round trip to restore the document.\nExpected:\n%s\nGot:\n%s", input, decrypted)
+	}
+
+	if _, _, err := DecryptYAML(encrypted, nil); err == nil {
+		t.Error("Expected error when decrypting without identities")
+	}
+}
+
+func TestLoadYAMLValuesWithAgeValues(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("Failed to generate identity: %v", err)
+	}
+
+	tempDir, err := os.MkdirTemp("", "test-age-values-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	encrypted, _, err := EncryptYAML([]byte("password: s3cret\nport: 5432\n"), []age.Recipient{identity.Recipient()}, []string{"password", "port"})
+	if err != nil {
+		t.Fatalf("EncryptYAML failed: %v", err)
+	}
+	valuesPath := filepath.Join(tempDir, "values.yaml")
+	if err := os.WriteFile(valuesPath, encrypted, 0o644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+	identityPath := filepath.Join(tempDir, "key.txt")
+	if err := os.WriteFile(identityPath, []byte(identity.String()+"\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write identity: %v", err)
+	}
+
+	loader := NewLoader()
+	if _, err := loader.LoadYAMLValues(valuesPath); err == nil {
+		t.Error("Expected error when loading encrypted values without identity")
+	}
+
+	identities, err := LoadAgeIdentities([]string{identityPath})
+	if err != nil {
+		t.Fatalf("LoadAgeIdentities failed: %v", err)
+	}
+	loader.SetAgeIdentities(identities)
+
+	loaded, err := loader.LoadYAMLValues(valuesPath)
+	if err != nil {
+		t.Fatalf("LoadYAMLValues failed: %v", err)
+	}
+	expected := map[string]any{"password": "s3cret", "port": 5432}
+	if !reflect.DeepEqual(loaded, expected) {
+		t.Errorf("Expected %v, got %v", expected, loaded)
+	}
+}
+
+func TestLoadYAMLValuesWithAgeFile(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("Failed to generate identity: %v", err)
+	}
+
+	tempDir, err := os.MkdirTemp("", "test-age-file-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	plaintext := "database:\n  password: s3cret\n  port: 5432\n"
+	tests := []struct {
+		name    string
+		armored bool
+	}{
+		{name: "armored", armored: true},
+		{name: "binary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			var dst io.WriteCloser = nopCloser{&buf}
+			if tt.armored {
+				dst = armor.NewWriter(&buf)
+			}
+			writer, err := age.Encrypt(dst, identity.Recipient())
+			if err != nil {
+				t.Fatalf("Encrypt failed: %v", err)
+			}
+			if _, err := io.WriteString(writer, plaintext); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+			if err := dst.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+
+			valuesPath := filepath.Join(tempDir, tt.name+".yaml.age")
+			if err := os.WriteFile(valuesPath, buf.Bytes(), 0o644); err != nil {
+				t.Fatalf("Failed to write values: %v", err)
+			}
+
+			loader := NewLoader()
+			if _, err := loader.LoadYAMLValues(valuesPath); err == nil {
+				t.Error("Expected error when loading an encrypted file without identity")
+			}
+
+			loader.SetAgeIdentities([]age.Identity{identity})
+			loaded, err := loader.LoadYAMLValues(valuesPath)
+			if err != nil {
+				t.Fatalf("LoadYAMLValues failed: %v", err)
+			}
+			expected := map[string]any{"database": map[any]any{"password": "s3cret", "port": 5432}}
+			if !reflect.DeepEqual(loaded, expected) {
+				t.Errorf("Expected %v, got %v", expected, loaded)
+			}
+
+			subset, err := loader.LoadYAMLValuesSubset(valuesPath, []string{"database"})
+			if err != nil {
+				t.Fatalf("LoadYAMLValuesSubset failed: %v", err)
+			}
+			if len(subset) != 1 {
+				t.Errorf("Expected the database key, got %v", subset)
+			}
+		})
+	}
+}
+
+// nopCloser adds a no-op Close to a writer.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func TestParseAgeRecipients(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("Failed to generate identity: %v", err)
+	}
+
+	recipients, err := ParseAgeRecipients([]string{identity.Recipient().String()})
+	if err != nil || len(recipients) != 1 {
+		t.Errorf("Expected 1 recipient, got %d (%v)", len(recipients), err)
+	}
+
+	if _, err := ParseAgeRecipients([]string{"not-a-key"}); err == nil {
+		t.Error("Expected error for invalid recipient")
+	}
+}
+
+func TestMatchesAny(t *testing.T) {
+	tests := []struct {
+		path     string
+		patterns []string
+		expected bool
+	}{
+		{"database.password", []string{"*.password"}, true},
+		{"a.b.c.password", []string{"*.password"}, true},
+		{"password", []string{"*.password"}, false},
+		{"users.0.token", []string{"users.*.token"}, true},
+		{"database.host", []string{"*.password", "*.token"}, false},
+	}
+
+	for _, tt := range tests {
+		if result := matchesAny(tt.path, tt.patterns); result != tt.expected {
+			t.Errorf("matchesAny(%s, %v) = %t, expected %t", tt.path, tt.patterns, result, tt.expected)
+		}
+	}
+}
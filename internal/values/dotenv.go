@@ -0,0 +1,206 @@
+package values
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// LoadEnvFiles loads values from dotenv files and converts keys to camelCase.
+// Later files override earlier ones.
+func (l *Loader) LoadEnvFiles(envFiles []string) (map[string]any, error) {
+	envValues := make(map[string]any)
+
+	for _, envFile := range envFiles {
+		data, err := os.ReadFile(envFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read env file: %w", err)
+		}
+
+		parsed, err := ParseDotenv(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse env file %s: %w", envFile, err)
+		}
+
+		for key, value := range parsed {
+			envValues[l.toCamelCase(key)] = value
+		}
+	}
+
+	return envValues, nil
+}
+
+// ParseDotenv parses dotenv formatted text into a map of variables.
+// It supports comments, an optional "export" prefix, and single or double quoted values.
+func ParseDotenv(data string) (map[string]string, error) {
+	vars := make(map[string]string)
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: invalid format (expected KEY=value)", lineNumber)
+		}
+
+		parsed, err := parseDotenvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		vars[key] = parsed
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return vars, nil
+}
+
+// parseDotenvValue unquotes a dotenv value and strips trailing comments from unquoted values.
+func parseDotenvValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	switch quote := value[0]; quote {
+	case '\'', '"':
+		end := strings.LastIndexByte(value, quote)
+		if end == 0 {
+			return "", fmt.Errorf("unterminated quoted value")
+		}
+		inner := value[1:end]
+		if quote == '\'' {
+			return inner, nil
+		}
+		return unescapeDotenv(inner), nil
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		return value, nil
+	}
+}
+
+// unescapeDotenv expands the escape sequences allowed in double quoted values.
+func unescapeDotenv(value string) string {
+	replacer := strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+	return replacer.Replace(value)
+}
+
+// dotenvSafeValue matches values written without quotes.
+var dotenvSafeValue = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=-]*$`)
+
+// FormatDotenv flattens values into dotenv lines sorted by variable name. Nested keys are
+// joined with underscores and converted to UPPER_SNAKE_CASE, so app.dbHost becomes
+// APP_DB_HOST; list items use their index as a key. Values are double quoted when they
+// hold characters other than letters, digits and common punctuation.
+func FormatDotenv(values map[string]any) ([]byte, error) {
+	vars := make(map[string]string)
+	sources := make(map[string]string)
+
+	var flatten func(v any, name, path string) error
+	flatten = func(v any, name, path string) error {
+		if m, ok := stringKeyMap(v); ok {
+			for key, value := range m {
+				if err := flatten(value, joinEnvName(name, envName(key)), path+"."+key); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if list, ok := v.([]any); ok {
+			for i, item := range list {
+				if err := flatten(item, joinEnvName(name, strconv.Itoa(i)), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		if existing, ok := sources[name]; ok {
+			first, second := existing, path
+			if second < first {
+				first, second = second, first
+			}
+			return fmt.Errorf("values %s and %s both export as %s", first, second, name)
+		}
+		sources[name] = path
+		vars[name] = FormatDotenvValue(v)
+		return nil
+	}
+	if err := flatten(values, "", ""); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%s=%s\n", name, vars[name])
+	}
+	return buf.Bytes(), nil
+}
+
+// envName converts a camelCase or dotted key to UPPER_SNAKE_CASE.
+func envName(key string) string {
+	var b strings.Builder
+	runes := []rune(key)
+	for i, r := range runes {
+		switch {
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])):
+			b.WriteByte('_')
+			b.WriteRune(r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(unicode.ToUpper(r))
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// joinEnvName appends a segment to a variable name.
+func joinEnvName(name, segment string) string {
+	if name == "" {
+		return segment
+	}
+	return name + "_" + segment
+}
+
+// FormatDotenvValue formats a scalar value for a dotenv file, double quoting and escaping
+// it when needed.
+func FormatDotenvValue(v any) string {
+	var value string
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		value = x
+	default:
+		value = FormatValue(x)
+	}
+	if dotenvSafeValue.MatchString(value) {
+		return value
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + replacer.Replace(value) + `"`
+}
@@ -0,0 +1,177 @@
+package values
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		expected  map[string]string
+		wantError bool
+	}{
+		{
+			name:     "simple pairs",
+			input:    "DATABASE_HOST=localhost\nDATABASE_PORT=5432\n",
+			expected: map[string]string{"DATABASE_HOST": "localhost", "DATABASE_PORT": "5432"},
+		},
+		{
+			name:     "comments and blank lines",
+			input:    "# comment\n\nAPP_NAME=myapp # inline comment\n",
+			expected: map[string]string{"APP_NAME": "myapp"},
+		},
+		{
+			name:     "export prefix",
+			input:    "export API_URL=https://example.com/path?a=b",
+			expected: map[string]string{"API_URL": "https://example.com/path?a=b"},
+		},
+		{
+			name:     "quoted values",
+			input:    "DOUBLE=\"line1\\nline2 # not a comment\"\nSINGLE='raw \\n value'\nEMPTY=",
+			expected: map[string]string{"DOUBLE": "line1\nline2 # not a comment", "SINGLE": "raw \\n value", "EMPTY": ""},
+		},
+		{name: "missing equals", input: "INVALID", wantError: true},
+		{name: "unterminated quote", input: "KEY=\"value", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseDotenv(tt.input)
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestLoadEnvFiles(t *testing.T) {
+	loader := NewLoader()
+
+	tempDir, err := os.MkdirTemp("", "test-env-files-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	basePath := filepath.Join(tempDir, ".env")
+	prodPath := filepath.Join(tempDir, ".env.production")
+	if err := os.WriteFile(basePath, []byte("DATABASE_HOST=localhost\nLOG_LEVEL=debug\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	if err := os.WriteFile(prodPath, []byte("DATABASE_HOST=db.prod\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	values, err := loader.LoadEnvFiles([]string{basePath, prodPath})
+	if err != nil {
+		t.Fatalf("LoadEnvFiles failed: %v", err)
+	}
+
+	expected := map[string]any{"databaseHost": "db.prod", "logLevel": "debug"}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+
+	if _, err := loader.LoadEnvFiles([]string{filepath.Join(tempDir, "missing.env")}); err == nil {
+		t.Error("Expected error for missing env file")
+	}
+}
+
+func TestFormatDotenv(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    map[string]any
+		expected  string
+		wantError string
+	}{
+		{
+			name: "nested camelCase keys",
+			values: map[string]any{
+				"app":      map[string]any{"dbHost": "db.internal", "tlsV2": map[string]any{"enabled": true}},
+				"logLevel": "debug",
+			},
+			expected: "APP_DB_HOST=db.internal\nAPP_TLS_V2_ENABLED=true\nLOG_LEVEL=debug\n",
+		},
+		{
+			name:     "lists",
+			values:   map[string]any{"servers": []any{map[string]any{"host": "a"}, map[string]any{"host": "b"}}},
+			expected: "SERVERS_0_HOST=a\nSERVERS_1_HOST=b\n",
+		},
+		{
+			name: "scalars",
+			values: map[string]any{
+				"port":  8080,
+				"ratio": 0.5,
+				"unset": nil,
+				"url":   "https://example.com/a?b=c",
+			},
+			expected: "PORT=8080\nRATIO=0.5\nUNSET=\nURL=\"https://example.com/a?b=c\"\n",
+		},
+		{
+			name:     "quoted values",
+			values:   map[string]any{"greeting": "hello \"world\"", "key": "line1\nline2", "path": `C:\temp`, "empty": ""},
+			expected: "EMPTY=\nGREETING=\"hello \\\"world\\\"\"\nKEY=\"line1\\nline2\"\nPATH=\"C:\\\\temp\"\n",
+		},
+		{
+			name:     "special characters in keys",
+			values:   map[string]any{"feature-flags": map[string]any{"new.ui": true}},
+			expected: "FEATURE_FLAGS_NEW_UI=true\n",
+		},
+		{
+			name:      "colliding keys",
+			values:    map[string]any{"app": map[string]any{"dbHost": "a", "db_host": "b"}},
+			wantError: "values .app.dbHost and .app.db_host both export as APP_DB_HOST",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := FormatDotenv(tt.values)
+			if tt.wantError != "" {
+				if err == nil || err.Error() != tt.wantError {
+					t.Errorf("Expected error %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, data)
+			}
+
+			// Exported files can be read back
+			if _, err := ParseDotenv(string(data)); err != nil {
+				t.Errorf("Failed to parse exported dotenv: %v", err)
+			}
+		})
+	}
+}
+
+func TestFormatDotenvRoundTrip(t *testing.T) {
+	values := map[string]any{"message": "a \"quoted\" # value\twith\\escapes\n"}
+	data, err := FormatDotenv(values)
+	if err != nil {
+		t.Fatalf("FormatDotenv failed: %v", err)
+	}
+	parsed, err := ParseDotenv(string(data))
+	if err != nil {
+		t.Fatalf("ParseDotenv failed: %v", err)
+	}
+	if parsed["MESSAGE"] != values["message"] {
+		t.Errorf("Expected %q after a round trip, got %q", values["message"], parsed["MESSAGE"])
+	}
+}
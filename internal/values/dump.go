@@ -0,0 +1,37 @@
+package values
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// Dump encodes merged values as YAML, JSON or dotenv, with keys sorted, for inspection
+// or for processes reading their configuration from the environment.
+func Dump(values map[string]any, format string) ([]byte, error) {
+	switch format {
+	case "yaml":
+		var buf bytes.Buffer
+		encoder := yaml3.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(EncodeYAMLNumbers(jsonCompatible(values))); err != nil {
+			return nil, fmt.Errorf("failed to encode values as YAML: %w", err)
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, fmt.Errorf("failed to encode values as YAML: %w", err)
+		}
+		return buf.Bytes(), nil
+	case "json":
+		data, err := json.MarshalIndent(jsonCompatible(values), "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode values as JSON: %w", err)
+		}
+		return append(data, '\n'), nil
+	case "dotenv":
+		return FormatDotenv(values)
+	default:
+		return nil, fmt.Errorf("unsupported values format '%s' (expected yaml, json or dotenv)", format)
+	}
+}
@@ -0,0 +1,52 @@
+package values
+
+import "testing"
+
+func TestDump(t *testing.T) {
+	values := map[string]any{
+		"app": map[interface{}]interface{}{
+			"name": "web",
+			"port": 8080,
+		},
+		"hosts":   []any{"a.example.com", "b.example.com"},
+		"enabled": true,
+	}
+
+	tests := []struct {
+		format    string
+		expected  string
+		wantError bool
+	}{
+		{
+			format:   "yaml",
+			expected: "app:\n  name: web\n  port: 8080\nenabled: true\nhosts:\n  - a.example.com\n  - b.example.com\n",
+		},
+		{
+			format:   "json",
+			expected: "{\n  \"app\": {\n    \"name\": \"web\",\n    \"port\": 8080\n  },\n  \"enabled\": true,\n  \"hosts\": [\n    \"a.example.com\",\n    \"b.example.com\"\n  ]\n}\n",
+		},
+		{
+			format:   "dotenv",
+			expected: "APP_NAME=web\nAPP_PORT=8080\nENABLED=true\nHOSTS_0=a.example.com\nHOSTS_1=b.example.com\n",
+		},
+		{format: "toml", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			data, err := Dump(values, tt.format)
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error for unsupported format")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, data)
+			}
+		})
+	}
+}
@@ -1,22 +1,45 @@
 package values
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
 
+	"filippo.io/age"
 	"gopkg.in/yaml.v2"
+	yaml3 "gopkg.in/yaml.v3"
 )
 
 // Loader handles loading values from various sources.
-type Loader struct{}
+type Loader struct {
+	ageIdentities   []age.Identity
+	mergeStrategies MergeStrategies
+	preciseNumbers  bool
+}
 
 // NewLoader creates a new values loader.
 func NewLoader() *Loader {
 	return &Loader{}
 }
 
+// SetAgeIdentities sets the identities used to decrypt !age values.
+func (l *Loader) SetAgeIdentities(identities []age.Identity) {
+	l.ageIdentities = identities
+}
+
+// SetMergeStrategies sets how lists present in several sources are merged.
+func (l *Loader) SetMergeStrategies(strategies MergeStrategies) {
+	l.mergeStrategies = strategies
+}
+
+// SetPreciseNumbers keeps numbers from YAML files, --set and --set-json as json.Number
+// holding the literal as written, instead of converting them to int or float64.
+func (l *Loader) SetPreciseNumbers(enabled bool) {
+	l.preciseNumbers = enabled
+}
+
 // LoadYAMLValues loads values from a YAML file.
 func (l *Loader) LoadYAMLValues(valuesFile string) (map[string]any, error) {
 	values := make(map[string]any)
@@ -25,43 +48,235 @@ func (l *Loader) LoadYAMLValues(valuesFile string) (map[string]any, error) {
 		return values, nil
 	}
 
+	data, err := l.readValuesFile(valuesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.preciseNumbers {
+		values, err = decodePrecise(data)
+	} else {
+		err = yaml.Unmarshal(data, &values)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	return values, nil
+}
+
+// readValuesFile reads a YAML values file, decrypting it when it is encrypted as a whole
+// (values.yaml.age) and decrypting its inline !age values, and rejects duplicate keys.
+func (l *Loader) readValuesFile(valuesFile string) ([]byte, error) {
 	data, err := os.ReadFile(valuesFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read values file: %w", err)
 	}
 
-	err = yaml.Unmarshal(data, &values)
+	if IsAgeFile(valuesFile) {
+		data, err = DecryptFile(data, l.ageIdentities)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt values file: %w", err)
+		}
+	}
+
+	// Decrypt inline !age values before parsing
+	if HasAgeValues(data) {
+		data, _, err = DecryptYAML(data, l.ageIdentities)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt values file: %w", err)
+		}
+	}
+
+	if err := checkStrictYAML(data); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	return data, nil
+}
+
+// LoadYAMLValuesSubset loads only the given top-level keys from a YAML file. The file is
+// parsed into yaml.v3 nodes and only the selected subtrees are decoded, which keeps
+// startup fast for very large generated values files.
+func (l *Loader) LoadYAMLValuesSubset(valuesFile string, keys []string) (map[string]any, error) {
+	values := make(map[string]any)
+
+	if valuesFile == "" {
+		return values, nil
+	}
+
+	data, err := l.readValuesFile(valuesFile)
 	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml3.Node
+	if err := yaml3.Unmarshal(data, &doc); err != nil {
 		return nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return values, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml3.MappingNode {
+		return nil, fmt.Errorf("failed to parse YAML: values document must be a mapping")
+	}
+
+	// Root-level merge keys pull in other subtrees, so decode the whole document
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "<<" {
+			return l.LoadYAMLValues(valuesFile)
+		}
+	}
+
+	wanted := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		wanted[key] = true
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key := root.Content[i].Value
+		if !wanted[key] {
+			continue
+		}
+
+		var value any
+		if l.preciseNumbers {
+			var precise preciseValue
+			err = root.Content[i+1].Decode(&precise)
+			value = precise.value
+		} else {
+			err = root.Content[i+1].Decode(&value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode value %s: %w", key, err)
+		}
+		values[key] = value
+	}
 
 	return values, nil
 }
 
 // LoadEnvValues loads values from environment variables and converts keys to camelCase.
 func (l *Loader) LoadEnvValues() map[string]any {
+	return l.LoadPrefixedEnvValues("")
+}
+
+// LoadPrefixedEnvValues loads only environment variables starting with prefix, stripping
+// the prefix before converting keys to camelCase. An empty prefix loads every variable.
+func (l *Loader) LoadPrefixedEnvValues(prefix string) map[string]any {
+	envValues, _ := l.LoadPrefixedEnvValuesWithNames(prefix)
+	return envValues
+}
+
+// LoadPrefixedEnvValuesWithNames is LoadPrefixedEnvValues, also returning the name of
+// the environment variable each key was read from.
+func (l *Loader) LoadPrefixedEnvValuesWithNames(prefix string) (map[string]any, map[string]string) {
 	envValues := make(map[string]any)
+	names := make(map[string]string)
 
 	for _, env := range os.Environ() {
-		// Split environment variable into key-value pair
-		for i := 0; i < len(env); i++ {
-			if env[i] == '=' {
-				key := env[:i]
-				value := env[i+1:]
-
-				// Convert environment variable key to camelCase
-				camelKey := l.toCamelCase(key)
-				envValues[camelKey] = value
-				break
-			}
+		name, value, ok := strings.Cut(env, "=")
+		if !ok || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		key := strings.TrimPrefix(name, prefix)
+		if key == "" {
+			continue
 		}
+
+		// Convert environment variable key to camelCase
+		key = l.toCamelCase(key)
+		envValues[key] = value
+		names[key] = name
 	}
 
-	return envValues
+	return envValues, names
 }
 
 // ParseSetValues parses command-line set values.
 func (l *Loader) ParseSetValues(setValues []string) (map[string]any, error) {
+	return l.parseSetValues(setValues, func(value string) (any, error) { return l.convertValue(value), nil })
+}
+
+// ParseSetStringValues parses command-line set values, keeping every value as a string.
+func (l *Loader) ParseSetStringValues(setValues []string) (map[string]any, error) {
+	return l.parseSetValues(setValues, func(value string) (any, error) { return value, nil })
+}
+
+// ParseSetFileValues parses command-line key=path pairs, using each file's content as the value.
+func (l *Loader) ParseSetFileValues(setValues []string) (map[string]any, error) {
+	return l.parseSetValues(setValues, func(path string) (any, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file for set-file value: %w", err)
+		}
+		return string(data), nil
+	})
+}
+
+// ParseSetJSONValues parses command-line key=<json> pairs. Each entry holds a single pair,
+// since JSON values may contain commas.
+func (l *Loader) ParseSetJSONValues(setValues []string) (map[string]any, error) {
+	parsedValues := make(map[string]any)
+
+	for _, setValue := range setValues {
+		parts := strings.SplitN(setValue, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid set-json value format: %s (expected key=<json>)", setValue)
+		}
+
+		key := strings.TrimSpace(parts[0])
+		decoder := json.NewDecoder(strings.NewReader(parts[1]))
+		decoder.UseNumber()
+
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("invalid JSON for key %s: %w", key, err)
+		}
+		if decoder.More() {
+			return nil, fmt.Errorf("invalid JSON for key %s: unexpected data after value", key)
+		}
+
+		if !l.preciseNumbers {
+			value = normalizeJSONNumbers(value)
+		}
+		err := l.setNestedValue(parsedValues, key, value)
+		if err != nil {
+			return nil, fmt.Errorf("error setting nested value for key %s: %w", key, err)
+		}
+	}
+
+	return parsedValues, nil
+}
+
+// normalizeJSONNumbers converts json.Number values to int when integral and float64 otherwise.
+func normalizeJSONNumbers(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = normalizeJSONNumbers(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = normalizeJSONNumbers(item)
+		}
+		return v
+	case json.Number:
+		if intVal, err := strconv.Atoi(v.String()); err == nil {
+			return intVal
+		}
+		floatVal, _ := v.Float64()
+		return floatVal
+	default:
+		return v
+	}
+}
+
+// parseSetValues parses key=value pairs, converting each value with convert.
+func (l *Loader) parseSetValues(setValues []string, convert func(string) (any, error)) (map[string]any, error) {
 	parsedValues := make(map[string]any)
 
 	for _, setValue := range setValues {
@@ -84,10 +299,13 @@ func (l *Loader) ParseSetValues(setValues []string) (map[string]any, error) {
 			value := strings.TrimSpace(parts[1])
 
 			// Convert value to appropriate type
-			convertedValue := l.convertValue(value)
+			convertedValue, err := convert(value)
+			if err != nil {
+				return nil, fmt.Errorf("error converting value for key %s: %w", key, err)
+			}
 
 			// Handle nested keys (e.g., app.name=value)
-			err := l.setNestedValue(parsedValues, key, convertedValue)
+			err = l.setNestedValue(parsedValues, key, convertedValue)
 			if err != nil {
 				return nil, fmt.Errorf("error setting nested value for key %s: %w", key, err)
 			}
@@ -109,7 +327,7 @@ func (l *Loader) MergeValues(yamlValues, envValues, setValues, configValues map[
 	l.deepMerge(merged, envValues)
 
 	// Override with --set values (highest precedence)
-	l.deepMerge(merged, setValues)
+	l.overrideMerge(merged, setValues)
 
 	// Add any values from config
 	l.deepMerge(merged, configValues)
@@ -119,6 +337,11 @@ func (l *Loader) MergeValues(yamlValues, envValues, setValues, configValues map[
 
 // convertValue attempts to convert string values to appropriate types.
 func (l *Loader) convertValue(value string) any {
+	// null removes the key when merged over other values
+	if value == "null" {
+		return nil
+	}
+
 	// Try to convert to boolean
 	if strings.ToLower(value) == "true" {
 		return true
@@ -127,6 +350,11 @@ func (l *Loader) convertValue(value string) any {
 		return false
 	}
 
+	// Keep numbers as written when requested
+	if l.preciseNumbers && jsonNumberPattern.MatchString(value) {
+		return json.Number(value)
+	}
+
 	// Try to convert to integer
 	if intVal, err := strconv.Atoi(value); err == nil {
 		return intVal
@@ -189,22 +417,71 @@ func (l *Loader) toCamelCase(s string) string {
 	return result
 }
 
-// deepMerge recursively merges source map into destination map.
+// deepMerge recursively merges source map into destination map. Lists are merged with
+// the configured merge strategies, and null source values are kept as nil.
 func (l *Loader) deepMerge(dst, src map[string]any) {
+	l.merge(dst, src, "", false)
+}
+
+// overrideMerge is deepMerge for --set and --set-json values: a null source value
+// removes the key from the destination, so --set key=null unsets a YAML default, and is
+// dropped when there is no key to remove.
+func (l *Loader) overrideMerge(dst, src map[string]any) {
+	l.merge(dst, src, "", true)
+}
+
+// merge merges src into dst, the map at the dotted path. With unset, null values remove
+// keys instead of being stored.
+func (l *Loader) merge(dst, src map[string]any, path string, unset bool) {
 	for k, v := range src {
-		if srcMap, srcIsMap := v.(map[string]any); srcIsMap {
-			if dstMap, dstIsMap := dst[k].(map[string]any); dstIsMap {
+		keyPath := k
+		if path != "" {
+			keyPath = path + "." + k
+		}
+
+		if v == nil && unset {
+			delete(dst, k)
+			continue
+		}
+
+		if srcMap, srcIsMap := stringKeyMap(v); srcIsMap {
+			if dstMap, dstIsMap := stringKeyMap(dst[k]); dstIsMap {
 				// Both are maps, merge recursively
-				l.deepMerge(dstMap, srcMap)
+				dst[k] = dstMap
+				l.merge(dstMap, srcMap, keyPath, unset)
 			} else {
 				// Destination is not a map, replace it
 				newMap := make(map[string]any)
-				l.deepMerge(newMap, srcMap)
+				l.merge(newMap, srcMap, keyPath, unset)
 				dst[k] = newMap
 			}
+		} else if srcList, srcIsList := v.([]any); srcIsList {
+			if dstList, dstIsList := dst[k].([]any); dstIsList {
+				// Both are lists, merge with the strategy for this key
+				dst[k] = l.mergeLists(dstList, srcList, keyPath)
+			} else {
+				dst[k] = v
+			}
 		} else {
 			// Not a map, just set the value
 			dst[k] = v
 		}
 	}
 }
+
+// stringKeyMap returns v as a map with string keys, converting the interface-keyed maps
+// produced by the YAML decoder so they merge with values from other sources.
+func stringKeyMap(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case map[interface{}]interface{}:
+		converted := make(map[string]any, len(m))
+		for key, value := range m {
+			converted[fmt.Sprint(key)] = value
+		}
+		return converted, true
+	default:
+		return nil, false
+	}
+}
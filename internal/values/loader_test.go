@@ -2,6 +2,7 @@ package values
 
 import (
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
@@ -92,6 +93,31 @@ func TestLoadEnvValues(t *testing.T) {
 	}
 }
 
+func TestLoadPrefixedEnvValues(t *testing.T) {
+	loader := NewLoader()
+
+	os.Setenv("TEMPLATER_VAL_DATABASE_HOST", "db.internal")
+	os.Setenv("TEMPLATER_VAL_", "ignored")
+	os.Setenv("UNPREFIXED_HOST", "localhost")
+	defer func() {
+		os.Unsetenv("TEMPLATER_VAL_DATABASE_HOST")
+		os.Unsetenv("TEMPLATER_VAL_")
+		os.Unsetenv("UNPREFIXED_HOST")
+	}()
+
+	values := loader.LoadPrefixedEnvValues("TEMPLATER_VAL_")
+
+	if values["databaseHost"] != "db.internal" {
+		t.Errorf("Expected databaseHost to be 'db.internal', got %v", values["databaseHost"])
+	}
+	if _, exists := values["unprefixedHost"]; exists {
+		t.Error("Expected variables without the prefix to be skipped")
+	}
+	if len(values) != 1 {
+		t.Errorf("Expected 1 value, got %d: %v", len(values), values)
+	}
+}
+
 func TestToCamelCase(t *testing.T) {
 	loader := NewLoader()
 
@@ -192,6 +218,144 @@ func TestParseSetValues(t *testing.T) {
 	}
 }
 
+func TestParseSetStringValues(t *testing.T) {
+	loader := NewLoader()
+
+	tests := []struct {
+		name      string
+		setValues []string
+		expected  map[string]interface{}
+		wantError bool
+	}{
+		{
+			name:      "numbers stay strings",
+			setValues: []string{"version=1.10,port=8080"},
+			expected:  map[string]interface{}{"version": "1.10", "port": "8080"},
+		},
+		{
+			name:      "booleans stay strings",
+			setValues: []string{"app.enabled=true"},
+			expected: map[string]interface{}{
+				"app": map[string]interface{}{"enabled": "true"},
+			},
+		},
+		{
+			name:      "invalid format",
+			setValues: []string{"invalidformat"},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := loader.ParseSetStringValues(tt.setValues)
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestParseSetFileValues(t *testing.T) {
+	loader := NewLoader()
+
+	tempDir, err := os.MkdirTemp("", "test-set-file-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	certPath := filepath.Join(tempDir, "tls.crt")
+	certContent := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+	if err := os.WriteFile(certPath, []byte(certContent), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	numberPath := filepath.Join(tempDir, "replicas")
+	if err := os.WriteFile(numberPath, []byte("3"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	result, err := loader.ParseSetFileValues([]string{"tls.cert=" + certPath + ",replicas=" + numberPath})
+	if err != nil {
+		t.Fatalf("ParseSetFileValues failed: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"tls":      map[string]interface{}{"cert": certContent},
+		"replicas": "3",
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+
+	if _, err := loader.ParseSetFileValues([]string{"key=" + filepath.Join(tempDir, "missing")}); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func TestParseSetJSONValues(t *testing.T) {
+	loader := NewLoader()
+
+	tests := []struct {
+		name      string
+		setValues []string
+		expected  map[string]interface{}
+		wantError bool
+	}{
+		{
+			name:      "nested object",
+			setValues: []string{`resources={"limits":{"cpu":"500m","replicas":2,"ratio":0.5}}`},
+			expected: map[string]interface{}{
+				"resources": map[string]interface{}{
+					"limits": map[string]interface{}{"cpu": "500m", "replicas": 2, "ratio": 0.5},
+				},
+			},
+		},
+		{
+			name:      "array at dotted key",
+			setValues: []string{`app.hosts=["a.example.com","b.example.com"]`},
+			expected: map[string]interface{}{
+				"app": map[string]interface{}{"hosts": []interface{}{"a.example.com", "b.example.com"}},
+			},
+		},
+		{
+			name:      "scalar values",
+			setValues: []string{`enabled=true`, `name="demo"`},
+			expected:  map[string]interface{}{"enabled": true, "name": "demo"},
+		},
+		{name: "invalid json", setValues: []string{`key={"a":}`}, wantError: true},
+		{name: "trailing data", setValues: []string{`key=1 2`}, wantError: true},
+		{name: "missing key", setValues: []string{`={"a":1}`}, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := loader.ParseSetJSONValues(tt.setValues)
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
 func TestConvertValue(t *testing.T) {
 	loader := NewLoader()
 
@@ -210,6 +374,8 @@ func TestConvertValue(t *testing.T) {
 		{"0.5", 0.5},
 		{"hello", "hello"},
 		{"", ""},
+		{"null", nil},
+		{"NULL", "NULL"},
 	}
 
 	for _, tt := range tests {
@@ -381,3 +547,128 @@ func TestDeepMerge(t *testing.T) {
 		t.Errorf("Expected %v, got %v", expected, dst)
 	}
 }
+
+func TestDeepMergeYAMLMaps(t *testing.T) {
+	loader := NewLoader()
+
+	// Nested maps decoded from YAML files use interface keys
+	dst := map[string]interface{}{
+		"app": map[interface{}]interface{}{
+			"name": "yaml-app",
+			"tls":  map[interface{}]interface{}{"enabled": false, "port": 443},
+		},
+	}
+
+	src := map[string]interface{}{
+		"app": map[string]interface{}{
+			"tls": map[string]interface{}{"enabled": true},
+		},
+	}
+
+	loader.deepMerge(dst, src)
+
+	expected := map[string]interface{}{
+		"app": map[string]interface{}{
+			"name": "yaml-app",
+			"tls":  map[string]interface{}{"enabled": true, "port": 443},
+		},
+	}
+
+	if !reflect.DeepEqual(dst, expected) {
+		t.Errorf("Expected %v, got %v", expected, dst)
+	}
+}
+
+func TestMergeValuesNull(t *testing.T) {
+	loader := NewLoader()
+
+	yamlValues := map[string]interface{}{
+		"app": map[interface{}]interface{}{
+			"name":        "yaml-app",
+			"legacyFlag":  true,
+			"annotations": map[interface{}]interface{}{"team": "web"},
+		},
+		"unset": nil,
+	}
+
+	setValues, err := loader.ParseSetValues([]string{"app.legacyFlag=null,app.annotations=null,app.extra=null,missing=null"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	stringValues, err := loader.ParseSetStringValues([]string{"literal=null"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result := loader.MergeValues(yamlValues, nil, setValues, stringValues)
+
+	expected := map[string]interface{}{
+		"app": map[string]interface{}{
+			"name": "yaml-app", // legacyFlag and annotations removed, extra never added
+		},
+		"unset": nil, // null in the values file is kept
+		// missing is null with nothing to remove, so it stays undefined
+		"literal": "null", // --set-string keeps the string
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
+func TestMergeLayersNull(t *testing.T) {
+	loader := NewLoader()
+
+	layers := []Layer{
+		{Source: "values file values.yaml", Values: map[string]any{"app": map[string]any{"name": "web", "debug": true}, "unset": nil}},
+		{Source: "values-from ssm:///app/", Values: map[string]any{"app": map[string]any{"debug": nil}}},
+		{Source: "--set", Values: map[string]any{"app": map[string]any{"name": nil}, "missing": nil}, Unset: true},
+	}
+
+	// Only the --set layer removes keys; nulls from other sources are kept
+	expected := map[string]any{
+		"app":   map[string]any{"debug": nil},
+		"unset": nil,
+	}
+	if result := loader.MergeLayers(layers); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
+func TestLoadYAMLValuesSubset(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-lazy-values-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// The undecodable "generated" key shows that unselected subtrees are never decoded
+	content := `app:
+  name: web
+  port: 8080
+generated: !!binary "not base64!"
+hosts: [a, b]
+`
+	valuesPath := filepath.Join(tempDir, "values.yaml")
+	if err := os.WriteFile(valuesPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+
+	loader := NewLoader()
+	values, err := loader.LoadYAMLValuesSubset(valuesPath, []string{"app", "hosts", "missing"})
+	if err != nil {
+		t.Fatalf("LoadYAMLValuesSubset failed: %v", err)
+	}
+
+	expected := map[string]any{
+		"app":   map[string]any{"name": "web", "port": 8080},
+		"hosts": []any{"a", "b"},
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+
+	if _, err := loader.LoadYAMLValuesSubset(valuesPath, []string{"generated"}); err == nil {
+		t.Error("Expected error when decoding a selected invalid value")
+	}
+}
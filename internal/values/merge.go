@@ -0,0 +1,163 @@
+package values
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Merge strategies for lists present in more than one values source.
+const (
+	MergeReplace = "replace"
+	MergeAppend  = "append"
+	MergeByIndex = "merge-by-index"
+	MergeByKey   = "merge-by-key"
+)
+
+// MergeStrategy controls how a list overriding another list is merged.
+type MergeStrategy struct {
+	Kind string
+	// Key is the field identifying items with MergeByKey, e.g. name.
+	Key string
+}
+
+// MergeStrategies holds the strategy used for every list and per-key exceptions, keyed by
+// dotted value path such as ingress.hosts.
+type MergeStrategies struct {
+	Default MergeStrategy
+	Keys    map[string]MergeStrategy
+}
+
+// ParseMergeStrategies parses --merge-strategy specs. A spec is either a strategy, which
+// becomes the default, or path=strategy for a single key. merge-by-key takes the field
+// identifying items after a colon: env=merge-by-key:name. Lists are replaced by default.
+func ParseMergeStrategies(specs []string) (MergeStrategies, error) {
+	strategies := MergeStrategies{
+		Default: MergeStrategy{Kind: MergeReplace},
+		Keys:    make(map[string]MergeStrategy),
+	}
+
+	for _, spec := range specs {
+		path, kind, perKey := strings.Cut(spec, "=")
+		if !perKey {
+			path, kind = "", spec
+		}
+		path = strings.TrimSpace(path)
+		if perKey && path == "" {
+			return MergeStrategies{}, fmt.Errorf("invalid merge strategy %s (expected strategy or key=strategy)", spec)
+		}
+
+		strategy, err := parseMergeStrategy(strings.TrimSpace(kind))
+		if err != nil {
+			return MergeStrategies{}, err
+		}
+		if perKey {
+			strategies.Keys[path] = strategy
+		} else {
+			strategies.Default = strategy
+		}
+	}
+
+	return strategies, nil
+}
+
+// parseMergeStrategy parses a single strategy such as append or merge-by-key:name.
+func parseMergeStrategy(s string) (MergeStrategy, error) {
+	kind, key, _ := strings.Cut(s, ":")
+	switch kind {
+	case MergeReplace, MergeAppend, MergeByIndex:
+		if key != "" {
+			return MergeStrategy{}, fmt.Errorf("merge strategy %s does not take a key", kind)
+		}
+	case MergeByKey:
+		if key == "" {
+			return MergeStrategy{}, fmt.Errorf("merge strategy %s requires the item key, e.g. %s:name", kind, kind)
+		}
+	default:
+		names := []string{MergeReplace, MergeAppend, MergeByIndex, MergeByKey + ":<field>"}
+		sort.Strings(names)
+		return MergeStrategy{}, fmt.Errorf("unknown merge strategy '%s' (expected one of: %s)", s, strings.Join(names, ", "))
+	}
+	return MergeStrategy{Kind: kind, Key: key}, nil
+}
+
+// forPath returns the strategy for the list at the dotted path.
+func (s MergeStrategies) forPath(path string) MergeStrategy {
+	if strategy, ok := s.Keys[path]; ok {
+		return strategy
+	}
+	if s.Default.Kind == "" {
+		return MergeStrategy{Kind: MergeReplace}
+	}
+	return s.Default
+}
+
+// mergeLists merges the src list over the dst list at path.
+func (l *Loader) mergeLists(dst, src []any, path string) []any {
+	strategy := l.mergeStrategies.forPath(path)
+
+	switch strategy.Kind {
+	case MergeAppend:
+		merged := make([]any, 0, len(dst)+len(src))
+		merged = append(merged, dst...)
+		return append(merged, src...)
+	case MergeByIndex:
+		merged := append([]any{}, dst...)
+		for i, item := range src {
+			if i < len(merged) {
+				merged[i] = l.mergeItem(merged[i], item, path)
+			} else {
+				merged = append(merged, item)
+			}
+		}
+		return merged
+	case MergeByKey:
+		merged := append([]any{}, dst...)
+		for _, item := range src {
+			index := -1
+			if id, ok := itemKey(item, strategy.Key); ok {
+				for i, existing := range merged {
+					if existingID, ok := itemKey(existing, strategy.Key); ok && existingID == id {
+						index = i
+						break
+					}
+				}
+			}
+			if index >= 0 {
+				merged[index] = l.mergeItem(merged[index], item, path)
+			} else {
+				merged = append(merged, item)
+			}
+		}
+		return merged
+	default:
+		return src
+	}
+}
+
+// mergeItem merges two list items: maps are merged deeply, anything else is replaced.
+func (l *Loader) mergeItem(dst, src any, path string) any {
+	srcMap, srcIsMap := stringKeyMap(src)
+	dstMap, dstIsMap := stringKeyMap(dst)
+	if !srcIsMap || !dstIsMap {
+		return src
+	}
+
+	merged := make(map[string]any, len(dstMap))
+	l.merge(merged, dstMap, path, false)
+	l.merge(merged, srcMap, path, false)
+	return merged
+}
+
+// itemKey returns the value identifying a list item with merge-by-key.
+func itemKey(item any, key string) (string, bool) {
+	m, ok := stringKeyMap(item)
+	if !ok {
+		return "", false
+	}
+	id, ok := m[key]
+	if !ok || id == nil {
+		return "", false
+	}
+	return fmt.Sprint(id), true
+}
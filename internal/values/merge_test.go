@@ -0,0 +1,140 @@
+package values
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMergeStrategies(t *testing.T) {
+	tests := []struct {
+		name      string
+		specs     []string
+		expected  MergeStrategies
+		wantError bool
+	}{
+		{
+			name:     "defaults to replace",
+			expected: MergeStrategies{Default: MergeStrategy{Kind: MergeReplace}, Keys: map[string]MergeStrategy{}},
+		},
+		{
+			name:  "global and per key",
+			specs: []string{"append", "env=merge-by-key:name", "ports = merge-by-index"},
+			expected: MergeStrategies{
+				Default: MergeStrategy{Kind: MergeAppend},
+				Keys: map[string]MergeStrategy{
+					"env":   {Kind: MergeByKey, Key: "name"},
+					"ports": {Kind: MergeByIndex},
+				},
+			},
+		},
+		{name: "unknown strategy", specs: []string{"union"}, wantError: true},
+		{name: "merge by key without key", specs: []string{"env=merge-by-key"}, wantError: true},
+		{name: "key on other strategy", specs: []string{"append:name"}, wantError: true},
+		{name: "missing path", specs: []string{"=append"}, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategies, err := ParseMergeStrategies(tt.specs)
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(strategies, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, strategies)
+			}
+		})
+	}
+}
+
+func TestMergeValuesListStrategies(t *testing.T) {
+	// Lists as decoded from an overlay values file
+	base := func() map[string]any {
+		return map[string]any{
+			"hosts": []any{"a.example.com"},
+			"env": []any{
+				map[interface{}]interface{}{"name": "LOG_LEVEL", "value": "info"},
+				map[interface{}]interface{}{"name": "PORT", "value": 8080},
+			},
+		}
+	}
+	overlay := map[string]any{
+		"hosts": []any{"b.example.com"},
+		"env": []any{
+			map[string]any{"name": "LOG_LEVEL", "value": "debug"},
+			map[string]any{"name": "REGION", "value": "eu-west-1"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		specs    []string
+		expected map[string]any
+	}{
+		{
+			name:  "replace",
+			specs: nil,
+			expected: map[string]any{
+				"hosts": []any{"b.example.com"},
+				"env":   overlay["env"],
+			},
+		},
+		{
+			name:  "append",
+			specs: []string{"append"},
+			expected: map[string]any{
+				"hosts": []any{"a.example.com", "b.example.com"},
+				"env": []any{
+					map[interface{}]interface{}{"name": "LOG_LEVEL", "value": "info"},
+					map[interface{}]interface{}{"name": "PORT", "value": 8080},
+					map[string]any{"name": "LOG_LEVEL", "value": "debug"},
+					map[string]any{"name": "REGION", "value": "eu-west-1"},
+				},
+			},
+		},
+		{
+			name:  "merge by index",
+			specs: []string{"merge-by-index"},
+			expected: map[string]any{
+				"hosts": []any{"b.example.com"},
+				"env": []any{
+					map[string]any{"name": "LOG_LEVEL", "value": "debug"},
+					map[string]any{"name": "REGION", "value": "eu-west-1"},
+				},
+			},
+		},
+		{
+			name:  "merge by key for one list",
+			specs: []string{"hosts=append", "env=merge-by-key:name"},
+			expected: map[string]any{
+				"hosts": []any{"a.example.com", "b.example.com"},
+				"env": []any{
+					map[string]any{"name": "LOG_LEVEL", "value": "debug"},
+					map[interface{}]interface{}{"name": "PORT", "value": 8080},
+					map[string]any{"name": "REGION", "value": "eu-west-1"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategies, err := ParseMergeStrategies(tt.specs)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			loader := NewLoader()
+			loader.SetMergeStrategies(strategies)
+
+			result := loader.MergeValues(base(), overlay, nil, nil)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
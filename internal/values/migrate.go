@@ -0,0 +1,310 @@
+package values
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// MigrationRules is the declarative rules file consumed by `templater values migrate`.
+type MigrationRules struct {
+	Migrations []Migration `yaml:"migrations"`
+}
+
+// Migration describes the steps that upgrade values from one schema version to the next.
+type Migration struct {
+	From  string          `yaml:"from"`
+	To    string          `yaml:"to"`
+	Rules []MigrationRule `yaml:"rules"`
+}
+
+// MigrationRule is a single key transformation. Exactly one operation must be set.
+type MigrationRule struct {
+	Rename *RenameRule `yaml:"rename,omitempty"`
+	Move   *MoveRule   `yaml:"move,omitempty"`
+	Split  *SplitRule  `yaml:"split,omitempty"`
+	Delete string      `yaml:"delete,omitempty"`
+}
+
+// RenameRule renames the last segment of a key, keeping it in place.
+type RenameRule struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// MoveRule moves a key (and its subtree) to a new dotted path.
+type MoveRule struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// SplitRule splits a scalar value on a separator into several keys.
+type SplitRule struct {
+	From      string   `yaml:"from"`
+	Separator string   `yaml:"separator"`
+	To        []string `yaml:"to"`
+}
+
+// LoadMigrationRules reads a migration rules file.
+func LoadMigrationRules(path string) (*MigrationRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+
+	rules := &MigrationRules{}
+	decoder := yaml3.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(rules); err != nil {
+		return nil, fmt.Errorf("failed to parse rules file: %w", err)
+	}
+
+	return rules, nil
+}
+
+// Plan returns the chain of migrations leading from one version to another.
+func (r *MigrationRules) Plan(from, to string) ([]Migration, error) {
+	var plan []Migration
+	visited := map[string]bool{from: true}
+
+	for current := from; current != to; {
+		next, ok := r.find(current)
+		if !ok {
+			return nil, fmt.Errorf("no migration path from %s to %s (stuck at %s)", from, to, current)
+		}
+		if visited[next.To] {
+			return nil, fmt.Errorf("migration cycle detected at %s", next.To)
+		}
+		visited[next.To] = true
+		plan = append(plan, next)
+		current = next.To
+	}
+
+	return plan, nil
+}
+
+// find returns the migration starting at the given version.
+func (r *MigrationRules) find(from string) (Migration, bool) {
+	for _, migration := range r.Migrations {
+		if migration.From == from {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// MigrateYAML applies migrations to a YAML document, preserving comments and key order.
+func MigrateYAML(data []byte, plan []Migration) ([]byte, error) {
+	var doc yaml3.Node
+	if err := yaml3.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	if doc.Kind == 0 {
+		doc = yaml3.Node{Kind: yaml3.DocumentNode, Content: []*yaml3.Node{{Kind: yaml3.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml3.MappingNode {
+		return nil, fmt.Errorf("values document must be a mapping")
+	}
+
+	for _, migration := range plan {
+		for i, rule := range migration.Rules {
+			if err := applyRule(root, rule); err != nil {
+				return nil, fmt.Errorf("migration %s -> %s, rule %d: %w", migration.From, migration.To, i+1, err)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml3.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// applyRule applies a single migration rule to the root mapping.
+func applyRule(root *yaml3.Node, rule MigrationRule) error {
+	switch {
+	case rule.Rename != nil:
+		if strings.Contains(rule.Rename.To, ".") {
+			return fmt.Errorf("rename target %s must be a single key (use move for paths)", rule.Rename.To)
+		}
+		return renameNode(root, rule.Rename)
+	case rule.Move != nil:
+		return moveNode(root, rule.Move.From, rule.Move.To)
+	case rule.Split != nil:
+		return splitNode(root, rule.Split)
+	case rule.Delete != "":
+		removeNode(root, strings.Split(rule.Delete, "."))
+		return nil
+	default:
+		return fmt.Errorf("rule has no operation (expected rename, move, split or delete)")
+	}
+}
+
+// renameNode renames a key in place, keeping its position and comments.
+func renameNode(root *yaml3.Node, rule *RenameRule) error {
+	path := strings.Split(rule.From, ".")
+	keyNode, _ := lookupPath(root, path)
+	if keyNode == nil {
+		return nil
+	}
+
+	parent := root
+	if len(path) > 1 {
+		_, parent = lookupPath(root, path[:len(path)-1])
+	}
+	if existing, _ := lookupKey(parent, rule.To); existing != nil {
+		return fmt.Errorf("cannot rename %s to %s: target already exists", rule.From, rule.To)
+	}
+
+	keyNode.Value = rule.To
+	return nil
+}
+
+// moveNode moves the key at from to the path to, keeping its comments.
+func moveNode(root *yaml3.Node, from, to string) error {
+	keyNode, valueNode := removeNode(root, strings.Split(from, "."))
+	if keyNode == nil {
+		// Nothing to migrate, the values file does not use this key
+		return nil
+	}
+
+	toPath := strings.Split(to, ".")
+	parent, err := ensureMapping(root, toPath[:len(toPath)-1])
+	if err != nil {
+		return err
+	}
+	if _, existing := lookupKey(parent, toPath[len(toPath)-1]); existing != nil {
+		return fmt.Errorf("cannot move %s to %s: target already exists", from, to)
+	}
+
+	keyNode.Value = toPath[len(toPath)-1]
+	parent.Content = append(parent.Content, keyNode, valueNode)
+	return nil
+}
+
+// splitNode splits a scalar value into several keys.
+func splitNode(root *yaml3.Node, rule *SplitRule) error {
+	keyNode, valueNode := lookupPath(root, strings.Split(rule.From, "."))
+	if keyNode == nil {
+		return nil
+	}
+	if valueNode.Kind != yaml3.ScalarNode {
+		return fmt.Errorf("cannot split %s: value is not a scalar", rule.From)
+	}
+	if rule.Separator == "" || len(rule.To) == 0 {
+		return fmt.Errorf("split of %s requires a separator and target keys", rule.From)
+	}
+
+	parts := strings.SplitN(valueNode.Value, rule.Separator, len(rule.To))
+	removeNode(root, strings.Split(rule.From, "."))
+
+	for i, target := range rule.To {
+		if i >= len(parts) {
+			break
+		}
+		path := strings.Split(target, ".")
+		parent, err := ensureMapping(root, path[:len(path)-1])
+		if err != nil {
+			return err
+		}
+		newKey := &yaml3.Node{Kind: yaml3.ScalarNode, Tag: "!!str", Value: path[len(path)-1]}
+		if i == 0 {
+			newKey.HeadComment = keyNode.HeadComment
+			newKey.LineComment = keyNode.LineComment
+		}
+		newValue := &yaml3.Node{Kind: yaml3.ScalarNode, Tag: "!!str", Value: parts[i]}
+		setKey(parent, newKey, newValue)
+	}
+
+	return nil
+}
+
+// lookupPath finds the key and value nodes at a dotted path.
+func lookupPath(root *yaml3.Node, path []string) (*yaml3.Node, *yaml3.Node) {
+	current := root
+	for i, segment := range path {
+		keyNode, valueNode := lookupKey(current, segment)
+		if keyNode == nil {
+			return nil, nil
+		}
+		if i == len(path)-1 {
+			return keyNode, valueNode
+		}
+		current = valueNode
+	}
+	return nil, nil
+}
+
+// lookupKey finds a key in a mapping node.
+func lookupKey(mapping *yaml3.Node, key string) (*yaml3.Node, *yaml3.Node) {
+	if mapping == nil || mapping.Kind != yaml3.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// removeNode removes the key at a dotted path and returns the removed key and value nodes.
+func removeNode(root *yaml3.Node, path []string) (*yaml3.Node, *yaml3.Node) {
+	parent := root
+	if len(path) > 1 {
+		_, parent = lookupPath(root, path[:len(path)-1])
+	}
+	if parent == nil || parent.Kind != yaml3.MappingNode {
+		return nil, nil
+	}
+
+	key := path[len(path)-1]
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == key {
+			keyNode, valueNode := parent.Content[i], parent.Content[i+1]
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			return keyNode, valueNode
+		}
+	}
+	return nil, nil
+}
+
+// ensureMapping returns the mapping at a dotted path, creating intermediate mappings as needed.
+func ensureMapping(root *yaml3.Node, path []string) (*yaml3.Node, error) {
+	current := root
+	for i, segment := range path {
+		_, valueNode := lookupKey(current, segment)
+		if valueNode == nil {
+			valueNode = &yaml3.Node{Kind: yaml3.MappingNode, Tag: "!!map"}
+			setKey(current, &yaml3.Node{Kind: yaml3.ScalarNode, Tag: "!!str", Value: segment}, valueNode)
+		}
+		if valueNode.Kind != yaml3.MappingNode {
+			return nil, fmt.Errorf("key %s is not a map", strings.Join(path[:i+1], "."))
+		}
+		current = valueNode
+	}
+	return current, nil
+}
+
+// setKey sets or replaces a key in a mapping node.
+func setKey(mapping, keyNode, valueNode *yaml3.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == keyNode.Value {
+			mapping.Content[i+1] = valueNode
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, keyNode, valueNode)
+}
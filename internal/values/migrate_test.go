@@ -0,0 +1,154 @@
+package values
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrationRulesPlan(t *testing.T) {
+	rules := &MigrationRules{Migrations: []Migration{
+		{From: "v1", To: "v2"},
+		{From: "v2", To: "v3"},
+		{From: "v3", To: "v1"},
+	}}
+
+	plan, err := rules.Plan("v1", "v3")
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if len(plan) != 2 || plan[0].To != "v2" || plan[1].To != "v3" {
+		t.Errorf("Unexpected plan: %+v", plan)
+	}
+
+	if _, err := rules.Plan("v1", "v4"); err == nil {
+		t.Error("Expected error for unreachable version")
+	}
+
+	empty, err := rules.Plan("v2", "v2")
+	if err != nil || len(empty) != 0 {
+		t.Errorf("Expected empty plan for same version, got %v (%v)", empty, err)
+	}
+}
+
+func TestMigrateYAML(t *testing.T) {
+	input := `# Application settings
+app:
+  # The app name
+  name: demo # inline
+  image: nginx:1.27
+db:
+  host: localhost
+legacy:
+  flag: true
+  keep: 1
+`
+	plan := []Migration{{
+		From: "v1",
+		To:   "v2",
+		Rules: []MigrationRule{
+			{Rename: &RenameRule{From: "app.name", To: "fullName"}},
+			{Move: &MoveRule{From: "db", To: "backend.database"}},
+			{Split: &SplitRule{From: "app.image", Separator: ":", To: []string{"app.image.repository", "app.image.tag"}}},
+			{Delete: "legacy.flag"},
+			{Move: &MoveRule{From: "missing.key", To: "other"}},
+		},
+	}}
+
+	result, err := MigrateYAML([]byte(input), plan)
+	if err != nil {
+		t.Fatalf("MigrateYAML failed: %v", err)
+	}
+
+	expected := `# Application settings
+app:
+  # The app name
+  fullName: demo # inline
+  image:
+    repository: nginx
+    tag: "1.27"
+legacy:
+  keep: 1
+backend:
+  database:
+    host: localhost
+`
+	if string(result) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, result)
+	}
+}
+
+func TestMigrateYAMLErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		rule  MigrationRule
+	}{
+		{
+			name:  "move onto existing key",
+			input: "a: 1\nb: 2\n",
+			rule:  MigrationRule{Move: &MoveRule{From: "a", To: "b"}},
+		},
+		{
+			name:  "rename onto existing key",
+			input: "a: 1\nb: 2\n",
+			rule:  MigrationRule{Rename: &RenameRule{From: "a", To: "b"}},
+		},
+		{
+			name:  "split non-scalar",
+			input: "a:\n  b: 1\n",
+			rule:  MigrationRule{Split: &SplitRule{From: "a", Separator: ":", To: []string{"x", "y"}}},
+		},
+		{
+			name:  "empty rule",
+			input: "a: 1\n",
+			rule:  MigrationRule{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := MigrateYAML([]byte(tt.input), []Migration{{From: "v1", To: "v2", Rules: []MigrationRule{tt.rule}}})
+			if err == nil {
+				t.Error("Expected error but got none")
+			}
+		})
+	}
+}
+
+func TestLoadMigrationRules(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-migration-rules-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	rulesPath := filepath.Join(tempDir, "rules.yaml")
+	content := `migrations:
+  - from: v1
+    to: v2
+    rules:
+      - rename: {from: app.name, to: fullName}
+      - delete: legacy
+`
+	if err := os.WriteFile(rulesPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write rules: %v", err)
+	}
+
+	rules, err := LoadMigrationRules(rulesPath)
+	if err != nil {
+		t.Fatalf("LoadMigrationRules failed: %v", err)
+	}
+	if len(rules.Migrations) != 1 || len(rules.Migrations[0].Rules) != 2 {
+		t.Errorf("Unexpected rules: %+v", rules)
+	}
+
+	invalidPath := filepath.Join(tempDir, "invalid.yaml")
+	if err := os.WriteFile(invalidPath, []byte("migrations:\n  - from: v1\n    unknown: true\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write rules: %v", err)
+	}
+	if _, err := LoadMigrationRules(invalidPath); err == nil || !strings.Contains(err.Error(), "unknown") {
+		t.Errorf("Expected unknown field error, got %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package values
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// jsonNumberPattern matches the JSON number grammar.
+var jsonNumberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// yamlNumber returns a YAML integer or float scalar as a json.Number. Literals that are
+// valid JSON numbers are kept as written; other forms such as 0x1F or .5 are normalized.
+// Infinity and NaN have no JSON form and are not numbers here.
+func yamlNumber(node *yaml3.Node) (json.Number, bool) {
+	tag := node.ShortTag()
+	if tag != "!!int" && tag != "!!float" {
+		return "", false
+	}
+	if jsonNumberPattern.MatchString(node.Value) {
+		return json.Number(node.Value), true
+	}
+
+	literal := strings.ReplaceAll(node.Value, "_", "")
+	if tag == "!!int" {
+		if i, ok := new(big.Int).SetString(literal, 0); ok {
+			return json.Number(i.String()), true
+		}
+		return "", false
+	}
+	f, err := strconv.ParseFloat(literal, 64)
+	if err != nil || !jsonNumberPattern.MatchString(strconv.FormatFloat(f, 'g', -1, 64)) {
+		return "", false
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), true
+}
+
+// preciseValue decodes a YAML value, keeping numbers as json.Number.
+type preciseValue struct {
+	value any
+}
+
+// UnmarshalYAML implements yaml3.Unmarshaler.
+func (p *preciseValue) UnmarshalYAML(node *yaml3.Node) error {
+	switch node.Kind {
+	case yaml3.ScalarNode:
+		if number, ok := yamlNumber(node); ok {
+			p.value = number
+			return nil
+		}
+		return node.Decode(&p.value)
+	case yaml3.MappingNode:
+		var m map[any]preciseValue
+		if err := node.Decode(&m); err != nil {
+			return err
+		}
+		values := make(map[string]any, len(m))
+		for key, value := range m {
+			values[fmt.Sprint(key)] = value.value
+		}
+		p.value = values
+		return nil
+	case yaml3.SequenceNode:
+		var items []preciseValue
+		if err := node.Decode(&items); err != nil {
+			return err
+		}
+		values := make([]any, len(items))
+		for i, item := range items {
+			values[i] = item.value
+		}
+		p.value = values
+		return nil
+	case yaml3.AliasNode:
+		return p.UnmarshalYAML(node.Alias)
+	default:
+		return node.Decode(&p.value)
+	}
+}
+
+// decodePrecise decodes a YAML mapping document, keeping numbers as json.Number.
+func decodePrecise(data []byte) (map[string]any, error) {
+	var doc preciseValue
+	if err := yaml3.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	switch v := doc.value.(type) {
+	case nil:
+		return map[string]any{}, nil
+	case map[string]any:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("values document must be a mapping")
+	}
+}
+
+// EncodeYAMLNumbers returns v with json.Number values, loaded with precise numbers,
+// replaced by YAML nodes holding the number literal, so yaml.v3 encodes them as written
+// instead of as strings.
+func EncodeYAMLNumbers(v any) any {
+	switch x := v.(type) {
+	case json.Number:
+		// Untagged plain scalars are written as is, while yaml.v3 tags integers it
+		// cannot represent as int64 when told they are integers
+		return &yaml3.Node{Kind: yaml3.ScalarNode, Value: string(x)}
+	case map[string]any:
+		converted := make(map[string]any, len(x))
+		for key, value := range x {
+			converted[key] = EncodeYAMLNumbers(value)
+		}
+		return converted
+	case map[interface{}]interface{}:
+		converted := make(map[interface{}]interface{}, len(x))
+		for key, value := range x {
+			converted[key] = EncodeYAMLNumbers(value)
+		}
+		return converted
+	case []any:
+		converted := make([]any, len(x))
+		for i, value := range x {
+			converted[i] = EncodeYAMLNumbers(value)
+		}
+		return converted
+	default:
+		return v
+	}
+}
+
+// HasNumbers reports whether v holds a json.Number.
+func HasNumbers(v any) bool {
+	switch x := v.(type) {
+	case json.Number:
+		return true
+	case map[string]any:
+		for _, value := range x {
+			if HasNumbers(value) {
+				return true
+			}
+		}
+	case map[interface{}]interface{}:
+		for _, value := range x {
+			if HasNumbers(value) {
+				return true
+			}
+		}
+	case []any:
+		for _, value := range x {
+			if HasNumbers(value) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package values
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadYAMLValuesPreciseNumbers(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-precise-numbers-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	content := `base: &base
+  timeout: 30
+app:
+  <<: *base
+  accountId: 123456789012345678901234
+  ratio: 1.10
+  limit: 1e3
+  mask: 0x1F
+  size: 1_000
+  half: .5
+  ports: [80, 443]
+  name: web
+  enabled: true
+  max: .inf
+  empty: null
+`
+	valuesPath := filepath.Join(tempDir, "values.yaml")
+	if err := os.WriteFile(valuesPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+
+	loader := NewLoader()
+	loader.SetPreciseNumbers(true)
+
+	values, err := loader.LoadYAMLValues(valuesPath)
+	if err != nil {
+		t.Fatalf("LoadYAMLValues failed: %v", err)
+	}
+	app := values["app"].(map[string]any)
+
+	tests := []struct {
+		key      string
+		expected any
+	}{
+		{"timeout", json.Number("30")},
+		{"accountId", json.Number("123456789012345678901234")},
+		{"ratio", json.Number("1.10")},
+		{"limit", json.Number("1e3")},
+		{"mask", json.Number("31")},
+		{"size", json.Number("1000")},
+		{"half", json.Number("0.5")},
+		{"ports", []any{json.Number("80"), json.Number("443")}},
+		{"name", "web"},
+		{"enabled", true},
+		{"empty", nil},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(app[tt.key], tt.expected) {
+			t.Errorf("Expected %s to be %#v, got %#v", tt.key, tt.expected, app[tt.key])
+		}
+	}
+	if _, ok := app["max"].(float64); !ok {
+		t.Errorf("Expected .inf to stay a float, got %#v", app["max"])
+	}
+
+	subset, err := loader.LoadYAMLValuesSubset(valuesPath, []string{"app"})
+	if err != nil {
+		t.Fatalf("LoadYAMLValuesSubset failed: %v", err)
+	}
+	if !reflect.DeepEqual(subset["app"].(map[string]any)["accountId"], json.Number("123456789012345678901234")) {
+		t.Errorf("Expected subset to keep precise numbers, got %#v", subset["app"])
+	}
+}
+
+func TestParseSetValuesPreciseNumbers(t *testing.T) {
+	loader := NewLoader()
+	loader.SetPreciseNumbers(true)
+
+	values, err := loader.ParseSetValues([]string{"big=12345678901234567890123,ratio=1.10,name=web,hex=0x1F"})
+	if err != nil {
+		t.Fatalf("ParseSetValues failed: %v", err)
+	}
+	expected := map[string]any{
+		"big":   json.Number("12345678901234567890123"),
+		"ratio": json.Number("1.10"),
+		"name":  "web",
+		"hex":   "0x1F",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+
+	values, err = loader.ParseSetJSONValues([]string{`limits={"cpu":1.50,"count":12345678901234567890123}`})
+	if err != nil {
+		t.Fatalf("ParseSetJSONValues failed: %v", err)
+	}
+	expected = map[string]any{
+		"limits": map[string]any{"cpu": json.Number("1.50"), "count": json.Number("12345678901234567890123")},
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+}
+
+func TestDumpPreciseNumbers(t *testing.T) {
+	values := map[string]any{
+		"accountId": json.Number("123456789012345678901234"),
+		"limits":    map[string]any{"ratio": json.Number("1.10"), "max": json.Number("1e3")},
+		"ports":     []any{json.Number("80")},
+		"version":   "1.10",
+	}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format:   "yaml",
+			expected: "accountId: 123456789012345678901234\nlimits:\n  max: 1e3\n  ratio: 1.10\nports:\n  - 80\nversion: \"1.10\"\n",
+		},
+		{
+			format:   "json",
+			expected: "{\n  \"accountId\": 123456789012345678901234,\n  \"limits\": {\n    \"max\": 1e3,\n    \"ratio\": 1.10\n  },\n  \"ports\": [\n    80\n  ],\n  \"version\": \"1.10\"\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			data, err := Dump(values, tt.format)
+			if err != nil {
+				t.Fatalf("Dump failed: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, string(data))
+			}
+		})
+	}
+}
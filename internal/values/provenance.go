@@ -0,0 +1,189 @@
+package values
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Layer is the values loaded from one source, such as the values file or --set flags.
+type Layer struct {
+	Source string
+	Values map[string]any
+	// Origins optionally names a more specific source per top-level key, such as the
+	// environment variable a key was read from.
+	Origins map[string]string
+	// Unset makes null values remove keys set by lower layers, as with --set key=null,
+	// instead of setting them to null.
+	Unset bool
+}
+
+// Provenance describes where a merged value came from.
+type Provenance struct {
+	Path   string
+	Value  any
+	Source string
+	// Removed is set when the key was unset with a null value from Source.
+	Removed bool
+	// Shadowed lists the values lower-precedence sources set for the same path.
+	Shadowed []ShadowedValue
+}
+
+// ShadowedValue is a value overridden by a higher-precedence source.
+type ShadowedValue struct {
+	Source string
+	Value  any
+}
+
+// MergeLayers merges layers in order, each overriding the previous ones.
+func (l *Loader) MergeLayers(layers []Layer) map[string]any {
+	merged := make(map[string]any)
+	for _, layer := range layers {
+		if layer.Unset {
+			l.overrideMerge(merged, layer.Values)
+		} else {
+			l.deepMerge(merged, layer.Values)
+		}
+	}
+	return merged
+}
+
+// Explain reports, for every value in merged, the layer it came from and the values of
+// lower-precedence layers it shadows. Keys removed with null are reported too. Layers
+// are ordered from lowest to highest precedence, as given to MergeLayers. Lists merged
+// from several layers name every contributing source.
+func Explain(layers []Layer, merged map[string]any) []Provenance {
+	leaves := make([]map[string]any, len(layers))
+	for i, layer := range layers {
+		leaves[i] = make(map[string]any)
+		flattenValues(layer.Values, "", leaves[i])
+	}
+	final := make(map[string]any)
+	flattenValues(merged, "", final)
+
+	var explained []Provenance
+	for path, value := range final {
+		p := Provenance{Path: path, Value: value}
+
+		winner := -1
+		var contributors []string
+		for i := len(layers) - 1; i >= 0; i-- {
+			layerValue, ok := leaves[i][path]
+			if !ok {
+				continue
+			}
+			contributors = append([]string{layerSource(layers[i], path)}, contributors...)
+			if winner < 0 && reflect.DeepEqual(jsonCompatible(layerValue), jsonCompatible(value)) {
+				winner = i
+			}
+		}
+
+		if winner < 0 {
+			// A list merged from several layers matches none of them
+			p.Source = strings.Join(contributors, " + ")
+		} else {
+			p.Source = layerSource(layers[winner], path)
+			p.Shadowed = shadowedValues(layers[:winner], leaves[:winner], path)
+		}
+		explained = append(explained, p)
+	}
+
+	// Report keys a layer removed with null, with the values they removed
+	for i, layer := range layers {
+		for path, value := range leaves[i] {
+			if value != nil || hasPathOrChild(final, path) || removedLater(leaves[i+1:], path) {
+				continue
+			}
+			var shadowed []ShadowedValue
+			for j := i - 1; j >= 0; j-- {
+				var paths []string
+				for leafPath := range leaves[j] {
+					if leafPath == path || strings.HasPrefix(leafPath, path+".") {
+						paths = append(paths, leafPath)
+					}
+				}
+				sort.Strings(paths)
+				for _, leafPath := range paths {
+					shadowed = append(shadowed, ShadowedValue{Source: layerSource(layers[j], leafPath), Value: leaves[j][leafPath]})
+				}
+			}
+			if len(shadowed) > 0 {
+				explained = append(explained, Provenance{Path: path, Source: layerSource(layer, path), Removed: true, Shadowed: shadowed})
+			}
+		}
+	}
+
+	sort.SliceStable(explained, func(i, j int) bool {
+		return explained[i].Path < explained[j].Path
+	})
+	return explained
+}
+
+// FormatValue formats a value for display as compact JSON, e.g. "web" or [80,443].
+func FormatValue(v any) string {
+	data, err := json.Marshal(jsonCompatible(v))
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// shadowedValues returns the values layers set at path, highest precedence first.
+func shadowedValues(layers []Layer, leaves []map[string]any, path string) []ShadowedValue {
+	var shadowed []ShadowedValue
+	for i := len(layers) - 1; i >= 0; i-- {
+		if value, ok := leaves[i][path]; ok {
+			shadowed = append(shadowed, ShadowedValue{Source: layerSource(layers[i], path), Value: value})
+		}
+	}
+	return shadowed
+}
+
+// layerSource names the source of the value at path in layer.
+func layerSource(layer Layer, path string) string {
+	key, _, _ := strings.Cut(strings.TrimPrefix(path, "."), ".")
+	if origin, ok := layer.Origins[key]; ok {
+		return origin
+	}
+	return layer.Source
+}
+
+// hasPathOrChild reports whether leaves holds path or a value below it.
+func hasPathOrChild(leaves map[string]any, path string) bool {
+	for leafPath := range leaves {
+		if leafPath == path || strings.HasPrefix(leafPath, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// removedLater reports whether a later layer also removes path, so only the last
+// removal is reported.
+func removedLater(leaves []map[string]any, path string) bool {
+	for _, layerLeaves := range leaves {
+		if value, ok := layerLeaves[path]; ok && value == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenValues stores every value below v in leaves, keyed by its .a.b path. Maps are
+// descended into; lists and scalars are leaves.
+func flattenValues(v any, path string, leaves map[string]any) {
+	m, ok := stringKeyMap(v)
+	if !ok {
+		leaves[path] = v
+		return
+	}
+	if len(m) == 0 && path != "" {
+		leaves[path] = m
+		return
+	}
+	for key, value := range m {
+		flattenValues(value, path+"."+key, leaves)
+	}
+}
@@ -0,0 +1,83 @@
+package values
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	loader := NewLoader()
+
+	layers := []Layer{
+		{Source: "values file values.yaml", Values: map[string]any{
+			"app": map[interface{}]interface{}{
+				"name":       "yaml-app",
+				"replicas":   1,
+				"legacyFlag": true,
+			},
+			"ports": []any{80},
+		}},
+		{Source: "environment", Values: map[string]any{"appName": "env-app"}, Origins: map[string]string{"appName": "env var APP_NAME"}},
+		{Source: "--set-json", Values: map[string]any{"app": map[string]any{"name": "json-app"}}, Unset: true},
+		{Source: "--set", Values: map[string]any{"app": map[string]any{"name": "set-app", "legacyFlag": nil}}, Unset: true},
+	}
+
+	explained := Explain(layers, loader.MergeLayers(layers))
+
+	expected := []Provenance{
+		{Path: ".app.legacyFlag", Source: "--set", Removed: true, Shadowed: []ShadowedValue{
+			{Source: "values file values.yaml", Value: true},
+		}},
+		{Path: ".app.name", Value: "set-app", Source: "--set", Shadowed: []ShadowedValue{
+			{Source: "--set-json", Value: "json-app"},
+			{Source: "values file values.yaml", Value: "yaml-app"},
+		}},
+		{Path: ".app.replicas", Value: 1, Source: "values file values.yaml"},
+		{Path: ".appName", Value: "env-app", Source: "env var APP_NAME"},
+		{Path: ".ports", Value: []any{80}, Source: "values file values.yaml"},
+	}
+	if !reflect.DeepEqual(explained, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, explained)
+	}
+}
+
+func TestExplainMergedLists(t *testing.T) {
+	strategies, err := ParseMergeStrategies([]string{"append"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	loader := NewLoader()
+	loader.SetMergeStrategies(strategies)
+
+	layers := []Layer{
+		{Source: "values file values.yaml", Values: map[string]any{"hosts": []any{"a"}}},
+		{Source: "--set-json", Values: map[string]any{"hosts": []any{"b"}}},
+	}
+
+	explained := Explain(layers, loader.MergeLayers(layers))
+
+	expected := []Provenance{
+		{Path: ".hosts", Value: []any{"a", "b"}, Source: "values file values.yaml + --set-json"},
+	}
+	if !reflect.DeepEqual(explained, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, explained)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		value    any
+		expected string
+	}{
+		{"web", `"web"`},
+		{8080, "8080"},
+		{nil, "null"},
+		{[]any{map[interface{}]interface{}{"name": "a"}}, `[{"name":"a"}]`},
+	}
+
+	for _, tt := range tests {
+		if got := FormatValue(tt.value); got != tt.expected {
+			t.Errorf("FormatValue(%v) = %s, expected %s", tt.value, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package values
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// SchemaFileName is the name of the values schema looked up next to the values file.
+const SchemaFileName = "values.schema.json"
+
+// SchemaViolation is a single value that does not match the values schema.
+type SchemaViolation struct {
+	Path    string
+	Message string
+}
+
+// SchemaError reports every violation found when validating values against a schema.
+type SchemaError struct {
+	Schema     string
+	Violations []SchemaViolation
+}
+
+func (e *SchemaError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "values do not match schema %s:", e.Schema)
+	for _, violation := range e.Violations {
+		fmt.Fprintf(&b, "\n  %s: %s", violation.Path, violation.Message)
+	}
+	return b.String()
+}
+
+// FindSchema returns the values.schema.json next to valuesFile, or "" if there is none.
+func FindSchema(valuesFile string) string {
+	if valuesFile == "" {
+		return ""
+	}
+	schemaFile := filepath.Join(filepath.Dir(valuesFile), SchemaFileName)
+	if _, err := os.Stat(schemaFile); err != nil {
+		return ""
+	}
+	return schemaFile
+}
+
+// ValidateSchema validates values against the JSON schema (draft 4 to 2020-12, draft 7
+// when the schema does not declare one) in schemaFile. All violations are returned in a
+// *SchemaError, with paths in the .a.b form used by templates.
+func ValidateSchema(schemaFile string, values map[string]any) error {
+	data, err := os.ReadFile(schemaFile)
+	if err != nil {
+		return fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft7
+	if err := compiler.AddResource(schemaFile, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to parse schema %s: %w", schemaFile, err)
+	}
+	schema, err := compiler.Compile(schemaFile)
+	if err != nil {
+		return fmt.Errorf("failed to compile schema %s: %w", schemaFile, err)
+	}
+
+	instance, err := jsonInstance(values)
+	if err != nil {
+		return err
+	}
+
+	err = schema.Validate(instance)
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+
+	schemaErr := &SchemaError{Schema: schemaFile}
+	seen := make(map[SchemaViolation]bool)
+	var collect func(*jsonschema.ValidationError)
+	collect = func(ve *jsonschema.ValidationError) {
+		// Only leaf errors describe a concrete problem; the others summarize their causes
+		if len(ve.Causes) > 0 {
+			for _, cause := range ve.Causes {
+				collect(cause)
+			}
+			return
+		}
+		violation := SchemaViolation{Path: instancePath(ve.InstanceLocation), Message: ve.Message}
+		if !seen[violation] {
+			seen[violation] = true
+			schemaErr.Violations = append(schemaErr.Violations, violation)
+		}
+	}
+	collect(validationErr)
+	sort.SliceStable(schemaErr.Violations, func(i, j int) bool {
+		return schemaErr.Violations[i].Path < schemaErr.Violations[j].Path
+	})
+
+	return schemaErr
+}
+
+// jsonInstance converts values to the plain JSON types the validator expects.
+func jsonInstance(values map[string]any) (any, error) {
+	data, err := json.Marshal(jsonCompatible(values))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode values for schema validation: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var instance any
+	if err := decoder.Decode(&instance); err != nil {
+		return nil, fmt.Errorf("failed to encode values for schema validation: %w", err)
+	}
+	return instance, nil
+}
+
+// jsonCompatible converts YAML maps with interface keys so values can be JSON encoded.
+func jsonCompatible(v any) any {
+	switch x := v.(type) {
+	case map[string]any, map[interface{}]interface{}:
+		m, _ := stringKeyMap(x)
+		converted := make(map[string]any, len(m))
+		for key, value := range m {
+			converted[key] = jsonCompatible(value)
+		}
+		return converted
+	case []any:
+		converted := make([]any, len(x))
+		for i, value := range x {
+			converted[i] = jsonCompatible(value)
+		}
+		return converted
+	default:
+		return v
+	}
+}
+
+// instancePath converts a JSON pointer such as /database/port to .database.port.
+func instancePath(pointer string) string {
+	if pointer == "" {
+		return "."
+	}
+	segments := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)
+	}
+	return "." + strings.Join(segments, ".")
+}
@@ -0,0 +1,135 @@
+package values
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "required": ["app"],
+  "properties": {
+    "app": {
+      "type": "object",
+      "required": ["name"],
+      "additionalProperties": false,
+      "properties": {
+        "name": {"type": "string"},
+        "replicas": {"type": "integer", "minimum": 1}
+      }
+    },
+    "ports": {"type": "array", "items": {"type": "integer"}}
+  }
+}`
+
+func TestValidateSchema(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-schema-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	schemaFile := filepath.Join(tempDir, SchemaFileName)
+	if err := os.WriteFile(schemaFile, []byte(testSchema), 0o644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		values     map[string]any
+		violations []SchemaViolation
+	}{
+		{
+			name: "valid values",
+			values: map[string]any{
+				"app":   map[interface{}]interface{}{"name": "web", "replicas": 3},
+				"ports": []any{80, 443},
+			},
+		},
+		{
+			name: "every violation is reported",
+			values: map[string]any{
+				"app":   map[string]any{"replicas": 0, "nmae": "web"},
+				"ports": []any{80, "https"},
+			},
+			violations: []SchemaViolation{
+				{Path: ".app", Message: "missing properties: 'name'"},
+				{Path: ".app", Message: "additionalProperties 'nmae' not allowed"},
+				{Path: ".app.replicas", Message: "must be >= 1 but found 0"},
+				{Path: ".ports.1", Message: "expected integer, but got string"},
+			},
+		},
+		{
+			name:       "missing required root value",
+			values:     map[string]any{},
+			violations: []SchemaViolation{{Path: ".", Message: "missing properties: 'app'"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSchema(schemaFile, tt.values)
+			if tt.violations == nil {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+
+			var schemaErr *SchemaError
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("Expected *SchemaError, got %v", err)
+			}
+			if !reflect.DeepEqual(schemaErr.Violations, tt.violations) {
+				t.Errorf("Expected violations %v, got %v", tt.violations, schemaErr.Violations)
+			}
+		})
+	}
+}
+
+func TestValidateSchemaInvalidSchema(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-schema-invalid-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	schemaFile := filepath.Join(tempDir, SchemaFileName)
+	if err := os.WriteFile(schemaFile, []byte(`{"type": "object", "required": "app"}`), 0o644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+
+	var schemaErr *SchemaError
+	err = ValidateSchema(schemaFile, map[string]any{})
+	if err == nil || errors.As(err, &schemaErr) {
+		t.Errorf("Expected schema compilation error, got %v", err)
+	}
+}
+
+func TestFindSchema(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-find-schema-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	valuesFile := filepath.Join(tempDir, "values.yaml")
+	if got := FindSchema(valuesFile); got != "" {
+		t.Errorf("Expected no schema, got %s", got)
+	}
+
+	schemaFile := filepath.Join(tempDir, SchemaFileName)
+	if err := os.WriteFile(schemaFile, []byte(`{}`), 0o644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+	if got := FindSchema(valuesFile); got != schemaFile {
+		t.Errorf("Expected %s, got %s", schemaFile, got)
+	}
+	if got := FindSchema(""); got != "" {
+		t.Errorf("Expected no schema without a values file, got %s", got)
+	}
+}
@@ -0,0 +1,85 @@
+package values
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// errorLinePattern extracts the line number from YAML parser errors.
+var errorLinePattern = regexp.MustCompile(`line (\d+):`)
+
+// checkStrictYAML parses a values document with yaml.v3 and rejects what a lenient
+// decode would silently accept: a key repeated in one mapping, where the last value
+// wins. Errors name the offending line, and tabs used for indentation are reported as
+// such rather than as an unexpected character.
+func checkStrictYAML(data []byte) error {
+	var doc yaml3.Node
+	if err := yaml3.Unmarshal(data, &doc); err != nil {
+		if line := tabIndentedLine(data, err); line > 0 {
+			return fmt.Errorf("line %d: tab character used for indentation", line)
+		}
+		return err
+	}
+	return checkDuplicateKeys(&doc, "")
+}
+
+// checkDuplicateKeys reports the first key defined twice in a mapping of node.
+func checkDuplicateKeys(node *yaml3.Node, valuePath string) error {
+	switch node.Kind {
+	case yaml3.DocumentNode:
+		for _, child := range node.Content {
+			if err := checkDuplicateKeys(child, valuePath); err != nil {
+				return err
+			}
+		}
+	case yaml3.MappingNode:
+		defined := make(map[string]int)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Kind == yaml3.ScalarNode && key.Value != "<<" {
+				// 1 and "1" are different keys
+				id := key.ShortTag() + " " + key.Value
+				if line, ok := defined[id]; ok {
+					return fmt.Errorf("line %d: key %q is already defined at line %d", key.Line, joinPath(valuePath, key.Value), line)
+				}
+				defined[id] = key.Line
+			}
+			if err := checkDuplicateKeys(node.Content[i+1], joinPath(valuePath, key.Value)); err != nil {
+				return err
+			}
+		}
+	case yaml3.SequenceNode:
+		for i, child := range node.Content {
+			if err := checkDuplicateKeys(child, joinPath(valuePath, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+	case yaml3.ScalarNode, yaml3.AliasNode:
+		// Aliases point at nodes that are checked where they are defined
+	}
+	return nil
+}
+
+// tabIndentedLine returns the line of a parse error when that line is indented with a
+// tab, or 0.
+func tabIndentedLine(data []byte, err error) int {
+	match := errorLinePattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0
+	}
+	line, _ := strconv.Atoi(match[1])
+	lines := bytes.Split(data, []byte("\n"))
+	if line < 1 || line > len(lines) {
+		return 0
+	}
+	text := lines[line-1]
+	indent := text[:len(text)-len(bytes.TrimLeft(text, " \t"))]
+	if !bytes.Contains(indent, []byte("\t")) {
+		return 0
+	}
+	return line
+}
@@ -0,0 +1,73 @@
+package values
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckStrictYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "valid", input: "app:\n  name: web\n  ports: [80, 443]\n"},
+		{name: "empty", input: ""},
+		{name: "different key types", input: "1: a\n\"1\": b\n"},
+		{name: "merge keys", input: "base: &base\n  a: 1\napp:\n  <<: *base\n  a: 2\n"},
+		{
+			name:     "top-level duplicate",
+			input:    "name: web\nport: 80\nname: api\n",
+			expected: `line 3: key "name" is already defined at line 1`,
+		},
+		{
+			name:     "nested duplicate",
+			input:    "app:\n  image:\n    tag: v1\n    tag: v2\n",
+			expected: `line 4: key "app.image.tag" is already defined at line 3`,
+		},
+		{
+			name:     "duplicate in list item",
+			input:    "users:\n  - name: a\n    name: b\n",
+			expected: `line 3: key "users.0.name" is already defined at line 2`,
+		},
+		{
+			name:     "tab indentation",
+			input:    "app:\n\tname: web\n",
+			expected: "line 2: tab character used for indentation",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkStrictYAML([]byte(tt.input))
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected error %q", tt.expected)
+			}
+			if err.Error() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestLoadYAMLValuesRejectsDuplicateKeys(t *testing.T) {
+	valuesPath := filepath.Join(t.TempDir(), "values.yaml")
+	if err := os.WriteFile(valuesPath, []byte("replicas: 3\nreplicas: 1\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+
+	loader := NewLoader()
+	if _, err := loader.LoadYAMLValues(valuesPath); err == nil {
+		t.Error("Expected LoadYAMLValues to reject duplicate keys")
+	}
+	if _, err := loader.LoadYAMLValuesSubset(valuesPath, []string{"replicas"}); err == nil {
+		t.Error("Expected LoadYAMLValuesSubset to reject duplicate keys")
+	}
+}
@@ -0,0 +1,78 @@
+// Package templater renders templates from Go programs, such as tools embedding
+// templater and their integration tests, with the same configuration as the command
+// line. Rendered output can be kept in an in-memory file system instead of on disk.
+package templater
+
+import (
+	"context"
+	"fmt"
+	"testing/fstest"
+
+	"github.com/menta2k/templater/internal/config"
+	"github.com/menta2k/templater/internal/output"
+	"github.com/menta2k/templater/internal/processor"
+)
+
+// MemoryOutput is the output location that keeps rendered files in memory.
+const MemoryOutput = "mem://"
+
+// Config holds the configuration for template processing.
+type Config = config.Config
+
+// Writer receives every rendered file, by output location.
+type Writer = output.Writer
+
+// MemoryWriter keeps rendered output in an in-memory file system.
+type MemoryWriter = output.MemoryWriter
+
+// NewConfig creates a configuration rendering templateFile, a template file or directory,
+// with the values file and --set style values into outputFile.
+func NewConfig(templateFile, valuesFile, outputFile string, setValues []string, isDirectory, strictMode bool) *Config {
+	return config.NewConfig(templateFile, valuesFile, outputFile, setValues, isDirectory, strictMode)
+}
+
+// NewMemoryWriter creates an empty in-memory writer. Its FS method returns the files
+// written so far as an fstest.MapFS.
+func NewMemoryWriter() *MemoryWriter {
+	return output.NewMemoryWriter()
+}
+
+// Processor renders the templates of a configuration.
+type Processor struct {
+	tp *processor.TemplateProcessor
+}
+
+// New creates a processor for cfg.
+func New(cfg *Config) *Processor {
+	return &Processor{tp: processor.NewTemplateProcessor(cfg)}
+}
+
+// SetWriter sends rendered output to w instead of writing files, for example a
+// MemoryWriter used with a mem:// output location.
+func (p *Processor) SetWriter(w Writer) {
+	p.tp.SetWriter(w)
+}
+
+// Process renders the templates, recording trace spans as children of the span in ctx.
+func (p *Processor) Process(ctx context.Context) error {
+	return p.tp.ProcessContext(ctx)
+}
+
+// RenderFS renders the templates of cfg into memory and returns the rendered tree. The
+// output location of cfg must be empty or a mem:// location.
+func RenderFS(ctx context.Context, cfg *Config) (fstest.MapFS, error) {
+	if cfg.OutputFile == "" {
+		cfg.OutputFile = MemoryOutput
+	}
+	if !output.IsMemoryURL(cfg.OutputFile) {
+		return nil, fmt.Errorf("output %s is not kept in memory (expected a %s location)", cfg.OutputFile, MemoryOutput)
+	}
+
+	memory := NewMemoryWriter()
+	p := New(cfg)
+	p.SetWriter(memory)
+	if err := p.Process(ctx); err != nil {
+		return nil, err
+	}
+	return memory.FS(), nil
+}
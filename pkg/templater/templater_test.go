@@ -0,0 +1,73 @@
+package templater_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/menta2k/templater/pkg/templater"
+)
+
+func TestRenderFS(t *testing.T) {
+	templateDir := t.TempDir()
+	files := map[string]string{
+		"config.tpl":              "name: {{ .app.name }}",
+		"services/deployment.tpl": "replicas: {{ .app.replicas }}",
+	}
+	for name, content := range files {
+		path := filepath.Join(templateDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	cfg := templater.NewConfig(templateDir, "", "", []string{"app.name=web", "app.replicas=2"}, true, true)
+	rendered, err := templater.RenderFS(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("RenderFS failed: %v", err)
+	}
+	if err := fstest.TestFS(rendered, "config", "services/deployment"); err != nil {
+		t.Fatalf("Rendered tree is not a valid file system: %v", err)
+	}
+
+	content, err := rendered.ReadFile("services/deployment")
+	if err != nil {
+		t.Fatalf("Expected rendered file in memory: %v", err)
+	}
+	if string(content) != "replicas: 2" {
+		t.Errorf("Expected 'replicas: 2', got %q", content)
+	}
+
+	// Rendering into memory never touches disk locations
+	cfg = templater.NewConfig(templateDir, "", t.TempDir(), nil, true, true)
+	if _, err := templater.RenderFS(context.Background(), cfg); err == nil {
+		t.Error("Expected error for an output location on disk")
+	}
+}
+
+func TestProcessorWithMemoryWriter(t *testing.T) {
+	templateFile := filepath.Join(t.TempDir(), "config.tpl")
+	if err := os.WriteFile(templateFile, []byte("name: {{ .name }}"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	memory := templater.NewMemoryWriter()
+	p := templater.New(templater.NewConfig(templateFile, "", templater.MemoryOutput+"config.yaml", []string{"name=web"}, false, true))
+	p.SetWriter(memory)
+	if err := p.Process(context.Background()); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	content, err := memory.FS().ReadFile("config.yaml")
+	if err != nil {
+		t.Fatalf("Expected rendered file in memory: %v", err)
+	}
+	if string(content) != "name: web" {
+		t.Errorf("Expected 'name: web', got %q", content)
+	}
+}